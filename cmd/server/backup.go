@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/jgirmay/unified-go/internal/backup"
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+const backupUsage = `usage: server backup <command>
+
+commands:
+  create   snapshot the database and audio into a new archive in backup.dir
+  list     show the archives in backup.dir, newest first
+  prune    delete archives outside the keep_daily/keep_weekly retention`
+
+const restoreUsage = `usage: server restore <archive>
+
+Stop the server first. The archived database must pass PRAGMA integrity_check
+before it replaces database_url; the replaced database and audio directories
+are kept with a .pre-restore-<time> suffix. Audio directories missing from the
+archive are replaced with empty ones.`
+
+// newBackupManager creates the backup manager for the configured database
+// and audio directories
+func newBackupManager(cfg *config.Config, db *database.Pool) *backup.Manager {
+	return backup.NewManager(db.DB, backup.Options{
+		Dir:        cfg.Backup.Dir,
+		AudioDirs:  audioDirs(cfg),
+		KeepDaily:  cfg.Backup.KeepDaily,
+		KeepWeekly: cfg.Backup.KeepWeekly,
+	})
+}
+
+// audioDirs names the directories of recorded audio bundled into backups
+func audioDirs(cfg *config.Config) map[string]string {
+	return map[string]string{
+		"math":    cfg.Apps.Math.AudioDir,
+		"reading": cfg.Apps.Reading.AudioDir,
+	}
+}
+
+// runBackup executes a backup subcommand against the running database
+func runBackup(ctx context.Context, manager *backup.Manager, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(backupUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		archive, err := manager.Create(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created %s (%d bytes)\n", archive.Path, archive.Size)
+		return nil
+
+	case "list":
+		archives, err := manager.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ARCHIVE\tCREATED AT\tSIZE")
+		for _, a := range archives {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", a.Name(), a.CreatedAt.Format("2006-01-02 15:04:05"), a.Size)
+		}
+		return w.Flush()
+
+	case "prune":
+		dropped, err := manager.Prune()
+		if err != nil {
+			return err
+		}
+		for _, a := range dropped {
+			fmt.Fprintf(out, "Deleted %s\n", a.Name())
+		}
+		fmt.Fprintf(out, "Pruned %d archives\n", len(dropped))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown backup command %q\n%s", args[0], backupUsage)
+	}
+}
+
+// runRestore restores an archive over the configured database and audio
+// directories
+func runRestore(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(restoreUsage)
+	}
+
+	result, err := backup.Restore(args[0], cfg.DatabaseURL, audioDirs(cfg))
+	if err != nil {
+		// A partial restore still lists where the previous copies are
+		if result != nil {
+			for _, p := range result.Previous {
+				fmt.Fprintf(out, "Previous copy kept at %s\n", p)
+			}
+		}
+		return err
+	}
+
+	if m := result.Manifest; m != nil {
+		fmt.Fprintf(out, "Restored backup from %s (%d audio files)\n",
+			m.CreatedAt.Format("2006-01-02 15:04:05 MST"), m.AudioFiles)
+	} else {
+		fmt.Fprintln(out, "Restored backup")
+	}
+	for _, p := range result.Previous {
+		fmt.Fprintf(out, "Previous copy kept at %s\n", p)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// setupBackupConfig configures a database with one row and a recording in a
+// temporary directory
+func setupBackupConfig(t *testing.T) (*config.Config, *database.Pool) {
+	t.Helper()
+	root := t.TempDir()
+
+	cfg := config.Default()
+	cfg.DatabaseURL = filepath.Join(root, "data", "unified.db")
+	cfg.Backup.Dir = filepath.Join(root, "backups")
+	cfg.Apps.Math.AudioDir = filepath.Join(root, "data", "audio", "math")
+	cfg.Apps.Reading.AudioDir = filepath.Join(root, "data", "audio", "reading")
+
+	db, err := database.InitPool(cfg.DatabaseURL)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);
+		INSERT INTO notes (body) VALUES ('before backup');`); err != nil {
+		t.Fatalf("Failed to seed database: %v", err)
+	}
+
+	recording := filepath.Join(cfg.Apps.Math.AudioDir, "u1", "math_1.webm")
+	if err := os.MkdirAll(filepath.Dir(recording), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(recording, []byte("recording"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return cfg, db
+}
+
+func TestRunBackup(t *testing.T) {
+	cfg, db := setupBackupConfig(t)
+	manager := newBackupManager(cfg, db)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "list empty", args: []string{"list"}, want: "ARCHIVE"},
+		{name: "create", args: []string{"create"}, want: "Created " + cfg.Backup.Dir},
+		{name: "list", args: []string{"list"}, want: "unified-"},
+		{name: "prune", args: []string{"prune"}, want: "Pruned 0 archives"},
+		{name: "no command", args: nil, wantErr: "usage: server backup"},
+		{name: "unknown command", args: []string{"restore"}, wantErr: `unknown backup command "restore"`},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := runBackup(context.Background(), manager, tt.args, &out)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case !strings.Contains(out.String(), tt.want):
+			t.Errorf("%s: expected output containing %q, got:\n%s", tt.name, tt.want, out.String())
+		}
+	}
+}
+
+func TestRunRestore(t *testing.T) {
+	cfg, db := setupBackupConfig(t)
+	archive, err := newBackupManager(cfg, db).Create(context.Background())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	db.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "restore", args: []string{archive.Path}, want: "(1 audio files)\nPrevious copy kept at " + cfg.DatabaseURL + ".pre-restore-"},
+		{name: "missing archive", args: []string{filepath.Join(cfg.Backup.Dir, "missing.tar.gz")}, wantErr: "no such file"},
+		{name: "no archive", args: nil, wantErr: "usage: server restore"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := runRestore(cfg, tt.args, &out)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case !strings.Contains(out.String(), tt.want):
+			t.Errorf("%s: expected output containing %q, got:\n%s", tt.name, tt.want, out.String())
+		}
+	}
+}
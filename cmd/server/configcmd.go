@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jgirmay/unified-go/internal/config"
+)
+
+const configUsage = `usage: server [-config file] config <command>
+
+commands:
+  check   validate the configuration and print the effective values with secrets redacted`
+
+// runConfig executes a config subcommand against the loaded configuration
+func runConfig(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errors.New(configUsage)
+	}
+
+	data, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	fmt.Fprintln(out, "# configuration is valid")
+	return nil
+}
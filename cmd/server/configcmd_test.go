@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/config"
+)
+
+func TestRunConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*config.Config)
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", modify: func(c *config.Config) { c.SessionSecret = "hunter2-secret" }, args: []string{"check"}, want: "# configuration is valid"},
+		{name: "invalid", modify: func(c *config.Config) { c.Port = 70000 }, args: []string{"check"}, want: "port: 70000", wantErr: "invalid configuration"},
+		{name: "no command", modify: func(c *config.Config) {}, wantErr: "usage: server"},
+		{name: "unknown command", modify: func(c *config.Config) {}, args: []string{"show"}, wantErr: "usage: server"},
+		{name: "extra argument", modify: func(c *config.Config) {}, args: []string{"check", "now"}, wantErr: "usage: server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			tt.modify(cfg)
+
+			var out bytes.Buffer
+			err := runConfig(cfg, tt.args, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("Expected output containing %q, got:\n%s", tt.want, out.String())
+			}
+			if strings.Contains(out.String(), "hunter2") {
+				t.Error("The session secret was printed")
+			}
+		})
+	}
+}
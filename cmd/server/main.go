@@ -2,87 +2,255 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"flag"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/cli"
 	"github.com/jgirmay/unified-go/internal/config"
 	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/lifecycle"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/repository"
 	"github.com/jgirmay/unified-go/internal/router"
+	"github.com/jgirmay/unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
 )
 
+// eventHistorySize is how many recent events the bus keeps
+const eventHistorySize = 1000
+
+// auditRetentionInterval is how often expired audit entries are purged
+const auditRetentionInterval = 24 * time.Hour
+
+// shutdownTimeout bounds the graceful shutdown: in-flight requests finish and
+// the queues drain within it, and whatever is left is dropped
+const shutdownTimeout = 30 * time.Second
+
 var startTime time.Time
 
 func main() {
 	startTime = time.Now()
 
-	// Load configuration
-	cfg, err := config.Load()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file")
+	flag.Parse()
+	args := flag.Args()
+
+	// Load configuration: defaults, then the config file, then env overrides
+	cfg, err := config.LoadFile(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("failed to load configuration", err)
+	}
+
+	// "server config check" prints the effective configuration and exits
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:], os.Stdout); err != nil {
+			fatal("config command failed", err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fatal("invalid configuration", err)
 	}
 
-	log.Printf("Starting Unified Educational App Server...")
-	log.Printf("Environment: %s", cfg.Environment)
-	log.Printf("Port: %d", cfg.Port)
+	// Structured logging; the standard log package is routed through it too
+	if err := logging.Setup(os.Stderr, logging.Options{
+		Level:    cfg.Logging.Level,
+		Format:   cfg.Logging.Format,
+		Packages: cfg.Logging.Packages,
+	}); err != nil {
+		fatal("failed to set up logging", err)
+	}
+
+	// "server restore <archive>" replaces the database, so it runs before
+	// the database is opened
+	if len(args) > 0 && args[0] == "restore" {
+		if err := runRestore(cfg, args[1:], os.Stdout); err != nil {
+			fatal("restore failed", err)
+		}
+		return
+	}
+
+	slog.Info("starting unified educational app server",
+		"environment", cfg.Environment, "port", cfg.Port)
 
 	// Initialize database
 	db, err := database.InitPool(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("failed to initialize database", err)
 	}
 	defer db.Close()
 
-	log.Printf("Database initialized successfully at: %s", cfg.DatabaseURL)
+	slog.Info("database initialized", "path", cfg.DatabaseURL)
+
+	// "server migrate ..." manages the schema and exits
+	if len(args) > 0 && args[0] == "migrate" {
+		err := cli.Audited(context.Background(), db, "server", func(ctx context.Context) error {
+			return cli.Migrate(ctx, db, "server", args[1:], os.Stdout)
+		})
+		if err != nil {
+			fatal("migration command failed", err)
+		}
+		return
+	}
+
+	// "server backup ..." creates, lists or prunes archives and exits
+	if len(args) > 0 && args[0] == "backup" {
+		if err := runBackup(context.Background(), newBackupManager(cfg, db), args[1:], os.Stdout); err != nil {
+			fatal("backup command failed", err)
+		}
+		return
+	}
 
 	// Run migrations
 	if err := database.RunMigrations(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		fatal("failed to run migrations", err)
+	}
+
+	// The readiness probe checks the audio directories without creating them
+	for _, dir := range []string{cfg.Apps.Math.AudioDir, cfg.Apps.Reading.AudioDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fatal("failed to create audio directory", err)
+		}
 	}
 
-	log.Printf("Database migrations completed successfully")
+	// Long-running components start in registration order and stop in
+	// reverse, so the HTTP server stops taking requests before the queues
+	// it feeds are drained
+	lc := lifecycle.New()
 
-	// Setup router
-	r := router.Setup(cfg, db)
+	// The realtime hub and event bus shared by the apps
+	hub := realtime.NewHubWithBuffers(cfg.Realtime.HubBroadcastBuffer, cfg.Realtime.ClientSendBuffer)
+	lc.Register("realtime hub", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			go hub.Run()
+			return nil
+		},
+		Stop: func(ctx context.Context) (int, error) {
+			return hub.Shutdown(ctx), nil
+		},
+	})
+	bus := events.NewBusWithQueue(eventHistorySize, cfg.Realtime.EventQueueSize)
+	lc.Register("event bus", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			go bus.Run()
+			return nil
+		},
+		Stop: func(ctx context.Context) (int, error) {
+			return bus.Drain(ctx), nil
+		},
+	})
+
+	// Audit log, with entries past the retention period purged daily
+	auditLog := audit.NewStore(db.DB)
+	lc.Register("audit log", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			if cfg.Audit.RetentionDays > 0 {
+				auditLog.StartRetention(time.Duration(cfg.Audit.RetentionDays)*24*time.Hour, auditRetentionInterval)
+			}
+			return nil
+		},
+		Stop: lifecycle.Closer(auditLog.Close),
+	})
+
+	// The GAIA task queue keeps its own database
+	gaiaStore, err := storage.NewSQLiteStore(storage.Config{DatabasePath: cfg.GaiaDatabaseURL})
+	if err != nil {
+		fatal("failed to open GAIA database", err)
+	}
+	if err := gaiaStore.Initialize(context.Background()); err != nil {
+		gaiaStore.Close()
+		fatal("failed to migrate GAIA database", err)
+	}
+	lc.Register("gaia store", lifecycle.Hooks{Stop: lifecycle.ErrCloser(gaiaStore.Close)})
+	slog.Info("GAIA database initialized", "path", cfg.GaiaDatabaseURL)
+
+	// Setup router; it registers session cleanup and the rate limiters
+	r := router.Setup(cfg, db, &router.Services{
+		Hub:       hub,
+		Bus:       bus,
+		Audit:     auditLog,
+		Gaia:      repository.NewManager(gaiaStore),
+		Lifecycle: lc,
+	})
+
+	// Scheduled backups of the database and recorded audio
+	backups := newBackupManager(cfg, db)
+	lc.Register("backups", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			if cfg.Backup.Enabled {
+				backups.Start(time.Duration(cfg.Backup.IntervalHours) * time.Hour)
+			}
+			return nil
+		},
+		Stop: lifecycle.ErrCloser(backups.Close),
+	})
 
 	// Create HTTP server
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
 		Handler:      r,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	lc.Register("http server", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				slog.Info("server listening", "addr", srv.Addr)
+				if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+					fatal("server failed", err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) (int, error) {
+			return 0, srv.Shutdown(ctx)
+		},
+	})
 
-	// Start server in goroutine
-	go func() {
-		log.Printf("Server listening on http://localhost:%d", cfg.Port)
-		log.Printf("Health check available at: http://localhost:%d/health", cfg.Port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
+	if err := lc.Start(context.Background()); err != nil {
+		fatal("failed to start server", err)
+	}
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
 
-	// Graceful shutdown with 30 second timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+	report := lc.Stop(ctx)
+	if err := report.Err(); err != nil {
+		slog.Error("shutdown incomplete", "error", err)
+	}
+	if dropped := report.Dropped(); dropped > 0 {
+		slog.Warn("messages dropped during shutdown", "dropped", dropped)
 	}
 
-	log.Println("Server stopped")
+	slog.Info("server stopped")
+}
+
+// fatal logs an error and exits the process
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 // GetUptime returns server uptime
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/pkg/piano"
+	"github.com/jgirmay/unified-go/pkg/reading"
+	"github.com/jgirmay/unified-go/pkg/typing"
+)
+
+const importUsage = `usage: unifiedctl import <kind> <file.json>
+
+kinds:
+  books   a JSON array of books: title, author, content, reading_level, language
+  songs   a JSON array of songs: title, composer, difficulty, duration, bpm, ...,
+          with the MIDI data as base64 in midi_file or a path in midi_path
+          relative to the JSON file
+  texts   a JSON array of typing texts: category, content
+
+Content is imported into the organization given with -org, or shared with
+every organization that opts in to shared content when -org is omitted.`
+
+const statsUsage = `usage: unifiedctl stats recompute
+
+Rebuilds the cached typing stats of every user from their test results.`
+
+// songImport is a song in an import file; MIDIPath names a MIDI file to
+// read instead of embedding it in midi_file
+type songImport struct {
+	piano.Song
+	MIDIPath string `json:"midi_path"`
+}
+
+// typingTextImport is a typing text in an import file
+type typingTextImport struct {
+	Category string `json:"category"`
+	Content  string `json:"content"`
+}
+
+// runImport imports content from a JSON file through the apps' repositories
+func runImport(ctx context.Context, db *database.Pool, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return errors.New(importUsage)
+	}
+	kind, path := args[0], args[1]
+	if kind != "books" && kind != "songs" && kind != "texts" {
+		return fmt.Errorf("unknown import kind %q\n%s", kind, importUsage)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Every entry is checked before any is saved, and all of them are saved in
+	// one transaction, so a bad file imports nothing
+	switch kind {
+	case "books":
+		var books []reading.Book
+		if err := json.Unmarshal(data, &books); err != nil {
+			return fmt.Errorf("invalid books file: %w", err)
+		}
+		for i := range books {
+			if err := books[i].Validate(); err != nil {
+				return fmt.Errorf("book %d (%q): %w", i+1, books[i].Title, err)
+			}
+		}
+		err = importTx(ctx, db, func(tx *sql.Tx) error {
+			service := reading.NewService(reading.NewRepository(db.DB).WithTx(tx))
+			for i := range books {
+				if _, err := service.SaveBook(ctx, &books[i]); err != nil {
+					return fmt.Errorf("book %d (%q): %w", i+1, books[i].Title, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		recordImport(ctx, kind, path, len(books))
+		fmt.Fprintf(out, "Imported %d books\n", len(books))
+		return nil
+
+	case "songs":
+		var songs []songImport
+		if err := json.Unmarshal(data, &songs); err != nil {
+			return fmt.Errorf("invalid songs file: %w", err)
+		}
+		for i := range songs {
+			s := &songs[i]
+			if s.MIDIPath != "" {
+				midi := s.MIDIPath
+				if !filepath.IsAbs(midi) {
+					midi = filepath.Join(filepath.Dir(path), midi)
+				}
+				if s.MIDIFile, err = os.ReadFile(midi); err != nil {
+					return fmt.Errorf("song %d (%q): %w", i+1, s.Title, err)
+				}
+			}
+			if err := s.Validate(); err != nil {
+				return fmt.Errorf("song %d (%q): %w", i+1, s.Title, err)
+			}
+		}
+		err = importTx(ctx, db, func(tx *sql.Tx) error {
+			repo := piano.NewRepository(db.DB).WithTx(tx)
+			for i, s := range songs {
+				if _, err := repo.SaveSong(ctx, &s.Song); err != nil {
+					return fmt.Errorf("song %d (%q): %w", i+1, s.Title, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		recordImport(ctx, kind, path, len(songs))
+		fmt.Fprintf(out, "Imported %d songs\n", len(songs))
+		return nil
+
+	case "texts":
+		var texts []typingTextImport
+		if err := json.Unmarshal(data, &texts); err != nil {
+			return fmt.Errorf("invalid texts file: %w", err)
+		}
+		for i := range texts {
+			texts[i].Category = strings.TrimSpace(texts[i].Category)
+			texts[i].Content = strings.TrimSpace(texts[i].Content)
+			if texts[i].Category == "" || texts[i].Content == "" {
+				return fmt.Errorf("text %d: category and content are required", i+1)
+			}
+		}
+		added := 0
+		err = importTx(ctx, db, func(tx *sql.Tx) error {
+			repo := typing.NewRepository(db.DB).WithTx(tx)
+			for i, t := range texts {
+				isNew, err := repo.SaveText(ctx, t.Category, t.Content)
+				if err != nil {
+					return fmt.Errorf("text %d: %w", i+1, err)
+				}
+				if isNew {
+					added++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		recordImport(ctx, kind, path, added)
+		fmt.Fprintf(out, "Imported %d typing texts (%d already present)\n", added, len(texts)-added)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown import kind %q\n%s", kind, importUsage)
+	}
+}
+
+// runStats executes a stats subcommand
+func runStats(ctx context.Context, db *database.Pool, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "recompute" {
+		return errors.New(statsUsage)
+	}
+
+	users, err := typing.NewRepository(db.DB).RecomputeAllStats(ctx)
+	if err != nil {
+		return err
+	}
+	audit.Describe(ctx, "stats.recompute", "", nil)
+	audit.RecordChange(ctx, nil, map[string]int{"users": users})
+	fmt.Fprintf(out, "Recomputed typing stats for %d users\n", users)
+	return nil
+}
+
+// importTx runs fn in a transaction, committed only if fn succeeds
+func importTx(ctx context.Context, db *database.Pool, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+// recordImport describes a completed import for the audit log
+func recordImport(ctx context.Context, kind, path string, imported int) {
+	audit.Describe(ctx, "content.import", "", nil)
+	audit.RecordChange(ctx, nil, map[string]interface{}{
+		"kind":     kind,
+		"file":     filepath.Base(path),
+		"imported": imported,
+	})
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/pkg/auth"
+	"github.com/jgirmay/unified-go/pkg/math"
+	"github.com/jgirmay/unified-go/pkg/piano"
+	"github.com/jgirmay/unified-go/pkg/privacy"
+	"github.com/jgirmay/unified-go/pkg/reading"
+	"github.com/jgirmay/unified-go/pkg/typing"
+)
+
+// exportPageSize is the largest page the app repositories return
+const exportPageSize = 1000
+
+// userExport is the JSON document written by "user export"
+type userExport struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	User       *auth.User  `json:"user"`
+	Typing     interface{} `json:"typing"`
+	Math       interface{} `json:"math"`
+	Reading    interface{} `json:"reading"`
+	Piano      interface{} `json:"piano"`
+}
+
+// exportUser writes a user's account and app data as JSON to path, or to
+// out when path is empty
+func exportUser(ctx context.Context, db *database.Pool, user *auth.User, path string, out io.Writer) error {
+	doc, err := collectUserData(ctx, db, user)
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Exported %s to %s\n", user.Username, path)
+	}
+	return nil
+}
+
+// exportArchive writes a user's full data archive to path, or to out when
+// path is empty
+func exportArchive(ctx context.Context, service *privacy.Service, user *auth.User, path string, out io.Writer) error {
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := service.Export(ctx, user.ID, out); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Exported %s to %s\n", user.Username, path)
+	}
+	return nil
+}
+
+// privacyService creates the export and erasure service with the apps'
+// configured audio directories
+func privacyService(cfg *config.Config, db *database.Pool) *privacy.Service {
+	return privacy.NewService(privacy.NewRepository(db.DB), map[string]string{
+		"math":    cfg.Apps.Math.AudioDir,
+		"reading": cfg.Apps.Reading.AudioDir,
+	})
+}
+
+// totalRows sums the rows erased across every table of a receipt
+func totalRows(receipt *privacy.Receipt) int64 {
+	var total int64
+	for _, n := range receipt.RowsDeleted {
+		total += n
+	}
+	return total
+}
+
+// collectUserData reads the user's data from each app's repository
+func collectUserData(ctx context.Context, db *database.Pool, user *auth.User) (*userExport, error) {
+	doc := &userExport{ExportedAt: time.Now().UTC(), User: user}
+
+	typingRepo := typing.NewRepository(db.DB)
+	typingStats, err := typingRepo.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	typingTests, err := allPages(func(limit, offset int) ([]typing.TypingTest, error) {
+		return typingRepo.GetUserTests(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Typing = map[string]interface{}{"stats": typingStats, "tests": typingTests}
+
+	mathRepo := math.NewRepository(db.DB)
+	mathResults, err := allPages(func(limit, offset int) ([]*math.MathResult, error) {
+		return mathRepo.GetResultsByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// LIMIT -1 returns every row
+	mathMistakes, err := mathRepo.GetMistakesByUser(ctx, user.ID, -1)
+	if err != nil {
+		return nil, err
+	}
+	doc.Math = map[string]interface{}{"results": mathResults, "mistakes": mathMistakes}
+
+	readingRepo := reading.NewRepository(db.DB)
+	readingStats, err := readingRepo.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	readingSessions, err := allPages(func(limit, offset int) ([]reading.ReadingSession, error) {
+		return readingRepo.GetUserSessions(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Reading = map[string]interface{}{"stats": readingStats, "sessions": readingSessions}
+
+	pianoRepo := piano.NewRepository(db.DB)
+	pianoProgress, err := pianoRepo.GetUserProgress(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	pianoLessons, err := allPages(func(limit, offset int) ([]piano.PianoLesson, error) {
+		return pianoRepo.GetUserLessons(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Piano = map[string]interface{}{"progress": pianoProgress, "lessons": pianoLessons}
+
+	return doc, nil
+}
+
+// allPages calls fetch with increasing offsets until a short page is returned
+func allPages[T any](fetch func(limit, offset int) ([]T, error)) ([]T, error) {
+	var all []T
+	for {
+		page, err := fetch(exportPageSize, len(all))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < exportPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Command unifiedctl administers a unified-go installation. It opens the
+// same database and repositories as the server, so it can run next to it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jgirmay/unified-go/internal/cli"
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+const usage = `usage: unifiedctl [-config file] [-org slug] <command> [args]
+
+commands:
+  org       list or create organizations and opt them in to shared content
+  user      create, list, disable, enable, reset-password, role, export or delete users
+  migrate   show, apply or roll back schema migrations
+  import    import books, songs or typing texts from a JSON file
+  stats     recompute cached statistics
+  tasks     inspect the GAIA task queue
+
+Run "unifiedctl <command>" for the command's usage.`
+
+func main() {
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file")
+	org := flag.String("org", "", "organization slug to scope the command to")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	if err := run(context.Background(), *configFile, *org, flag.Args(), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run loads the configuration, opens the database and dispatches a command,
+// scoped to the organization named org if it is not empty
+func run(ctx context.Context, configFile, org string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	cfg, err := config.LoadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Only warnings and errors are logged so command output stays readable
+	if err := logging.Setup(os.Stderr, logging.Options{Level: "warn", Format: "text"}); err != nil {
+		return err
+	}
+
+	// The GAIA queue has its own database
+	if args[0] == "tasks" {
+		return runTasks(ctx, cfg, args[1:], out)
+	}
+
+	db, err := database.InitPool(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Commands that change data describe themselves in the audit log
+	return cli.Audited(ctx, db, "unifiedctl", func(ctx context.Context) error {
+		if org != "" {
+			scoped, err := scopeToOrg(ctx, db, org)
+			if err != nil {
+				return err
+			}
+			ctx = scoped
+		}
+
+		switch args[0] {
+		case "org":
+			return runOrg(ctx, db, args[1:], out)
+		case "user":
+			return runUser(ctx, cfg, db, args[1:], out)
+		case "migrate":
+			return cli.Migrate(ctx, db, "unifiedctl", args[1:], out)
+		case "import":
+			return runImport(ctx, db, args[1:], out)
+		case "stats":
+			return runStats(ctx, db, args[1:], out)
+		default:
+			return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+		}
+	})
+}
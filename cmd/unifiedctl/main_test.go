@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/storage"
+)
+
+// ctlStep is one unifiedctl invocation and what it must print or fail with
+type ctlStep struct {
+	name    string
+	org     string
+	args    []string
+	want    string
+	wantErr string
+}
+
+// setupCtl points the configuration at databases and audio directories in a
+// temporary directory, applies the migrations and returns the directory
+func setupCtl(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("ENVIRONMENT", "development")
+	t.Setenv("DATABASE_URL", filepath.Join(dir, "unified.db"))
+	t.Setenv("GAIA_DATABASE_URL", filepath.Join(dir, "gaia.db"))
+	t.Setenv("MATH_AUDIO_DIR", filepath.Join(dir, "audio", "math"))
+	t.Setenv("READING_AUDIO_DIR", filepath.Join(dir, "audio", "reading"))
+
+	runSteps(t, []ctlStep{{name: "migrate", args: []string{"migrate", "up"}, want: "Applied"}})
+	return dir
+}
+
+// runSteps runs each step in order against the same databases
+func runSteps(t *testing.T, steps []ctlStep) {
+	t.Helper()
+	for _, step := range steps {
+		var out bytes.Buffer
+		err := run(context.Background(), "", step.org, step.args, &out)
+
+		switch {
+		case step.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), step.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", step.name, step.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", step.name, err)
+		case !strings.Contains(out.String(), step.want):
+			t.Errorf("%s: expected output containing %q, got:\n%s", step.name, step.want, out.String())
+		}
+	}
+}
+
+// openDB opens the test database configured by setupCtl
+func openDB(t *testing.T) *database.Pool {
+	db, err := database.InitPool(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRunUsage(t *testing.T) {
+	setupCtl(t)
+	runSteps(t, []ctlStep{
+		{name: "no command", args: nil, wantErr: "usage: unifiedctl"},
+		{name: "unknown command", args: []string{"frobnicate"}, wantErr: `unknown command "frobnicate"`},
+		{name: "unknown organization", org: "nowhere", args: []string{"user", "list"}, wantErr: "organization not found"},
+	})
+}
+
+func TestUserCommands(t *testing.T) {
+	setupCtl(t)
+	runSteps(t, []ctlStep{
+		{name: "create", args: []string{"user", "create", "-role", "teacher", "-password", "Secret123", "alice"}, want: "Created alice"},
+		{name: "create generated password", args: []string{"user", "create", "bob"}, want: "Password: "},
+		{name: "create duplicate", args: []string{"user", "create", "-password", "Secret123", "alice"}, wantErr: "already taken"},
+		{name: "create invalid role", args: []string{"user", "create", "-role", "wizard", "carol"}, wantErr: "invalid role"},
+		{name: "create weak password", args: []string{"user", "create", "-password", "short", "carol"}, wantErr: "password must be"},
+		{name: "list", args: []string{"user", "list"}, want: "teacher"},
+		{name: "disable", args: []string{"user", "disable", "alice"}, want: "Disabled alice and revoked 0 sessions"},
+		{name: "list disabled", args: []string{"user", "list"}, want: "disabled"},
+		{name: "enable", args: []string{"user", "enable", "alice"}, want: "Enabled alice"},
+		{name: "reset password", args: []string{"user", "reset-password", "-password", "Another456", "alice"}, want: "Reset the password of alice"},
+		{name: "reset generated password", args: []string{"user", "reset-password", "alice"}, want: "New password: "},
+		{name: "role", args: []string{"user", "role", "alice", "admin"}, want: "Set the role of alice to admin"},
+		{name: "invalid role", args: []string{"user", "role", "alice", "wizard"}, wantErr: "invalid role"},
+		{name: "export", args: []string{"user", "export", "alice"}, want: `"username": "alice"`},
+		{name: "delete", args: []string{"user", "delete", "bob"}, want: "Deleted bob"},
+		{name: "deleted user", args: []string{"user", "disable", "bob"}, wantErr: `user "bob" not found`},
+		{name: "missing username", args: []string{"user", "enable"}, wantErr: "usage: unifiedctl user"},
+		{name: "unknown command", args: []string{"user", "promote", "alice"}, wantErr: `unknown user command "promote"`},
+	})
+}
+
+func TestOrgCommands(t *testing.T) {
+	setupCtl(t)
+	runSteps(t, []ctlStep{
+		{name: "create", args: []string{"org", "create", "-name", "Oak School", "oak"}, want: "Created oak"},
+		{name: "create duplicate", args: []string{"org", "create", "oak"}, wantErr: "organization already exists"},
+		{name: "create invalid slug", args: []string{"org", "create", "Oak School"}, wantErr: "slug must be"},
+		{name: "list", args: []string{"org", "list"}, want: "Oak School"},
+		{name: "share", args: []string{"org", "share", "oak", "true"}, want: "Set shared content of oak to true"},
+		{name: "share invalid value", args: []string{"org", "share", "oak", "maybe"}, wantErr: "usage: unifiedctl org"},
+		{name: "share unknown", args: []string{"org", "share", "elm", "true"}, wantErr: "organization not found"},
+		{name: "scoped user create", org: "oak", args: []string{"user", "create", "dana"}, want: "Created dana"},
+		{name: "scoped user list", org: "oak", args: []string{"user", "list"}, want: "dana"},
+		{name: "unknown command", args: []string{"org", "rename", "oak"}, wantErr: `unknown org command "rename"`},
+	})
+}
+
+func TestImportCommands(t *testing.T) {
+	dir := setupCtl(t)
+	content := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 3)
+	books := writeFile(t, dir, "books.json",
+		`[{"title": "First", "author": "A", "content": "`+content+`"}, {"title": "Second", "author": "B", "content": "`+content+`"}]`)
+	shortBook := writeFile(t, dir, "short.json", `[{"title": "Short", "content": "Too short"}]`)
+	writeFile(t, dir, "song.mid", "MThd\x00\x00\x00\x06")
+	songs := writeFile(t, dir, "songs.json",
+		`[{"title": "Etude", "composer": "C", "duration": 60, "bpm": 90, "midi_path": "song.mid"}]`)
+	missingMIDI := writeFile(t, dir, "missing.json",
+		`[{"title": "Etude", "composer": "C", "duration": 60, "bpm": 90, "midi_path": "missing.mid"}]`)
+	texts := writeFile(t, dir, "texts.json",
+		`[{"category": "animals", "content": "Cats sleep a lot."}, {"category": "animals", "content": "Dogs bark."}]`)
+
+	runSteps(t, []ctlStep{
+		{name: "books", args: []string{"import", "books", books}, want: "Imported 2 books"},
+		{name: "invalid book", args: []string{"import", "books", shortBook}, wantErr: `book 1 ("Short")`},
+		{name: "songs", args: []string{"import", "songs", songs}, want: "Imported 1 songs"},
+		{name: "missing midi file", args: []string{"import", "songs", missingMIDI}, wantErr: `song 1 ("Etude")`},
+		{name: "texts", args: []string{"import", "texts", texts}, want: "Imported 2 typing texts (0 already present)"},
+		{name: "texts again", args: []string{"import", "texts", texts}, want: "Imported 0 typing texts (2 already present)"},
+		{name: "missing file", args: []string{"import", "books", filepath.Join(dir, "nope.json")}, wantErr: "no such file"},
+		{name: "unknown kind", args: []string{"import", "videos", books}, wantErr: `unknown import kind "videos"`},
+		{name: "stats", args: []string{"stats", "recompute"}, want: "Recomputed typing stats for 0 users"},
+		{name: "stats usage", args: []string{"stats"}, wantErr: "usage: unifiedctl stats"},
+	})
+}
+
+func TestImportIsAtomic(t *testing.T) {
+	dir := setupCtl(t)
+	db := openDB(t)
+
+	// The second book fails to save after the first was inserted
+	if _, err := db.Exec(`CREATE TRIGGER fail_book BEFORE INSERT ON books WHEN NEW.title = 'Broken'
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+	content := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 3)
+	books := writeFile(t, dir, "books.json",
+		`[{"title": "First", "content": "`+content+`"}, {"title": "Broken", "content": "`+content+`"}]`)
+
+	runSteps(t, []ctlStep{{name: "import", args: []string{"import", "books", books}, wantErr: "disk full"}})
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM books WHERE title = 'First'`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count books: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected a failed import to save nothing, found %d books", count)
+	}
+}
+
+func TestMigrateCommands(t *testing.T) {
+	setupCtl(t)
+	runSteps(t, []ctlStep{
+		{name: "status", args: []string{"migrate", "status"}, want: "applied"},
+		{name: "up to date", args: []string{"migrate", "up"}, want: "Applied 0 migrations"},
+		{name: "down", args: []string{"migrate", "down", "typing"}, want: "Rolled back 1 migrations"},
+		{name: "status pending", args: []string{"migrate", "status"}, want: "pending"},
+		{name: "up target", args: []string{"migrate", "up", "typing"}, want: "Applied 1 migrations"},
+		{name: "redo", args: []string{"migrate", "redo", "typing"}, want: "Re-applied the latest typing migration"},
+		{name: "invalid steps", args: []string{"migrate", "down", "typing", "x"}, wantErr: `invalid steps "x"`},
+		{name: "down without target", args: []string{"migrate", "down"}, wantErr: "usage: unifiedctl migrate"},
+		{name: "unknown command", args: []string{"migrate", "sideways"}, wantErr: `unknown migrate command "sideways"`},
+	})
+}
+
+func TestTasksCommands(t *testing.T) {
+	setupCtl(t)
+	ctx := context.Background()
+
+	store, err := storage.NewSQLiteStore(storage.Config{DatabasePath: os.Getenv("GAIA_DATABASE_URL")})
+	if err != nil {
+		t.Fatalf("Failed to open GAIA store: %v", err)
+	}
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Failed to initialize GAIA store: %v", err)
+	}
+	for _, task := range []*storage.TaskRow{
+		{Content: "Summarize the logs", Priority: 5, Status: "pending", TimeoutMinutes: 30},
+		{Content: "Rebuild the index", Priority: 1, Status: "completed", TimeoutMinutes: 30},
+	} {
+		if _, err := store.InsertTask(ctx, task); err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+	store.Close()
+
+	runSteps(t, []ctlStep{
+		{name: "summary", args: []string{"tasks", "summary"}, want: "pending    1"},
+		{name: "list", args: []string{"tasks", "list"}, want: "Rebuild the index"},
+		{name: "list by status", args: []string{"tasks", "list", "-status", "pending"}, want: "Summarize the logs"},
+		{name: "list invalid limit", args: []string{"tasks", "list", "-limit", "0"}, wantErr: "usage: unifiedctl tasks"},
+		{name: "show", args: []string{"tasks", "show", "1"}, want: "Summarize the logs"},
+		{name: "show missing", args: []string{"tasks", "show", "99"}, wantErr: "task 99 not found"},
+		{name: "show invalid id", args: []string{"tasks", "show", "one"}, wantErr: `invalid task id "one"`},
+		{name: "unknown command", args: []string{"tasks", "purge"}, wantErr: `unknown tasks command "purge"`},
+	})
+}
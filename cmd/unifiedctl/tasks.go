@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/storage"
+)
+
+const tasksUsage = `usage: unifiedctl tasks <command> [flags] [args]
+
+commands:
+  summary                             count tasks by status
+  list [-status s] [-limit n]         list tasks, highest priority and oldest first
+  show <id>                           show a task in full`
+
+// runTasks executes a tasks subcommand against the GAIA database
+func runTasks(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(tasksUsage)
+	}
+
+	store, err := storage.NewSQLiteStore(storage.Config{DatabasePath: cfg.GaiaDatabaseURL})
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := store.Initialize(ctx); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "summary":
+		counts, err := store.CountTasksByStatus(ctx)
+		if err != nil {
+			return err
+		}
+		statuses := make([]string, 0, len(counts))
+		for status := range counts {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tTASKS")
+		for _, status := range statuses {
+			fmt.Fprintf(w, "%s\t%d\n", status, counts[status])
+		}
+		return w.Flush()
+
+	case "list":
+		fs := flag.NewFlagSet("tasks list", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		status := fs.String("status", "", "only list tasks with this status")
+		limit := fs.Int("limit", 50, "maximum number of tasks")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 0 || *limit < 1 {
+			return errors.New(tasksUsage)
+		}
+
+		tasks, err := store.ListTasks(ctx, *status, *limit)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPRIORITY\tSTATUS\tSESSION\tRETRIES\tCREATED AT\tCONTENT")
+		for _, t := range tasks {
+			session := t.TargetSession
+			if session == "" {
+				session = "-"
+			}
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d/%d\t%s\t%s\n", t.ID, t.Priority, t.Status, session,
+				t.RetryCount, t.MaxRetries, t.CreatedAt.Format("2006-01-02 15:04:05"), summarize(t.Content, 60))
+		}
+		return w.Flush()
+
+	case "show":
+		if len(args) != 2 {
+			return errors.New(tasksUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid task id %q", args[1])
+		}
+		task, err := store.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task %d not found", id)
+		}
+		printTask(out, task)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown tasks command %q\n%s", args[0], tasksUsage)
+	}
+}
+
+// printTask writes every field of a task
+func printTask(out io.Writer, t *storage.TaskRow) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%d\n", t.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", t.Status)
+	fmt.Fprintf(w, "Priority:\t%d\n", t.Priority)
+	if t.TargetSession != "" {
+		fmt.Fprintf(w, "Target session:\t%s\n", t.TargetSession)
+	}
+	fmt.Fprintf(w, "Retries:\t%d/%d\n", t.RetryCount, t.MaxRetries)
+	fmt.Fprintf(w, "Timeout:\t%d minutes\n", t.TimeoutMinutes)
+	fmt.Fprintf(w, "Created at:\t%s\n", t.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Updated at:\t%s\n", t.UpdatedAt.Format("2006-01-02 15:04:05"))
+	if t.AssignedAt != nil {
+		fmt.Fprintf(w, "Assigned at:\t%s\n", t.AssignedAt.Format("2006-01-02 15:04:05"))
+	}
+	if t.CompletedAt != nil {
+		fmt.Fprintf(w, "Completed at:\t%s\n", t.CompletedAt.Format("2006-01-02 15:04:05"))
+	}
+	if t.ErrorMessage != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", t.ErrorMessage)
+	}
+	if t.Metadata != "" {
+		fmt.Fprintf(w, "Metadata:\t%s\n", t.Metadata)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n%s\n", t.Content)
+}
+
+// summarize shortens s to at most n runes on one line
+func summarize(s string, n int) string {
+	r := []rune(s)
+	for i, c := range r {
+		if c == '\n' || c == '\t' {
+			r[i] = ' '
+		}
+	}
+	if len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return string(r)
+}
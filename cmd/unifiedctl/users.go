@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/middleware"
+	"github.com/jgirmay/unified-go/pkg/auth"
+)
+
+const userUsage = `usage: unifiedctl user <command> [flags] [args]
+
+commands:
+  list                                          list every account, or those of -org
+  create [-email e] [-role r] [-password p] <username>
+                                                create an account in -org or the default organization;
+                                                a password is generated if -password is omitted
+  disable <username>                            block logins, revoke sessions and delete API tokens
+  enable <username>                             allow a disabled account to log in again
+  reset-password [-password p] <username>       set a new password and revoke sessions
+  role <username> <role>                        set the role: student, parent, teacher or admin
+  export [-zip] [-o file] <username>            write the user's data as JSON (stdout by default), or
+                                                with -zip the full archive with CSV, MIDI and audio files
+  delete <username>                             erase the account, its data and recordings; prints the receipt`
+
+// passwordAlphabet is used for generated passwords
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// runUser executes a user subcommand
+func runUser(ctx context.Context, cfg *config.Config, db *database.Pool, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	service := auth.NewService(auth.NewRepository(db.DB))
+	sessions := middleware.NewSQLiteStore(db.DB)
+
+	switch args[0] {
+	case "list":
+		users, err := service.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tORG\tROLE\tSTATUS\tLAST LOGIN")
+		for _, u := range users {
+			status := "active"
+			if u.DisabledAt != nil {
+				status = "disabled"
+			}
+			lastLogin := "-"
+			if u.LastLoginAt != nil {
+				lastLogin = u.LastLoginAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\n", u.ID, u.Username, u.OrgID, u.Role, status, lastLogin)
+		}
+		return w.Flush()
+
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		email := fs.String("email", "", "email address")
+		role := fs.String("role", auth.RoleStudent, "account role")
+		password := fs.String("password", "", "password (generated if empty)")
+		username, err := parseOne(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		return createUser(ctx, service, username, *email, *role, *password, out)
+
+	case "disable":
+		user, err := lookupUser(ctx, service, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := service.DisableUser(ctx, user.ID); err != nil {
+			return err
+		}
+		revoked, err := sessions.RevokeUserSessions(ctx, int(user.ID), "")
+		if err != nil {
+			return err
+		}
+		audit.Describe(ctx, "user.disable", "user", user.ID)
+		audit.RecordChange(ctx, map[string]bool{"disabled": user.DisabledAt != nil}, map[string]bool{"disabled": true})
+		fmt.Fprintf(out, "Disabled %s and revoked %d sessions\n", user.Username, revoked)
+		return nil
+
+	case "enable":
+		user, err := lookupUser(ctx, service, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := service.EnableUser(ctx, user.ID); err != nil {
+			return err
+		}
+		audit.Describe(ctx, "user.enable", "user", user.ID)
+		audit.RecordChange(ctx, map[string]bool{"disabled": user.DisabledAt != nil}, map[string]bool{"disabled": false})
+		fmt.Fprintf(out, "Enabled %s\n", user.Username)
+		return nil
+
+	case "reset-password":
+		fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+		password := fs.String("password", "", "new password (generated if empty)")
+		username, err := parseOne(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		user, err := lookupUser(ctx, service, []string{username})
+		if err != nil {
+			return err
+		}
+		generated := *password == ""
+		if generated {
+			if *password, err = generatePassword(user.Username); err != nil {
+				return err
+			}
+		}
+		if err := service.ChangePassword(ctx, user.ID, *password); err != nil {
+			return err
+		}
+		revoked, err := sessions.RevokeUserSessions(ctx, int(user.ID), "")
+		if err != nil {
+			return err
+		}
+		audit.Describe(ctx, "user.reset_password", "user", user.ID)
+		fmt.Fprintf(out, "Reset the password of %s and revoked %d sessions\n", user.Username, revoked)
+		if generated {
+			fmt.Fprintf(out, "New password: %s\n", *password)
+		}
+		return nil
+
+	case "role":
+		if len(args) != 3 {
+			return errors.New(userUsage)
+		}
+		user, err := lookupUser(ctx, service, args[1:2])
+		if err != nil {
+			return err
+		}
+		if err := service.SetRole(ctx, user.ID, args[2]); err != nil {
+			return fmt.Errorf("%w: %s", err, args[2])
+		}
+		audit.Describe(ctx, "user.set_role", "user", user.ID)
+		audit.RecordChange(ctx, map[string]string{"role": user.Role}, map[string]string{"role": args[2]})
+		fmt.Fprintf(out, "Set the role of %s to %s\n", user.Username, args[2])
+		return nil
+
+	case "export":
+		fs := flag.NewFlagSet("user export", flag.ContinueOnError)
+		output := fs.String("o", "", "output file")
+		archive := fs.Bool("zip", false, "write the full ZIP archive")
+		username, err := parseOne(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		user, err := lookupUser(ctx, service, []string{username})
+		if err != nil {
+			return err
+		}
+		if *archive {
+			return exportArchive(ctx, privacyService(cfg, db), user, *output, out)
+		}
+		return exportUser(ctx, db, user, *output, out)
+
+	case "delete":
+		user, err := lookupUser(ctx, service, args[1:])
+		if err != nil {
+			return err
+		}
+		// Erasures from the command line are recorded as requested by user 0
+		receipt, err := privacyService(cfg, db).Erase(ctx, user.ID, 0, user.Username)
+		if err != nil {
+			return err
+		}
+		audit.Describe(ctx, "account.erase", "user", user.ID)
+		audit.RecordChange(ctx, nil, map[string]interface{}{
+			"receipt_id":    receipt.ID,
+			"files_deleted": receipt.FilesDeleted,
+		})
+		fmt.Fprintf(out, "Deleted %s (receipt %s): %d rows and %d files\n",
+			user.Username, receipt.ID, totalRows(receipt), receipt.FilesDeleted)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown user command %q\n%s", args[0], userUsage)
+	}
+}
+
+// createUser registers an account. Registration only creates students, so
+// other roles are granted once the account exists.
+func createUser(ctx context.Context, service *auth.Service, username, email, role, password string, out io.Writer) error {
+	if !auth.ValidRole(role) {
+		return fmt.Errorf("%w: %s", auth.ErrInvalidRole, role)
+	}
+
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = generatePassword(username); err != nil {
+			return err
+		}
+	}
+
+	user, err := service.Register(ctx, &auth.RegisterRequest{
+		Username: username,
+		Password: password,
+		Email:    email,
+	})
+	if err != nil {
+		return err
+	}
+	if role != auth.RoleStudent {
+		if err := service.SetRole(ctx, user.ID, role); err != nil {
+			return err
+		}
+	}
+
+	audit.Describe(ctx, "user.create", "user", user.ID)
+	audit.RecordChange(ctx, nil, map[string]string{"username": user.Username, "role": role})
+	fmt.Fprintf(out, "Created %s (id %d, role %s)\n", user.Username, user.ID, role)
+	if generated {
+		fmt.Fprintf(out, "Password: %s\n", password)
+	}
+	return nil
+}
+
+// lookupUser resolves the single username in args
+func lookupUser(ctx context.Context, service *auth.Service, args []string) (*auth.User, error) {
+	if len(args) != 1 {
+		return nil, errors.New(userUsage)
+	}
+	user, err := service.GetUserByUsername(ctx, args[0])
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return nil, fmt.Errorf("user %q not found", args[0])
+	}
+	return user, err
+}
+
+// parseOne parses flags followed by exactly one argument
+func parseOne(fs *flag.FlagSet, args []string) (string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return "", fmt.Errorf("%w\n%s", err, userUsage)
+	}
+	if fs.NArg() != 1 {
+		return "", errors.New(userUsage)
+	}
+	return fs.Arg(0), nil
+}
+
+// generatePassword returns a random password that meets the strength rules
+func generatePassword(username string) (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for {
+		b := make([]byte, 16)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b[i] = passwordAlphabet[n.Int64()]
+		}
+		if auth.ValidatePasswordStrength(string(b), username) == nil {
+			return string(b), nil
+		}
+	}
+}
@@ -5,10 +5,10 @@ go 1.21
 require (
 	github.com/go-chi/chi/v5 v5.0.11
 	github.com/gorilla/sessions v1.2.2
+	github.com/gorilla/websocket v1.5.3
 	github.com/mattn/go-sqlite3 v1.14.19
 )
 
-require (
-	github.com/gorilla/securecookie v1.1.2 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
-)
+require github.com/gorilla/securecookie v1.1.2
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,187 @@
+// Package apierror is the single error format of the HTTP API: an RFC 9457
+// problem+json body with a stable machine-readable code, optional
+// field-level validation details and the request ID.
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "detail": "title is required",
+//	  "instance": "/reading/api/api/books",
+//	  "code": "VALIDATION_FAILED",
+//	  "request_id": "host/abc123-000042",
+//	  "errors": [{"field": "title", "message": "is required"}]
+//	}
+//
+// Clients should branch on code, not on detail, which is for people.
+//
+// Services return *Error for mistakes the caller can fix, such as failed
+// validation; handlers pass those through and report anything else as a
+// generic INTERNAL_ERROR so internal error text never reaches the client.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentType is the media type of error responses
+const ContentType = "application/problem+json"
+
+// Code is a stable, machine-readable error identifier
+type Code string
+
+// Error codes
+const (
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeMethodNotAllowed     Code = "METHOD_NOT_ALLOWED"
+	CodeConflict             Code = "CONFLICT"
+	CodePayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+	CodeUnavailable          Code = "SERVICE_UNAVAILABLE"
+)
+
+// CodeForStatus is the default code for an HTTP status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeValidationFailed
+}
+
+// FieldError is one invalid field, named by its JSON path such as
+// "body.quality" or "query.limit"
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an API error with the status and code it is reported with
+type Error struct {
+	Status int
+	Code   Code
+	Detail string
+	Fields []FieldError
+}
+
+// Error returns the detail message
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// New creates an error with the default code for status
+func New(status int, detail string) *Error {
+	return &Error{Status: status, Code: CodeForStatus(status), Detail: detail}
+}
+
+// Invalid reports one invalid field. Its message reads as "<field> <message>",
+// e.g. Invalid("title", "is required") is "title is required".
+func Invalid(field, message string) *Error {
+	return Validation(field+" "+message, FieldError{Field: field, Message: message})
+}
+
+// Validation reports a failed validation with optional field details
+func Validation(detail string, fields ...FieldError) *Error {
+	return &Error{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: detail,
+		Fields: fields,
+	}
+}
+
+// NotFound reports a missing resource
+func NotFound(detail string) *Error {
+	return New(http.StatusNotFound, detail)
+}
+
+// Forbidden reports a caller who may not perform the action
+func Forbidden(detail string) *Error {
+	return New(http.StatusForbidden, detail)
+}
+
+// Conflict reports a request that conflicts with existing state
+func Conflict(detail string) *Error {
+	return New(http.StatusConflict, detail)
+}
+
+// As finds an *Error in err's chain
+func As(err error) (*Error, bool) {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// problem is the problem+json body
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Write sends e as problem+json and echoes the request ID in the body and
+// the X-Request-Id header
+func Write(w http.ResponseWriter, r *http.Request, e *Error) {
+	p := problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Detail: e.Detail,
+		Code:   e.Code,
+		Errors: e.Fields,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = chimiddleware.GetReqID(r.Context())
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", ContentType)
+	if p.RequestID != "" {
+		h.Set("X-Request-Id", p.RequestID)
+	}
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Respond sends a message with the default code for status
+func Respond(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
@@ -0,0 +1,113 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Code
+	}{
+		{http.StatusBadRequest, CodeValidationFailed},
+		{http.StatusUnprocessableEntity, CodeValidationFailed},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusForbidden, CodeForbidden},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusBadGateway, CodeInternal},
+		{http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := CodeForStatus(tt.status); got != tt.want {
+			t.Errorf("CodeForStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestAsFindsWrappedError(t *testing.T) {
+	err := fmt.Errorf("invalid book: %w", Invalid("title", "is required"))
+
+	apiErr, ok := As(err)
+	if !ok {
+		t.Fatal("As did not find the wrapped *Error")
+	}
+	if apiErr.Status != http.StatusBadRequest || apiErr.Code != CodeValidationFailed {
+		t.Errorf("got %d %s, want 400 VALIDATION_FAILED", apiErr.Status, apiErr.Code)
+	}
+	if apiErr.Detail != "title is required" {
+		t.Errorf("Detail = %q", apiErr.Detail)
+	}
+
+	if _, ok := As(fmt.Errorf("disk full")); ok {
+		t.Error("As matched a plain error")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/reading/api/api/books", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chimiddleware.RequestIDKey, "host/abc-000001"))
+	w := httptest.NewRecorder()
+
+	Write(w, req, Validation("title is required", FieldError{Field: "title", Message: "is required"}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if id := w.Header().Get("X-Request-Id"); id != "host/abc-000001" {
+		t.Errorf("X-Request-Id = %q", id)
+	}
+
+	var got problem
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := problem{
+		Type:      "about:blank",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Detail:    "title is required",
+		Instance:  "/reading/api/api/books",
+		Code:      CodeValidationFailed,
+		RequestID: "host/abc-000001",
+		Errors:    []FieldError{{Field: "title", Message: "is required"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %+v, want %+v", got, want)
+	}
+}
+
+func TestRespondWithoutRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	Respond(w, httptest.NewRequest(http.MethodGet, "/piano/api/songs/9", nil), http.StatusNotFound, "Song not found")
+
+	if w.Header().Get("X-Request-Id") != "" {
+		t.Error("X-Request-Id set without a request ID")
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["code"] != string(CodeNotFound) || got["detail"] != "Song not found" {
+		t.Errorf("body = %v", got)
+	}
+	for _, key := range []string{"request_id", "errors"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("empty %s was not omitted", key)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package audit keeps an append-only log of who changed what: every
+// data-modifying HTTP request and every admin CLI operation. The HTTP
+// middleware and the CLI put an entry in the context; handlers and commands
+// describe the action and the change through Describe and RecordChange.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Entry sources
+const (
+	SourceHTTP = "http"
+	SourceCLI  = "cli"
+)
+
+// maxChangeValueLen caps string values kept in a change, so book texts and
+// MIDI data are not copied into the log
+const maxChangeValueLen = 256
+
+// Entry is one audited action
+type Entry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source"`
+	// OrgID is the organization the action was taken in, or 0 for
+	// deployment-wide command-line operations
+	OrgID int64 `json:"org_id,omitempty"`
+	// ActorID is the authenticated user, or 0 when there was none
+	ActorID int `json:"actor_id,omitempty"`
+	// Actor names a non-user actor, e.g. the operating system user running unifiedctl
+	Actor      string `json:"actor,omitempty"`
+	Action     string `json:"action"`
+	App        string `json:"app,omitempty"`
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   string `json:"target_id,omitempty"`
+	// Changes maps each changed field to its before and after values
+	Changes   json.RawMessage `json:"changes,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Path      string          `json:"path,omitempty"`
+	Status    int             `json:"status,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// Change is the before and after value of one field
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type contextKey struct{}
+
+// pending is the entry being built for a request or command. Fields learned
+// later, such as the target, are filled in as they become known.
+type pending struct {
+	mu    sync.Mutex
+	entry Entry
+}
+
+// NewContext returns a context carrying an entry to be completed and recorded
+// by the caller
+func NewContext(ctx context.Context, entry Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &pending{entry: entry})
+}
+
+// FromContext returns a copy of the entry built so far, if ctx carries one
+func FromContext(ctx context.Context) (Entry, bool) {
+	p, ok := ctx.Value(contextKey{}).(*pending)
+	if !ok {
+		return Entry{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.entry, true
+}
+
+// update applies fn to the context's entry, if there is one
+func update(ctx context.Context, fn func(e *Entry)) {
+	if p, ok := ctx.Value(contextKey{}).(*pending); ok {
+		p.mu.Lock()
+		fn(&p.entry)
+		p.mu.Unlock()
+	}
+}
+
+// Describe names the action and its target, e.g. "book.create" on book 12.
+// An empty targetType leaves the target unset.
+func Describe(ctx context.Context, action, targetType string, targetID interface{}) {
+	update(ctx, func(e *Entry) {
+		e.Action = action
+		if targetType != "" {
+			e.TargetType = targetType
+			e.TargetID = fmt.Sprint(targetID)
+		}
+	})
+}
+
+// SetApp records which app served the request
+func SetApp(ctx context.Context, app string) {
+	update(ctx, func(e *Entry) { e.App = app })
+}
+
+// SetOrg records the organization the action was taken in
+func SetOrg(ctx context.Context, orgID int64) {
+	update(ctx, func(e *Entry) { e.OrgID = orgID })
+}
+
+// SetActor records the authenticated user
+func SetActor(ctx context.Context, userID int) {
+	update(ctx, func(e *Entry) { e.ActorID = userID })
+}
+
+// RecordChange stores the fields that differ between before and after. Either
+// may be nil, for a create or a delete. Values are compared by their JSON form.
+func RecordChange(ctx context.Context, before, after interface{}) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to diff audited change", "error", err)
+		return
+	}
+	update(ctx, func(e *Entry) { e.Changes = changes })
+}
+
+// Diff returns the JSON object of fields that differ between before and
+// after, or nil when nothing changed
+func Diff(before, after interface{}) (json.RawMessage, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changes[k] = Change{Before: truncate(v), After: truncate(a[k])}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{Before: nil, After: truncate(v)}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+// fields converts v to a map of its JSON fields
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("audited values must be JSON objects: %w", err)
+	}
+	return m, nil
+}
+
+// truncate shortens long string values
+func truncate(v interface{}) interface{} {
+	if s, ok := v.(string); ok && len(s) > maxChangeValueLen {
+		n := maxChangeValueLen
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return fmt.Sprintf("%s… (%d bytes)", s[:n], len(s))
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type book struct {
+		Title  string `json:"title"`
+		Author string `json:"author"`
+		Pages  int    `json:"pages"`
+	}
+
+	tests := []struct {
+		name          string
+		before, after interface{}
+		expected      string
+	}{
+		{"create", nil, book{"Dune", "Herbert", 412}, `{"author":{"before":null,"after":"Herbert"},"pages":{"before":null,"after":412},"title":{"before":null,"after":"Dune"}}`},
+		{"delete", &book{"Dune", "Herbert", 412}, (*book)(nil), `{"author":{"before":"Herbert","after":null},"pages":{"before":412,"after":null},"title":{"before":"Dune","after":null}}`},
+		{"update", book{"Dune", "Herbert", 412}, book{"Dune", "Herbert", 896}, `{"pages":{"before":412,"after":896}}`},
+		{"unchanged", book{"Dune", "Herbert", 412}, book{"Dune", "Herbert", 412}, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("Diff failed: %v", err)
+			}
+			if string(changes) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestDiffTruncatesLongValues(t *testing.T) {
+	changes, err := Diff(nil, map[string]string{"content": strings.Repeat("é", 300)})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	var decoded map[string]Change
+	if err := json.Unmarshal(changes, &decoded); err != nil {
+		t.Fatalf("invalid changes: %v", err)
+	}
+	after, _ := decoded["content"].After.(string)
+	if !strings.HasSuffix(after, "… (600 bytes)") || len(after) > maxChangeValueLen+20 {
+		t.Errorf("Expected the content to be truncated, got %q", after)
+	}
+}
+
+func TestDiffRejectsNonObjects(t *testing.T) {
+	if _, err := Diff(nil, []int{1, 2}); err == nil {
+		t.Error("Expected an error for a non-object value")
+	}
+}
+
+func TestContextHooks(t *testing.T) {
+	// Hooks are no-ops without an entry in the context
+	Describe(context.Background(), "book.create", "book", 1)
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("Expected no entry in a plain context")
+	}
+
+	ctx := NewContext(context.Background(), Entry{Source: SourceHTTP})
+	SetApp(ctx, "reading")
+	SetActor(ctx, 4)
+	Describe(ctx, "book.create", "book", 12)
+	RecordChange(ctx, nil, map[string]string{"title": "Dune"})
+
+	e, ok := FromContext(ctx)
+	if !ok {
+		t.Fatal("Expected an entry in the context")
+	}
+	if e.App != "reading" || e.ActorID != 4 || e.Action != "book.create" || e.TargetType != "book" || e.TargetID != "12" {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+	if string(e.Changes) != `{"title":{"before":null,"after":"Dune"}}` {
+		t.Errorf("Unexpected changes: %s", e.Changes)
+	}
+}
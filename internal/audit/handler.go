@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// Handler serves GET /admin/audit. It does not check the caller; mount it
+// behind an admin-only middleware.
+type Handler struct {
+	store *Store
+}
+
+// NewHandler creates the audit log query handler
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+// ServeHTTP lists audit entries matching the query's filters, newest first.
+// Only the request organization's entries are listed; the default
+// organization also sees deployment-wide command-line operations.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if org, ok := tenant.FromContext(r.Context()); ok {
+		f.OrgID = org.ID
+		f.Deployment = org.Slug == tenant.DefaultSlug
+	}
+
+	entries, total, err := h.store.List(r.Context(), f)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to list audit entries", "error", err)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to list audit entries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
+// parseFilter reads the list filters from the query string
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Action:     q.Get("action"),
+		App:        q.Get("app"),
+		Source:     q.Get("source"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"actor_id", &f.ActorID},
+		{"limit", &f.Limit},
+		{"offset", &f.Offset},
+	}
+	for _, i := range ints {
+		if v := q.Get(i.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return f, fmt.Errorf("invalid %s", i.name)
+			}
+			*i.dst = n
+		}
+	}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+	}
+	for _, t := range times {
+		if v := q.Get(t.name); v != "" {
+			parsed, err := parseTime(v)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s: use RFC 3339 or YYYY-MM-DD", t.name)
+			}
+			*t.dst = parsed
+		}
+	}
+
+	return f, nil
+}
+
+// parseTime accepts an RFC 3339 timestamp or a UTC date
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
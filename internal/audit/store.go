@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// logger is the audit package logger
+var logger = logging.Logger("audit")
+
+// Default and maximum page sizes for List
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Store writes and queries the audit_log table
+type Store struct {
+	db *sql.DB
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStore creates an audit store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Filter selects audit entries. Zero fields match everything.
+type Filter struct {
+	// OrgID restricts the entries to one organization
+	OrgID int64
+	// Deployment also matches entries recorded outside any organization
+	Deployment bool
+	ActorID    int
+	Action     string
+	App        string
+	Source     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Offset     int
+}
+
+// Record appends an entry. CreatedAt defaults to now.
+func (s *Store) Record(ctx context.Context, e *Entry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (created_at, source, org_id, actor_id, actor, action, app, target_type, target_id,
+			changes, method, path, status, ip, request_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.CreatedAt, e.Source, nullInt(int(e.OrgID)), nullInt(e.ActorID), nullString(e.Actor), e.Action, nullString(e.App),
+		nullString(e.TargetType), nullString(e.TargetID), nullString(string(e.Changes)),
+		nullString(e.Method), nullString(e.Path), nullInt(e.Status), nullString(e.IP), nullString(e.RequestID))
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// List returns the entries matching f, newest first, and the number of
+// matching entries across all pages
+func (s *Store) List(ctx context.Context, f Filter) ([]Entry, int, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+	if f.OrgID > 0 && f.Deployment {
+		add("(org_id = ? OR org_id IS NULL)", f.OrgID)
+	} else if f.OrgID > 0 {
+		add("org_id = ?", f.OrgID)
+	}
+	if f.ActorID > 0 {
+		add("actor_id = ?", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.App != "" {
+		add("app = ?", f.App)
+	}
+	if f.Source != "" {
+		add("source = ?", f.Source)
+	}
+	if f.TargetType != "" {
+		add("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = ?", f.TargetID)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >= ?", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		add("created_at < ?", f.Until.UTC())
+	}
+
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+clause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, created_at, source, org_id, actor_id, actor, action, app, target_type, target_id,
+			changes, method, path, status, ip, request_id
+		FROM audit_log`+clause+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var orgID, actorID, status sql.NullInt64
+		var actor, app, targetType, targetID, changes, method, path, ip, requestID sql.NullString
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Source, &orgID, &actorID, &actor, &e.Action, &app,
+			&targetType, &targetID, &changes, &method, &path, &status, &ip, &requestID); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		e.OrgID = orgID.Int64
+		e.ActorID = int(actorID.Int64)
+		e.Status = int(status.Int64)
+		e.Actor, e.App, e.TargetType, e.TargetID = actor.String, app.String, targetType.String, targetID.String
+		e.Method, e.Path, e.IP, e.RequestID = method.String, path.String, ip.String, requestID.String
+		if changes.String != "" {
+			e.Changes = []byte(changes.String)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Purge deletes entries created before cutoff and returns how many were removed
+func (s *Store) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM audit_log WHERE created_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit entries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StartRetention purges entries older than maxAge every interval until Close
+// is called
+func (s *Store) StartRetention(maxAge, interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeOlderThan(maxAge)
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// purgeOlderThan runs one retention pass
+func (s *Store) purgeOlderThan(maxAge time.Duration) {
+	n, err := s.Purge(context.Background(), time.Now().Add(-maxAge))
+	if err != nil {
+		logger.Error("audit retention failed", "error", err)
+	} else if n > 0 {
+		logger.Info("purged expired audit entries", "count", n)
+	}
+}
+
+// Close stops the retention loop started by StartRetention
+func (s *Store) Close() {
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+			<-s.done
+		}
+	})
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullInt stores zero as NULL
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
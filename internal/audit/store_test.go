@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// setupTestStore opens a migrated database
+func setupTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := database.InitPool(filepath.Join(t.TempDir(), "unified.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return NewStore(db.DB)
+}
+
+// seedEntries records three entries, a day apart, ending now
+func seedEntries(t *testing.T, store *Store) {
+	t.Helper()
+	now := time.Now().UTC()
+	entries := []Entry{
+		{CreatedAt: now.Add(-48 * time.Hour), Source: SourceCLI, Actor: "unifiedctl:root", Action: "user.create", TargetType: "user", TargetID: "1"},
+		{CreatedAt: now.Add(-24 * time.Hour), Source: SourceHTTP, ActorID: 1, Action: "book.create", App: "reading", TargetType: "book", TargetID: "5", Status: 201},
+		{CreatedAt: now, Source: SourceHTTP, ActorID: 2, Action: "song.create", App: "piano", TargetType: "song", TargetID: "3", Status: 201,
+			Changes: json.RawMessage(`{"title":{"before":null,"after":"Clair de Lune"}}`)},
+	}
+	for i := range entries {
+		if err := store.Record(context.Background(), &entries[i]); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	store := setupTestStore(t)
+	seedEntries(t, store)
+	ctx := context.Background()
+
+	entries, total, err := store.List(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total != 3 || len(entries) != 3 || entries[0].Action != "song.create" {
+		t.Fatalf("Expected all entries newest first, got %d: %+v", total, entries)
+	}
+	if string(entries[0].Changes) != `{"title":{"before":null,"after":"Clair de Lune"}}` || entries[2].Actor != "unifiedctl:root" {
+		t.Errorf("Expected the entries' fields to round-trip, got %+v", entries)
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"actor", Filter{ActorID: 1}, []string{"book.create"}},
+		{"action", Filter{Action: "user.create"}, []string{"user.create"}},
+		{"app", Filter{App: "piano"}, []string{"song.create"}},
+		{"source", Filter{Source: SourceHTTP}, []string{"song.create", "book.create"}},
+		{"target", Filter{TargetType: "book", TargetID: "5"}, []string{"book.create"}},
+		{"since", Filter{Since: time.Now().Add(-36 * time.Hour)}, []string{"song.create", "book.create"}},
+		{"until", Filter{Until: time.Now().Add(-36 * time.Hour)}, []string{"user.create"}},
+		{"page", Filter{Limit: 1, Offset: 1}, []string{"book.create"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, _, err := store.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			var actions []string
+			for _, e := range entries {
+				actions = append(actions, e.Action)
+			}
+			if len(actions) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, actions)
+			}
+			for i := range actions {
+				if actions[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, actions)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreIsAppendOnly(t *testing.T) {
+	store := setupTestStore(t)
+	seedEntries(t, store)
+
+	if _, err := store.db.Exec(`UPDATE audit_log SET action = 'nothing' WHERE action = 'book.create'`); err == nil {
+		t.Error("Expected rewriting an entry to be rejected")
+	}
+
+	if _, err := store.db.Exec(`UPDATE audit_log SET changes = '{}' WHERE action = 'song.create'`); err == nil {
+		t.Error("Expected rewriting the changes of an entry to be rejected")
+	}
+
+	// Erasing an account clears its actor, IP and the recorded changes
+	if _, err := store.db.Exec(`UPDATE audit_log SET actor_id = NULL, ip = NULL, changes = NULL WHERE actor_id = 2`); err != nil {
+		t.Errorf("Expected anonymizing an entry to be allowed, got %v", err)
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	store := setupTestStore(t)
+	seedEntries(t, store)
+
+	purged, err := store.Purge(context.Background(), time.Now().Add(-36*time.Hour))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 entry purged, got %d", purged)
+	}
+
+	store.StartRetention(12*time.Hour, time.Hour)
+	store.Close()
+	if _, total, _ := store.List(context.Background(), Filter{}); total != 1 {
+		t.Errorf("Expected the retention pass to keep only the newest entry, got %d", total)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := setupTestStore(t)
+	seedEntries(t, store)
+	handler := NewHandler(store)
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected int
+	}{
+		{"all", "", http.StatusOK, 3},
+		{"filtered", "?app=reading&actor_id=1", http.StatusOK, 1},
+		{"date", "?until=2000-01-01", http.StatusOK, 0},
+		{"invalid actor", "?actor_id=abc", http.StatusBadRequest, 0},
+		{"invalid date", "?since=yesterday", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Entries []Entry `json:"entries"`
+				Total   int     `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if body.Total != tt.expected || len(body.Entries) != tt.expected {
+				t.Errorf("Expected %d entries, got %d", tt.expected, body.Total)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+// Package backup takes consistent online snapshots of the SQLite database
+// with VACUUM INTO, bundles them with the recorded audio into tar.gz
+// archives, prunes old archives by a daily/weekly retention policy and
+// restores an archive after checking the database's integrity.
+//
+// An archive named unified-20261016T093000Z.tar.gz holds:
+//
+//	manifest.json
+//	unified.db
+//	audio/math/...
+//	audio/reading/...
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// logger is the backup package logger
+var logger = logging.Logger("backup")
+
+const (
+	archivePrefix = "unified-"
+	archiveExt    = ".tar.gz"
+	timeLayout    = "20060102T150405Z"
+
+	manifestEntry = "manifest.json"
+	databaseEntry = "unified.db"
+	audioEntry    = "audio"
+)
+
+// Archive is a backup archive on disk
+type Archive struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Name returns the archive's file name
+func (a Archive) Name() string {
+	return filepath.Base(a.Path)
+}
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Database   string    `json:"database"`
+	AudioDirs  []string  `json:"audio_dirs"`
+	AudioFiles int       `json:"audio_files"`
+}
+
+// Options configures a Manager
+type Options struct {
+	// Dir is where archives are written
+	Dir string
+	// AudioDirs maps a name, such as "math", to a directory bundled into
+	// each archive under audio/<name>
+	AudioDirs map[string]string
+	// KeepDaily and KeepWeekly set the retention policy applied by Prune
+	KeepDaily  int
+	KeepWeekly int
+}
+
+// Manager creates, lists and prunes archives of a live database
+type Manager struct {
+	db   *sql.DB
+	opts Options
+	now  func() time.Time
+
+	// mu serializes Create and Prune between the schedule and the CLI
+	mu       sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewManager creates a manager that backs up db
+func NewManager(db *sql.DB, opts Options) *Manager {
+	return &Manager{db: db, opts: opts, now: time.Now}
+}
+
+// Create snapshots the database and audio directories into a new archive
+func (m *Manager) Create(ctx context.Context) (*Archive, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.opts.Dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	createdAt := m.now().UTC().Truncate(time.Second)
+	name := archivePrefix + createdAt.Format(timeLayout) + archiveExt
+	final := filepath.Join(m.opts.Dir, name)
+	if _, err := os.Stat(final); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	// VACUUM INTO writes a consistent, compacted copy while other
+	// connections keep reading and writing
+	snapshot := filepath.Join(m.opts.Dir, "."+name+".db")
+	os.Remove(snapshot)
+	defer os.Remove(snapshot)
+	if _, err := m.db.ExecContext(ctx, "VACUUM INTO ?", snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+
+	partial := filepath.Join(m.opts.Dir, "."+name+".partial")
+	defer os.Remove(partial)
+	manifest, err := m.writeArchive(partial, snapshot, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(partial, final); err != nil {
+		return nil, fmt.Errorf("failed to finish backup: %w", err)
+	}
+
+	info, err := os.Stat(final)
+	if err != nil {
+		return nil, err
+	}
+	archive := &Archive{Path: final, CreatedAt: createdAt, Size: info.Size()}
+	logger.InfoContext(ctx, "backup created", "archive", name, "bytes", archive.Size,
+		"audio_files", manifest.AudioFiles)
+	return archive, nil
+}
+
+// writeArchive writes the snapshot, the audio directories and the manifest
+// to a gzipped tar file at dst
+func (m *Manager) writeArchive(dst, snapshot string, createdAt time.Time) (*Manifest, error) {
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	manifest := &Manifest{CreatedAt: createdAt, Database: databaseEntry}
+
+	if err := addFile(tw, snapshot, databaseEntry); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(m.opts.AudioDirs))
+	for name := range m.opts.AudioDirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		count, err := addDir(tw, m.opts.AudioDirs[name], path.Join(audioEntry, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifest.AudioDirs = append(manifest.AudioDirs, name)
+		manifest.AudioFiles += count
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	hdr := &tar.Header{Name: manifestEntry, Mode: 0640, Size: int64(len(data)), ModTime: createdAt}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return manifest, f.Close()
+}
+
+// addFile copies the regular file src into the archive as name
+func addFile(tw *tar.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", src, err)
+	}
+	return nil
+}
+
+// addDir copies the regular files under dir into the archive below prefix
+// and returns how many were written
+func addDir(tw *tar.Writer, dir, prefix string) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		count++
+		return addFile(tw, p, path.Join(prefix, filepath.ToSlash(rel)))
+	})
+	return count, err
+}
+
+// List returns the archives in the backup directory, newest first
+func (m *Manager) List() ([]Archive, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []Archive
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveExt) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), archiveExt)
+		createdAt, err := time.Parse(timeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, Archive{
+			Path:      filepath.Join(m.opts.Dir, name),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].CreatedAt.After(archives[j].CreatedAt)
+	})
+	return archives, nil
+}
+
+// Prune deletes the archives the retention policy does not keep and
+// returns them
+func (m *Manager) Prune() ([]Archive, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	archives, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	_, drop := retain(archives, m.opts.KeepDaily, m.opts.KeepWeekly)
+	for _, a := range drop {
+		if err := os.Remove(a.Path); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", a.Name(), err)
+		}
+		logger.Info("backup pruned", "archive", a.Name())
+	}
+	return drop, nil
+}
+
+// retain splits archives, newest first, into those the policy keeps and
+// those it drops. It keeps the newest archive of each of the last keepDaily
+// days and of each of the last keepWeekly ISO weeks that have archives, and
+// always the newest archive.
+func retain(archives []Archive, keepDaily, keepWeekly int) (keep, drop []Archive) {
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	for i, a := range archives {
+		t := a.CreatedAt.UTC()
+		day := t.Format("2006-01-02")
+		year, w := t.ISOWeek()
+		week := fmt.Sprintf("%d-W%02d", year, w)
+
+		kept := i == 0
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			kept = true
+		}
+		if !weeks[week] && len(weeks) < keepWeekly {
+			weeks[week] = true
+			kept = true
+		}
+
+		if kept {
+			keep = append(keep, a)
+		} else {
+			drop = append(drop, a)
+		}
+	}
+	return keep, drop
+}
+
+// Latest returns the newest archive, or nil when there is none
+func (m *Manager) Latest() (*Archive, error) {
+	archives, err := m.List()
+	if err != nil || len(archives) == 0 {
+		return nil, err
+	}
+	return &archives[0], nil
+}
+
+// Start creates a backup and prunes old ones every interval until Close is
+// called. The first backup is due one interval after the newest archive, so
+// restarts do not reset the schedule.
+func (m *Manager) Start(interval time.Duration) {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		timer := time.NewTimer(m.untilNext(interval))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+				m.runScheduled()
+				timer.Reset(interval)
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// untilNext returns how long to wait before the next scheduled backup
+func (m *Manager) untilNext(interval time.Duration) time.Duration {
+	latest, err := m.Latest()
+	if err != nil {
+		logger.Error("failed to list backups", "error", err)
+		return 0
+	}
+	if latest == nil {
+		return 0
+	}
+	if wait := interval - m.now().Sub(latest.CreatedAt); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// runScheduled creates a backup and prunes old ones, logging failures
+func (m *Manager) runScheduled() {
+	if _, err := m.Create(context.Background()); err != nil {
+		logger.Error("scheduled backup failed", "error", err)
+		return
+	}
+	if _, err := m.Prune(); err != nil {
+		logger.Error("failed to prune backups", "error", err)
+	}
+}
+
+// Close stops the schedule, waiting for a backup in progress to finish
+func (m *Manager) Close() error {
+	m.stopOnce.Do(func() {
+		if m.stop != nil {
+			close(m.stop)
+			<-m.done
+		}
+	})
+	return nil
+}
@@ -0,0 +1,328 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// setupManager opens a WAL database with one row and an audio directory
+// with one recording
+func setupManager(t *testing.T) (*Manager, *database.Pool, string, string) {
+	t.Helper()
+	root := t.TempDir()
+
+	dbPath := filepath.Join(root, "data", "unified.db")
+	db, err := database.InitPool(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);
+		INSERT INTO notes (body) VALUES ('before backup');`); err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	audioDir := filepath.Join(root, "data", "audio", "math")
+	writeTestFile(t, filepath.Join(audioDir, "u1", "math_1.webm"), "recording")
+
+	m := NewManager(db.DB, Options{
+		Dir:        filepath.Join(root, "backups"),
+		AudioDirs:  map[string]string{"math": audioDir, "reading": filepath.Join(root, "data", "audio", "reading")},
+		KeepDaily:  7,
+		KeepWeekly: 4,
+	})
+	return m, db, dbPath, audioDir
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	m, db, dbPath, audioDir := setupManager(t)
+
+	archive, err := m.Create(context.Background())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(archive.Name(), "unified-") || !strings.HasSuffix(archive.Name(), ".tar.gz") {
+		t.Errorf("archive name = %s", archive.Name())
+	}
+
+	// Changes after the backup are undone by the restore
+	if _, err := db.Exec(`INSERT INTO notes (body) VALUES ('after backup')`); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(audioDir, "u1", "math_2.webm"), "later")
+	db.Close()
+
+	result, err := Restore(archive.Path, dbPath, m.opts.AudioDirs)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if result.Manifest == nil || result.Manifest.AudioFiles != 1 || !reflect.DeepEqual(result.Manifest.AudioDirs, []string{"math"}) {
+		t.Errorf("manifest = %+v", result.Manifest)
+	}
+	if len(result.Previous) != 2 {
+		t.Errorf("Previous = %v, want the old database and math audio", result.Previous)
+	}
+
+	restored, err := database.InitPool(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+	var bodies []string
+	rows, err := restored.Query(`SELECT body FROM notes ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var body string
+		rows.Scan(&body)
+		bodies = append(bodies, body)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(bodies, []string{"before backup"}) {
+		t.Errorf("restored rows = %v", bodies)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(audioDir, "u1", "math_1.webm")); err != nil || string(data) != "recording" {
+		t.Errorf("restored audio = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(audioDir, "u1", "math_2.webm")); !os.IsNotExist(err) {
+		t.Errorf("audio recorded after the backup survived the restore: %v", err)
+	}
+	// The replaced files are kept
+	if _, err := os.Stat(filepath.Join(result.Previous[1], "u1", "math_2.webm")); err != nil {
+		t.Errorf("previous audio not kept: %v", err)
+	}
+}
+
+func TestRestoreRejectsCorruptDatabase(t *testing.T) {
+	root := t.TempDir()
+	archivePath := filepath.Join(root, "bad.tar.gz")
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	junk := []byte(strings.Repeat("not a database ", 512))
+	tw.WriteHeader(&tar.Header{Name: databaseEntry, Mode: 0640, Size: int64(len(junk))})
+	tw.Write(junk)
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	dbPath := filepath.Join(root, "unified.db")
+	writeTestFile(t, dbPath, "current")
+
+	if _, err := Restore(archivePath, dbPath, nil); err == nil || !strings.Contains(err.Error(), "integrity check failed") {
+		t.Fatalf("Restore() error = %v, want integrity check failure", err)
+	}
+	if data, _ := os.ReadFile(dbPath); string(data) != "current" {
+		t.Errorf("current database was replaced: %q", data)
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 2 {
+		t.Errorf("staging files left behind: %v", entries)
+	}
+}
+
+func TestRestoreEmptiesAudioMissingFromArchive(t *testing.T) {
+	m, db, dbPath, _ := setupManager(t)
+	archive, err := m.Create(context.Background())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	db.Close()
+
+	// The reading directory did not exist when the backup was taken
+	readingDir := m.opts.AudioDirs["reading"]
+	writeTestFile(t, filepath.Join(readingDir, "u1", "reading_1.webm"), "later")
+
+	result, err := Restore(archive.Path, dbPath, m.opts.AudioDirs)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if entries, err := os.ReadDir(readingDir); err != nil || len(entries) != 0 {
+		t.Errorf("reading audio after restore = %v, %v, want an empty directory", entries, err)
+	}
+	if len(result.Previous) != 3 {
+		t.Errorf("Previous = %v, want the old database and both audio directories", result.Previous)
+	}
+}
+
+func TestRestoreUndoesFailedSwap(t *testing.T) {
+	m, db, dbPath, audioDir := setupManager(t)
+	archive, err := m.Create(context.Background())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO notes (body) VALUES ('after backup')`); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(audioDir, "u1", "math_2.webm"), "later")
+	db.Close()
+
+	// Moving the restored audio into place fails after the database was
+	// swapped in
+	failed := false
+	rename = func(src, dst string) error {
+		if dst == audioDir && !failed {
+			failed = true
+			return os.ErrPermission
+		}
+		return os.Rename(src, dst)
+	}
+	defer func() { rename = os.Rename }()
+
+	if _, err := Restore(archive.Path, dbPath, m.opts.AudioDirs); err == nil || !strings.Contains(err.Error(), "were put back") {
+		t.Fatalf("Restore() error = %v, want the swap undone", err)
+	}
+
+	current, err := database.InitPool(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer current.Close()
+	var count int
+	if err := current.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("notes = %d, %v, want the database from before the restore", count, err)
+	}
+	if _, err := os.Stat(filepath.Join(audioDir, "u1", "math_2.webm")); err != nil {
+		t.Errorf("audio from before the restore was not put back: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dbPath))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), "restore") {
+			t.Errorf("restore files left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestRestoreReportsPartialFailure(t *testing.T) {
+	m, db, dbPath, audioDir := setupManager(t)
+	archive, err := m.Create(context.Background())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	db.Close()
+
+	// The audio directory can be neither replaced nor put back
+	rename = func(src, dst string) error {
+		if dst == audioDir {
+			return os.ErrPermission
+		}
+		return os.Rename(src, dst)
+	}
+	defer func() { rename = os.Rename }()
+
+	result, err := Restore(archive.Path, dbPath, m.opts.AudioDirs)
+	if err == nil || !strings.Contains(err.Error(), "restore is partial") {
+		t.Fatalf("Restore() error = %v, want a partial restore", err)
+	}
+	if result == nil || len(result.Previous) != 2 {
+		t.Errorf("result = %+v, want the moved database and audio listed", result)
+	}
+}
+
+func TestRetain(t *testing.T) {
+	day := func(d, hour int) Archive {
+		return Archive{Path: time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			CreatedAt: time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC)}
+	}
+	// Newest first: two on the 16th, then one a day back to the 1st.
+	// October 2026: the 12th and 5th are Mondays.
+	archives := []Archive{day(16, 12), day(16, 0)}
+	for d := 15; d >= 1; d-- {
+		archives = append(archives, day(d, 0))
+	}
+
+	keep, drop := retain(archives, 3, 2)
+
+	var got []string
+	for _, a := range keep {
+		got = append(got, a.CreatedAt.Format("01-02T15"))
+	}
+	// Daily: 16th (newest), 15th, 14th. Weekly: week of the 12th (16th
+	// noon) and week of the 5th (11th).
+	want := []string{"10-16T12", "10-15T00", "10-14T00", "10-11T00"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+	if len(keep)+len(drop) != len(archives) {
+		t.Errorf("kept %d + dropped %d != %d", len(keep), len(drop), len(archives))
+	}
+
+	if keep, _ := retain(archives[:1], 0, 0); len(keep) != 1 {
+		t.Error("the newest archive was not kept")
+	}
+}
+
+func TestPruneAndList(t *testing.T) {
+	m, _, _, _ := setupManager(t)
+	m.opts.KeepDaily = 1
+	m.opts.KeepWeekly = 0
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		m.now = func() time.Time { return now.Add(time.Duration(i) * time.Hour) }
+		if _, err := m.Create(context.Background()); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	writeTestFile(t, filepath.Join(m.opts.Dir, "notes.txt"), "not an archive")
+
+	dropped, err := m.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(dropped) != 2 {
+		t.Errorf("dropped %d archives, want 2", len(dropped))
+	}
+
+	archives, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || !archives[0].CreatedAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("remaining archives = %+v", archives)
+	}
+}
+
+func TestUntilNext(t *testing.T) {
+	m, _, _, _ := setupManager(t)
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	if wait := m.untilNext(24 * time.Hour); wait != 0 {
+		t.Errorf("with no archives wait = %v, want 0", wait)
+	}
+
+	m.now = func() time.Time { return now.Add(-6 * time.Hour) }
+	if _, err := m.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return now }
+	if wait := m.untilNext(24 * time.Hour); wait != 18*time.Hour {
+		t.Errorf("wait = %v, want 18h", wait)
+	}
+}
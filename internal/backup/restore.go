@@ -0,0 +1,289 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// RestoreResult reports what Restore replaced
+type RestoreResult struct {
+	Manifest *Manifest
+	// Previous lists where the replaced database and audio directories
+	// were moved; delete them once the restore is confirmed
+	Previous []string
+}
+
+// Restore replaces the database at dbPath, and each audio directory in
+// audioDirs, with the archive's contents; an audio directory the archive
+// does not contain is replaced with an empty one. The database is extracted
+// next to dbPath and must pass PRAGMA integrity_check before anything is
+// swapped in. The current files are renamed with a .pre-restore-<time>
+// suffix rather than deleted, and if the swap fails partway they are put
+// back. When they cannot all be put back the result lists what was moved
+// and the error says the restore is partial.
+//
+// Restore must run while the server is stopped.
+func Restore(archivePath, dbPath string, audioDirs map[string]string) (*RestoreResult, error) {
+	staging, err := os.MkdirTemp(filepath.Dir(dbPath), ".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, audio, err := extract(archivePath, staging, audioDirs)
+	defer func() {
+		for _, dir := range audio {
+			os.RemoveAll(dir)
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	restoredDB := filepath.Join(staging, databaseEntry)
+	if err := integrityCheck(restoredDB); err != nil {
+		return nil, err
+	}
+
+	// Recordings made since the backup must not survive the restore
+	for name, dir := range audioDirs {
+		if _, ok := audio[name]; ok {
+			continue
+		}
+		if _, err := os.Lstat(dir); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		empty, err := os.MkdirTemp(filepath.Dir(filepath.Clean(dir)), ".restore-"+name+"-")
+		if err != nil {
+			return nil, err
+		}
+		audio[name] = empty
+	}
+
+	result := &RestoreResult{Manifest: manifest}
+	s := &swap{suffix: ".pre-restore-" + time.Now().UTC().Format(timeLayout)}
+	if err := s.install(result, restoredDB, dbPath, audio, audioDirs); err != nil {
+		if undoErr := s.undo(); undoErr != nil {
+			return result, fmt.Errorf("restore is partial: %w; putting the previous files back also failed: %v", err, undoErr)
+		}
+		return nil, fmt.Errorf("%w; the previous database and audio were put back", err)
+	}
+	return result, nil
+}
+
+// rename is os.Rename, replaced in tests to fail partway through a swap
+var rename = os.Rename
+
+// swap moves the restored files into place and records every rename so a
+// failed restore can be undone
+type swap struct {
+	suffix  string
+	renames [][2]string
+}
+
+// install moves the current database and audio directories aside and the
+// restored ones into place, listing the moved copies in result
+func (s *swap) install(result *RestoreResult, restoredDB, dbPath string, audio, audioDirs map[string]string) error {
+	// The WAL and shared-memory files belong to the old database and move
+	// with it, so the old copy stays complete
+	for _, ext := range []string{"", "-wal", "-shm"} {
+		moved, err := s.moveAside(dbPath+ext, dbPath+s.suffix+ext)
+		if err != nil {
+			return err
+		}
+		if moved && ext == "" {
+			result.Previous = append(result.Previous, dbPath+s.suffix)
+		}
+	}
+	if err := s.rename(restoredDB, dbPath); err != nil {
+		return fmt.Errorf("failed to move restored database into place: %w", err)
+	}
+
+	for name, staged := range audio {
+		dir := audioDirs[name]
+		moved, err := s.moveAside(dir, dir+s.suffix)
+		if err != nil {
+			return err
+		}
+		if moved {
+			result.Previous = append(result.Previous, dir+s.suffix)
+		}
+		if err := s.rename(staged, dir); err != nil {
+			return fmt.Errorf("failed to move restored audio into %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// rename renames src to dst and records it
+func (s *swap) rename(src, dst string) error {
+	if err := rename(src, dst); err != nil {
+		return err
+	}
+	s.renames = append(s.renames, [2]string{src, dst})
+	return nil
+}
+
+// moveAside renames src to dst if src exists
+func (s *swap) moveAside(src, dst string) (bool, error) {
+	if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err := s.rename(src, dst); err != nil {
+		return false, fmt.Errorf("failed to move %s aside: %w", src, err)
+	}
+	return true, nil
+}
+
+// undo reverses the recorded renames, newest first
+func (s *swap) undo() error {
+	var errs []error
+	for i := len(s.renames) - 1; i >= 0; i-- {
+		r := s.renames[i]
+		if err := rename(r[1], r[0]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move %s back: %w", r[1], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// extract unpacks the archive's database into staging and each known audio
+// directory into a temporary directory beside its destination, so the final
+// swap is a rename on the same filesystem
+func extract(archivePath, staging string, audioDirs map[string]string) (*Manifest, map[string]string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a backup archive: %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	audio := make(map[string]string)
+	haveDB := false
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, audio, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, audio, fmt.Errorf("archive entry %q escapes the archive", hdr.Name)
+		}
+
+		switch {
+		case name == manifestEntry:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, audio, fmt.Errorf("invalid manifest: %w", err)
+			}
+
+		case name == databaseEntry:
+			if err := writeFile(filepath.Join(staging, databaseEntry), tr, 0640); err != nil {
+				return nil, audio, err
+			}
+			haveDB = true
+
+		case strings.HasPrefix(name, audioEntry+"/"):
+			parts := strings.SplitN(strings.TrimPrefix(name, audioEntry+"/"), "/", 2)
+			dest, ok := audioDirs[parts[0]]
+			if !ok || len(parts) < 2 {
+				continue
+			}
+			dir, ok := audio[parts[0]]
+			if !ok {
+				parent := filepath.Dir(filepath.Clean(dest))
+				if err := os.MkdirAll(parent, 0755); err != nil {
+					return nil, audio, err
+				}
+				if dir, err = os.MkdirTemp(parent, ".restore-"+parts[0]+"-"); err != nil {
+					return nil, audio, err
+				}
+				audio[parts[0]] = dir
+			}
+			if err := writeFile(filepath.Join(dir, filepath.FromSlash(parts[1])), tr, 0644); err != nil {
+				return nil, audio, err
+			}
+		}
+	}
+
+	if !haveDB {
+		return nil, audio, fmt.Errorf("archive has no %s", databaseEntry)
+	}
+	return manifest, audio, nil
+}
+
+// writeFile copies r to a new file at dst, creating parent directories
+func writeFile(dst string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(dst), err)
+	}
+	return f.Close()
+}
+
+// integrityCheck runs PRAGMA integrity_check on the database at path
+func integrityCheck(dbPath string) error {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return fmt.Errorf("integrity check failed: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// Audited runs a command with an audit entry in its context. Commands that
+// change data name the action with audit.Describe; the entry is recorded if
+// they succeed. prog names the binary in the entry's actor.
+func Audited(ctx context.Context, db *database.Pool, prog string, run func(ctx context.Context) error) error {
+	ctx = audit.NewContext(ctx, audit.Entry{Source: audit.SourceCLI, Actor: actor(prog)})
+	if err := run(ctx); err != nil {
+		return err
+	}
+
+	entry, _ := audit.FromContext(ctx)
+	if entry.Action == "" {
+		return nil
+	}
+	if err := audit.NewStore(db.DB).Record(ctx, &entry); err != nil {
+		return fmt.Errorf("%s was applied but not recorded in the audit log: %w", entry.Action, err)
+	}
+	return nil
+}
+
+// actor names the operating system user running prog, e.g. "unifiedctl:alice"
+func actor(prog string) string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		return prog
+	}
+	return prog + ":" + name
+}
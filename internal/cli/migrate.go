@@ -0,0 +1,120 @@
+// Package cli holds subcommands shared by the server and unifiedctl binaries
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+const migrateUsage = `usage: %s migrate <command> [args]
+
+commands:
+  status                 show every migration and whether it is applied
+  up [target]            apply pending migrations (all targets by default)
+  down <target> [steps]  roll back the last steps migrations of a target (default 1)
+  redo <target>          roll back and re-apply the last migration of a target`
+
+// Migrate executes a migrate subcommand against the application database.
+// prog names the binary in usage messages.
+func Migrate(ctx context.Context, db *database.Pool, prog string, args []string, out io.Writer) error {
+	usage := fmt.Sprintf(migrateUsage, prog)
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	migrator, err := database.NewServerMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(ctx, migrator, out)
+
+	case "up":
+		target := ""
+		if len(args) > 1 {
+			target = args[1]
+		}
+		applied, err := migrator.Up(ctx, target)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			audit.Describe(ctx, "migrate.up", "migration_target", targetName(target))
+			audit.RecordChange(ctx, nil, map[string]int{"applied": applied})
+		}
+		fmt.Fprintf(out, "Applied %d migrations\n", applied)
+		return nil
+
+	case "down":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		steps := 1
+		if len(args) > 2 {
+			steps, err = strconv.Atoi(args[2])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", args[2])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, args[1], steps)
+		if err != nil {
+			return err
+		}
+		if rolledBack > 0 {
+			audit.Describe(ctx, "migrate.down", "migration_target", args[1])
+			audit.RecordChange(ctx, nil, map[string]int{"rolled_back": rolledBack})
+		}
+		fmt.Fprintf(out, "Rolled back %d migrations\n", rolledBack)
+		return nil
+
+	case "redo":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		if err := migrator.Redo(ctx, args[1]); err != nil {
+			return err
+		}
+		audit.Describe(ctx, "migrate.redo", "migration_target", args[1])
+		fmt.Fprintf(out, "Re-applied the latest %s migration\n", args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+// targetName names the targets an "up" applies to for the audit log
+func targetName(target string) string {
+	if target == "" {
+		return "all"
+	}
+	return target
+}
+
+// printMigrationStatus writes the migration status table
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tVERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%04d\t%s\t%s\t%s\n", s.Target, s.Version, s.Name, s.State, appliedAt)
+	}
+	return w.Flush()
+}
@@ -1,38 +1,316 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+// DefaultSessionSecret is used when no secret is configured. It is accepted
+// in development but Validate rejects it in production.
+const DefaultSessionSecret = "unified-go-default-secret-change-in-production"
+
+// redacted replaces secret values in Redacted output
+const redacted = "[REDACTED]"
+
 // Config holds application configuration
 type Config struct {
-	Port          int
-	Host          string
-	Environment   string
-	DatabaseURL   string
-	SessionSecret string
-	SessionName   string
-	CORSOrigins   []string
-	StaticDir     string
-	TemplateDir   string
-}
-
-// Load reads configuration from environment variables
-func Load() (*Config, error) {
-	cfg := &Config{
-		Port:        getEnvAsInt("PORT", 5000),
-		Host:        getEnv("HOST", "0.0.0.0"),
-		Environment: getEnv("ENVIRONMENT", "development"),
-		DatabaseURL: getEnv("DATABASE_URL", "./data/unified.db"),
-		SessionSecret: getEnv("SESSION_SECRET", generateDefaultSecret()),
-		SessionName:   getEnv("SESSION_NAME", "unified_session"),
-		CORSOrigins:   []string{
-			getEnv("CORS_ORIGIN", "*"),
+	Port        int    `yaml:"port" json:"port"`
+	Host        string `yaml:"host" json:"host"`
+	Environment string `yaml:"environment" json:"environment"`
+	DatabaseURL string `yaml:"database_url" json:"database_url"`
+	// GaiaDatabaseURL is the separate SQLite database of the GAIA task queue
+	GaiaDatabaseURL string   `yaml:"gaia_database_url" json:"gaia_database_url"`
+	SessionSecret   string   `yaml:"session_secret" json:"session_secret"`
+	SessionName     string   `yaml:"session_name" json:"session_name"`
+	CORSOrigins     []string `yaml:"cors_origins" json:"cors_origins"`
+	// TrustedProxies lists the addresses or CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For and X-Real-IP headers name the client
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+	StaticDir      string   `yaml:"static_dir" json:"static_dir"`
+	TemplateDir    string   `yaml:"template_dir" json:"template_dir"`
+
+	Logging     LoggingConfig     `yaml:"logging" json:"logging"`
+	Apps        AppsConfig        `yaml:"apps" json:"apps"`
+	Realtime    RealtimeConfig    `yaml:"realtime" json:"realtime"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" json:"rate_limit"`
+	Backup      BackupConfig      `yaml:"backup" json:"backup"`
+	Audit       AuditConfig       `yaml:"audit" json:"audit"`
+	Tenancy     TenancyConfig     `yaml:"tenancy" json:"tenancy"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" json:"idempotency"`
+	Gaia        GaiaConfig        `yaml:"gaia" json:"gaia"`
+}
+
+// LoggingConfig sets the log format and the default and per-package levels
+type LoggingConfig struct {
+	Level    string            `yaml:"level" json:"level"`
+	Format   string            `yaml:"format" json:"format"`
+	Packages map[string]string `yaml:"packages,omitempty" json:"packages,omitempty"`
+}
+
+// AppsConfig holds the per-app settings
+type AppsConfig struct {
+	Math    MathConfig    `yaml:"math" json:"math"`
+	Reading ReadingConfig `yaml:"reading" json:"reading"`
+	Piano   PianoConfig   `yaml:"piano" json:"piano"`
+	Typing  TypingConfig  `yaml:"typing" json:"typing"`
+}
+
+// MathConfig holds math app settings
+type MathConfig struct {
+	AudioDir    string `yaml:"audio_dir" json:"audio_dir"`
+	MaxUploadMB int    `yaml:"max_upload_mb" json:"max_upload_mb"`
+}
+
+// ReadingConfig holds reading app settings
+type ReadingConfig struct {
+	AudioDir        string `yaml:"audio_dir" json:"audio_dir"`
+	MaxUploadMB     int    `yaml:"max_upload_mb" json:"max_upload_mb"`
+	LeaderboardSize int    `yaml:"leaderboard_size" json:"leaderboard_size"`
+}
+
+// PianoConfig holds piano app settings
+type PianoConfig struct {
+	MaxUploadMB     int `yaml:"max_upload_mb" json:"max_upload_mb"`
+	LeaderboardSize int `yaml:"leaderboard_size" json:"leaderboard_size"`
+}
+
+// TypingConfig holds typing app settings
+type TypingConfig struct {
+	LeaderboardSize       int `yaml:"leaderboard_size" json:"leaderboard_size"`
+	RacingLeaderboardSize int `yaml:"racing_leaderboard_size" json:"racing_leaderboard_size"`
+}
+
+// RealtimeConfig sizes the buffers of the websocket hub and event bus
+type RealtimeConfig struct {
+	HubBroadcastBuffer int `yaml:"hub_broadcast_buffer" json:"hub_broadcast_buffer"`
+	ClientSendBuffer   int `yaml:"client_send_buffer" json:"client_send_buffer"`
+	EventQueueSize     int `yaml:"event_queue_size" json:"event_queue_size"`
+}
+
+// RateLimitConfig sets the token-bucket limits for each route group. Buckets
+// are keyed by authenticated user, falling back to client IP.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Persist saves bucket state to the database so limits survive restarts
+	Persist bool `yaml:"persist" json:"persist"`
+	// Auth covers /auth, including login and registration
+	Auth RateLimitRule `yaml:"auth" json:"auth"`
+	// API covers every request to an app API
+	API RateLimitRule `yaml:"api" json:"api"`
+	// Writes covers non-GET app API requests such as answers and race results
+	Writes RateLimitRule `yaml:"writes" json:"writes"`
+}
+
+// RateLimitRule is a refill rate and the bucket size allowed as a burst
+type RateLimitRule struct {
+	RequestsPerMinute int `yaml:"requests_per_minute" json:"requests_per_minute"`
+	Burst             int `yaml:"burst" json:"burst"`
+}
+
+// BackupConfig schedules online backups of the database and recorded audio.
+// Retention keeps the newest archive of each of the last KeepDaily days and
+// of each of the last KeepWeekly weeks.
+type BackupConfig struct {
+	Enabled       bool   `yaml:"enabled" json:"enabled"`
+	Dir           string `yaml:"dir" json:"dir"`
+	IntervalHours int    `yaml:"interval_hours" json:"interval_hours"`
+	KeepDaily     int    `yaml:"keep_daily" json:"keep_daily"`
+	KeepWeekly    int    `yaml:"keep_weekly" json:"keep_weekly"`
+}
+
+// AuditConfig sets how long audit log entries are kept. Zero keeps them forever.
+type AuditConfig struct {
+	RetentionDays int `yaml:"retention_days" json:"retention_days"`
+}
+
+// IdempotencyConfig sets how long the response to an Idempotency-Key is kept
+// for replay
+type IdempotencyConfig struct {
+	TTLHours int `yaml:"ttl_hours" json:"ttl_hours"`
+}
+
+// GaiaConfig tunes the GAIA task dispatcher, session watchdog and metrics
+// collector. A pass every DispatchIntervalSeconds assigns pending tasks to
+// idle sessions, giving each session at most one task per
+// AssignIntervalSeconds. A failed task waits RetryBackoffSeconds before its
+// first retry, doubling for each retry after. A session without a heartbeat
+// for HeartbeatTimeoutSeconds is marked unhealthy, and after
+// TerminateAfterSeconds it is terminated and its work reclaimed. The metrics
+// are sampled every MetricsIntervalSeconds and kept for MetricsRetentionDays,
+// zero keeping them forever. An alert is resolved once its metric is
+// AlertHysteresisPercent of the threshold back past it.
+type GaiaConfig struct {
+	DispatchIntervalSeconds int `yaml:"dispatch_interval_seconds" json:"dispatch_interval_seconds"`
+	AssignIntervalSeconds   int `yaml:"assign_interval_seconds" json:"assign_interval_seconds"`
+	RetryBackoffSeconds     int `yaml:"retry_backoff_seconds" json:"retry_backoff_seconds"`
+	HeartbeatTimeoutSeconds int `yaml:"heartbeat_timeout_seconds" json:"heartbeat_timeout_seconds"`
+	TerminateAfterSeconds   int `yaml:"terminate_after_seconds" json:"terminate_after_seconds"`
+	MetricsIntervalSeconds  int `yaml:"metrics_interval_seconds" json:"metrics_interval_seconds"`
+	MetricsRetentionDays    int `yaml:"metrics_retention_days" json:"metrics_retention_days"`
+	AlertHysteresisPercent  int `yaml:"alert_hysteresis_percent" json:"alert_hysteresis_percent"`
+	// Alerts are the thresholds by metric name, one of AlertMetrics. An
+	// entry replaces the default for its metric; one without bounds turns
+	// the metric's alerts off.
+	Alerts map[string]AlertThreshold `yaml:"alerts" json:"alerts"`
+}
+
+// AlertThreshold bounds a GAIA metric. A sample past a warning or critical
+// bound opens an alert of that severity.
+type AlertThreshold struct {
+	WarningMin  *float64 `yaml:"warning_min" json:"warning_min,omitempty"`
+	WarningMax  *float64 `yaml:"warning_max" json:"warning_max,omitempty"`
+	CriticalMin *float64 `yaml:"critical_min" json:"critical_min,omitempty"`
+	CriticalMax *float64 `yaml:"critical_max" json:"critical_max,omitempty"`
+}
+
+// AlertMetrics are the metrics sampled by the GAIA collector that alerts can
+// be set on
+var AlertMetrics = []string{
+	"memory_mb",
+	"goroutines",
+	"db_open_connections",
+	"db_in_use",
+	"db_waits",
+	"db_wait_ms",
+	"pending_tasks",
+	"active_sessions",
+	"tasks_completed",
+	"tasks_failed",
+	"task_throughput",
+	"avg_latency_ms",
+	"success_rate",
+}
+
+// TenancyConfig sets how requests are matched to an organization. When
+// disabled, every request belongs to DefaultOrganization.
+type TenancyConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Header carries the organization's slug, e.g. from a reverse proxy
+	Header string `yaml:"header" json:"header"`
+	// BaseDomain maps <slug>.<base_domain> hosts to organizations
+	BaseDomain          string `yaml:"base_domain" json:"base_domain"`
+	DefaultOrganization string `yaml:"default_organization" json:"default_organization"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Port:            5000,
+		Host:            "0.0.0.0",
+		Environment:     "development",
+		DatabaseURL:     "./data/unified.db",
+		GaiaDatabaseURL: "./data/gaia.db",
+		SessionSecret:   DefaultSessionSecret,
+		SessionName:     "unified_session",
+		CORSOrigins:     []string{"*"},
+		StaticDir:       "./static",
+		TemplateDir:     "./templates",
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Apps: AppsConfig{
+			Math: MathConfig{
+				AudioDir:    "data/audio/math",
+				MaxUploadMB: 10,
+			},
+			Reading: ReadingConfig{
+				AudioDir:        "data/audio/reading",
+				MaxUploadMB:     10,
+				LeaderboardSize: 10,
+			},
+			Piano: PianoConfig{
+				MaxUploadMB:     10,
+				LeaderboardSize: 10,
+			},
+			Typing: TypingConfig{
+				LeaderboardSize:       100,
+				RacingLeaderboardSize: 10,
+			},
+		},
+		Realtime: RealtimeConfig{
+			HubBroadcastBuffer: 256,
+			ClientSendBuffer:   256,
+			EventQueueSize:     1000,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Auth:    RateLimitRule{RequestsPerMinute: 20, Burst: 10},
+			API:     RateLimitRule{RequestsPerMinute: 600, Burst: 120},
+			Writes:  RateLimitRule{RequestsPerMinute: 60, Burst: 20},
+		},
+		Backup: BackupConfig{
+			Enabled:       true,
+			Dir:           "data/backups",
+			IntervalHours: 24,
+			KeepDaily:     7,
+			KeepWeekly:    4,
+		},
+		Audit: AuditConfig{
+			RetentionDays: 365,
+		},
+		Tenancy: TenancyConfig{
+			Header:              "X-Organization",
+			DefaultOrganization: "default",
+		},
+		Idempotency: IdempotencyConfig{
+			TTLHours: 24,
+		},
+		Gaia: GaiaConfig{
+			DispatchIntervalSeconds: 5,
+			AssignIntervalSeconds:   5,
+			RetryBackoffSeconds:     30,
+			HeartbeatTimeoutSeconds: 120,
+			TerminateAfterSeconds:   600,
+			MetricsIntervalSeconds:  30,
+			MetricsRetentionDays:    7,
+			AlertHysteresisPercent:  10,
+			Alerts: map[string]AlertThreshold{
+				"memory_mb":     {WarningMax: bound(512), CriticalMax: bound(1024)},
+				"goroutines":    {WarningMax: bound(1000), CriticalMax: bound(5000)},
+				"pending_tasks": {WarningMax: bound(100), CriticalMax: bound(500)},
+				"success_rate":  {WarningMin: bound(0.9), CriticalMin: bound(0.5)},
+			},
 		},
-		StaticDir:   getEnv("STATIC_DIR", "./static"),
-		TemplateDir: getEnv("TEMPLATE_DIR", "./templates"),
+	}
+}
+
+// bound returns a pointer to an alert threshold bound
+func bound(value float64) *float64 {
+	return &value
+}
+
+// Load reads configuration from the file named by CONFIG_FILE, if set, and
+// then from environment variables
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile builds the configuration from defaults, then the YAML or JSON file
+// at path (skipped when path is empty), then environment variable overrides
+func LoadFile(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
 	}
 
 	// Validate required fields
@@ -43,32 +321,375 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// getEnv retrieves environment variable or returns default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// readFile decodes a config file over the current values, choosing the format
+// by extension. Unknown keys are rejected so typos do not go unnoticed.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (want .yaml, .yml or .json)", ext)
+	}
+
+	return nil
+}
+
+// applyEnv overrides file and default values with environment variables
+func (c *Config) applyEnv() error {
+	strs := []struct {
+		key string
+		dst *string
+	}{
+		{"HOST", &c.Host},
+		{"ENVIRONMENT", &c.Environment},
+		{"DATABASE_URL", &c.DatabaseURL},
+		{"GAIA_DATABASE_URL", &c.GaiaDatabaseURL},
+		{"SESSION_SECRET", &c.SessionSecret},
+		{"SESSION_NAME", &c.SessionName},
+		{"STATIC_DIR", &c.StaticDir},
+		{"TEMPLATE_DIR", &c.TemplateDir},
+		{"LOG_LEVEL", &c.Logging.Level},
+		{"LOG_FORMAT", &c.Logging.Format},
+		{"MATH_AUDIO_DIR", &c.Apps.Math.AudioDir},
+		{"READING_AUDIO_DIR", &c.Apps.Reading.AudioDir},
+		{"BACKUP_DIR", &c.Backup.Dir},
+		{"TENANCY_HEADER", &c.Tenancy.Header},
+		{"TENANCY_BASE_DOMAIN", &c.Tenancy.BaseDomain},
+		{"TENANCY_DEFAULT_ORGANIZATION", &c.Tenancy.DefaultOrganization},
+	}
+	for _, s := range strs {
+		if value := os.Getenv(s.key); value != "" {
+			*s.dst = value
+		}
+	}
+
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"PORT", &c.Port},
+		{"MATH_MAX_UPLOAD_MB", &c.Apps.Math.MaxUploadMB},
+		{"READING_MAX_UPLOAD_MB", &c.Apps.Reading.MaxUploadMB},
+		{"READING_LEADERBOARD_SIZE", &c.Apps.Reading.LeaderboardSize},
+		{"PIANO_MAX_UPLOAD_MB", &c.Apps.Piano.MaxUploadMB},
+		{"PIANO_LEADERBOARD_SIZE", &c.Apps.Piano.LeaderboardSize},
+		{"TYPING_LEADERBOARD_SIZE", &c.Apps.Typing.LeaderboardSize},
+		{"TYPING_RACING_LEADERBOARD_SIZE", &c.Apps.Typing.RacingLeaderboardSize},
+		{"REALTIME_HUB_BROADCAST_BUFFER", &c.Realtime.HubBroadcastBuffer},
+		{"REALTIME_CLIENT_SEND_BUFFER", &c.Realtime.ClientSendBuffer},
+		{"REALTIME_EVENT_QUEUE_SIZE", &c.Realtime.EventQueueSize},
+		{"BACKUP_INTERVAL_HOURS", &c.Backup.IntervalHours},
+		{"BACKUP_KEEP_DAILY", &c.Backup.KeepDaily},
+		{"BACKUP_KEEP_WEEKLY", &c.Backup.KeepWeekly},
+		{"AUDIT_RETENTION_DAYS", &c.Audit.RetentionDays},
+		{"IDEMPOTENCY_TTL_HOURS", &c.Idempotency.TTLHours},
+		{"GAIA_DISPATCH_INTERVAL_SECONDS", &c.Gaia.DispatchIntervalSeconds},
+		{"GAIA_ASSIGN_INTERVAL_SECONDS", &c.Gaia.AssignIntervalSeconds},
+		{"GAIA_RETRY_BACKOFF_SECONDS", &c.Gaia.RetryBackoffSeconds},
+		{"GAIA_HEARTBEAT_TIMEOUT_SECONDS", &c.Gaia.HeartbeatTimeoutSeconds},
+		{"GAIA_TERMINATE_AFTER_SECONDS", &c.Gaia.TerminateAfterSeconds},
+		{"GAIA_METRICS_INTERVAL_SECONDS", &c.Gaia.MetricsIntervalSeconds},
+		{"GAIA_METRICS_RETENTION_DAYS", &c.Gaia.MetricsRetentionDays},
+		{"GAIA_ALERT_HYSTERESIS_PERCENT", &c.Gaia.AlertHysteresisPercent},
+	}
+	for _, i := range ints {
+		value := os.Getenv(i.key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer: %q", i.key, value)
+		}
+		*i.dst = n
+	}
+
+	bools := []struct {
+		key string
+		dst *bool
+	}{
+		{"RATE_LIMIT_ENABLED", &c.RateLimit.Enabled},
+		{"RATE_LIMIT_PERSIST", &c.RateLimit.Persist},
+		{"BACKUP_ENABLED", &c.Backup.Enabled},
+		{"TENANCY_ENABLED", &c.Tenancy.Enabled},
+	}
+	for _, b := range bools {
+		value := os.Getenv(b.key)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a boolean: %q", b.key, value)
+		}
+		*b.dst = v
+	}
+
+	// LOG_LEVELS takes "pkg=level,pkg=level" and is merged over the file's levels
+	if value := os.Getenv("LOG_LEVELS"); value != "" {
+		levels, err := logging.ParsePackageLevels(value)
+		if err != nil {
+			return fmt.Errorf("LOG_LEVELS: %w", err)
+		}
+		if c.Logging.Packages == nil {
+			c.Logging.Packages = make(map[string]string, len(levels))
+		}
+		for pkg, level := range levels {
+			c.Logging.Packages[pkg] = level
+		}
+	}
+
+	// CORS_ORIGINS takes a comma-separated list; CORS_ORIGIN is kept for
+	// existing deployments
+	origins := os.Getenv("CORS_ORIGINS")
+	if origins == "" {
+		origins = os.Getenv("CORS_ORIGIN")
 	}
-	return defaultValue
+	if origins != "" {
+		c.CORSOrigins = splitList(origins)
+	}
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		c.TrustedProxies = splitList(proxies)
+	}
+
+	return nil
 }
 
-// getEnvAsInt retrieves environment variable as integer or returns default value
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
-	if valueStr == "" {
-		return defaultValue
+// Validate checks the configuration for invalid values and settings that are
+// unsafe in production. All problems are reported together.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Environment {
+	case "development", "staging", "production":
+	default:
+		errs = append(errs, fmt.Errorf("environment must be development, staging or production, got %q", c.Environment))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("database_url is required"))
+	}
+	if c.GaiaDatabaseURL == "" {
+		errs = append(errs, errors.New("gaia_database_url is required"))
+	}
+	if c.SessionSecret == "" {
+		errs = append(errs, errors.New("session_secret is required"))
+	}
+	if c.SessionName == "" {
+		errs = append(errs, errors.New("session_name is required"))
 	}
 
-	value, err := strconv.Atoi(valueStr)
-	if err != nil {
-		return defaultValue
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	for pkg, level := range c.Logging.Packages {
+		if _, err := logging.ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("logging.packages.%s: %w", pkg, err))
+		}
+	}
+	switch c.Logging.Format {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("logging.format must be json or text, got %q", c.Logging.Format))
 	}
 
-	return value
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"apps.math.max_upload_mb", c.Apps.Math.MaxUploadMB},
+		{"apps.reading.max_upload_mb", c.Apps.Reading.MaxUploadMB},
+		{"apps.reading.leaderboard_size", c.Apps.Reading.LeaderboardSize},
+		{"apps.piano.max_upload_mb", c.Apps.Piano.MaxUploadMB},
+		{"apps.piano.leaderboard_size", c.Apps.Piano.LeaderboardSize},
+		{"apps.typing.leaderboard_size", c.Apps.Typing.LeaderboardSize},
+		{"apps.typing.racing_leaderboard_size", c.Apps.Typing.RacingLeaderboardSize},
+		{"realtime.hub_broadcast_buffer", c.Realtime.HubBroadcastBuffer},
+		{"realtime.client_send_buffer", c.Realtime.ClientSendBuffer},
+		{"realtime.event_queue_size", c.Realtime.EventQueueSize},
+		{"idempotency.ttl_hours", c.Idempotency.TTLHours},
+		{"gaia.dispatch_interval_seconds", c.Gaia.DispatchIntervalSeconds},
+		{"gaia.retry_backoff_seconds", c.Gaia.RetryBackoffSeconds},
+		{"gaia.heartbeat_timeout_seconds", c.Gaia.HeartbeatTimeoutSeconds},
+		{"gaia.metrics_interval_seconds", c.Gaia.MetricsIntervalSeconds},
+	}
+	if c.RateLimit.Enabled {
+		positive = append(positive, []struct {
+			name  string
+			value int
+		}{
+			{"rate_limit.auth.requests_per_minute", c.RateLimit.Auth.RequestsPerMinute},
+			{"rate_limit.auth.burst", c.RateLimit.Auth.Burst},
+			{"rate_limit.api.requests_per_minute", c.RateLimit.API.RequestsPerMinute},
+			{"rate_limit.api.burst", c.RateLimit.API.Burst},
+			{"rate_limit.writes.requests_per_minute", c.RateLimit.Writes.RequestsPerMinute},
+			{"rate_limit.writes.burst", c.RateLimit.Writes.Burst},
+		}...)
+	}
+	if c.Backup.Enabled {
+		positive = append(positive, []struct {
+			name  string
+			value int
+		}{
+			{"backup.interval_hours", c.Backup.IntervalHours},
+			{"backup.keep_daily", c.Backup.KeepDaily},
+		}...)
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", p.name, p.value))
+		}
+	}
+	if c.Apps.Math.AudioDir == "" {
+		errs = append(errs, errors.New("apps.math.audio_dir is required"))
+	}
+	if c.Apps.Reading.AudioDir == "" {
+		errs = append(errs, errors.New("apps.reading.audio_dir is required"))
+	}
+	if c.Backup.Enabled && c.Backup.Dir == "" {
+		errs = append(errs, errors.New("backup.dir is required"))
+	}
+	if c.Backup.KeepWeekly < 0 {
+		errs = append(errs, fmt.Errorf("backup.keep_weekly must not be negative, got %d", c.Backup.KeepWeekly))
+	}
+	if c.Audit.RetentionDays < 0 {
+		errs = append(errs, fmt.Errorf("audit.retention_days must not be negative, got %d", c.Audit.RetentionDays))
+	}
+	if c.Gaia.AssignIntervalSeconds < 0 {
+		errs = append(errs, fmt.Errorf("gaia.assign_interval_seconds must not be negative, got %d", c.Gaia.AssignIntervalSeconds))
+	}
+	if c.Gaia.TerminateAfterSeconds <= c.Gaia.HeartbeatTimeoutSeconds {
+		errs = append(errs, fmt.Errorf("gaia.terminate_after_seconds must be greater than gaia.heartbeat_timeout_seconds, got %d", c.Gaia.TerminateAfterSeconds))
+	}
+	if c.Gaia.MetricsRetentionDays < 0 {
+		errs = append(errs, fmt.Errorf("gaia.metrics_retention_days must not be negative, got %d", c.Gaia.MetricsRetentionDays))
+	}
+	if c.Gaia.AlertHysteresisPercent < 0 || c.Gaia.AlertHysteresisPercent >= 100 {
+		errs = append(errs, fmt.Errorf("gaia.alert_hysteresis_percent must be from 0 to 99, got %d", c.Gaia.AlertHysteresisPercent))
+	}
+	errs = append(errs, c.Gaia.validateAlerts()...)
+	if _, err := ParseTrustedProxies(c.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("trusted_proxies: %w", err))
+	}
+	if c.Tenancy.DefaultOrganization == "" {
+		errs = append(errs, errors.New("tenancy.default_organization is required"))
+	}
+	if c.Tenancy.Enabled && c.Tenancy.Header == "" && c.Tenancy.BaseDomain == "" {
+		errs = append(errs, errors.New("tenancy needs a header or a base_domain when enabled"))
+	}
+
+	if c.IsProduction() {
+		if c.SessionSecret == DefaultSessionSecret {
+			errs = append(errs, errors.New("session_secret must be changed from the default in production"))
+		}
+		for _, origin := range c.CORSOrigins {
+			if origin == "*" {
+				errs = append(errs, errors.New("cors_origins must not contain \"*\" in production"))
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with secrets masked, for
+// printing or logging
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.CORSOrigins = append([]string(nil), c.CORSOrigins...)
+	out.TrustedProxies = append([]string(nil), c.TrustedProxies...)
+	if c.Logging.Packages != nil {
+		out.Logging.Packages = make(map[string]string, len(c.Logging.Packages))
+		for pkg, level := range c.Logging.Packages {
+			out.Logging.Packages[pkg] = level
+		}
+	}
+	if c.Gaia.Alerts != nil {
+		out.Gaia.Alerts = make(map[string]AlertThreshold, len(c.Gaia.Alerts))
+		for metric, threshold := range c.Gaia.Alerts {
+			out.Gaia.Alerts[metric] = threshold
+		}
+	}
+	if out.SessionSecret != "" {
+		out.SessionSecret = redacted
+	}
+	return &out
 }
 
-// generateDefaultSecret creates a default session secret (should be overridden in production)
-func generateDefaultSecret() string {
-	return "unified-go-default-secret-change-in-production"
+// validateAlerts checks that the alerts are on known metrics and that their
+// critical bounds are past their warning bounds
+func (g GaiaConfig) validateAlerts() []error {
+	known := make(map[string]bool, len(AlertMetrics))
+	for _, metric := range AlertMetrics {
+		known[metric] = true
+	}
+
+	var errs []error
+	for metric, threshold := range g.Alerts {
+		if !known[metric] {
+			errs = append(errs, fmt.Errorf("gaia.alerts: unknown metric %q", metric))
+			continue
+		}
+		if threshold.WarningMax != nil && threshold.CriticalMax != nil && *threshold.CriticalMax < *threshold.WarningMax {
+			errs = append(errs, fmt.Errorf("gaia.alerts.%s.critical_max must not be below warning_max", metric))
+		}
+		if threshold.WarningMin != nil && threshold.CriticalMin != nil && *threshold.CriticalMin > *threshold.WarningMin {
+			errs = append(errs, fmt.Errorf("gaia.alerts.%s.critical_min must not be above warning_min", metric))
+		}
+	}
+	return errs
+}
+
+// ParseTrustedProxies parses proxy addresses and CIDR ranges; a bare address
+// stands for itself alone
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range %q", proxy)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q", proxy)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // IsDevelopment returns true if running in development mode
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -114,3 +116,251 @@ func TestConfigEnvironmentChecks(t *testing.T) {
 		})
 	}
 }
+
+// writeConfigFile writes a config file with the given name into a temp dir
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	os.Clearenv()
+
+	yamlPath := writeConfigFile(t, "config.yaml", `
+port: 7000
+environment: staging
+cors_origins: [https://a.example, https://b.example]
+apps:
+  reading:
+    audio_dir: /srv/audio/reading
+    leaderboard_size: 25
+realtime:
+  event_queue_size: 50
+gaia:
+  alerts:
+    memory_mb: {warning_max: 256}
+`)
+	jsonPath := writeConfigFile(t, "config.json", `{"port": 7001, "apps": {"typing": {"leaderboard_size": 5}}}`)
+
+	cfg, err := LoadFile(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadFile(yaml) error = %v", err)
+	}
+	if cfg.Port != 7000 || cfg.Environment != "staging" {
+		t.Errorf("got port %d env %q, want 7000 staging", cfg.Port, cfg.Environment)
+	}
+	if len(cfg.CORSOrigins) != 2 || cfg.CORSOrigins[1] != "https://b.example" {
+		t.Errorf("CORSOrigins = %v", cfg.CORSOrigins)
+	}
+	if cfg.Apps.Reading.AudioDir != "/srv/audio/reading" || cfg.Apps.Reading.LeaderboardSize != 25 {
+		t.Errorf("Apps.Reading = %+v", cfg.Apps.Reading)
+	}
+	// Keys missing from the file keep their defaults
+	if cfg.Apps.Reading.MaxUploadMB != 10 || cfg.Realtime.HubBroadcastBuffer != 256 {
+		t.Errorf("defaults not kept: reading %+v realtime %+v", cfg.Apps.Reading, cfg.Realtime)
+	}
+	if cfg.Realtime.EventQueueSize != 50 {
+		t.Errorf("EventQueueSize = %d, want 50", cfg.Realtime.EventQueueSize)
+	}
+	// An alert entry replaces its metric's default and keeps the others
+	if memory := cfg.Gaia.Alerts["memory_mb"]; memory.WarningMax == nil || *memory.WarningMax != 256 || memory.CriticalMax != nil {
+		t.Errorf("Gaia.Alerts[memory_mb] = %+v, want only warning_max 256", memory)
+	}
+	if _, ok := cfg.Gaia.Alerts["pending_tasks"]; !ok {
+		t.Errorf("Gaia.Alerts = %v, want the pending_tasks default kept", cfg.Gaia.Alerts)
+	}
+
+	cfg, err = LoadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("LoadFile(json) error = %v", err)
+	}
+	if cfg.Port != 7001 || cfg.Apps.Typing.LeaderboardSize != 5 {
+		t.Errorf("got port %d typing %+v", cfg.Port, cfg.Apps.Typing)
+	}
+
+	// Environment variables override the file
+	t.Setenv("PORT", "9000")
+	t.Setenv("CORS_ORIGIN", "https://c.example , https://d.example")
+	t.Setenv("READING_LEADERBOARD_SIZE", "40")
+	t.Setenv("LOG_LEVELS", "database=debug,http=warn")
+	t.Setenv("RATE_LIMIT_PERSIST", "true")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+	cfg, err = LoadFile(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadFile() with env error = %v", err)
+	}
+	if cfg.Port != 9000 || cfg.Apps.Reading.LeaderboardSize != 40 {
+		t.Errorf("env overrides not applied: port %d reading %+v", cfg.Port, cfg.Apps.Reading)
+	}
+	if len(cfg.CORSOrigins) != 2 || cfg.CORSOrigins[0] != "https://c.example" {
+		t.Errorf("CORSOrigins = %v", cfg.CORSOrigins)
+	}
+	if cfg.Logging.Packages["database"] != "debug" || cfg.Logging.Packages["http"] != "warn" {
+		t.Errorf("Logging.Packages = %v", cfg.Logging.Packages)
+	}
+	if !cfg.RateLimit.Enabled || !cfg.RateLimit.Persist {
+		t.Errorf("RateLimit = %+v, want enabled and persisted", cfg.RateLimit)
+	}
+	if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1] != "127.0.0.1" {
+		t.Errorf("TrustedProxies = %v", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+	}{
+		{name: "unknown yaml key", file: "c.yaml", content: "prot: 5000\n"},
+		{name: "unknown json key", file: "c.json", content: `{"apps": {"math": {"audio": "x"}}}`},
+		{name: "unsupported extension", file: "c.toml", content: "port = 5000\n"},
+		{name: "invalid env integer", file: "c.yaml", content: "port: 5000\n", env: map[string]string{"PORT": "abc"}},
+		{name: "invalid env boolean", file: "c.yaml", content: "port: 5000\n", env: map[string]string{"RATE_LIMIT_ENABLED": "maybe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := LoadFile(writeConfigFile(t, tt.file, tt.content)); err == nil {
+				t.Error("LoadFile() error = nil, want error")
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile(missing) error = nil, want error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "development defaults", modify: func(c *Config) {}},
+		{
+			name: "production with safe settings",
+			modify: func(c *Config) {
+				c.Environment = "production"
+				c.SessionSecret = "a-real-secret"
+				c.CORSOrigins = []string{"https://example.com"}
+			},
+		},
+		{
+			name: "production with default secret",
+			modify: func(c *Config) {
+				c.Environment = "production"
+				c.CORSOrigins = []string{"https://example.com"}
+			},
+			wantErr: "session_secret",
+		},
+		{
+			name: "production with wildcard cors",
+			modify: func(c *Config) {
+				c.Environment = "production"
+				c.SessionSecret = "a-real-secret"
+				c.CORSOrigins = []string{"https://example.com", "*"}
+			},
+			wantErr: "cors_origins",
+		},
+		{name: "unknown environment", modify: func(c *Config) { c.Environment = "prod" }, wantErr: "environment"},
+		{name: "invalid port", modify: func(c *Config) { c.Port = 70000 }, wantErr: "port"},
+		{name: "trusted proxy cidr and address", modify: func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8", "::1"} }},
+		{name: "invalid trusted proxy", modify: func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/33"} }, wantErr: "trusted_proxies"},
+		{name: "zero upload limit", modify: func(c *Config) { c.Apps.Piano.MaxUploadMB = 0 }, wantErr: "apps.piano.max_upload_mb"},
+		{name: "negative buffer", modify: func(c *Config) { c.Realtime.ClientSendBuffer = -1 }, wantErr: "realtime.client_send_buffer"},
+		{name: "invalid log level", modify: func(c *Config) { c.Logging.Level = "loud" }, wantErr: "logging.level"},
+		{
+			name:    "invalid package log level",
+			modify:  func(c *Config) { c.Logging.Packages = map[string]string{"database": "chatty"} },
+			wantErr: "logging.packages.database",
+		},
+		{name: "invalid log format", modify: func(c *Config) { c.Logging.Format = "xml" }, wantErr: "logging.format"},
+		{name: "zero rate limit burst", modify: func(c *Config) { c.RateLimit.Writes.Burst = 0 }, wantErr: "rate_limit.writes.burst"},
+		{name: "zero daily backups", modify: func(c *Config) { c.Backup.KeepDaily = 0 }, wantErr: "backup.keep_daily"},
+		{name: "negative weekly backups", modify: func(c *Config) { c.Backup.KeepWeekly = -1 }, wantErr: "backup.keep_weekly"},
+		{name: "negative audit retention", modify: func(c *Config) { c.Audit.RetentionDays = -1 }, wantErr: "audit.retention_days"},
+		{name: "zero idempotency ttl", modify: func(c *Config) { c.Idempotency.TTLHours = 0 }, wantErr: "idempotency.ttl_hours"},
+		{name: "zero gaia dispatch interval", modify: func(c *Config) { c.Gaia.DispatchIntervalSeconds = 0 }, wantErr: "gaia.dispatch_interval_seconds"},
+		{name: "negative gaia assign interval", modify: func(c *Config) { c.Gaia.AssignIntervalSeconds = -1 }, wantErr: "gaia.assign_interval_seconds"},
+		{name: "gaia terminate before heartbeat timeout", modify: func(c *Config) { c.Gaia.TerminateAfterSeconds = 60 }, wantErr: "gaia.terminate_after_seconds"},
+		{name: "gaia alert hysteresis too large", modify: func(c *Config) { c.Gaia.AlertHysteresisPercent = 100 }, wantErr: "gaia.alert_hysteresis_percent"},
+		{name: "gaia alert on unknown metric", modify: func(c *Config) { c.Gaia.Alerts["disk_mb"] = AlertThreshold{WarningMax: bound(1)} }, wantErr: `unknown metric "disk_mb"`},
+		{
+			name: "gaia critical bound inside warning bound",
+			modify: func(c *Config) {
+				c.Gaia.Alerts["goroutines"] = AlertThreshold{WarningMax: bound(100), CriticalMax: bound(50)}
+			},
+			wantErr: "gaia.alerts.goroutines.critical_max",
+		},
+		{name: "missing default organization", modify: func(c *Config) { c.Tenancy.DefaultOrganization = "" }, wantErr: "tenancy.default_organization"},
+		{
+			name: "tenancy without header or domain",
+			modify: func(c *Config) {
+				c.Tenancy.Enabled = true
+				c.Tenancy.Header = ""
+			},
+			wantErr: "tenancy needs a header",
+		},
+		{
+			name: "backup settings ignored when disabled",
+			modify: func(c *Config) {
+				c.Backup = BackupConfig{}
+			},
+		},
+		{
+			name: "rate limits ignored when disabled",
+			modify: func(c *Config) {
+				c.RateLimit.Enabled = false
+				c.RateLimit.API = RateLimitRule{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want mention of %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.SessionSecret = "super-secret"
+
+	out := cfg.Redacted()
+	if out.SessionSecret == "super-secret" {
+		t.Error("Redacted() kept the session secret")
+	}
+	if cfg.SessionSecret != "super-secret" {
+		t.Error("Redacted() modified the original config")
+	}
+
+	out.CORSOrigins[0] = "changed"
+	if cfg.CORSOrigins[0] != "*" {
+		t.Error("Redacted() shares CORSOrigins with the original config")
+	}
+}
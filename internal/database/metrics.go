@@ -0,0 +1,20 @@
+package database
+
+import (
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// Collect implements metrics.Collector with the connection pool statistics
+func (p *Pool) Collect() []metrics.Family {
+	s := p.Stats()
+	return []metrics.Family{
+		metrics.Gauge("unified_db_open_connections", "Open database connections, in use and idle.", float64(s.OpenConnections)),
+		metrics.Gauge("unified_db_in_use_connections", "Database connections currently in use.", float64(s.InUse)),
+		metrics.Gauge("unified_db_idle_connections", "Idle database connections.", float64(s.Idle)),
+		metrics.Gauge("unified_db_max_open_connections", "Maximum open database connections.", float64(s.MaxOpenConnections)),
+		metrics.Counter("unified_db_wait_count_total", "Connections waited for because the pool was exhausted.", float64(s.WaitCount)),
+		metrics.Counter("unified_db_wait_duration_seconds_total", "Total time spent waiting for a connection.", s.WaitDuration.Seconds()),
+		metrics.Counter("unified_db_max_idle_closed_total", "Connections closed due to the idle limit.", float64(s.MaxIdleClosed)),
+		metrics.Counter("unified_db_max_lifetime_closed_total", "Connections closed due to the maximum lifetime.", float64(s.MaxLifetimeClosed)),
+	}
+}
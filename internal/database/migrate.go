@@ -0,0 +1,575 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by the migrator
+var (
+	ErrChecksumMismatch = errors.New("applied migration has been edited")
+	ErrIrreversible     = errors.New("migration has no down script")
+	ErrUnknownTarget    = errors.New("unknown migration target")
+	ErrNoMigrations     = errors.New("no applied migrations to roll back")
+)
+
+// Migration states reported by Status
+const (
+	StatePending  = "pending"
+	StateApplied  = "applied"
+	StateModified = "modified"
+	StateMissing  = "missing"
+)
+
+// migrationFilePattern matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change for a target
+type Migration struct {
+	Target   string
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	HasDown  bool
+	Checksum string
+}
+
+// MigrationStatus describes a migration's state in the database
+type MigrationStatus struct {
+	Target    string     `json:"target"`
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies and rolls back migrations for a set of targets. Each
+// migration runs in its own transaction and is recorded with a checksum of its
+// up script so edits to applied migrations are detected.
+type Migrator struct {
+	db         *sql.DB
+	targets    []string
+	migrations map[string][]Migration
+}
+
+// NewMigrator loads the migrations for the given targets from fsys, where each
+// target is a directory of <version>_<name>.up.sql / .down.sql files
+func NewMigrator(db *sql.DB, fsys fs.FS, targets ...string) (*Migrator, error) {
+	m := &Migrator{
+		db:         db,
+		targets:    targets,
+		migrations: make(map[string][]Migration, len(targets)),
+	}
+
+	for _, target := range targets {
+		migrations, err := LoadMigrations(fsys, target)
+		if err != nil {
+			return nil, err
+		}
+		m.migrations[target] = migrations
+	}
+
+	return m, nil
+}
+
+// LoadMigrations reads and validates one target's migrations, sorted by version
+func LoadMigrations(fsys fs.FS, target string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations for %s: %w", target, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s/%s", target, entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		name, direction := match[2], match[3]
+
+		content, err := fs.ReadFile(fsys, path.Join(target, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s/%s: %w", target, entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Target: target, Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration %s/%04d has conflicting names %q and %q", target, version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+			migration.Checksum = checksum(content)
+		} else {
+			migration.Down = string(content)
+			migration.HasDown = true
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %s/%04d_%s has no up script", target, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Targets returns the targets managed by the migrator, in run order
+func (m *Migrator) Targets() []string {
+	return m.targets
+}
+
+// Status reports every known migration plus any applied migration whose file
+// no longer exists
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	return m.status(ctx)
+}
+
+// Inspect reports the same as Status without writing to the database, for
+// callers such as health probes that must not run DDL. Until
+// schema_migrations exists in its per-target form every migration is
+// reported pending.
+func (m *Migrator) Inspect(ctx context.Context) ([]MigrationStatus, error) {
+	columns, err := m.tableColumns(ctx, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !columns["target"] {
+		var statuses []MigrationStatus
+		for _, target := range m.targets {
+			for _, migration := range m.migrations[target] {
+				statuses = append(statuses, MigrationStatus{
+					Target: target, Version: migration.Version, Name: migration.Name, State: StatePending,
+				})
+			}
+		}
+		return statuses, nil
+	}
+	return m.status(ctx)
+}
+
+// status reads the applied migrations from schema_migrations and compares
+// them with the loaded ones
+func (m *Migrator) status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	for _, target := range m.targets {
+		applied, err := m.applied(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, migration := range m.migrations[target] {
+			status := MigrationStatus{Target: target, Version: migration.Version, Name: migration.Name, State: StatePending}
+			if row, ok := applied[migration.Version]; ok {
+				appliedAt := row.appliedAt
+				status.AppliedAt = &appliedAt
+				status.State = StateApplied
+				if row.checksum != "" && row.checksum != migration.Checksum {
+					status.State = StateModified
+				}
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+
+		for version, row := range applied {
+			appliedAt := row.appliedAt
+			statuses = append(statuses, MigrationStatus{
+				Target: target, Version: version, Name: row.name, State: StateMissing, AppliedAt: &appliedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Target != statuses[j].Target {
+			return m.targetIndex(statuses[i].Target) < m.targetIndex(statuses[j].Target)
+		}
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Up applies pending migrations for target, or for every target when target
+// is empty, and returns how many were applied. It refuses to run while an
+// applied migration of the same target has been edited.
+func (m *Migrator) Up(ctx context.Context, target string) (int, error) {
+	targets, err := m.selectTargets(target)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, target := range targets {
+		applied, err := m.applied(ctx, target)
+		if err != nil {
+			return total, err
+		}
+
+		if err := m.verifyChecksums(ctx, target, applied); err != nil {
+			return total, err
+		}
+
+		for _, migration := range m.migrations[target] {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, migration); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// Down rolls back the last steps applied migrations of target and returns how
+// many were rolled back
+func (m *Migrator) Down(ctx context.Context, target string, steps int) (int, error) {
+	if _, ok := m.migrations[target]; !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownTarget, target)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+
+	applied, err := m.applied(ctx, target)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, fmt.Errorf("%w for %s", ErrNoMigrations, target)
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	rolledBack := 0
+	for _, version := range versions {
+		if rolledBack >= steps {
+			break
+		}
+
+		migration, ok := m.find(target, version)
+		if !ok {
+			return rolledBack, fmt.Errorf("migration %s/%04d_%s is applied but its files are missing",
+				target, version, applied[version].name)
+		}
+		if err := m.revert(ctx, migration); err != nil {
+			return rolledBack, err
+		}
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}
+
+// Redo rolls back the last applied migration of target and applies it again,
+// picking up any edits to its scripts
+func (m *Migrator) Redo(ctx context.Context, target string) error {
+	if _, ok := m.migrations[target]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTarget, target)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	applied, err := m.applied(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	latest := 0
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+	if latest == 0 {
+		return fmt.Errorf("%w for %s", ErrNoMigrations, target)
+	}
+
+	migration, ok := m.find(target, latest)
+	if !ok {
+		return fmt.Errorf("migration %s/%04d is applied but its files are missing", target, latest)
+	}
+	if err := m.revert(ctx, migration); err != nil {
+		return err
+	}
+	return m.apply(ctx, migration)
+}
+
+// apply runs a migration's up script and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	logger.Info("applying migration", "target", migration.Target, "version", migration.Version, "name", migration.Name)
+
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		if hasStatements(migration.Up) {
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return fmt.Errorf("migration %s/%04d failed: %w", migration.Target, migration.Version, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (target, version, name, checksum, applied_at) VALUES (?, ?, ?, ?, ?)`,
+			migration.Target, migration.Version, migration.Name, migration.Checksum, time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to record migration %s/%04d: %w", migration.Target, migration.Version, err)
+		}
+		return nil
+	})
+}
+
+// revert runs a migration's down script and removes its record in one transaction
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	if !migration.HasDown {
+		return fmt.Errorf("%w: %s/%04d_%s", ErrIrreversible, migration.Target, migration.Version, migration.Name)
+	}
+
+	logger.Info("rolling back migration", "target", migration.Target, "version", migration.Version, "name", migration.Name)
+
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		if hasStatements(migration.Down) {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return fmt.Errorf("rollback of %s/%04d failed: %w", migration.Target, migration.Version, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM schema_migrations WHERE target = ? AND version = ?`,
+			migration.Target, migration.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %s/%04d: %w", migration.Target, migration.Version, err)
+		}
+		return nil
+	})
+}
+
+// verifyChecksums fails if an applied migration's up script has changed.
+// Rows without a checksum (imported from the legacy table) adopt the current one.
+func (m *Migrator) verifyChecksums(ctx context.Context, target string, applied map[int]appliedMigration) error {
+	for _, migration := range m.migrations[target] {
+		row, ok := applied[migration.Version]
+		if !ok {
+			continue
+		}
+
+		if row.checksum == "" {
+			if _, err := m.db.ExecContext(ctx,
+				`UPDATE schema_migrations SET checksum = ? WHERE target = ? AND version = ?`,
+				migration.Checksum, target, migration.Version); err != nil {
+				return fmt.Errorf("failed to record checksum: %w", err)
+			}
+			continue
+		}
+
+		if row.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %s/%04d_%s (use redo to re-apply it)",
+				ErrChecksumMismatch, target, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// ensureTable creates schema_migrations, converting the legacy single-target
+// table (version, name, applied_at) if present
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	columns, err := m.tableColumns(ctx, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	if columns["target"] {
+		return nil
+	}
+
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		legacy := len(columns) > 0
+		if legacy {
+			if _, err := tx.ExecContext(ctx, `ALTER TABLE schema_migrations RENAME TO schema_migrations_legacy`); err != nil {
+				return fmt.Errorf("failed to rename legacy migrations table: %w", err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			CREATE TABLE schema_migrations (
+				target TEXT NOT NULL,
+				version INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				checksum TEXT NOT NULL DEFAULT '',
+				applied_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (target, version)
+			)`); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+
+		if !legacy {
+			return nil
+		}
+
+		// Only the core schema used the integer-versioned legacy table; the old
+		// GAIA table is dropped and its idempotent schema simply re-applied
+		if columns["name"] && !columns["description"] {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (target, version, name, checksum, applied_at)
+				SELECT ?, version, name, '', applied_at FROM schema_migrations_legacy`, TargetCore); err != nil {
+				return fmt.Errorf("failed to import legacy migrations: %w", err)
+			}
+			logger.Info("converted legacy schema_migrations table to per-target versioning")
+		}
+
+		if _, err := tx.ExecContext(ctx, `DROP TABLE schema_migrations_legacy`); err != nil {
+			return fmt.Errorf("failed to drop legacy migrations table: %w", err)
+		}
+		return nil
+	})
+}
+
+// applied returns the recorded migrations of a target keyed by version
+func (m *Migrator) applied(ctx context.Context, target string) (map[int]appliedMigration, error) {
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT version, name, checksum, applied_at FROM schema_migrations WHERE target = ?`, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = row
+	}
+
+	return applied, rows.Err()
+}
+
+// tableColumns returns the column names of a table, or an empty set if it does not exist
+func (m *Migrator) tableColumns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// inTx runs fn in a transaction, rolling back on error
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// selectTargets resolves an optional target name to the targets to run
+func (m *Migrator) selectTargets(target string) ([]string, error) {
+	if target == "" {
+		return m.targets, nil
+	}
+	if _, ok := m.migrations[target]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTarget, target)
+	}
+	return []string{target}, nil
+}
+
+// find returns a target's migration by version
+func (m *Migrator) find(target string, version int) (Migration, bool) {
+	for _, migration := range m.migrations[target] {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// targetIndex returns a target's position in run order
+func (m *Migrator) targetIndex(target string) int {
+	for i, t := range m.targets {
+		if t == target {
+			return i
+		}
+	}
+	return len(m.targets)
+}
+
+// checksum returns the hex SHA-256 of a migration script
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// hasStatements reports whether a script contains anything besides comments
+func hasStatements(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"app/0001_create_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id INTEGER PRIMARY KEY);")},
+		"app/0001_create_widgets.down.sql": {Data: []byte("DROP TABLE widgets;")},
+		"app/0002_add_name.up.sql":         {Data: []byte("ALTER TABLE widgets ADD COLUMN name TEXT;")},
+		"app/0002_add_name.down.sql":       {Data: []byte("ALTER TABLE widgets DROP COLUMN name;")},
+		"other/0001_create_gadgets.up.sql": {Data: []byte("CREATE TABLE gadgets (id INTEGER PRIMARY KEY);")},
+	}
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+		t.Fatalf("Failed to check table %s: %v", table, err)
+	}
+	return n > 0
+}
+
+func TestMigratorUpDownRedo(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, testMigrations(), "app", "other")
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+
+	applied, err := m.Up(ctx, "")
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if applied != 3 {
+		t.Errorf("Expected 3 migrations applied, got %d", applied)
+	}
+	if applied, _ := m.Up(ctx, ""); applied != 0 {
+		t.Errorf("Expected second Up to be a no-op, applied %d", applied)
+	}
+
+	if _, err := m.Down(ctx, "app", 1); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO widgets (name) VALUES ('x')`); err == nil {
+		t.Error("Expected name column to be dropped")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	want := []string{StateApplied, StatePending, StateApplied}
+	for i, s := range statuses {
+		if s.State != want[i] {
+			t.Errorf("Status %s/%d: expected %s, got %s", s.Target, s.Version, want[i], s.State)
+		}
+	}
+
+	if _, err := m.Up(ctx, "app"); err != nil {
+		t.Fatalf("Up app failed: %v", err)
+	}
+	if err := m.Redo(ctx, "app"); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO widgets (name) VALUES ('x')`); err != nil {
+		t.Errorf("Expected name column after redo: %v", err)
+	}
+
+	if _, err := m.Down(ctx, "other", 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("Expected ErrIrreversible, got %v", err)
+	}
+	if _, err := m.Up(ctx, "missing"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("Expected ErrUnknownTarget, got %v", err)
+	}
+}
+
+func TestMigratorDetectsEditedMigration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	fsys := testMigrations()
+	m, _ := NewMigrator(db, fsys, "app")
+	if _, err := m.Up(ctx, ""); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	fsys["app/0001_create_widgets.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE widgets (id INTEGER PRIMARY KEY, extra TEXT);")}
+	m, _ = NewMigrator(db, fsys, "app")
+
+	if _, err := m.Up(ctx, ""); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	statuses, _ := m.Status(ctx)
+	if statuses[0].State != StateModified {
+		t.Errorf("Expected first migration to be modified, got %s", statuses[0].State)
+	}
+}
+
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	fsys := testMigrations()
+	fsys["app/0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE half (id INTEGER); INSERT INTO nowhere VALUES (1);")}
+	m, _ := NewMigrator(db, fsys, "app")
+
+	if _, err := m.Up(ctx, ""); err == nil {
+		t.Fatal("Expected broken migration to fail")
+	}
+	if tableExists(t, db, "half") {
+		t.Error("Expected failed migration to be rolled back")
+	}
+
+	statuses, _ := m.Status(ctx)
+	if statuses[2].State != StatePending {
+		t.Errorf("Expected broken migration to stay pending, got %s", statuses[2].State)
+	}
+}
+
+func TestMigratorInspectIsReadOnly(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, testMigrations(), "app", "other")
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+
+	statuses, err := m.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if len(statuses) != 3 {
+		t.Errorf("Expected 3 migrations, got %d", len(statuses))
+	}
+	for _, s := range statuses {
+		if s.State != StatePending {
+			t.Errorf("Expected %s/%d to be pending, got %s", s.Target, s.Version, s.State)
+		}
+	}
+	if tableExists(t, db, "schema_migrations") {
+		t.Error("Expected Inspect not to create schema_migrations")
+	}
+
+	if _, err := m.Up(ctx, "app"); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	statuses, err = m.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	states := make(map[string]string)
+	for _, s := range statuses {
+		states[s.Target+"/"+s.Name] = s.State
+	}
+	if states["app/add_name"] != StateApplied || states["other/create_gadgets"] != StatePending {
+		t.Errorf("Unexpected states after Up: %v", states)
+	}
+}
+
+func TestMigratorConvertsLegacyTable(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`
+		CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		INSERT INTO schema_migrations (version, name) VALUES (1, 'create_users_table');
+		CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT UNIQUE NOT NULL, password_hash TEXT NOT NULL, email TEXT, created_at DATETIME, updated_at DATETIME);
+	`); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+
+	m, err := NewMigrator(db, MigrationFS(), TargetCore)
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+	if _, err := m.Up(ctx, ""); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	var checksum string
+	if err := db.QueryRow(`SELECT checksum FROM schema_migrations WHERE target = 'core' AND version = 1`).Scan(&checksum); err != nil {
+		t.Fatalf("Expected legacy migration to be imported: %v", err)
+	}
+	if checksum == "" {
+		t.Error("Expected imported migration to adopt the current checksum")
+	}
+}
+
+func TestEmbeddedMigrationsRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, MigrationFS(), ServerTargets...)
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+	if _, err := m.Up(ctx, ""); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	for _, table := range []string{"users", "api_tokens", "results", "books", "typing_results", "races"} {
+		if !tableExists(t, db, table) {
+			t.Errorf("Expected table %s after migrating", table)
+		}
+	}
+
+	// Roll every target back in reverse order, then forward again
+	for i := len(ServerTargets) - 1; i >= 0; i-- {
+		target := ServerTargets[i]
+		if _, err := m.Down(ctx, target, len(m.migrations[target])); err != nil {
+			t.Fatalf("Down %s failed: %v", target, err)
+		}
+	}
+	if tableExists(t, db, "users") {
+		t.Error("Expected users table to be dropped")
+	}
+	if _, err := m.Up(ctx, ""); err != nil {
+		t.Fatalf("Re-applying migrations failed: %v", err)
+	}
+
+	gaia := setupTestDB(t)
+	gm, err := NewMigrator(gaia, MigrationFS(), TargetGaia)
+	if err != nil {
+		t.Fatalf("NewMigrator gaia failed: %v", err)
+	}
+	if _, err := gm.Up(ctx, ""); err != nil {
+		t.Fatalf("Up gaia failed: %v", err)
+	}
+	if !tableExists(t, gaia, "tasks") {
+		t.Error("Expected gaia tasks table")
+	}
+}
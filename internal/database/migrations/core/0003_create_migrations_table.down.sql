@@ -0,0 +1 @@
+-- Migration tracking is owned by the migration engine; nothing to undo
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS piano_progress;
+DROP TABLE IF EXISTS reading_progress;
+DROP TABLE IF EXISTS math_progress;
+DROP TABLE IF EXISTS typing_progress;
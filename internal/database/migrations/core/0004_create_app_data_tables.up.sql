@@ -0,0 +1,46 @@
+-- Typing app data
+CREATE TABLE IF NOT EXISTS typing_progress (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	lesson_id TEXT NOT NULL,
+	wpm INTEGER,
+	accuracy REAL,
+	completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_typing_progress_user_id ON typing_progress(user_id);
+
+-- Math app data
+CREATE TABLE IF NOT EXISTS math_progress (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	problem_type TEXT NOT NULL,
+	correct_answers INTEGER DEFAULT 0,
+	total_attempts INTEGER DEFAULT 0,
+	completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_math_progress_user_id ON math_progress(user_id);
+
+-- Reading app data
+CREATE TABLE IF NOT EXISTS reading_progress (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	book_id TEXT NOT NULL,
+	page_number INTEGER DEFAULT 0,
+	comprehension_score REAL,
+	completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_reading_progress_user_id ON reading_progress(user_id);
+
+-- Piano app data
+CREATE TABLE IF NOT EXISTS piano_progress (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	song_id TEXT NOT NULL,
+	accuracy REAL,
+	completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_piano_progress_user_id ON piano_progress(user_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_music_metrics;
+DROP TABLE IF EXISTS music_theory_quizzes;
+DROP TABLE IF EXISTS practice_sessions;
+DROP TABLE IF EXISTS piano_lessons;
+DROP TABLE IF EXISTS songs;
@@ -0,0 +1,4 @@
+-- Login lockout and audit columns for the auth module
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until DATETIME;
+ALTER TABLE users ADD COLUMN last_login_at DATETIME;
@@ -0,0 +1,26 @@
+-- Account role: student, parent, teacher or admin
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'student';
+
+-- Classrooms and households
+CREATE TABLE IF NOT EXISTS user_groups (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	owner_id INTEGER NOT NULL,
+	join_code TEXT UNIQUE NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_groups_owner_id ON user_groups(owner_id);
+
+-- Group membership: teacher/student in classrooms, parent/child in households
+CREATE TABLE IF NOT EXISTS group_members (
+	group_id INTEGER NOT NULL,
+	user_id INTEGER NOT NULL,
+	role TEXT NOT NULL,
+	joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (group_id, user_id),
+	FOREIGN KEY (group_id) REFERENCES user_groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members(user_id);
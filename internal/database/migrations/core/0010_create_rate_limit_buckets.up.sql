@@ -0,0 +1,8 @@
+-- Token-bucket state for the HTTP rate limiters, saved so limits survive restarts
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	limiter TEXT NOT NULL,
+	bucket_key TEXT NOT NULL,
+	tokens REAL NOT NULL,
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (limiter, bucket_key)
+);
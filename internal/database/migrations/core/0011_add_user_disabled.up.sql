@@ -0,0 +1,2 @@
+-- Accounts disabled by an administrator can no longer log in
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;
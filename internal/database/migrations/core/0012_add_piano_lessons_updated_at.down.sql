@@ -0,0 +1 @@
+ALTER TABLE piano_lessons DROP COLUMN updated_at;
@@ -0,0 +1,3 @@
+-- The piano repository reads and writes piano_lessons.updated_at
+ALTER TABLE piano_lessons ADD COLUMN updated_at DATETIME;
+UPDATE piano_lessons SET updated_at = created_at;
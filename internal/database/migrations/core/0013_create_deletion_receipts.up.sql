@@ -0,0 +1,10 @@
+-- Receipts for erased accounts; they outlive the user row and hold no personal data
+CREATE TABLE IF NOT EXISTS deletion_receipts (
+	id TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	requested_by INTEGER NOT NULL,
+	rows_deleted TEXT NOT NULL,
+	files_deleted INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_deletion_receipts_user_id ON deletion_receipts(user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE deletion_receipts DROP COLUMN rows_anonymized;
+DROP TRIGGER IF EXISTS audit_log_append_only;
+DROP TABLE IF EXISTS audit_log;
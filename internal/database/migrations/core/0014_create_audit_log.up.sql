@@ -0,0 +1,32 @@
+-- Append-only record of data-modifying requests and admin CLI operations
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at DATETIME NOT NULL,
+	source TEXT NOT NULL,
+	actor_id INTEGER,
+	actor TEXT,
+	action TEXT NOT NULL,
+	app TEXT,
+	target_type TEXT,
+	target_id TEXT,
+	changes TEXT,
+	method TEXT,
+	path TEXT,
+	status INTEGER,
+	ip TEXT,
+	request_id TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id);
+
+-- Entries are never rewritten; only the actor and IP may be cleared when an
+-- account is erased, and only the retention purge deletes rows
+CREATE TRIGGER IF NOT EXISTS audit_log_append_only
+BEFORE UPDATE OF id, created_at, source, actor, action, app, target_type, target_id, changes, method, path, status, request_id ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+-- Erasure receipts also count the rows anonymized rather than deleted
+ALTER TABLE deletion_receipts ADD COLUMN rows_anonymized TEXT;
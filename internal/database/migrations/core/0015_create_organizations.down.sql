@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS audit_log_append_only;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OF id, created_at, source, actor, action, app, target_type, target_id, changes, method, path, status, request_id ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+DROP INDEX IF EXISTS idx_audit_log_org_id;
+ALTER TABLE audit_log DROP COLUMN org_id;
+DROP INDEX IF EXISTS idx_songs_org_id;
+ALTER TABLE songs DROP COLUMN org_id;
+DROP INDEX IF EXISTS idx_users_org_id;
+ALTER TABLE users DROP COLUMN org_id;
+DROP TABLE IF EXISTS organizations;
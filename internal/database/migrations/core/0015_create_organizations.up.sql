@@ -0,0 +1,32 @@
+-- Organizations (schools) sharing one deployment. Every user belongs to one;
+-- songs belong to one or, with a NULL org_id, are shared with organizations
+-- that opt in to shared content.
+CREATE TABLE IF NOT EXISTS organizations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	slug TEXT UNIQUE NOT NULL,
+	name TEXT NOT NULL,
+	shared_content BOOLEAN NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing users move into the default organization, which keeps seeing the
+-- existing, now shared, catalog
+INSERT OR IGNORE INTO organizations (id, slug, name, shared_content) VALUES (1, 'default', 'Default', 1);
+
+ALTER TABLE users ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_users_org_id ON users(org_id);
+
+ALTER TABLE songs ADD COLUMN org_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_songs_org_id ON songs(org_id);
+
+-- Audit entries are listed per organization, so org_id joins the columns
+-- that can never be rewritten
+ALTER TABLE audit_log ADD COLUMN org_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_audit_log_org_id ON audit_log(org_id);
+
+DROP TRIGGER IF EXISTS audit_log_append_only;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OF id, created_at, source, actor, action, app, target_type, target_id, changes, method, path, status, request_id, org_id ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
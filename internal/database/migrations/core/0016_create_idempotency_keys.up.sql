@@ -0,0 +1,15 @@
+-- First response to each Idempotency-Key, replayed when a client retries the
+-- request. status is NULL while the first request is still being handled.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	scope TEXT NOT NULL,
+	idempotency_key TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	status INTEGER,
+	content_type TEXT,
+	body BLOB,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL,
+	PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
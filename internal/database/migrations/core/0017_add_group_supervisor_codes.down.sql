@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_groups_supervisor_code;
+ALTER TABLE user_groups DROP COLUMN supervisor_code;
@@ -0,0 +1,3 @@
+-- Separate invite code for teachers and parents joining a group as supervisors
+ALTER TABLE user_groups ADD COLUMN supervisor_code TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_groups_supervisor_code ON user_groups(supervisor_code);
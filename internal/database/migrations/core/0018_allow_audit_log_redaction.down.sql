@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS audit_log_changes_clear_only;
+DROP TRIGGER IF EXISTS audit_log_append_only;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OF id, created_at, source, actor, action, app, target_type, target_id, changes, method, path, status, request_id, org_id ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
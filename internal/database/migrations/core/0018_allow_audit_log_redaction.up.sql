@@ -0,0 +1,16 @@
+-- Erasing an account clears the changes recorded by or about the user, which
+-- can hold their username or token names. changes may be cleared but never
+-- rewritten; every other column except actor_id and ip stays immutable.
+DROP TRIGGER IF EXISTS audit_log_append_only;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OF id, created_at, source, actor, action, app, target_type, target_id, method, path, status, request_id, org_id ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_changes_clear_only
+BEFORE UPDATE OF changes ON audit_log
+WHEN NEW.changes IS NOT NULL
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
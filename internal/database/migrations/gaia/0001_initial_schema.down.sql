@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS rate_limiter_state;
+DROP TABLE IF EXISTS task_log;
+DROP TABLE IF EXISTS session_state_log;
+DROP TABLE IF EXISTS metrics;
+DROP TABLE IF EXISTS locks;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS tasks;
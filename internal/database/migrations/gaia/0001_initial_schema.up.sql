@@ -1,5 +1,4 @@
 -- GAIA_HOME Go Rewrite: Initial Schema
--- Migration: 001_initial_schema
 -- Purpose: Create core tables for task management, sessions, locks, and metrics
 
 -- Tasks Table: Core task queue storage
@@ -107,15 +106,3 @@ CREATE INDEX IF NOT EXISTS idx_locks_expires_at ON locks(expires_at);
 CREATE INDEX IF NOT EXISTS idx_metrics_timestamp ON metrics(timestamp);
 CREATE INDEX IF NOT EXISTS idx_task_log_task_id ON task_log(task_id);
 CREATE INDEX IF NOT EXISTS idx_session_state_log_session_id ON session_state_log(session_id);
-
--- Schema version tracking
-CREATE TABLE IF NOT EXISTS schema_migrations (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    version TEXT NOT NULL UNIQUE,
-    description TEXT,
-    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
-
--- Record this migration
-INSERT OR IGNORE INTO schema_migrations (version, description)
-VALUES ('001_initial_schema', 'Create core tables for tasks, sessions, locks, metrics');
@@ -0,0 +1,3 @@
+-- Migration: A failed task is held back from dispatch until next_attempt_at
+
+ALTER TABLE tasks ADD COLUMN next_attempt_at TIMESTAMP;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lock_stats;
+ALTER TABLE locks DROP COLUMN fencing_token;
@@ -0,0 +1,16 @@
+-- Migration: Lock leases carry a fencing token, increasing with every new
+-- lease of a lock, so a holder whose lease expired or was preempted can be
+-- told apart from the current one
+
+ALTER TABLE locks ADD COLUMN fencing_token INTEGER NOT NULL DEFAULT 0;
+
+-- Per-lock counters kept apart from the lock rows so the fencing tokens keep
+-- increasing after expired locks are cleaned up
+CREATE TABLE IF NOT EXISTS lock_stats (
+    lock_id TEXT PRIMARY KEY,
+    last_fencing_token INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    contended INTEGER NOT NULL DEFAULT 0,
+    acquisitions INTEGER NOT NULL DEFAULT 0,
+    total_wait_ms INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,15 @@
+-- Migration: Alerts opened when a sampled metric crosses a configured
+-- threshold, kept after they are resolved
+
+CREATE TABLE IF NOT EXISTS metrics_alerts (
+    id TEXT PRIMARY KEY,
+    metric_name TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    value REAL NOT NULL,
+    threshold REAL NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_alerts_created_at ON metrics_alerts(created_at);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS repetition_schedule;
+DROP TABLE IF EXISTS performance_patterns;
+DROP TABLE IF EXISTS learning_profile;
+DROP TABLE IF EXISTS mastery;
+DROP TABLE IF EXISTS mistakes;
+DROP TABLE IF EXISTS question_history;
+DROP TABLE IF EXISTS results;
+ALTER TABLE users DROP COLUMN last_active;
@@ -1,13 +1,7 @@
--- TARGET: math
--- Migration: Create initial Math app schema with 8 core tables
+-- Migration: Create initial Math app schema with 7 core tables
+-- The shared users table is owned by the core target; math tracks activity on it
 
--- Users table (shared with other apps)
-CREATE TABLE IF NOT EXISTS users (
-  id INTEGER PRIMARY KEY AUTOINCREMENT,
-  username TEXT UNIQUE NOT NULL,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  last_active TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
+ALTER TABLE users ADD COLUMN last_active TIMESTAMP;
 
 -- Practice session results
 CREATE TABLE IF NOT EXISTS results (
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS comprehension_tests;
+DROP TABLE IF EXISTS reading_sessions;
+DROP TABLE IF EXISTS books;
@@ -0,0 +1,49 @@
+-- Migration: Create reading app library, sessions and comprehension tests
+
+CREATE TABLE IF NOT EXISTS books (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    author TEXT,
+    content TEXT,
+    reading_level TEXT,
+    language TEXT DEFAULT 'english',
+    word_count INTEGER,
+    estimated_time_minutes REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reading_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    start_time DATETIME,
+    end_time DATETIME,
+    wpm REAL,
+    accuracy REAL,
+    comprehension REAL,
+    duration REAL,
+    words_read INTEGER,
+    error_count INTEGER,
+    completed BOOLEAN DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+CREATE TABLE IF NOT EXISTS comprehension_tests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL,
+    question TEXT,
+    user_answer TEXT,
+    correct_answer TEXT,
+    is_correct BOOLEAN,
+    score REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES reading_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_sessions_user_id ON reading_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_reading_sessions_book_id ON reading_sessions(book_id);
+CREATE INDEX IF NOT EXISTS idx_comprehension_tests_session_id ON comprehension_tests(session_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_books_org_id;
+ALTER TABLE books DROP COLUMN org_id;
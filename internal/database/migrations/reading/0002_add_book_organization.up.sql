@@ -0,0 +1,4 @@
+-- Migration: Books belong to an organization, or are shared when org_id is NULL
+
+ALTER TABLE books ADD COLUMN org_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_books_org_id ON books(org_id);
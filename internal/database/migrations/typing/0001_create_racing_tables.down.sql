@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_racing_stats;
+DROP TABLE IF EXISTS races;
@@ -1,4 +1,3 @@
--- TARGET: typing
 -- Migration: Create typing racing tables for Phase 5 migration
 -- Description: Creates races and user_racing_stats tables for racing mode support
 
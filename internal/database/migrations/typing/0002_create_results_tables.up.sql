@@ -0,0 +1,31 @@
+-- Migration: Create typing test results and per-user aggregate stats
+
+CREATE TABLE IF NOT EXISTS typing_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    wpm REAL,
+    raw_wpm REAL,
+    accuracy REAL,
+    errors INTEGER,
+    time_taken REAL,
+    test_mode TEXT,
+    test_duration INTEGER,
+    text_snippet TEXT,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_stats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER UNIQUE,
+    total_tests INTEGER DEFAULT 0,
+    average_wpm REAL DEFAULT 0,
+    average_accuracy REAL DEFAULT 0,
+    best_wpm INTEGER DEFAULT 0,
+    total_time_typed INTEGER DEFAULT 0,
+    last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_typing_results_user_id ON typing_results(user_id);
+CREATE INDEX IF NOT EXISTS idx_typing_results_timestamp ON typing_results(timestamp);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_typing_texts_category;
+DROP TABLE IF EXISTS typing_texts;
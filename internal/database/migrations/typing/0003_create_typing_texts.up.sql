@@ -0,0 +1,11 @@
+-- Migration: Imported typing texts, offered alongside the built-in samples
+
+CREATE TABLE IF NOT EXISTS typing_texts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (category, content)
+);
+
+CREATE INDEX IF NOT EXISTS idx_typing_texts_category ON typing_texts(category);
@@ -0,0 +1,17 @@
+CREATE TABLE typing_texts_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (category, content)
+);
+
+INSERT OR IGNORE INTO typing_texts_old (id, category, content, created_at)
+SELECT id, category, content, created_at FROM typing_texts ORDER BY id;
+
+DROP INDEX IF EXISTS idx_typing_texts_unique;
+DROP INDEX IF EXISTS idx_typing_texts_category;
+DROP TABLE typing_texts;
+ALTER TABLE typing_texts_old RENAME TO typing_texts;
+
+CREATE INDEX IF NOT EXISTS idx_typing_texts_category ON typing_texts(category);
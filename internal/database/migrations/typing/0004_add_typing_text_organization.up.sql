@@ -0,0 +1,21 @@
+-- Migration: Typing texts belong to an organization, or are shared when
+-- org_id is NULL. The table is rebuilt so the same text can be imported by
+-- several organizations.
+
+CREATE TABLE typing_texts_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER,
+    category TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO typing_texts_new (id, category, content, created_at)
+SELECT id, category, content, created_at FROM typing_texts;
+
+DROP INDEX IF EXISTS idx_typing_texts_category;
+DROP TABLE typing_texts;
+ALTER TABLE typing_texts_new RENAME TO typing_texts;
+
+CREATE INDEX IF NOT EXISTS idx_typing_texts_category ON typing_texts(category);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_typing_texts_unique ON typing_texts(IFNULL(org_id, 0), category, content);
@@ -4,19 +4,31 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+// logger is the database package logger
+var logger = logging.Logger("database")
+
 // Pool represents a database connection pool
 type Pool struct {
 	*sql.DB
 }
 
+// Conn runs statements on the pool or within a transaction, so that a
+// repository can take part in its caller's transaction
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // InitPool initializes a new SQLite database connection pool with WAL mode
 func InitPool(databaseURL string) (*Pool, error) {
 	// Ensure directory exists
@@ -53,18 +65,18 @@ func InitPool(databaseURL string) (*Pool, error) {
 
 	for _, pragma := range pragmas {
 		if _, err := db.Exec(pragma); err != nil {
-			log.Printf("Warning: failed to execute %s: %v", pragma, err)
+			logger.Warn("failed to execute pragma", "pragma", pragma, "error", err)
 		}
 	}
 
-	log.Println("Database connection pool initialized with WAL mode")
+	logger.Info("database connection pool initialized", "journal_mode", "wal")
 
 	return &Pool{DB: db}, nil
 }
 
 // Close closes the database connection pool
 func (p *Pool) Close() error {
-	log.Println("Closing database connection pool")
+	logger.Info("closing database connection pool")
 	return p.DB.Close()
 }
 
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errDiskUsageUnsupported is returned by diskUsage where statfs is missing
+var errDiskUsageUnsupported = errors.New("disk usage is not supported on this platform")
+
+// DiskSpace checks the filesystem holding dir, which must exist. It fails
+// below minFree bytes and warns when less than warnPercent of the filesystem
+// is free.
+func DiskSpace(dir string, minFree uint64, warnPercent float64) Check {
+	return func(ctx context.Context, report *Report) error {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("storage directory unavailable: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("storage directory unavailable: %s is not a directory", dir)
+		}
+
+		free, total, err := diskUsage(dir)
+		if errors.Is(err, errDiskUsageUnsupported) {
+			report.Warn(err.Error())
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat filesystem: %w", err)
+		}
+
+		report.Detail("free_bytes", fmt.Sprint(free))
+		report.Detail("total_bytes", fmt.Sprint(total))
+
+		if free < minFree {
+			return fmt.Errorf("%s free in %s, need at least %s", formatBytes(free), dir, formatBytes(minFree))
+		}
+		if total > 0 && float64(free)/float64(total)*100 < warnPercent {
+			report.Warn(fmt.Sprintf("only %s free in %s (%.1f%%)", formatBytes(free), dir, float64(free)/float64(total)*100))
+		}
+		return nil
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package health
+
+// diskUsage is not implemented on this platform
+func diskUsage(dir string) (free, total uint64, err error) {
+	return 0, 0, errDiskUsageUnsupported
+}
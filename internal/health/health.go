@@ -0,0 +1,177 @@
+// Package health runs liveness and readiness probes and reports them in the
+// models.HealthCheckResult shape.
+//
+// Liveness only says the process is serving HTTP. Readiness runs every
+// registered dependency check and answers 503 when any of them fails, so an
+// orchestrator stops routing traffic to a broken instance.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+)
+
+// DefaultTimeout bounds a whole readiness run
+const DefaultTimeout = 5 * time.Second
+
+// Report is filled in by a check. A check fails by returning an error;
+// warnings and details are reported without failing readiness.
+type Report struct {
+	mu       sync.Mutex
+	warnings []string
+	details  map[string]string
+}
+
+// Warn records a problem that does not make the instance unready
+func (r *Report) Warn(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warnings = append(r.warnings, message)
+}
+
+// Detail records an informational value, such as free disk space
+func (r *Report) Detail(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.details == nil {
+		r.details = make(map[string]string)
+	}
+	r.details[key] = value
+}
+
+// Check probes one dependency
+type Check func(ctx context.Context, report *Report) error
+
+// Checker holds the named readiness checks
+type Checker struct {
+	mu      sync.RWMutex
+	checks  map[string]Check
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewChecker creates a checker with no checks
+func NewChecker() *Checker {
+	return &Checker{
+		checks:  make(map[string]Check),
+		timeout: DefaultTimeout,
+		now:     time.Now,
+	}
+}
+
+// Add registers a readiness check, replacing any check with the same name
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Run executes every check concurrently and collects the results. Warnings
+// and errors are prefixed with the check name and sorted for stable output.
+func (c *Checker) Run(ctx context.Context) *models.HealthCheckResult {
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type outcome struct {
+		name   string
+		report *Report
+		err    error
+	}
+	outcomes := make(chan outcome, len(checks))
+	for name, check := range checks {
+		go func(name string, check Check) {
+			report := &Report{}
+			outcomes <- outcome{name: name, report: report, err: runCheck(ctx, check, report)}
+		}(name, check)
+	}
+
+	result := &models.HealthCheckResult{
+		Healthy:   true,
+		Timestamp: c.now().UTC(),
+		Checks:    make(map[string]bool, len(checks)),
+		Warnings:  []string{},
+		Errors:    []string{},
+	}
+	for range checks {
+		o := <-outcomes
+		result.Checks[o.name] = o.err == nil
+		if o.err != nil {
+			result.Healthy = false
+			result.Errors = append(result.Errors, o.name+": "+o.err.Error())
+		}
+
+		o.report.mu.Lock()
+		for _, w := range o.report.warnings {
+			result.Warnings = append(result.Warnings, o.name+": "+w)
+		}
+		for k, v := range o.report.details {
+			if result.Details == nil {
+				result.Details = make(map[string]string)
+			}
+			result.Details[o.name+"."+k] = v
+		}
+		o.report.mu.Unlock()
+	}
+	sort.Strings(result.Errors)
+	sort.Strings(result.Warnings)
+
+	return result
+}
+
+// runCheck runs a check, failing it when the deadline passes first
+func runCheck(ctx context.Context, check Check, report *Report) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx, report) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReadyHandler serves the readiness result, with 503 when any check fails
+func (c *Checker) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := c.Run(r.Context())
+		status := http.StatusOK
+		if !result.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+		respond(w, status, result)
+	}
+}
+
+// LiveHandler reports that the process is up without probing dependencies
+func (c *Checker) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, &models.HealthCheckResult{
+			Healthy:   true,
+			Timestamp: c.now().UTC(),
+			Checks:    map[string]bool{},
+			Warnings:  []string{},
+			Errors:    []string{},
+		})
+	}
+}
+
+func respond(w http.ResponseWriter, status int, result *models.HealthCheckResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
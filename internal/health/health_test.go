@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+)
+
+func TestReadyHandler(t *testing.T) {
+	checker := NewChecker()
+	checker.Add("database", func(ctx context.Context, report *Report) error {
+		report.Detail("driver", "sqlite3")
+		return nil
+	})
+	checker.Add("disk", func(ctx context.Context, report *Report) error {
+		report.Warn("running low")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	checker.ReadyHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var result models.HealthCheckResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !result.Healthy || !result.Checks["database"] || !result.Checks["disk"] {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0] != "disk: running low" {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+	if result.Details["database.driver"] != "sqlite3" {
+		t.Errorf("details = %v", result.Details)
+	}
+
+	// A failing check makes the instance unready
+	checker.Add("event_bus", func(ctx context.Context, report *Report) error {
+		return errors.New("not running")
+	})
+	w = httptest.NewRecorder()
+	checker.ReadyHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	result = models.HealthCheckResult{}
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.Healthy || result.Checks["event_bus"] || len(result.Errors) != 1 || result.Errors[0] != "event_bus: not running" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRunTimesOutSlowChecks(t *testing.T) {
+	checker := NewChecker()
+	checker.timeout = 20 * time.Millisecond
+	checker.Add("stuck", func(ctx context.Context, report *Report) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	result := checker.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() took %v, want it bounded by the timeout", elapsed)
+	}
+	if result.Healthy || result.Checks["stuck"] {
+		t.Errorf("result = %+v, want stuck check failed", result)
+	}
+}
+
+func TestLiveHandler(t *testing.T) {
+	checker := NewChecker()
+	checker.Add("database", func(ctx context.Context, report *Report) error {
+		return errors.New("down")
+	})
+
+	w := httptest.NewRecorder()
+	checker.LiveHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 regardless of dependencies", w.Code)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	report := &Report{}
+	if err := DiskSpace(dir, 0, 0)(context.Background(), report); err != nil {
+		t.Fatalf("DiskSpace() error = %v", err)
+	}
+	if report.details["free_bytes"] == "" || report.details["total_bytes"] == "" {
+		t.Errorf("details = %v", report.details)
+	}
+
+	if err := DiskSpace(dir, 1<<62, 0)(context.Background(), &Report{}); err == nil {
+		t.Error("DiskSpace() with an impossible minimum succeeded")
+	}
+
+	report = &Report{}
+	DiskSpace(dir, 0, 101)(context.Background(), report)
+	if len(report.warnings) != 1 {
+		t.Errorf("warnings = %v, want a low space warning", report.warnings)
+	}
+
+	// The probe never creates the directory
+	missing := filepath.Join(dir, "missing")
+	if err := DiskSpace(missing, 0, 0)(context.Background(), &Report{}); err == nil {
+		t.Error("DiskSpace() of a missing directory succeeded")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("DiskSpace() created %s", missing)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:       "512 B",
+		1536:      "1.5 KiB",
+		100 << 20: "100.0 MiB",
+		3 << 30:   "3.0 GiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
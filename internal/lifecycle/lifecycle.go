@@ -0,0 +1,165 @@
+// Package lifecycle starts the server's long-running components in the order
+// they were registered and stops them in reverse order on shutdown, so that
+// the HTTP server stops taking requests before the queues it feeds are
+// drained.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// logger is the lifecycle package logger
+var logger = logging.Logger("lifecycle")
+
+// Hooks start and stop a component. Either may be nil.
+type Hooks struct {
+	// Start launches the component's background work
+	Start func(ctx context.Context) error
+	// Stop shuts the component down within ctx's deadline and returns how
+	// many queued items it had to drop
+	Stop func(ctx context.Context) (dropped int, err error)
+}
+
+// Closer adapts a Close method without a result to a Stop hook
+func Closer(close func()) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		close()
+		return 0, nil
+	}
+}
+
+// ErrCloser adapts a Close method returning an error to a Stop hook
+func ErrCloser(close func() error) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		return 0, close()
+	}
+}
+
+// Result is the outcome of stopping one component
+type Result struct {
+	Name     string
+	Dropped  int
+	Err      error
+	Duration time.Duration
+}
+
+// Report lists the components stopped, in the order they were stopped
+type Report []Result
+
+// Dropped returns the number of queued items dropped by every component
+func (r Report) Dropped() int {
+	n := 0
+	for _, result := range r {
+		n += result.Dropped
+	}
+	return n
+}
+
+// Err joins the errors of the components that failed to stop
+func (r Report) Err() error {
+	var errs []error
+	for _, result := range r {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type component struct {
+	name  string
+	hooks Hooks
+}
+
+// Manager runs the registered components
+type Manager struct {
+	mu         sync.Mutex
+	components []component
+	started    int
+	stopped    bool
+}
+
+// New creates a manager with no components
+func New() *Manager {
+	return &Manager{}
+}
+
+// Register adds a component. Components are started in registration order
+// and stopped in reverse.
+func (m *Manager) Register(name string, hooks Hooks) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, hooks: hooks})
+}
+
+// Start starts every registered component that has not been started yet. If
+// one fails, the components it started are stopped and the error returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return errors.New("lifecycle: manager already stopped")
+	}
+
+	for m.started < len(m.components) {
+		c := m.components[m.started]
+		if c.hooks.Start != nil {
+			if err := c.hooks.Start(ctx); err != nil {
+				m.stopLocked(ctx)
+				return fmt.Errorf("failed to start %s: %w", c.name, err)
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Components left when
+// ctx expires are still stopped, with the expired context, so they drop
+// their queues rather than block. Stop only runs once; later calls return an
+// empty report.
+func (m *Manager) Stop(ctx context.Context) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return nil
+	}
+	return m.stopLocked(ctx)
+}
+
+// stopLocked stops the started components; m.mu must be held
+func (m *Manager) stopLocked(ctx context.Context) Report {
+	m.stopped = true
+
+	report := make(Report, 0, m.started)
+	for i := m.started - 1; i >= 0; i-- {
+		c := m.components[i]
+		if c.hooks.Stop == nil {
+			continue
+		}
+
+		start := time.Now()
+		dropped, err := c.hooks.Stop(ctx)
+		result := Result{Name: c.name, Dropped: dropped, Err: err, Duration: time.Since(start)}
+		report = append(report, result)
+
+		switch {
+		case err != nil:
+			logger.Error("component failed to stop", "component", c.name, "dropped", dropped, "error", err)
+		case dropped > 0:
+			logger.Warn("component stopped", "component", c.name, "dropped", dropped, "duration", result.Duration)
+		default:
+			logger.Info("component stopped", "component", c.name, "duration", result.Duration)
+		}
+	}
+	m.started = 0
+	return report
+}
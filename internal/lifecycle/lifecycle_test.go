@@ -0,0 +1,96 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recorder registers components that append their start and stop calls
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) hooks(name string, startErr error, dropped int) Hooks {
+	return Hooks{
+		Start: func(ctx context.Context) error {
+			r.calls = append(r.calls, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) (int, error) {
+			r.calls = append(r.calls, "stop "+name)
+			return dropped, nil
+		},
+	}
+}
+
+func TestManagerOrder(t *testing.T) {
+	var rec recorder
+	m := New()
+	m.Register("hub", rec.hooks("hub", nil, 2))
+	m.Register("bus", rec.hooks("bus", nil, 3))
+	m.Register("http", rec.hooks("http", nil, 0))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	report := m.Stop(context.Background())
+
+	expected := []string{"start hub", "start bus", "start http", "stop http", "stop bus", "stop hub"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, rec.calls)
+	}
+	if report.Dropped() != 5 {
+		t.Errorf("Expected 5 dropped, got %d", report.Dropped())
+	}
+	if report.Err() != nil {
+		t.Errorf("Expected no error, got %v", report.Err())
+	}
+
+	if again := m.Stop(context.Background()); len(again) != 0 {
+		t.Errorf("Expected a second Stop to do nothing, got %v", again)
+	}
+	if err := m.Start(context.Background()); err == nil {
+		t.Error("Expected Start after Stop to fail")
+	}
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	var rec recorder
+	m := New()
+	m.Register("hub", rec.hooks("hub", nil, 0))
+	m.Register("bus", rec.hooks("bus", errors.New("boom"), 0))
+	m.Register("http", rec.hooks("http", nil, 0))
+
+	if err := m.Start(context.Background()); err == nil {
+		t.Fatal("Expected Start to fail")
+	}
+
+	expected := []string{"start hub", "start bus", "stop hub"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, rec.calls)
+	}
+}
+
+func TestManagerStopErrors(t *testing.T) {
+	m := New()
+	m.Register("sessions", Hooks{Stop: Closer(func() {})})
+	m.Register("limits", Hooks{Stop: ErrCloser(func() error { return errors.New("disk full") })})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// An expired context still stops every component
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := m.Stop(ctx)
+
+	if len(report) != 2 || report[0].Name != "limits" || report[1].Name != "sessions" {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if err := report.Err(); err == nil || err.Error() != "limits: disk full" {
+		t.Errorf("Expected the limits error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type contextKey struct{}
+
+// RequestInfo holds the request fields attached to every record logged with
+// the request's context. Fields learned later in the middleware chain, such as
+// the authenticated user, are filled in as they become known.
+type RequestInfo struct {
+	mu        sync.Mutex
+	requestID string
+	userID    int
+	app       string
+}
+
+// NewContext returns a context carrying request info for the given request ID
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &RequestInfo{requestID: requestID})
+}
+
+// SetUserID records the authenticated user for the request, if it is logged
+func SetUserID(ctx context.Context, userID int) {
+	if info, ok := ctx.Value(contextKey{}).(*RequestInfo); ok {
+		info.mu.Lock()
+		info.userID = userID
+		info.mu.Unlock()
+	}
+}
+
+// SetApp records which app is serving the request, if it is logged
+func SetApp(ctx context.Context, app string) {
+	if info, ok := ctx.Value(contextKey{}).(*RequestInfo); ok {
+		info.mu.Lock()
+		info.app = app
+		info.mu.Unlock()
+	}
+}
+
+// requestAttrs returns the request fields known for ctx
+func requestAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if info, ok := ctx.Value(contextKey{}).(*RequestInfo); ok {
+		info.mu.Lock()
+		if info.requestID != "" {
+			attrs = append(attrs, slog.String("request_id", info.requestID))
+		}
+		if info.userID > 0 {
+			attrs = append(attrs, slog.Int("user_id", info.userID))
+		}
+		if info.app != "" {
+			attrs = append(attrs, slog.String("app", info.app))
+		}
+		info.mu.Unlock()
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			attrs = append(attrs, slog.String("route", pattern))
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,160 @@
+// Package logging provides structured JSON logging on top of log/slog.
+//
+// Each package gets its own logger from Logger so its level can be tuned
+// independently, and every record logged with a request context carries the
+// request's ID, authenticated user, app and route pattern.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Options configures the root logger
+type Options struct {
+	// Level is the default minimum level: debug, info, warn or error
+	Level string
+	// Format is json or text
+	Format string
+	// Packages overrides the level for individual packages, e.g. {"database": "debug"}
+	Packages map[string]string
+}
+
+// root is the handler every package logger writes through. It is swapped
+// atomically by Setup so loggers created at init time pick up the config.
+type root struct {
+	handler  slog.Handler
+	level    slog.Level
+	packages map[string]slog.Level
+}
+
+var current atomic.Pointer[root]
+
+func init() {
+	current.Store(&root{
+		handler: slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		level:   slog.LevelInfo,
+	})
+}
+
+// Setup installs the root logger writing to w and makes it the slog default,
+// so records from the standard log package are also emitted as JSON
+func Setup(w io.Writer, opts Options) error {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+
+	packages := make(map[string]slog.Level, len(opts.Packages))
+	for pkg, value := range opts.Packages {
+		l, err := ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+		packages[pkg] = l
+	}
+
+	// Filtering happens in Enabled, so the inner handler accepts everything
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	case "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("unknown log format %q (want json or text)", opts.Format)
+	}
+
+	current.Store(&root{handler: handler, level: level, packages: packages})
+	slog.SetDefault(slog.New(&packageHandler{}))
+	return nil
+}
+
+// ParseLevel parses debug, info, warn or error; an empty string means info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// Logger returns the logger for a package. Records carry a "package"
+// attribute and are filtered by that package's configured level.
+func Logger(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg})
+}
+
+// packageHandler applies per-package levels and request context attributes,
+// then forwards to the current root handler
+type packageHandler struct {
+	pkg string
+	// ops replays WithAttrs and WithGroup calls onto the root handler
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h *packageHandler) Enabled(_ context.Context, level slog.Level) bool {
+	r := current.Load()
+	min, ok := r.packages[h.pkg]
+	if !ok {
+		min = r.level
+	}
+	return level >= min
+}
+
+func (h *packageHandler) Handle(ctx context.Context, record slog.Record) error {
+	var attrs []slog.Attr
+	if h.pkg != "" {
+		attrs = append(attrs, slog.String("package", h.pkg))
+	}
+	attrs = append(attrs, requestAttrs(ctx)...)
+
+	handler := current.Load().handler
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
+	}
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h *packageHandler) with(op func(slog.Handler) slog.Handler) *packageHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &packageHandler{pkg: h.pkg, ops: append(ops, op)}
+}
+
+// ParsePackageLevels parses "pkg=level,pkg=level" as used by LOG_LEVELS
+func ParsePackageLevels(s string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pkg, level, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(pkg) == "" {
+			return nil, fmt.Errorf("invalid package level %q (want pkg=level)", entry)
+		}
+		levels[strings.TrimSpace(pkg)] = strings.TrimSpace(level)
+	}
+	return levels, nil
+}
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// captureLogs installs a JSON root logger writing to a buffer for the test
+func captureLogs(t *testing.T, opts Options) *bytes.Buffer {
+	t.Helper()
+	previous := current.Load()
+	t.Cleanup(func() { current.Store(previous) })
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, opts); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	return &buf
+}
+
+// records decodes the JSON lines written to buf
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestPackageLevels(t *testing.T) {
+	buf := captureLogs(t, Options{Level: "warn", Packages: map[string]string{"database": "debug"}})
+
+	Logger("reading").Info("hidden")
+	Logger("reading").Warn("shown")
+	Logger("database").Debug("query")
+
+	got := records(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d records, want 2: %v", len(got), got)
+	}
+	if got[0]["msg"] != "shown" || got[0]["package"] != "reading" {
+		t.Errorf("first record = %v", got[0])
+	}
+	if got[1]["msg"] != "query" || got[1]["package"] != "database" {
+		t.Errorf("second record = %v", got[1])
+	}
+}
+
+func TestLoggerCreatedBeforeSetup(t *testing.T) {
+	logger := Logger("early")
+	buf := captureLogs(t, Options{Level: "info"})
+
+	logger.With("k", "v").Info("hello")
+
+	got := records(t, buf)
+	if len(got) != 1 || got[0]["k"] != "v" || got[0]["package"] != "early" {
+		t.Errorf("records = %v", got)
+	}
+}
+
+func TestRequestAttrs(t *testing.T) {
+	buf := captureLogs(t, Options{Level: "info"})
+
+	router := chi.NewRouter()
+	router.Get("/api/users/{userId}/stats", func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), 42)
+		SetApp(r.Context(), "math")
+		Logger("math").ErrorContext(r.Context(), "failed to get stats")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/42/stats", nil)
+	req = req.WithContext(NewContext(req.Context(), "req-1"))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := records(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d records, want 1", len(got))
+	}
+	want := map[string]interface{}{
+		"request_id": "req-1",
+		"user_id":    float64(42),
+		"app":        "math",
+		"route":      "/api/users/{userId}/stats",
+		"level":      "ERROR",
+	}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Errorf("%s = %v, want %v", k, got[0][k], v)
+		}
+	}
+}
+
+func TestSetupRejectsInvalidOptions(t *testing.T) {
+	previous := current.Load()
+	t.Cleanup(func() { current.Store(previous) })
+
+	if err := Setup(&bytes.Buffer{}, Options{Level: "loud"}); err == nil {
+		t.Error("Setup() with invalid level succeeded")
+	}
+	if err := Setup(&bytes.Buffer{}, Options{Packages: map[string]string{"db": "x"}}); err == nil {
+		t.Error("Setup() with invalid package level succeeded")
+	}
+	if err := Setup(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("Setup() with invalid format succeeded")
+	}
+
+	// Without a request context nothing is added
+	if attrs := requestAttrs(context.Background()); len(attrs) != 0 {
+		t.Errorf("requestAttrs(background) = %v", attrs)
+	}
+}
+
+func TestParsePackageLevels(t *testing.T) {
+	levels, err := ParsePackageLevels(" database=debug, http=warn ,")
+	if err != nil {
+		t.Fatalf("ParsePackageLevels() error = %v", err)
+	}
+	if len(levels) != 2 || levels["database"] != "debug" || levels["http"] != "warn" {
+		t.Errorf("levels = %v", levels)
+	}
+
+	if _, err := ParsePackageLevels("database"); err == nil {
+		t.Error("ParsePackageLevels() without = succeeded")
+	}
+}
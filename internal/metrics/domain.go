@@ -0,0 +1,13 @@
+package metrics
+
+// Domain counters shared by the apps
+var (
+	// SessionsSaved counts practice sessions persisted, labelled by app
+	SessionsSaved = NewCounterVec("unified_sessions_saved_total",
+		"Practice sessions saved, by app.", "app")
+
+	// Logins counts login attempts by result: success, invalid_credentials,
+	// locked, disabled or error
+	Logins = NewCounterVec("unified_auth_logins_total",
+		"Login attempts, by result.", "result")
+)
@@ -0,0 +1,192 @@
+// Package metrics is a small in-process metrics registry that serves the
+// Prometheus text exposition format. It supports labelled counters, gauges
+// and histograms, plus collectors that read existing stats at scrape time.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is the metric family type written in the # TYPE line
+type Type string
+
+// Metric family types
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+)
+
+// Label is a single label name and value
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one exposition line. Suffix is appended to the family name, as
+// with the _bucket, _sum and _count series of a histogram.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named group of samples of one type
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+// Collector produces metric families at scrape time
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc adapts a function to the Collector interface
+type CollectorFunc func() []Family
+
+// Collect calls f
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Counter returns a single-sample counter family, for collectors that
+// expose totals kept elsewhere
+func Counter(name, help string, value float64, labels ...Label) Family {
+	return Family{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Labels: labels, Value: value}}}
+}
+
+// Gauge returns a single-sample gauge family
+func Gauge(name, help string, value float64, labels ...Label) Family {
+	return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Labels: labels, Value: value}}}
+}
+
+// Registry holds the collectors exposed by a metrics endpoint
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry the package-level constructors register with
+var Default = NewRegistry()
+
+// MustRegister adds collectors to the registry
+func (r *Registry) MustRegister(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Gather collects every family, merging families that share a name and
+// sorting them by name
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	byName := make(map[string]*Family)
+	var names []string
+	for _, c := range collectors {
+		for _, f := range c.Collect() {
+			if existing, ok := byName[f.Name]; ok {
+				existing.Samples = append(existing.Samples, f.Samples...)
+				continue
+			}
+			family := f
+			byName[f.Name] = &family
+			names = append(names, f.Name)
+		}
+	}
+
+	sort.Strings(names)
+	families := make([]Family, 0, len(names))
+	for _, name := range names {
+		families = append(families, *byName[name])
+	}
+	return families
+}
+
+// WriteText writes every family in the Prometheus text exposition format
+func (r *Registry) WriteText(w *bufio.Writer) error {
+	for _, f := range r.Gather() {
+		if f.Help != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			w.WriteString(f.Name)
+			w.WriteString(s.Suffix)
+			writeLabels(w, s.Labels)
+			w.WriteByte(' ')
+			w.WriteString(formatValue(s.Value))
+			w.WriteByte('\n')
+		}
+	}
+	return w.Flush()
+}
+
+// Handler serves the registry in the text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(bufio.NewWriter(w))
+	})
+}
+
+// writeLabels writes {name="value",...} when there are labels
+func writeLabels(w *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(l.Name)
+		w.WriteString(`="`)
+		w.WriteString(escapeLabelValue(l.Value))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatValue renders a sample value, spelling infinities the Prometheus way
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
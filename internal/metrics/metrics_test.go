@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests served.", "route", "status")
+	requests.Inc("/users/{id}", "200")
+	requests.Add(2, "/users/{id}", "200")
+	requests.Inc(`/a"b\c`, "500")
+	inFlight := r.NewGaugeVec("test_in_flight", "Line one\nline two.")
+	inFlight.Set(3)
+	r.MustRegister(CollectorFunc(func() []Family {
+		return []Family{Gauge("test_ratio", "", math.Inf(1))}
+	}))
+
+	var b strings.Builder
+	if err := r.WriteText(bufio.NewWriter(&b)); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	want := `# HELP test_in_flight Line one\nline two.
+# TYPE test_in_flight gauge
+test_in_flight 3
+# TYPE test_ratio gauge
+test_ratio +Inf
+# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"b\\c",status="500"} 1
+test_requests_total{route="/users/{id}",status="200"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogramVec("test_duration_seconds", "Latency.", []float64{1, 0.1}, "route")
+	latency.Observe(0.05, "/")
+	latency.Observe(0.5, "/")
+	latency.Observe(7, "/")
+
+	var b strings.Builder
+	r.WriteText(bufio.NewWriter(&b))
+
+	for _, line := range []string{
+		`test_duration_seconds_bucket{route="/",le="0.1"} 1`,
+		`test_duration_seconds_bucket{route="/",le="1"} 2`,
+		`test_duration_seconds_bucket{route="/",le="+Inf"} 3`,
+		`test_duration_seconds_sum{route="/"} 7.55`,
+		`test_duration_seconds_count{route="/"} 3`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("output missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestGatherMergesFamilies(t *testing.T) {
+	r := NewRegistry()
+	for _, queue := range []string{"a", "b"} {
+		queue := queue
+		r.MustRegister(CollectorFunc(func() []Family {
+			return []Family{Gauge("test_queue_length", "Queue length.", 1, Label{Name: "queue", Value: queue})}
+		}))
+	}
+
+	families := r.Gather()
+	if len(families) != 1 || len(families[0].Samples) != 2 {
+		t.Fatalf("Gather() = %+v, want one family with two samples", families)
+	}
+}
+
+func TestCounterRejectsNegativeAndWrongLabels(t *testing.T) {
+	c := NewRegistry().NewCounterVec("test_total", "", "app")
+
+	for name, fn := range map[string]func(){
+		"negative":     func() { c.Add(-1, "math") },
+		"wrong labels": func() { c.Inc() },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Total.").Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "test_total 1\n") {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
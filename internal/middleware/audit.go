@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// AuditRecorder stores completed audit entries
+type AuditRecorder interface {
+	Record(ctx context.Context, e *audit.Entry) error
+}
+
+// Audit records every request with a method other than GET, HEAD or OPTIONS
+// in the audit log, with the caller, status, IP and request ID. Handlers name
+// the action and its target with audit.Describe; otherwise the action is the
+// method and route pattern. It must run after the session, bearer and
+// organization middleware so the caller and organization are known.
+func Audit(recorder AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := audit.Entry{
+				Source:    audit.SourceHTTP,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				IP:        clientIP(r),
+				RequestID: chimiddleware.GetReqID(r.Context()),
+			}
+			if callerID, ok := CallerID(r); ok {
+				entry.ActorID = callerID
+			}
+			if org, ok := tenant.FromContext(r.Context()); ok {
+				entry.OrgID = org.ID
+			}
+			ctx := audit.NewContext(r.Context(), entry)
+
+			wrapped := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			// Recorded on the way out, including when a handler panics
+			completed := false
+			defer func() {
+				e, _ := audit.FromContext(ctx)
+				e.Status = wrapped.statusCode
+				if !completed {
+					e.Status = http.StatusInternalServerError
+				}
+				if e.Action == "" {
+					e.Action = r.Method + " " + routePattern(r)
+				}
+				if err := recorder.Record(context.WithoutCancel(ctx), &e); err != nil {
+					logger.ErrorContext(ctx, "failed to record audit entry", "action", e.Action, "error", err)
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+			completed = true
+		})
+	}
+}
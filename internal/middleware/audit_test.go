@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+)
+
+// memoryRecorder keeps recorded entries in memory
+type memoryRecorder struct {
+	entries []audit.Entry
+}
+
+func (m *memoryRecorder) Record(ctx context.Context, e *audit.Entry) error {
+	m.entries = append(m.entries, *e)
+	return nil
+}
+
+func newAuditTestRouter(recorder AuditRecorder) chi.Router {
+	r := chi.NewRouter()
+	r.Use(chimiddleware.Recoverer)
+	r.Use(Audit(recorder))
+	r.Get("/books", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Post("/books", func(w http.ResponseWriter, r *http.Request) {
+		audit.Describe(r.Context(), "book.create", "book", 7)
+		audit.RecordChange(r.Context(), nil, map[string]string{"title": "Dune"})
+		w.WriteHeader(http.StatusCreated)
+	})
+	r.Delete("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.Put("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	return r
+}
+
+func TestAuditRecordsMutatingRequests(t *testing.T) {
+	recorder := &memoryRecorder{}
+	router := newAuditTestRouter(recorder)
+
+	serve := func(method, path string, caller int) {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if caller != 0 {
+			req = req.WithContext(WithCaller(req.Context(), caller))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(http.MethodGet, "/books", 1)
+	if len(recorder.entries) != 0 {
+		t.Fatalf("Expected GET to be skipped, got %+v", recorder.entries)
+	}
+
+	serve(http.MethodPost, "/books", 1)
+	serve(http.MethodDelete, "/books/3", 0)
+	serve(http.MethodPut, "/books/3", 2)
+	if len(recorder.entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(recorder.entries))
+	}
+
+	created := recorder.entries[0]
+	if created.Action != "book.create" || created.TargetType != "book" || created.TargetID != "7" {
+		t.Errorf("Expected the handler's description, got %+v", created)
+	}
+	if created.ActorID != 1 || created.Status != http.StatusCreated || created.IP != "192.0.2.1" || created.Source != audit.SourceHTTP {
+		t.Errorf("Unexpected request details: %+v", created)
+	}
+	if string(created.Changes) != `{"title":{"before":null,"after":"Dune"}}` {
+		t.Errorf("Unexpected changes: %s", created.Changes)
+	}
+
+	deleted := recorder.entries[1]
+	if deleted.Action != "DELETE /books/{id}" || deleted.ActorID != 0 || deleted.Path != "/books/3" {
+		t.Errorf("Expected the route pattern as the default action, got %+v", deleted)
+	}
+
+	if panicked := recorder.entries[2]; panicked.Status != http.StatusInternalServerError || panicked.ActorID != 2 {
+		t.Errorf("Expected a panicking handler to be recorded as a 500, got %+v", panicked)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gorilla/sessions"
+
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
 // SessionKey is the context key for session data
@@ -12,34 +14,60 @@ type contextKey string
 
 const SessionContextKey contextKey = "session"
 
+// sessionRefresher is a session store whose expiry slides forward as
+// sessions are used, and which sends the cookie again when it does
+type sessionRefresher interface {
+	Refresh(r *http.Request, w http.ResponseWriter, session *sessions.Session) error
+}
+
 // AuthMiddleware handles session validation
 type AuthMiddleware struct {
-	store *sessions.CookieStore
+	store       sessions.Store
+	sessionName string
 }
 
-// NewAuthMiddleware creates a new auth middleware
+// NewAuthMiddleware creates a new auth middleware backed by a cookie store
 func NewAuthMiddleware(sessionSecret, sessionName string) *AuthMiddleware {
 	store := sessions.NewCookieStore([]byte(sessionSecret))
 	store.Options = &sessions.Options{
 		Path:     "/",
 		MaxAge:   86400 * 7, // 7 days
 		HttpOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
+		Secure:   false,
 		SameSite: http.SameSiteLaxMode,
 	}
 
+	return NewAuthMiddlewareWithStore(store, sessionName)
+}
+
+// NewAuthMiddlewareWithStore creates a new auth middleware using the given session store
+func NewAuthMiddlewareWithStore(store sessions.Store, sessionName string) *AuthMiddleware {
+	if sessionName == "" {
+		sessionName = "unified_session"
+	}
+
 	return &AuthMiddleware{
-		store: store,
+		store:       store,
+		sessionName: sessionName,
 	}
 }
 
 // Handler returns the middleware handler function
 func (am *AuthMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, err := am.store.Get(r, "unified_session")
+		session, err := am.store.Get(r, am.sessionName)
 		if err != nil {
 			// Session error, create new session
-			session, _ = am.store.New(r, "unified_session")
+			session, _ = am.store.New(r, am.sessionName)
+		}
+		if refresher, ok := am.store.(sessionRefresher); ok {
+			if err := refresher.Refresh(r, w, session); err != nil {
+				logger.WarnContext(r.Context(), "failed to refresh session cookie", "error", err)
+			}
+		}
+
+		if userID, ok := session.Values["user_id"].(int); ok {
+			logging.SetUserID(r.Context(), userID)
 		}
 
 		// Add session to request context
@@ -54,7 +82,7 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		session := r.Context().Value(SessionContextKey).(*sessions.Session)
 
 		if auth, ok := session.Values["authenticated"].(bool); !ok || !auth {
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
 			return
 		}
 
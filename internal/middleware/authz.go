@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+)
+
+// callerContextKey holds a caller identity established without a cookie session
+const callerContextKey contextKey = "caller_id"
+
+// maxInspectBodySize caps how much of a body is buffered to find user_id. It
+// matches the upload limit, so JSON carrying a MIDI file can be checked.
+const maxInspectBodySize = 10 << 20
+
+// errBodyTooLarge is returned for a body too large to find user_id in
+var errBodyTooLarge = errors.New("request body too large to authorize")
+
+// maxMultipartMemory matches the in-memory limit the audio upload handlers use
+const maxMultipartMemory = 10 << 20
+
+// RelationshipChecker reports whether a caller may act on another user's data,
+// e.g. a guardian for their child or a teacher for a student in their class
+type RelationshipChecker interface {
+	CanActFor(ctx context.Context, callerID, userID int) (bool, error)
+}
+
+// AdminChecker reports whether a user is an administrator
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userID int) (bool, error)
+}
+
+// Authorizer enforces that per-user routes are only used by that user or by
+// someone with a guardian or teacher relationship to them
+type Authorizer struct {
+	relationships RelationshipChecker
+}
+
+// NewAuthorizer creates a new authorizer. A nil checker allows self-access only.
+func NewAuthorizer(relationships RelationshipChecker) *Authorizer {
+	return &Authorizer{relationships: relationships}
+}
+
+// WithCaller returns a context carrying an authenticated caller ID
+func WithCaller(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, callerContextKey, userID)
+}
+
+// CallerID resolves the authenticated user for a request, preferring an
+// identity set with WithCaller over the cookie session
+func CallerID(r *http.Request) (int, bool) {
+	if userID, ok := r.Context().Value(callerContextKey).(int); ok && userID > 0 {
+		return userID, true
+	}
+
+	userID, ok := GetUserID(r)
+	if !ok || userID <= 0 {
+		return 0, false
+	}
+	return userID, true
+}
+
+// RequireUser rejects requests without an authenticated caller
+func (a *Authorizer) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := CallerID(r); !ok {
+			apierror.Respond(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin rejects requests from callers who are not administrators
+func RequireAdmin(admins AdminChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			callerID, ok := CallerID(r)
+			if !ok {
+				apierror.Respond(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			allowed, err := admins.IsAdmin(r.Context(), callerID)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "admin check failed", "caller_id", callerID, "error", err)
+				apierror.Respond(w, r, http.StatusInternalServerError, "authorization check failed")
+				return
+			}
+			if !allowed {
+				apierror.Respond(w, r, http.StatusForbidden, "administrator access required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUserAccess rejects requests whose target user, taken from the
+// {userId} URL parameter, the user_id query or form field, or a user_id
+// field in any other body, read as JSON, is neither the caller nor related to the caller.
+// It must be installed with With or Group so URL parameters are resolved.
+func (a *Authorizer) RequireUserAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callerID, ok := CallerID(r)
+		if !ok {
+			apierror.Respond(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		targets, err := targetUserIDs(r)
+		if errors.Is(err, errBodyTooLarge) {
+			apierror.Respond(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			apierror.Respond(w, r, http.StatusBadRequest, "invalid user_id")
+			return
+		}
+
+		for _, targetID := range targets {
+			allowed, err := a.CanAccess(r.Context(), callerID, targetID)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "authorization check failed",
+					"caller_id", callerID, "target_user_id", targetID, "error", err)
+				apierror.Respond(w, r, http.StatusInternalServerError, "authorization check failed")
+				return
+			}
+			if !allowed {
+				apierror.Respond(w, r, http.StatusForbidden, "not allowed to access this user's data")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthorizeOwner checks that the caller may act for ownerID, the user a record
+// loaded by ID belongs to. When not, it writes the error response and returns false.
+func (a *Authorizer) AuthorizeOwner(w http.ResponseWriter, r *http.Request, ownerID int) bool {
+	callerID, ok := CallerID(r)
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "authentication required")
+		return false
+	}
+
+	allowed, err := a.CanAccess(r.Context(), callerID, ownerID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "authorization check failed",
+			"caller_id", callerID, "target_user_id", ownerID, "error", err)
+		apierror.Respond(w, r, http.StatusInternalServerError, "authorization check failed")
+		return false
+	}
+	if !allowed {
+		apierror.Respond(w, r, http.StatusForbidden, "not allowed to access this user's data")
+		return false
+	}
+	return true
+}
+
+// CanAccess reports whether callerID may act on targetID's data
+func (a *Authorizer) CanAccess(ctx context.Context, callerID, targetID int) (bool, error) {
+	if callerID == targetID {
+		return true, nil
+	}
+	if a.relationships == nil {
+		return false, nil
+	}
+	return a.relationships.CanActFor(ctx, callerID, targetID)
+}
+
+// targetUserIDs collects every user ID the request names
+func targetUserIDs(r *http.Request) ([]int, error) {
+	var ids []int
+
+	add := func(raw string) error {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return nil
+		}
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			return strconv.ErrSyntax
+		}
+		ids = append(ids, id)
+		return nil
+	}
+
+	if err := add(chi.URLParam(r, "userId")); err != nil {
+		return nil, err
+	}
+	if err := add(r.URL.Query().Get("user_id")); err != nil {
+		return nil, err
+	}
+
+	// Parsed the way net/http parses it for the handlers' FormValue, which
+	// ignores case and parameters
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxMultipartMemory); err == nil && r.MultipartForm != nil {
+			if values := r.MultipartForm.Value["user_id"]; len(values) > 0 {
+				if err := add(values[0]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err == nil {
+			if err := add(r.PostForm.Get("user_id")); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		// Handlers decode the body as JSON whatever the Content-Type says, so
+		// any other body, or one without a Content-Type, is checked as JSON
+		raw, err := peekJSONUserID(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// peekJSONUserID reads a user_id field from a JSON body and restores the body
+// so the handler can decode it again
+func peekJSONUserID(r *http.Request) (string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", nil
+	}
+
+	original := r.Body
+	buf, err := io.ReadAll(io.LimitReader(original, maxInspectBodySize+1))
+	if err != nil {
+		return "", err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), original), original}
+
+	if len(buf) > maxInspectBodySize {
+		// A body too large to check could name any user
+		return "", errBodyTooLarge
+	}
+
+	// Decoded the way the handlers decode it, which reads only the first
+	// JSON value
+	var body struct {
+		UserID json.RawMessage `json:"user_id"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(&body); err != nil || len(body.UserID) == 0 {
+		// Malformed bodies are reported by the handler itself
+		return "", nil
+	}
+
+	raw := strings.Trim(string(body.UserID), `"`)
+	if raw == "null" || raw == "0" {
+		return "", nil
+	}
+	return raw, nil
+}
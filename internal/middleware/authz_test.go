@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// staticRelationships allows callers to act for a fixed set of users
+type staticRelationships map[int][]int
+
+func (s staticRelationships) CanActFor(ctx context.Context, callerID, userID int) (bool, error) {
+	for _, id := range s[callerID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newAuthzTestRouter(authz *Authorizer) chi.Router {
+	r := chi.NewRouter()
+	r.Group(func(api chi.Router) {
+		api.Use(authz.RequireUserAccess)
+		api.Get("/users/{userId}/stats", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		api.Post("/sessions", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+	return r
+}
+
+func TestRequireUserAccess(t *testing.T) {
+	// Caller 10 is guardian of user 20
+	router := newAuthzTestRouter(NewAuthorizer(staticRelationships{10: {20}}))
+
+	tests := []struct {
+		name     string
+		caller   int
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"anonymous", 0, "GET", "/users/1/stats", "", http.StatusUnauthorized},
+		{"self", 1, "GET", "/users/1/stats", "", http.StatusOK},
+		{"other user", 1, "GET", "/users/2/stats", "", http.StatusForbidden},
+		{"guardian", 10, "GET", "/users/20/stats", "", http.StatusOK},
+		{"guardian of someone else", 10, "GET", "/users/21/stats", "", http.StatusForbidden},
+		{"query mismatch", 1, "GET", "/users/1/stats?user_id=2", "", http.StatusForbidden},
+		{"invalid id", 1, "GET", "/users/abc/stats", "", http.StatusBadRequest},
+		{"json body self", 1, "POST", "/sessions", `{"user_id": 1}`, http.StatusCreated},
+		{"json body other", 1, "POST", "/sessions", `{"user_id": 2}`, http.StatusForbidden},
+		{"json body without user", 1, "POST", "/sessions", `{"book_id": 3}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.caller != 0 {
+				req = req.WithContext(WithCaller(req.Context(), tt.caller))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireUserAccessPreservesBody(t *testing.T) {
+	authz := NewAuthorizer(nil)
+
+	var received string
+	handler := authz.RequireUserAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+	}))
+
+	body := `{"user_id": 5, "score": 42}`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(WithCaller(req.Context(), 5))
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if received != body {
+		t.Errorf("Expected handler to receive original body, got %q", received)
+	}
+}
+
+func TestRequireUserAccessChecksAnyBody(t *testing.T) {
+	router := newAuthzTestRouter(NewAuthorizer(nil))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    int
+	}{
+		{"no content type", "", `{"user_id": 2}`, http.StatusForbidden},
+		{"text content type", "text/plain", `{"user_id": 2}`, http.StatusForbidden},
+		{"trailing data", "application/json", `{"user_id": 2} {`, http.StatusForbidden},
+		{"no content type self", "", `{"user_id": 1}`, http.StatusCreated},
+		{"too large to check", "", `{"user_id": 1, "pad": "` + strings.Repeat("x", maxInspectBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+		{"mixed case multipart", "Multipart/Form-Data; boundary=XYZ", multipartUserID("2"), http.StatusForbidden},
+		{"mixed case multipart self", "Multipart/Form-Data; boundary=XYZ", multipartUserID("1"), http.StatusCreated},
+		{"mixed case form", "Application/X-WWW-Form-Urlencoded", "user_id=2", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/sessions", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req = req.WithContext(WithCaller(req.Context(), 1))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// multipartUserID builds a multipart body with boundary XYZ and a user_id field
+func multipartUserID(id string) string {
+	return "--XYZ\r\nContent-Disposition: form-data; name=\"user_id\"\r\n\r\n" + id + "\r\n--XYZ--\r\n"
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	// Caller 10 is guardian of user 20
+	authz := NewAuthorizer(staticRelationships{10: {20}})
+
+	tests := []struct {
+		name     string
+		caller   int
+		owner    int
+		expected int
+	}{
+		{"anonymous", 0, 1, http.StatusUnauthorized},
+		{"owner", 1, 1, http.StatusOK},
+		{"other user", 2, 1, http.StatusForbidden},
+		{"guardian", 10, 20, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/sessions/7", nil)
+			if tt.caller != 0 {
+				req = req.WithContext(WithCaller(req.Context(), tt.caller))
+			}
+
+			w := httptest.NewRecorder()
+			if authz.AuthorizeOwner(w, req, tt.owner) {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// staticAdmins treats a fixed set of users as administrators
+type staticAdmins map[int]bool
+
+func (s staticAdmins) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	return s[userID], nil
+}
+
+func TestRequireAdmin(t *testing.T) {
+	handler := RequireAdmin(staticAdmins{1: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		caller   int
+		expected int
+	}{
+		{"anonymous", 0, http.StatusUnauthorized},
+		{"admin", 1, http.StatusOK},
+		{"other user", 2, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/audit", nil)
+			if tt.caller != 0 {
+				req = req.WithContext(WithCaller(req.Context(), tt.caller))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
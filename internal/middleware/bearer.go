@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// tokenScopesContextKey holds the scopes of the API token a request was made with
+const tokenScopesContextKey contextKey = "token_scopes"
+
+// ScopeStatsRead grants read access to every app's progress and statistics
+const ScopeStatsRead = "stats:read"
+
+// TokenIdentity is the user and scopes an API token was issued for
+type TokenIdentity struct {
+	UserID int
+	Scopes []string
+}
+
+// TokenValidator resolves a raw bearer token, returning nil for unknown,
+// revoked or expired tokens
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*TokenIdentity, error)
+}
+
+// BearerAuth accepts "Authorization: Bearer <token>" as an alternative to the
+// cookie session
+type BearerAuth struct {
+	validator TokenValidator
+}
+
+// NewBearerAuth creates a new bearer token middleware
+func NewBearerAuth(validator TokenValidator) *BearerAuth {
+	return &BearerAuth{validator: validator}
+}
+
+// Handler authenticates requests carrying a bearer token. Requests without an
+// Authorization header pass through to the cookie session unchanged.
+func (b *BearerAuth) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, token, found := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+			apierror.Respond(w, r, http.StatusUnauthorized, "invalid authorization header")
+			return
+		}
+
+		identity, err := b.validator.ValidateToken(r.Context(), token)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "api token validation failed", "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "token validation failed")
+			return
+		}
+		if identity == nil || identity.UserID <= 0 {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			apierror.Respond(w, r, http.StatusUnauthorized, "invalid or expired token")
+			return
+		}
+
+		logging.SetUserID(r.Context(), identity.UserID)
+		ctx := WithCaller(r.Context(), identity.UserID)
+		ctx = context.WithValue(ctx, tokenScopesContextKey, identity.Scopes)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// TokenScopes returns the scopes of the API token used for the request and
+// whether the request was token-authenticated at all
+func TokenScopes(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(tokenScopesContextKey).([]string)
+	return scopes, ok
+}
+
+// HasScope reports whether the request may use any of the given scopes.
+// Cookie-session requests are not limited by scopes.
+func HasScope(r *http.Request, scopes ...string) bool {
+	granted, ok := TokenScopes(r)
+	if !ok {
+		return true
+	}
+	for _, g := range granted {
+		for _, s := range scopes {
+			if g == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequireAppScope limits token-authenticated requests to an app: safe methods
+// need <app>:read, <app>:write or stats:read, and other methods need <app>:write
+func RequireAppScope(app string) func(http.Handler) http.Handler {
+	read, write := app+":read", app+":write"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var allowed bool
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				allowed = HasScope(r, read, write, ScopeStatsRead)
+			default:
+				allowed = HasScope(r, write)
+			}
+
+			if !allowed {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+				apierror.Respond(w, r, http.StatusForbidden, "token lacks the required scope")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
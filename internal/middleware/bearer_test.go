@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticTokens resolves a fixed set of bearer tokens
+type staticTokens map[string]*TokenIdentity
+
+func (s staticTokens) ValidateToken(ctx context.Context, token string) (*TokenIdentity, error) {
+	return s[token], nil
+}
+
+func TestBearerAuth(t *testing.T) {
+	bearer := NewBearerAuth(staticTokens{
+		"good": {UserID: 5, Scopes: []string{"typing:write"}},
+	})
+
+	h := bearer.Handler(RequireAppScope("typing")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, ok := CallerID(r); !ok || userID != 5 {
+			t.Errorf("Expected caller 5, got %d", userID)
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		want   int
+	}{
+		{"valid token write", "POST", "Bearer good", http.StatusOK},
+		{"valid token read", "GET", "Bearer good", http.StatusOK},
+		{"unknown token", "GET", "Bearer bad", http.StatusUnauthorized},
+		{"wrong scheme", "GET", "Basic good", http.StatusUnauthorized},
+		{"empty token", "GET", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAppScope(t *testing.T) {
+	bearer := NewBearerAuth(staticTokens{
+		"stats": {UserID: 5, Scopes: []string{ScopeStatsRead}},
+	})
+	h := bearer.Handler(RequireAppScope("math")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		want   int
+	}{
+		{"stats token read", "GET", "Bearer stats", http.StatusOK},
+		{"stats token write", "POST", "Bearer stats", http.StatusForbidden},
+		{"session request", "POST", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -17,7 +17,7 @@ func NewCORSMiddleware(origins []string) *CORSMiddleware {
 	return &CORSMiddleware{
 		allowedOrigins: origins,
 		allowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		allowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With"},
+		allowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", IdempotencyHeader},
 	}
 }
 
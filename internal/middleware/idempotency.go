@@ -0,0 +1,327 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// IdempotencyHeader carries the client's key for a retryable request
+const IdempotencyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response replayed from an earlier request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds keys; clients normally send a UUID
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentResponseBytes bounds stored responses. A larger response is
+// not stored, so a retry runs the request again.
+const maxIdempotentResponseBytes = 1 << 20
+
+// maxIdempotentRequestBytes bounds the request bodies buffered to fingerprint
+// a request. It matches the upload limit, so audio uploads can be retried.
+const maxIdempotentRequestBytes = 10 << 20
+
+// idempotentRequests counts requests carrying an Idempotency-Key
+var idempotentRequests = metrics.NewCounterVec("unified_http_idempotent_requests_total",
+	"Requests with an Idempotency-Key, by outcome: first, replayed, conflict or in_progress.", "outcome")
+
+// IdempotencyStore keeps the first response to each Idempotency-Key in the
+// idempotency_keys table so that retries get the same response instead of
+// repeating the request. Keys are scoped to the caller, by user or client IP,
+// and expire after the store's TTL.
+type IdempotencyStore struct {
+	db  *sql.DB
+	ttl time.Duration
+	now func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewIdempotencyStore creates a store whose keys expire after ttl
+func NewIdempotencyStore(db *sql.DB, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{db: db, ttl: ttl, now: time.Now}
+}
+
+// idempotentResponse is a stored key; complete is false while the first
+// request is still being handled
+type idempotentResponse struct {
+	fingerprint string
+	complete    bool
+	status      int
+	contentType string
+	body        []byte
+}
+
+// Handler replays the stored response when a request repeats an
+// Idempotency-Key with the same method, path, query and body, and rejects the
+// request with 409 Conflict when the key was used for a different request or
+// the first one is still in progress. Requests without the header, and GET,
+// HEAD and OPTIONS requests, pass through. Server errors are not stored, so
+// they can be retried. Bodies larger than maxIdempotentRequestBytes are
+// rejected with 413. It must run after the session and bearer token
+// middleware so keys are scoped to the caller.
+func (s *IdempotencyStore) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !validIdempotencyKey(key) {
+			apierror.Respond(w, r, http.StatusBadRequest,
+				fmt.Sprintf("%s must be 1-%d printable ASCII characters", IdempotencyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Respond(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			apierror.Respond(w, r, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The key outlives a cancelled request, so it must still be settled
+		ctx := context.WithoutCancel(r.Context())
+		scope := callerKey(r)
+		fingerprint := requestFingerprint(r, body)
+
+		stored, err := s.reserve(ctx, scope, key, fingerprint)
+		if err != nil {
+			logger.ErrorContext(ctx, "idempotency key lookup failed", "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+			return
+		}
+		if stored != nil {
+			s.respondStored(w, r, stored, fingerprint)
+			return
+		}
+		idempotentRequests.Inc("first")
+
+		recorder := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			var err error
+			if completed && recorder.status < http.StatusInternalServerError && !recorder.overflow {
+				err = s.complete(ctx, scope, key, recorder.status, w.Header().Get("Content-Type"), recorder.body.Bytes())
+			} else {
+				err = s.release(ctx, scope, key)
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to settle idempotency key", "error", err)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+		completed = true
+	})
+}
+
+// respondStored answers a request whose key is already stored
+func (s *IdempotencyStore) respondStored(w http.ResponseWriter, r *http.Request, stored *idempotentResponse, fingerprint string) {
+	switch {
+	case stored.fingerprint != fingerprint:
+		idempotentRequests.Inc("conflict")
+		apierror.Respond(w, r, http.StatusConflict,
+			IdempotencyHeader+" was already used for a different request")
+
+	case !stored.complete:
+		idempotentRequests.Inc("in_progress")
+		w.Header().Set("Retry-After", "1")
+		apierror.Respond(w, r, http.StatusConflict,
+			"A request with this "+IdempotencyHeader+" is still in progress")
+
+	default:
+		idempotentRequests.Inc("replayed")
+		if stored.contentType != "" {
+			w.Header().Set("Content-Type", stored.contentType)
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(stored.status)
+		w.Write(stored.body)
+	}
+}
+
+// validIdempotencyKey reports whether key is short, printable ASCII
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint hashes what must match for a retry to be replayed
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reserve claims key for a new request and returns nil, or returns the
+// stored key when it was claimed before and has not expired
+func (s *IdempotencyStore) reserve(ctx context.Context, scope, key, fingerprint string) (*idempotentResponse, error) {
+	now := s.now().UTC()
+
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND expires_at <= ?`,
+		scope, key, now); err != nil {
+		return nil, fmt.Errorf("failed to expire idempotency key: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT (scope, idempotency_key) DO NOTHING`,
+		scope, key, fingerprint, now, now.Add(s.ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	var stored idempotentResponse
+	var status sql.NullInt64
+	var contentType sql.NullString
+	err = s.db.QueryRowContext(ctx,
+		`SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`,
+		scope, key).Scan(&stored.fingerprint, &status, &contentType, &stored.body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the insert and the select; let the caller retry
+		return &idempotentResponse{fingerprint: fingerprint}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	stored.complete = status.Valid
+	stored.status = int(status.Int64)
+	stored.contentType = contentType.String
+	return &stored, nil
+}
+
+// complete stores the response to a reserved key
+func (s *IdempotencyStore) complete(ctx context.Context, scope, key string, status int, contentType string, body []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE scope = ? AND idempotency_key = ?`,
+		status, sql.NullString{String: contentType, Valid: contentType != ""}, body, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// release forgets a reserved key so the request can be retried
+func (s *IdempotencyStore) release(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes expired keys and returns how many were removed
+func (s *IdempotencyStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE expires_at <= ?`, s.now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StartCleanup purges expired keys every interval until Close is called
+func (s *IdempotencyStore) StartCleanup(interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n, err := s.Purge(context.Background())
+				if err != nil {
+					logger.Error("idempotency key cleanup failed", "error", err)
+				} else if n > 0 {
+					logger.Info("purged expired idempotency keys", "count", n)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background cleanup started by StartCleanup
+func (s *IdempotencyStore) Close() {
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+			<-s.done
+		}
+	})
+}
+
+// idempotencyRecorder captures the status and body written by the handler
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func (rw *idempotencyRecorder) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *idempotencyRecorder) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	if !rw.overflow {
+		if rw.body.Len()+len(b) > maxIdempotentResponseBytes {
+			rw.overflow = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	return rw.ResponseWriter.Write(b)
+}
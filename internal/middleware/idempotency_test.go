@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupIdempotencyStore creates a store over an in-memory idempotency_keys table
+func setupIdempotencyStore(t *testing.T) *IdempotencyStore {
+	db := setupSessionDB(t)
+	if _, err := db.Exec(`
+	CREATE TABLE idempotency_keys (
+		scope TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		status INTEGER,
+		content_type TEXT,
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY (scope, idempotency_key)
+	)`); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	return NewIdempotencyStore(db, time.Hour)
+}
+
+// countingHandler answers with status and counts how often it ran
+type countingHandler struct {
+	calls  int
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `}`))
+}
+
+// sendIdempotent sends a request with an Idempotency-Key through handler
+func sendIdempotent(handler http.Handler, method, key, body string, userID int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/practice", strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	if userID != 0 {
+		req = req.WithContext(WithCaller(req.Context(), userID))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	next := &countingHandler{status: http.StatusCreated}
+	handler := store.Handler(next)
+
+	first := sendIdempotent(handler, http.MethodPost, "abc", `{"wpm":80}`, 7)
+	if first.Code != http.StatusCreated || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected the first request to run, got %d %v", first.Code, first.Header())
+	}
+
+	retry := sendIdempotent(handler, http.MethodPost, "abc", `{"wpm":80}`, 7)
+	if retry.Code != http.StatusCreated {
+		t.Errorf("Expected the stored status, got %d", retry.Code)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("Expected the retry to be marked as replayed")
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the stored content type, got %q", retry.Header().Get("Content-Type"))
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected body %q, got %q", first.Body.String(), retry.Body.String())
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", next.calls)
+	}
+
+	if w := sendIdempotent(handler, http.MethodPost, "abc", `{"wpm":95}`, 7); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a different body, got %d", w.Code)
+	}
+
+	// Keys are scoped to the caller
+	if w := sendIdempotent(handler, http.MethodPost, "abc", `{"wpm":95}`, 8); w.Code != http.StatusCreated {
+		t.Errorf("Expected another user's key to run, got %d", w.Code)
+	}
+	if w := sendIdempotent(handler, http.MethodPost, "abc", `{"wpm":95}`, 0); w.Code != http.StatusCreated {
+		t.Errorf("Expected an anonymous key to run, got %d", w.Code)
+	}
+	if next.calls != 3 {
+		t.Errorf("Expected the handler to run 3 times, ran %d times", next.calls)
+	}
+}
+
+func TestIdempotencyPassThrough(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	next := &countingHandler{status: http.StatusOK}
+	handler := store.Handler(next)
+
+	sendIdempotent(handler, http.MethodPost, "", `{}`, 7)
+	sendIdempotent(handler, http.MethodPost, "", `{}`, 7)
+	sendIdempotent(handler, http.MethodGet, "abc", "", 7)
+	sendIdempotent(handler, http.MethodGet, "abc", "", 7)
+	if next.calls != 4 {
+		t.Errorf("Expected requests without a key and GETs to pass through, ran %d times", next.calls)
+	}
+
+	for _, key := range []string{strings.Repeat("k", maxIdempotencyKeyLength+1), "bad\nkey", "ключ"} {
+		if w := sendIdempotent(handler, http.MethodPost, key, `{}`, 7); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for key %q, got %d", key, w.Code)
+		}
+	}
+}
+
+func TestIdempotencyServerErrorReleased(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	next := &countingHandler{status: http.StatusInternalServerError}
+	handler := store.Handler(next)
+
+	sendIdempotent(handler, http.MethodPost, "abc", `{}`, 7)
+	next.status = http.StatusOK
+	w := sendIdempotent(handler, http.MethodPost, "abc", `{}`, 7)
+	if w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("Expected the retry to run after a server error, got %d", w.Code)
+	}
+	if next.calls != 2 {
+		t.Errorf("Expected the handler to run twice, ran %d times", next.calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	next := &countingHandler{status: http.StatusOK}
+	var retry *httptest.ResponseRecorder
+	handler := store.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A retry arrives while the first request is still running
+		retry = sendIdempotent(store.Handler(next), http.MethodPost, "abc", `{}`, 7)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	sendIdempotent(handler, http.MethodPost, "abc", `{}`, 7)
+	if retry.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the first request runs, got %d", retry.Code)
+	}
+	if retry.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	if next.calls != 0 {
+		t.Errorf("Expected the retry not to run, ran %d times", next.calls)
+	}
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	next := &countingHandler{status: http.StatusOK}
+	handler := store.Handler(next)
+
+	sendIdempotent(handler, http.MethodPost, "abc", `{}`, 7)
+	sendIdempotent(handler, http.MethodPost, "old", `{}`, 7)
+
+	now = now.Add(2 * time.Hour)
+	if w := sendIdempotent(handler, http.MethodPost, "abc", `{"new":true}`, 7); w.Code != http.StatusOK {
+		t.Errorf("Expected an expired key to be reusable, got %d", w.Code)
+	}
+	if next.calls != 3 {
+		t.Errorf("Expected the handler to run 3 times, ran %d times", next.calls)
+	}
+
+	n, err := store.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 expired key purged, got %d", n)
+	}
+}
+
+func TestIdempotencyQueryAndBodyLimit(t *testing.T) {
+	store := setupIdempotencyStore(t)
+	next := &countingHandler{status: http.StatusOK}
+	handler := store.Handler(next)
+
+	send := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(IdempotencyHeader, "abc")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	send("/api/practice?song_id=1", `{}`)
+	if w := send("/api/practice?song_id=2", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a different query string, got %d", w.Code)
+	}
+	if w := send("/api/practice?song_id=1", `{}`); w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("Expected the same query string to be replayed, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/practice", strings.NewReader(strings.Repeat("x", maxIdempotentRequestBytes+1)))
+	req.Header.Set(IdempotencyHeader, "big")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized body, got %d", w.Code)
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", next.calls)
+	}
+}
@@ -1,9 +1,24 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// logger is the middleware package logger; request records use "http"
+var (
+	logger    = logging.Logger("middleware")
+	accessLog = logging.Logger("http")
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -24,10 +39,25 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// Logging middleware logs HTTP requests and responses
+// Flush lets streaming handlers flush through the wrapper
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Logging middleware attaches request info to the context for structured
+// logging and writes one record per request with its status and latency.
+// It must run after chimiddleware.RequestID.
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		ctx := logging.NewContext(r.Context(), chimiddleware.GetReqID(r.Context()))
 
 		// Wrap response writer to capture status
 		wrapped := &responseWriter{
@@ -36,32 +66,65 @@ func Logging(next http.Handler) http.Handler {
 		}
 
 		// Process request
-		next.ServeHTTP(wrapped, r)
-
-		// Log request
-		duration := time.Since(start)
-		log.Printf(
-			"%s %s %d %s %d bytes %v",
-			r.Method,
-			r.RequestURI,
-			wrapped.statusCode,
-			r.RemoteAddr,
-			wrapped.written,
-			duration,
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		switch {
+		case wrapped.statusCode >= 500:
+			level = slog.LevelError
+		case wrapped.statusCode >= 400:
+			level = slog.LevelWarn
+		}
+
+		accessLog.LogAttrs(ctx, level, "request completed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.statusCode),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", wrapped.written),
+			slog.String("remote_addr", r.RemoteAddr),
 		)
 	})
 }
 
-// Recovery middleware recovers from panics and logs them
+// Recovery middleware recovers from panics and logs them with a stack trace.
+// It must run inside Logging so the record carries the request fields.
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("PANIC: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				logger.ErrorContext(r.Context(), "panic recovered",
+					"panic", fmt.Sprint(err),
+					"stack", string(debug.Stack()),
+				)
+				apierror.Respond(w, r, http.StatusInternalServerError, "Internal server error")
 			}
 		}()
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// appContextKey holds the app name set by App
+const appContextKey contextKey = "app"
+
+// App tags the requests it wraps with an app name for logging, auditing and
+// per-app rate limits
+func App(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.SetApp(r.Context(), name)
+			audit.SetApp(r.Context(), name)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), appContextKey, name)))
+		})
+	}
+}
+
+// appName returns the app name set by App, or "" outside any app
+func appName(r *http.Request) string {
+	name, _ := r.Context().Value(appContextKey).(string)
+	return name
+}
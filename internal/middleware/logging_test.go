@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+func TestLoggingAndRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, logging.Options{Level: "info"}); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { logging.Setup(os.Stderr, logging.Options{}) })
+
+	r := chi.NewRouter()
+	r.Use(chimiddleware.RequestID)
+	r.Use(Logging)
+	r.Use(Recovery)
+	r.With(App("math")).Get("/api/users/{userId}/boom", func(w http.ResponseWriter, req *http.Request) {
+		panic("kaboom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/7/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want panic and access records: %v", len(records), records)
+	}
+
+	panicRecord, access := records[0], records[1]
+	if panicRecord["panic"] != "kaboom" || !strings.Contains(panicRecord["stack"].(string), "goroutine") {
+		t.Errorf("panic record = %v", panicRecord)
+	}
+	if panicRecord["request_id"] == nil || panicRecord["request_id"] != access["request_id"] {
+		t.Errorf("request IDs differ: %v vs %v", panicRecord["request_id"], access["request_id"])
+	}
+
+	want := map[string]interface{}{
+		"msg":    "request completed",
+		"level":  "ERROR",
+		"status": float64(500),
+		"app":    "math",
+		"route":  "/api/users/{userId}/boom",
+		"method": "GET",
+	}
+	for k, v := range want {
+		if access[k] != v {
+			t.Errorf("access %s = %v, want %v", k, access[k], v)
+		}
+	}
+	if _, ok := access["latency_ms"]; !ok {
+		t.Error("access record missing latency_ms")
+	}
+}
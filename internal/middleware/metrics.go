@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// HTTP request metrics, labelled by chi route pattern rather than raw path
+// so that IDs in URLs do not create unbounded series
+var (
+	httpRequests = metrics.NewCounterVec("unified_http_requests_total",
+		"HTTP requests served, by method, route pattern and status code.",
+		"method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("unified_http_request_duration_seconds",
+		"HTTP request latency in seconds, by method and route pattern.",
+		nil, "method", "route")
+	httpInFlight = metrics.NewGaugeVec("unified_http_requests_in_flight",
+		"HTTP requests currently being served.")
+)
+
+// Metrics middleware records request counts, latencies and in-flight
+// requests. It must run outside Recovery so panics are counted as 500s.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpInFlight.Add(1)
+		defer httpInFlight.Add(-1)
+
+		wrapped := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		next.ServeHTTP(wrapped, r)
+
+		route := routePattern(r)
+		httpRequests.Inc(r.Method, route, strconv.Itoa(wrapped.statusCode))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// routePattern returns the matched chi route, or "unmatched" for 404s
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	r.Use(Recovery)
+	r.Route("/math", func(r chi.Router) {
+		r.Get("/api/users/{userId}/stats", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		r.Get("/api/boom", func(w http.ResponseWriter, req *http.Request) {
+			panic("kaboom")
+		})
+	})
+
+	route := "/math/api/users/{userId}/stats"
+	before := httpRequests.Value("GET", route, "418")
+	for _, path := range []string{"/math/api/users/1/stats", "/math/api/users/2/stats", "/math/api/boom", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := httpRequests.Value("GET", route, "418") - before; got != 2 {
+		t.Errorf("requests for %s = %v, want 2 (one series for both user IDs)", route, got)
+	}
+	if got := httpRequests.Value("GET", "/math/api/boom", "500"); got < 1 {
+		t.Errorf("panicking request not counted as 500")
+	}
+	if got := httpRequests.Value("GET", "unmatched", "404"); got < 1 {
+		t.Errorf("unmatched request not counted")
+	}
+	if got := httpInFlight.Value(); got != 0 {
+		t.Errorf("in-flight = %v after requests finished", got)
+	}
+}
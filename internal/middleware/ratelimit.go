@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// rateLimited counts requests rejected by a rate limiter
+var rateLimited = metrics.NewCounterVec("unified_http_rate_limited_total",
+	"Requests rejected with 429 by a rate limiter, by limiter name.", "limiter")
+
+// RateLimitPolicy describes one token-bucket limiter. Each caller gets a
+// bucket of Burst tokens that refills at RequestsPerMinute, and every request
+// takes one token.
+type RateLimitPolicy struct {
+	// Name identifies the limiter in metrics and persisted state
+	Name              string
+	RequestsPerMinute int
+	Burst             int
+	// WritesOnly exempts GET, HEAD and OPTIONS requests
+	WritesOnly bool
+	// PerApp gives each caller a separate bucket in every app, so heavy use
+	// of one app does not use up the others
+	PerApp bool
+}
+
+// tokenBucket is the state of one caller's bucket as of updated
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter applies a RateLimitPolicy per caller, keyed by authenticated
+// user and falling back to client IP. It must run after the session and
+// bearer token middleware so the caller is known, and after App for per-app
+// buckets.
+type RateLimiter struct {
+	policy RateLimitPolicy
+	rate   float64 // tokens per second
+	burst  float64
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+
+	store    *RateLimitStore
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// rateLimitResult is the outcome of taking a token from a bucket
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // until the bucket is full again
+	retryAfter time.Duration // until the next token, when not allowed
+}
+
+// NewRateLimiter creates an in-memory limiter for policy
+func NewRateLimiter(policy RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		policy:  policy,
+		rate:    float64(policy.RequestsPerMinute) / 60,
+		burst:   float64(policy.Burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Handler rejects requests over the limit with 429 Too Many Requests and a
+// Retry-After header. Limited responses also carry RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.policy.WritesOnly {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		key := callerKey(r)
+		if app := appName(r); l.policy.PerApp && app != "" {
+			key = "app:" + app + ":" + key
+		}
+		result := l.take(key)
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(l.policy.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+
+		if !result.allowed {
+			rateLimited.Inc(l.policy.Name)
+			logger.WarnContext(r.Context(), "rate limit exceeded", "limiter", l.policy.Name)
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+			apierror.Respond(w, r, http.StatusTooManyRequests, "Rate limit exceeded, try again later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// callerKey identifies authenticated callers by user and everyone else by
+// IP, which is the proxy's forwarded address only for trusted proxies; see
+// RealIP
+func callerKey(r *http.Request) string {
+	if userID, ok := CallerID(r); ok {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return "ip:" + clientIP(r)
+}
+
+// take refills the bucket for key and takes one token if available
+func (l *RateLimiter) take(key string) rateLimitResult {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	result := rateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = l.durationFor(1 - b.tokens)
+	}
+	result.remaining = int(math.Floor(b.tokens))
+	result.reset = l.durationFor(l.burst - b.tokens)
+	return result
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (l *RateLimiter) refill(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+	}
+	b.updated = now
+}
+
+// durationFor is how long the bucket takes to earn tokens
+func (l *RateLimiter) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// ceilSeconds rounds a duration up to whole seconds for headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// prune drops buckets that have refilled completely; a missing bucket is
+// equivalent to a full one
+func (l *RateLimiter) prune() {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// SetStore loads persisted buckets from store and saves them back on each
+// cleanup tick and on Close
+func (l *RateLimiter) SetStore(ctx context.Context, store *RateLimitStore) error {
+	buckets, err := store.load(ctx, l.policy.Name)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, b := range buckets {
+		l.buckets[key] = b
+	}
+	l.store = store
+	return nil
+}
+
+// save writes the current buckets to the store, if one is set
+func (l *RateLimiter) save(ctx context.Context) error {
+	if l.store == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	snapshot := make(map[string]tokenBucket, len(l.buckets))
+	for key, b := range l.buckets {
+		snapshot[key] = *b
+	}
+	l.mu.Unlock()
+
+	return l.store.save(ctx, l.policy.Name, snapshot)
+}
+
+// StartCleanup prunes full buckets and persists state every interval until
+// Close is called
+func (l *RateLimiter) StartCleanup(interval time.Duration) {
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				l.prune()
+				if err := l.save(context.Background()); err != nil {
+					logger.Error("failed to save rate limiter state", "limiter", l.policy.Name, "error", err)
+				}
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background cleanup and saves the final state
+func (l *RateLimiter) Close() error {
+	var err error
+	l.stopOnce.Do(func() {
+		if l.stop != nil {
+			close(l.stop)
+			<-l.done
+		}
+		l.prune()
+		err = l.save(context.Background())
+	})
+	return err
+}
+
+// RateLimitStore persists limiter buckets in the rate_limit_buckets table
+type RateLimitStore struct {
+	db *sql.DB
+}
+
+// NewRateLimitStore creates a store backed by the rate_limit_buckets table
+func NewRateLimitStore(db *sql.DB) *RateLimitStore {
+	return &RateLimitStore{db: db}
+}
+
+// load returns the saved buckets for a limiter
+func (s *RateLimitStore) load(ctx context.Context, limiter string) (map[string]*tokenBucket, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT bucket_key, tokens, updated_at FROM rate_limit_buckets WHERE limiter = ?`, limiter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit buckets: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := make(map[string]*tokenBucket)
+	for rows.Next() {
+		var key string
+		b := &tokenBucket{}
+		if err := rows.Scan(&key, &b.tokens, &b.updated); err != nil {
+			return nil, fmt.Errorf("failed to scan rate limit bucket: %w", err)
+		}
+		buckets[key] = b
+	}
+	return buckets, rows.Err()
+}
+
+// save replaces the saved buckets for a limiter
+func (s *RateLimitStore) save(ctx context.Context, limiter string, buckets map[string]tokenBucket) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE limiter = ?`, limiter); err != nil {
+		return fmt.Errorf("failed to clear rate limit buckets: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO rate_limit_buckets (limiter, bucket_key, tokens, updated_at) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare rate limit insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, b := range buckets {
+		if _, err := stmt.ExecContext(ctx, limiter, key, b.tokens, b.updated.UTC()); err != nil {
+			return fmt.Errorf("failed to save rate limit bucket: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testLimiter returns a limiter with a controllable clock
+func testLimiter(policy RateLimitPolicy) (*RateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(policy)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func serveLimited(h http.Handler, method string, userID int, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/check-answer", nil)
+	req.RemoteAddr = remoteAddr
+	if userID > 0 {
+		req = req.WithContext(WithCaller(req.Context(), userID))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter(t *testing.T) {
+	l, now := testLimiter(RateLimitPolicy{Name: "test", RequestsPerMinute: 60, Burst: 2})
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := serveLimited(h, "POST", 7, "10.0.0.1:1234")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %q", i, got, wantRemaining)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("RateLimit-Limit = %q, want 2", got)
+		}
+	}
+
+	w := serveLimited(h, "POST", 7, "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the burst is spent, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "2" {
+		t.Errorf("RateLimit-Reset = %q, want 2", got)
+	}
+
+	// Another user and an anonymous client have their own buckets
+	if w := serveLimited(h, "POST", 8, "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected other user to be allowed, got %d", w.Code)
+	}
+	if w := serveLimited(h, "POST", 0, "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected anonymous client to be allowed, got %d", w.Code)
+	}
+
+	// Tokens refill at the configured rate
+	*now = now.Add(time.Second)
+	if w := serveLimited(h, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected request after refill to be allowed, got %d", w.Code)
+	}
+	if w := serveLimited(h, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 after using the refilled token, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterWritesOnly(t *testing.T) {
+	l, _ := testLimiter(RateLimitPolicy{Name: "writes", RequestsPerMinute: 60, Burst: 1, WritesOnly: true})
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		if w := serveLimited(h, "GET", 7, "10.0.0.1:1234"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("Expected GET to bypass the limiter, got %d", w.Code)
+		}
+	}
+	serveLimited(h, "POST", 7, "10.0.0.1:1234")
+	if w := serveLimited(h, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected second POST to be limited, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterPerApp(t *testing.T) {
+	shared, _ := testLimiter(RateLimitPolicy{Name: "auth", RequestsPerMinute: 60, Burst: 1})
+	perApp, _ := testLimiter(RateLimitPolicy{Name: "api", RequestsPerMinute: 60, Burst: 1, PerApp: true})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	sharedMath, sharedReading := App("math")(shared.Handler(ok)), App("reading")(shared.Handler(ok))
+	perAppMath, perAppReading := App("math")(perApp.Handler(ok)), App("reading")(perApp.Handler(ok))
+
+	serveLimited(sharedMath, "POST", 7, "10.0.0.1:1234")
+	if w := serveLimited(sharedReading, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected one bucket across apps without PerApp, got %d", w.Code)
+	}
+
+	serveLimited(perAppMath, "POST", 7, "10.0.0.1:1234")
+	if w := serveLimited(perAppReading, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected a separate bucket for each app, got %d", w.Code)
+	}
+	if w := serveLimited(perAppMath, "POST", 7, "10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the app's own bucket to be spent, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	l, now := testLimiter(RateLimitPolicy{Name: "test", RequestsPerMinute: 60, Burst: 5})
+	l.take("user:1")
+	l.take("user:2")
+	l.take("user:2")
+
+	*now = now.Add(1500 * time.Millisecond)
+	l.prune()
+
+	if _, ok := l.buckets["user:1"]; ok {
+		t.Error("Expected refilled bucket to be pruned")
+	}
+	if _, ok := l.buckets["user:2"]; !ok {
+		t.Error("Expected partially used bucket to be kept")
+	}
+}
+
+func TestRateLimitStore(t *testing.T) {
+	db := setupSessionDB(t)
+	if _, err := db.Exec(`
+	CREATE TABLE rate_limit_buckets (
+		limiter TEXT NOT NULL,
+		bucket_key TEXT NOT NULL,
+		tokens REAL NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (limiter, bucket_key)
+	)`); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	store := NewRateLimitStore(db)
+	ctx := context.Background()
+
+	policy := RateLimitPolicy{Name: "writes", RequestsPerMinute: 1, Burst: 1}
+	first, _ := testLimiter(policy)
+	if err := first.SetStore(ctx, store); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	if !first.take("user:7").allowed {
+		t.Fatal("Expected first request to be allowed")
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A limiter created after a restart still has the spent bucket
+	second, _ := testLimiter(policy)
+	if err := second.SetStore(ctx, store); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	if second.take("user:7").allowed {
+		t.Error("Expected spent bucket to survive a restart")
+	}
+
+	// Other limiters sharing the store are unaffected
+	other, _ := testLimiter(RateLimitPolicy{Name: "auth", RequestsPerMinute: 1, Burst: 1})
+	if err := other.SetStore(ctx, store); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	if !other.take("user:7").allowed {
+		t.Error("Expected a separate limiter to start with a full bucket")
+	}
+}
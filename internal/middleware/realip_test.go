@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.5/32")}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		realIP    string
+		expected  string
+	}{
+		{"direct client", "203.0.113.7:4000", nil, "", "203.0.113.7:4000"},
+		{"untrusted peer spoofing", "203.0.113.7:4000", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.7:4000"},
+		{"trusted proxy", "10.0.0.2:80", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"client prepends a fake hop", "10.0.0.2:80", []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:80", []string{"198.51.100.1, 192.168.1.5", "10.0.0.3"}, "", "198.51.100.1"},
+		{"all hops trusted", "10.0.0.2:80", []string{"10.0.0.9"}, "", "10.0.0.9"},
+		{"malformed hop", "10.0.0.2:80", []string{"198.51.100.1, nonsense"}, "", "10.0.0.2:80"},
+		{"real ip header", "192.168.1.5:80", nil, "198.51.100.3", "198.51.100.3"},
+		{"ipv6 client", "10.0.0.2:80", []string{"2001:db8::1"}, "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.peer
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("Expected RemoteAddr %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
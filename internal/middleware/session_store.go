@@ -0,0 +1,382 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// sessionTouchInterval limits how often sliding expiry rewrites a session row
+const sessionTouchInterval = time.Minute
+
+// sessionSlidKey marks in session.Values a session whose expiry slid forward
+// when it was loaded, until its cookie is sent again. It is never stored.
+type sessionSlidKey struct{}
+
+// SessionInfo describes one active session for a user
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	Current    bool      `json:"current"`
+}
+
+// SQLiteStore is a sessions.Store that keeps session data in the sessions
+// table and only a signed session ID in the cookie. Sessions can therefore be
+// listed and revoked server-side, and expiry slides forward on each request.
+type SQLiteStore struct {
+	db      *sql.DB
+	codecs  []securecookie.Codec
+	Options *sessions.Options
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewSQLiteStore creates a session store backed by the sessions table.
+// keyPairs are passed to securecookie to sign the session ID cookie.
+func NewSQLiteStore(db *sql.DB, keyPairs ...[]byte) *SQLiteStore {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			// The cookie only carries the ID, so its lifetime is enforced in the table
+			sc.MaxAge(0)
+		}
+	}
+
+	return &SQLiteStore{
+		db:     db,
+		codecs: codecs,
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   86400 * 7, // 7 days
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+}
+
+// Get returns the session for the request, loading it once per request
+func (s *SQLiteStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session named in the request cookie, or returns a new empty
+// session when there is no valid cookie or the session has expired or been revoked
+func (s *SQLiteStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var id string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &id, s.codecs...); err != nil {
+		// Tampered or rotated-key cookies start a fresh session
+		return session, nil
+	}
+
+	found, err := s.load(r.Context(), id, session)
+	if err != nil {
+		return session, err
+	}
+	if found {
+		session.ID = id
+		session.IsNew = false
+	}
+
+	return session, nil
+}
+
+// Save writes the session row and the signed ID cookie. A negative MaxAge
+// deletes the session.
+func (s *SQLiteStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.RevokeSession(r.Context(), session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := generateSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+
+	delete(session.Values, sessionSlidKey{})
+	if err := s.save(r, session); err != nil {
+		return err
+	}
+
+	return s.writeCookie(w, session)
+}
+
+// Refresh sends the cookie again when the session's expiry slid forward as
+// it was loaded, so the browser keeps the cookie as long as the row lasts
+func (s *SQLiteStore) Refresh(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if _, ok := session.Values[sessionSlidKey{}]; !ok {
+		return nil
+	}
+	delete(session.Values, sessionSlidKey{})
+	return s.writeCookie(w, session)
+}
+
+// writeCookie sets the signed session ID cookie
+func (s *SQLiteStore) writeCookie(w http.ResponseWriter, session *sessions.Session) error {
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return fmt.Errorf("failed to encode session cookie: %w", err)
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// load reads a live session row into session.Values and slides its expiry,
+// marking the session so Refresh sends its cookie again
+func (s *SQLiteStore) load(ctx context.Context, id string, session *sessions.Session) (bool, error) {
+	now := time.Now().UTC()
+
+	var data string
+	var lastSeen sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(data, ''), last_seen_at FROM sessions WHERE id = ? AND expires_at > ?`,
+		id, now).Scan(&data, &lastSeen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	if err := decodeSessionValues(data, session.Values); err != nil {
+		logger.WarnContext(ctx, "discarding undecodable session", "error", err)
+		return false, nil
+	}
+
+	if !lastSeen.Valid || now.Sub(lastSeen.Time) >= sessionTouchInterval {
+		expiresAt := now.Add(time.Duration(session.Options.MaxAge) * time.Second)
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?`,
+			now, expiresAt, id); err != nil {
+			return false, fmt.Errorf("failed to extend session: %w", err)
+		}
+		session.Values[sessionSlidKey{}] = true
+	}
+
+	return true, nil
+}
+
+// save upserts the session row
+func (s *SQLiteStore) save(r *http.Request, session *sessions.Session) error {
+	data, err := encodeSessionValues(session.Values)
+	if err != nil {
+		return err
+	}
+
+	var userID sql.NullInt64
+	if id, ok := session.Values["user_id"].(int); ok && id > 0 {
+		userID = sql.NullInt64{Int64: int64(id), Valid: true}
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(time.Duration(session.Options.MaxAge) * time.Second)
+
+	_, err = s.db.ExecContext(r.Context(),
+		`INSERT INTO sessions (id, user_id, data, expires_at, created_at, last_seen_at, user_agent, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			user_id = excluded.user_id,
+			data = excluded.data,
+			expires_at = excluded.expires_at,
+			last_seen_at = excluded.last_seen_at,
+			user_agent = excluded.user_agent,
+			ip_address = excluded.ip_address`,
+		session.ID, userID, data, expiresAt, now, now, truncate(r.UserAgent(), 255), clientIP(r))
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// ListUserSessions returns a user's unexpired sessions, most recently used first
+func (s *SQLiteStore) ListUserSessions(ctx context.Context, userID int, currentID string) ([]SessionInfo, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, user_id, created_at, last_seen_at, expires_at,
+			COALESCE(user_agent, ''), COALESCE(ip_address, '')
+		FROM sessions WHERE user_id = ? AND expires_at > ?
+		ORDER BY COALESCE(last_seen_at, created_at) DESC`,
+		userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	infos := make([]SessionInfo, 0)
+	for rows.Next() {
+		var info SessionInfo
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&info.ID, &info.UserID, &info.CreatedAt, &lastSeen, &info.ExpiresAt,
+			&info.UserAgent, &info.IPAddress); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		info.LastSeenAt = info.CreatedAt
+		if lastSeen.Valid {
+			info.LastSeenAt = lastSeen.Time
+		}
+		info.Current = info.ID == currentID
+		infos = append(infos, info)
+	}
+
+	return infos, rows.Err()
+}
+
+// RevokeSession deletes a single session
+func (s *SQLiteStore) RevokeSession(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSession deletes one of a user's sessions, reporting whether it existed
+func (s *SQLiteStore) RevokeUserSession(ctx context.Context, userID int, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// RevokeUserSessions deletes all of a user's sessions except exceptID, which
+// may be empty to log the user out everywhere. It returns the number revoked.
+func (s *SQLiteStore) RevokeUserSessions(ctx context.Context, userID int, exceptID string) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ? AND id != ?`, userID, exceptID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PurgeExpired deletes expired sessions and returns how many were removed
+func (s *SQLiteStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StartCleanup purges expired sessions every interval until Close is called
+func (s *SQLiteStore) StartCleanup(interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n, err := s.PurgeExpired(context.Background())
+				if err != nil {
+					logger.Error("session cleanup failed", "error", err)
+				} else if n > 0 {
+					logger.Info("purged expired sessions", "count", n)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background cleanup started by StartCleanup
+func (s *SQLiteStore) Close() {
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+			<-s.done
+		}
+	})
+}
+
+// generateSessionID returns a random, URL-safe session identifier
+func generateSessionID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(buf), "="), nil
+}
+
+// encodeSessionValues serializes session values for the data column
+func encodeSessionValues(values map[interface{}]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return "", fmt.Errorf("failed to encode session values: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeSessionValues restores session values written by encodeSessionValues
+func decodeSessionValues(data string, values map[interface{}]interface{}) error {
+	if data == "" {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(raw)).Decode(&values)
+}
+
+// clientIP returns the request's remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupSessionDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER,
+		data TEXT,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at DATETIME,
+		user_agent TEXT,
+		ip_address TEXT
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	return db
+}
+
+// saveUserSession creates an authenticated session and returns its cookie
+func saveUserSession(t *testing.T, store *SQLiteStore, userID int) *http.Cookie {
+	req := httptest.NewRequest("GET", "/", nil)
+	session, err := store.New(req, "unified_session")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	SetAuthenticated(session, userID, "user")
+
+	w := httptest.NewRecorder()
+	if err := store.Save(req, w, session); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected one cookie, got %d", len(cookies))
+	}
+	return cookies[0]
+}
+
+// loadUserID reads the session named by cookie and returns its user
+func loadUserID(t *testing.T, store *SQLiteStore, cookie *http.Cookie) (int, bool) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	session, err := store.New(req, "unified_session")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	userID, ok := session.Values["user_id"].(int)
+	return userID, ok
+}
+
+func TestSQLiteStoreRoundTrip(t *testing.T) {
+	store := NewSQLiteStore(setupSessionDB(t), []byte("test-secret"))
+
+	cookie := saveUserSession(t, store, 7)
+	if !cookie.HttpOnly || cookie.Secure {
+		t.Errorf("Expected HttpOnly non-Secure cookie, got HttpOnly=%v Secure=%v", cookie.HttpOnly, cookie.Secure)
+	}
+
+	userID, ok := loadUserID(t, store, cookie)
+	if !ok || userID != 7 {
+		t.Errorf("Expected user 7, got %d (ok=%v)", userID, ok)
+	}
+
+	tampered := *cookie
+	tampered.Value = "x" + cookie.Value
+	if _, ok := loadUserID(t, store, &tampered); ok {
+		t.Error("Expected tampered cookie to yield an empty session")
+	}
+}
+
+func TestSQLiteStoreSecureCookie(t *testing.T) {
+	store := NewSQLiteStore(setupSessionDB(t), []byte("test-secret"))
+	store.Options.Secure = true
+
+	if cookie := saveUserSession(t, store, 1); !cookie.Secure {
+		t.Error("Expected Secure cookie")
+	}
+}
+
+func TestSQLiteStoreRevocation(t *testing.T) {
+	store := NewSQLiteStore(setupSessionDB(t), []byte("test-secret"))
+	ctx := context.Background()
+
+	first := saveUserSession(t, store, 1)
+	second := saveUserSession(t, store, 1)
+	other := saveUserSession(t, store, 2)
+
+	sessions, err := store.ListUserSessions(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("ListUserSessions failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions for user 1, got %d", len(sessions))
+	}
+
+	found, err := store.RevokeUserSession(ctx, 2, sessions[0].ID)
+	if err != nil || found {
+		t.Errorf("Expected another user's session to be untouched, found=%v err=%v", found, err)
+	}
+
+	n, err := store.RevokeUserSessions(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("RevokeUserSessions failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 sessions revoked, got %d", n)
+	}
+
+	for _, cookie := range []*http.Cookie{first, second} {
+		if _, ok := loadUserID(t, store, cookie); ok {
+			t.Error("Expected revoked session to be unauthenticated")
+		}
+	}
+	if userID, ok := loadUserID(t, store, other); !ok || userID != 2 {
+		t.Error("Expected other user's session to survive")
+	}
+}
+
+func TestSQLiteStoreSlidingExpiry(t *testing.T) {
+	db := setupSessionDB(t)
+	store := NewSQLiteStore(db, []byte("test-secret"))
+	cookie := saveUserSession(t, store, 1)
+
+	// Pretend the session was last used two minutes ago and is about to expire
+	past := time.Now().UTC().Add(-2 * time.Minute)
+	soon := time.Now().UTC().Add(time.Minute)
+	if _, err := db.Exec(`UPDATE sessions SET last_seen_at = ?, expires_at = ?`, past, soon); err != nil {
+		t.Fatalf("Failed to age session: %v", err)
+	}
+
+	if _, ok := loadUserID(t, store, cookie); !ok {
+		t.Fatal("Expected session to load before expiry")
+	}
+
+	var expiresAt time.Time
+	if err := db.QueryRow(`SELECT expires_at FROM sessions`).Scan(&expiresAt); err != nil {
+		t.Fatalf("Failed to read expiry: %v", err)
+	}
+	if time.Until(expiresAt) < 24*time.Hour {
+		t.Errorf("Expected expiry to slide forward, got %v", expiresAt)
+	}
+}
+
+func TestAuthMiddlewareResendsSlidCookie(t *testing.T) {
+	db := setupSessionDB(t)
+	store := NewSQLiteStore(db, []byte("test-secret"))
+	am := NewAuthMiddlewareWithStore(store, "unified_session")
+	cookie := saveUserSession(t, store, 1)
+
+	serve := func(save bool) []*http.Cookie {
+		h := am.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if save {
+				GetSession(r).Values["theme"] = "dark"
+				if err := GetSession(r).Save(r, w); err != nil {
+					t.Errorf("Save failed: %v", err)
+				}
+			}
+		}))
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result().Cookies()
+	}
+
+	// Used within the touch interval: the expiry and the cookie stay
+	if cookies := serve(false); len(cookies) != 0 {
+		t.Errorf("Expected no cookie while the expiry stays, got %v", cookies)
+	}
+
+	age := func() {
+		past := time.Now().UTC().Add(-2 * time.Minute)
+		if _, err := db.Exec(`UPDATE sessions SET last_seen_at = ?, expires_at = ?`, past, time.Now().UTC().Add(time.Minute)); err != nil {
+			t.Fatalf("Failed to age session: %v", err)
+		}
+	}
+
+	age()
+	cookies := serve(false)
+	if len(cookies) != 1 || cookies[0].Name != "unified_session" || cookies[0].MaxAge != store.Options.MaxAge {
+		t.Fatalf("Expected the cookie sent again with the slid expiry, got %v", cookies)
+	}
+	if userID, ok := loadUserID(t, store, cookies[0]); !ok || userID != 1 {
+		t.Errorf("Expected the resent cookie to name the session, got %d (ok=%v)", userID, ok)
+	}
+
+	// A handler saving the slid session stores its values without the mark
+	age()
+	if cookies := serve(true); len(cookies) == 0 {
+		t.Fatal("Expected the cookie sent")
+	}
+	if _, ok := loadUserID(t, store, cookie); !ok {
+		t.Error("Expected the saved session to load")
+	}
+}
+
+func TestSQLiteStorePurgeExpired(t *testing.T) {
+	db := setupSessionDB(t)
+	store := NewSQLiteStore(db, []byte("test-secret"))
+
+	expired := saveUserSession(t, store, 1)
+	saveUserSession(t, store, 1)
+
+	if _, err := db.Exec(`UPDATE sessions SET expires_at = ? WHERE rowid = 1`, time.Now().UTC().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to expire session: %v", err)
+	}
+
+	if _, ok := loadUserID(t, store, expired); ok {
+		t.Error("Expected expired session to be unauthenticated")
+	}
+
+	n, err := store.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 expired session purged, got %d", n)
+	}
+}
+
+func TestAuthMiddlewareHonorsSessionName(t *testing.T) {
+	store := NewSQLiteStore(setupSessionDB(t), []byte("test-secret"))
+	am := NewAuthMiddlewareWithStore(store, "custom_session")
+
+	h := am.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := GetSession(r)
+		SetAuthenticated(session, 3, "user")
+		session.Save(r, w)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "custom_session" {
+		t.Fatalf("Expected custom_session cookie, got %v", cookies)
+	}
+}
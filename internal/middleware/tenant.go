@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// Organizations looks up organizations and the organization of a user
+type Organizations interface {
+	GetBySlug(ctx context.Context, slug string) (*tenant.Organization, error)
+	MemberOf(ctx context.Context, userID int) (int64, error)
+}
+
+// TenantOptions choose how a request's organization is resolved
+type TenantOptions struct {
+	// Enabled turns on resolution; otherwise every request uses Default
+	Enabled bool
+	// Header names a request header carrying the organization's slug
+	Header string
+	// BaseDomain resolves <slug>.<BaseDomain> hosts to the organization
+	BaseDomain string
+	// Default is the slug used when neither the header nor the host names one
+	Default string
+}
+
+// Tenants scopes each request to an organization and keeps signed-in users
+// out of organizations they do not belong to
+type Tenants struct {
+	orgs    Organizations
+	options TenantOptions
+}
+
+// NewTenants creates the organization middleware
+func NewTenants(orgs Organizations, options TenantOptions) *Tenants {
+	if options.Default == "" {
+		options.Default = tenant.DefaultSlug
+	}
+	return &Tenants{orgs: orgs, options: options}
+}
+
+// Handler resolves the organization from the header, then the subdomain, then
+// the default, and stores it in the request context. It must run after the
+// session and bearer middleware so the caller's membership can be checked.
+// Admins get no exception: they only reach their own organization, and the
+// relationship checks refuse target users outside the request's organization.
+func (t *Tenants) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		org, err := t.orgs.GetBySlug(r.Context(), t.slug(r))
+		if errors.Is(err, tenant.ErrNotFound) {
+			apierror.Respond(w, r, http.StatusNotFound, "organization not found")
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "organization lookup failed", "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "organization lookup failed")
+			return
+		}
+
+		if callerID, ok := CallerID(r); ok {
+			orgID, err := t.orgs.MemberOf(r.Context(), callerID)
+			if err != nil && !errors.Is(err, tenant.ErrNotFound) {
+				logger.ErrorContext(r.Context(), "organization membership lookup failed", "error", err)
+				apierror.Respond(w, r, http.StatusInternalServerError, "organization lookup failed")
+				return
+			}
+			if orgID != org.ID {
+				apierror.Respond(w, r, http.StatusForbidden, "account belongs to another organization")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), org)))
+	})
+}
+
+// slug picks the organization named by the request
+func (t *Tenants) slug(r *http.Request) string {
+	if !t.options.Enabled {
+		return t.options.Default
+	}
+
+	if t.options.Header != "" {
+		if slug := strings.TrimSpace(r.Header.Get(t.options.Header)); slug != "" {
+			return strings.ToLower(slug)
+		}
+	}
+
+	if t.options.BaseDomain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if sub, ok := strings.CutSuffix(host, "."+strings.ToLower(t.options.BaseDomain)); ok && sub != "" {
+			return sub
+		}
+	}
+
+	return t.options.Default
+}
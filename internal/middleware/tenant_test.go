@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// staticOrganizations serves a fixed set of organizations and memberships
+type staticOrganizations struct {
+	orgs    map[string]*tenant.Organization
+	members map[int]int64
+}
+
+func (s staticOrganizations) GetBySlug(ctx context.Context, slug string) (*tenant.Organization, error) {
+	if org, ok := s.orgs[slug]; ok {
+		return org, nil
+	}
+	return nil, tenant.ErrNotFound
+}
+
+func (s staticOrganizations) MemberOf(ctx context.Context, userID int) (int64, error) {
+	if orgID, ok := s.members[userID]; ok {
+		return orgID, nil
+	}
+	return 0, tenant.ErrNotFound
+}
+
+func TestTenants(t *testing.T) {
+	orgs := staticOrganizations{
+		orgs: map[string]*tenant.Organization{
+			"default": {ID: 1, Slug: "default"},
+			"lincoln": {ID: 2, Slug: "lincoln"},
+		},
+		members: map[int]int64{10: 1, 20: 2},
+	}
+	options := TenantOptions{Enabled: true, Header: "X-Organization", BaseDomain: "schools.example.com"}
+
+	tests := []struct {
+		name     string
+		options  TenantOptions
+		host     string
+		header   string
+		caller   int
+		expected int
+		slug     string
+	}{
+		{"default", options, "localhost:5000", "", 0, http.StatusOK, "default"},
+		{"header", options, "localhost:5000", "Lincoln", 0, http.StatusOK, "lincoln"},
+		{"subdomain", options, "lincoln.schools.example.com:443", "", 0, http.StatusOK, "lincoln"},
+		{"base domain", options, "schools.example.com", "", 0, http.StatusOK, "default"},
+		{"unknown organization", options, "nowhere.schools.example.com", "", 0, http.StatusNotFound, ""},
+		{"member", options, "lincoln.schools.example.com", "", 20, http.StatusOK, "lincoln"},
+		{"member of another organization", options, "lincoln.schools.example.com", "", 10, http.StatusForbidden, ""},
+		{"disabled ignores the header", TenantOptions{Header: "X-Organization"}, "localhost", "lincoln", 10, http.StatusOK, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolved string
+			handler := NewTenants(orgs, tt.options).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if org, ok := tenant.FromContext(r.Context()); ok {
+					resolved = org.Slug
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set("X-Organization", tt.header)
+			}
+			if tt.caller != 0 {
+				req = req.WithContext(WithCaller(req.Context(), tt.caller))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if resolved != tt.slug {
+				t.Errorf("Expected organization %q, got %q", tt.slug, resolved)
+			}
+		})
+	}
+}
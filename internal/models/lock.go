@@ -1,7 +1,6 @@
 package models
 
 import (
-	"database/sql"
 	"time"
 )
 
@@ -14,6 +13,9 @@ type Lock struct {
 	PriorityLevel int       `json:"priority_level"`
 	AcquiredAt    time.Time `json:"acquired_at"`
 	ReleasedAt    *time.Time `json:"released_at"`
+	// FencingToken identifies the lease; it increases with every new lease
+	// of the lock, so a write guarded by an older token is stale
+	FencingToken  int64     `json:"fencing_token"`
 }
 
 // LockCreate represents input for acquiring a lock
@@ -34,6 +36,7 @@ type LockStatus struct {
 	AcquiredAt    time.Time  `json:"acquired_at"`
 	ReleasedAt    *time.Time `json:"released_at"`
 	PriorityLevel int        `json:"priority_level"`
+	FencingToken  int64      `json:"fencing_token"`
 }
 
 // IsExpired checks if the lock has expired
@@ -63,6 +66,7 @@ func (l *Lock) ToLockStatus() LockStatus {
 		AcquiredAt:    l.AcquiredAt,
 		ReleasedAt:    l.ReleasedAt,
 		PriorityLevel: l.PriorityLevel,
+		FencingToken:  l.FencingToken,
 	}
 }
 
@@ -73,6 +77,7 @@ type LockWaitResult struct {
 	WaitedMS    int64  `json:"waited_ms"`
 	HolderID    string `json:"holder_id"`
 	ExpiresAt   time.Time `json:"expires_at"`
+	FencingToken int64 `json:"fencing_token"`
 }
 
 // LockMetrics represents metrics about locks
@@ -82,7 +87,10 @@ type LockMetrics struct {
 	ExpiredLocks         int64   `json:"expired_locks"`
 	ReleasedLocks        int64   `json:"released_locks"`
 	AverageHoldTimeMS    float64 `json:"average_hold_time_ms"`
+	// AverageWaitTimeMS is the mean time an acquisition waited for the lock
 	AverageWaitTimeMS    float64 `json:"average_wait_time_ms"`
+	// ContentionRatio is the share of acquisition attempts that found the
+	// lock held by another holder
 	ContentionRatio      float64 `json:"contention_ratio"`
 }
 
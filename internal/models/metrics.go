@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -19,6 +20,32 @@ type Metrics struct {
 	Metadata        sql.NullString `json:"metadata"`
 }
 
+// MarshalJSON renders the nullable columns as their value or null
+func (m Metrics) MarshalJSON() ([]byte, error) {
+	type metrics Metrics
+	return json.Marshal(struct {
+		metrics
+		TaskThroughput *int64          `json:"task_throughput"`
+		AvgLatencyMS   *float64        `json:"avg_latency_ms"`
+		SuccessRate    *float64        `json:"success_rate"`
+		MemoryMB       *float64        `json:"memory_mb"`
+		CPUPercent     *float64        `json:"cpu_percent"`
+		ActiveSessions *int64          `json:"active_sessions"`
+		PendingTasks   *int64          `json:"pending_tasks"`
+		Metadata       json.RawMessage `json:"metadata"`
+	}{
+		metrics:        metrics(m),
+		TaskThroughput: nullInt64(m.TaskThroughput),
+		AvgLatencyMS:   nullFloat64(m.AvgLatencyMS),
+		SuccessRate:    nullFloat64(m.SuccessRate),
+		MemoryMB:       nullFloat64(m.MemoryMB),
+		CPUPercent:     nullFloat64(m.CPUPercent),
+		ActiveSessions: nullInt64(m.ActiveSessions),
+		PendingTasks:   nullInt64(m.PendingTasks),
+		Metadata:       rawJSON(m.Metadata),
+	})
+}
+
 // MetricsCreate represents input for recording metrics
 type MetricsCreate struct {
 	TaskThroughput *int    `json:"task_throughput"`
@@ -101,6 +128,7 @@ type HealthCheckResult struct {
 	Checks            map[string]bool   `json:"checks"`
 	Warnings          []string          `json:"warnings"`
 	Errors            []string          `json:"errors"`
+	Details           map[string]string `json:"details,omitempty"`
 }
 
 // MetricsTimeSeries represents a time series of metric data
@@ -119,6 +147,21 @@ type TimeSeriesPoint struct {
 	Tags      map[string]string `json:"tags,omitempty"`
 }
 
+// TaskThroughput counts the tasks that finished in a period of time
+type TaskThroughput struct {
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	// AvgLatencyMS is the mean time from assignment to completion of the
+	// completed tasks
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+}
+
+// Alert severities, from least to most severe
+const (
+	AlertWarning  = "warning"
+	AlertCritical = "critical"
+)
+
 // AlertThreshold represents thresholds for alerting
 type AlertThreshold struct {
 	MetricName  string  `json:"metric_name"`
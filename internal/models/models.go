@@ -30,6 +30,7 @@ models package provides:
    - SystemHealth: Overall system health status
    - PerformanceReport: Detailed performance analysis
    - AlertThreshold: Alert configuration
+   - MetricsAlert: Alert opened when a threshold is crossed
 
 5. Common Types (types.go)
    - Response: Standard API response wrapper
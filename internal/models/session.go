@@ -117,6 +117,21 @@ func (st SessionType) String() string {
 	return string(st)
 }
 
+// MarshalJSON renders the nullable columns as their value or null, and the
+// metrics as the JSON they hold
+func (s Session) MarshalJSON() ([]byte, error) {
+	type session Session
+	return json.Marshal(struct {
+		session
+		CurrentTaskID *int64          `json:"current_task_id"`
+		MetricsJSON   json.RawMessage `json:"metrics_json"`
+	}{
+		session:       session(s),
+		CurrentTaskID: nullInt64(s.CurrentTaskID),
+		MetricsJSON:   rawJSON(s.MetricsJSON),
+	})
+}
+
 // MarshalJSON converts SessionState to JSON
 func (s SessionState) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(s))
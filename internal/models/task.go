@@ -102,6 +102,23 @@ func (p TaskPriority) String() string {
 	}
 }
 
+// MarshalJSON renders the nullable columns as their value or null, and the
+// metadata as the JSON it holds
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	return json.Marshal(struct {
+		task
+		TargetSession *string         `json:"target_session"`
+		ErrorMessage  *string         `json:"error_message"`
+		Metadata      json.RawMessage `json:"metadata"`
+	}{
+		task:          task(t),
+		TargetSession: nullString(t.TargetSession),
+		ErrorMessage:  nullString(t.ErrorMessage),
+		Metadata:      rawJSON(t.Metadata),
+	})
+}
+
 // MarshalJSON converts TaskStatus to JSON
 func (s TaskStatus) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(s))
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -225,3 +227,40 @@ func NewNotification(notifType, target, subject, body string) Notification {
 		CreatedAt: time.Now(),
 	}
 }
+
+// nullString returns the value of a nullable column, or nil for NULL
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// nullInt64 returns the value of a nullable column, or nil for NULL
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// nullFloat64 returns the value of a nullable column, or nil for NULL
+func nullFloat64(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+// rawJSON returns a column holding JSON as raw JSON, quoting it as a string
+// when it is not valid JSON and returning nil for NULL
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	if json.Valid([]byte(s.String)) {
+		return json.RawMessage(s.String)
+	}
+	quoted, _ := json.Marshal(s.String)
+	return quoted
+}
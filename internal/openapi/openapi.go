@@ -0,0 +1,273 @@
+// Package openapi embeds the OpenAPI 3 description of the HTTP API, serves it
+// as JSON with a browsable viewer, and validates incoming requests against it.
+//
+// openapi.yaml is the source of truth for routes, parameters and request
+// bodies. A router test fails when a chi route has no entry here, so the
+// document cannot drift from the code.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// Document is the part of an OpenAPI document needed to route and validate
+// requests. The full document, including responses, is kept for serving.
+type Document struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+
+	json []byte
+}
+
+// Components holds the reusable parameters and schemas that $ref points to
+type Components struct {
+	Parameters map[string]*Parameter `yaml:"parameters"`
+	Schemas    map[string]*Schema    `yaml:"schemas"`
+}
+
+// PathItem lists the operations on one path template
+type PathItem struct {
+	Get    *Operation `yaml:"get"`
+	Post   *Operation `yaml:"post"`
+	Put    *Operation `yaml:"put"`
+	Patch  *Operation `yaml:"patch"`
+	Delete *Operation `yaml:"delete"`
+}
+
+// Operations returns the path's operations keyed by HTTP method
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		http.MethodGet:    p.Get,
+		http.MethodPost:   p.Post,
+		http.MethodPut:    p.Put,
+		http.MethodPatch:  p.Patch,
+		http.MethodDelete: p.Delete,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// Operation is one method on a path
+type Operation struct {
+	OperationID string       `yaml:"operationId"`
+	Summary     string       `yaml:"summary"`
+	Tags        []string     `yaml:"tags"`
+	Parameters  []*Parameter `yaml:"parameters"`
+	RequestBody *RequestBody `yaml:"requestBody"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+// RequestBody describes the accepted body per media type
+type RequestBody struct {
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType holds the schema of one request body media type
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Load parses the embedded document
+func Load() (*Document, error) {
+	return Parse(specYAML)
+}
+
+// MustLoad parses the embedded document and panics if it is invalid. The
+// document is checked by the package tests, so a failure is a build defect.
+func MustLoad() *Document {
+	doc, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Parse reads an OpenAPI document in YAML, resolves its $refs and renders
+// the JSON served at /openapi.json
+func Parse(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+	if err := doc.resolve(); err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	encoded, err := json.Marshal(jsonValue(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	doc.json = encoded
+
+	return doc, nil
+}
+
+// Operation returns the operation for method on the exact path template,
+// such as "/auth/tokens/{id}", or nil
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item.Operations()[method]
+}
+
+// Handler serves the document as JSON
+func (d *Document) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(d.json)
+	}
+}
+
+// ViewerHandler serves an HTML page that renders /openapi.json and can send
+// requests to the documented operations
+func ViewerHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(viewerHTML)
+	}
+}
+
+// resolve replaces parameter and schema $refs with the components they name
+// and compiles schema patterns
+func (d *Document) resolve() error {
+	r := &resolver{doc: d, done: make(map[*Schema]bool)}
+
+	for _, schema := range d.Components.Schemas {
+		if err := r.schema(&schema); err != nil {
+			return err
+		}
+	}
+	for path, item := range d.Paths {
+		for method, op := range item.Operations() {
+			where := method + " " + path
+			for i := range op.Parameters {
+				if err := r.parameter(&op.Parameters[i]); err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+			}
+			if op.RequestBody == nil {
+				continue
+			}
+			for _, media := range op.RequestBody.Content {
+				if err := r.schema(&media.Schema); err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type resolver struct {
+	doc  *Document
+	done map[*Schema]bool
+}
+
+func (r *resolver) parameter(p **Parameter) error {
+	if ref := (*p).Ref; ref != "" {
+		name := strings.TrimPrefix(ref, "#/components/parameters/")
+		target, ok := r.doc.Components.Parameters[name]
+		if name == ref || !ok {
+			return fmt.Errorf("unknown parameter $ref %q", ref)
+		}
+		*p = target
+	}
+	if (*p).Name == "" || ((*p).In != "path" && (*p).In != "query" && (*p).In != "header") {
+		return fmt.Errorf("parameter %q must be in path, query or header", (*p).Name)
+	}
+	return r.schema(&(*p).Schema)
+}
+
+func (r *resolver) schema(s **Schema) error {
+	if *s == nil {
+		return nil
+	}
+	if ref := (*s).Ref; ref != "" {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target, ok := r.doc.Components.Schemas[name]
+		if name == ref || !ok {
+			return fmt.Errorf("unknown schema $ref %q", ref)
+		}
+		*s = target
+	}
+	if r.done[*s] {
+		return nil
+	}
+	r.done[*s] = true
+
+	if (*s).Pattern != "" {
+		re, err := regexp.Compile((*s).Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern %q: %w", (*s).Pattern, err)
+		}
+		(*s).pattern = re
+	}
+	for name := range (*s).Properties {
+		prop := (*s).Properties[name]
+		if err := r.schema(&prop); err != nil {
+			return err
+		}
+		(*s).Properties[name] = prop
+	}
+	return r.schema(&(*s).Items)
+}
+
+// jsonValue converts decoded YAML into values encoding/json accepts
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = jsonValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,282 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("embedded document is invalid: %v", err)
+	}
+
+	ids := make(map[string]string)
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			where := method + " " + path
+			if op.OperationID == "" {
+				t.Errorf("%s has no operationId", where)
+			} else if other, dup := ids[op.OperationID]; dup {
+				t.Errorf("%s and %s share operationId %q", where, other, op.OperationID)
+			}
+			ids[op.OperationID] = where
+
+			for _, p := range op.Parameters {
+				if p.Ref != "" || p.Schema == nil {
+					t.Errorf("%s: parameter %q is unresolved", where, p.Name)
+				}
+				if p.In == "path" && !strings.Contains(path, "{"+p.Name+"}") {
+					t.Errorf("%s: path parameter %q is not in the template", where, p.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestParseRejectsUnknownRef(t *testing.T) {
+	_, err := Parse([]byte(`
+openapi: 3.0.3
+paths:
+  /things/{id}:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/Missing'
+`))
+	if err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("err = %v, want unknown $ref error", err)
+	}
+}
+
+func TestHandlerServesJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	MustLoad().Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var doc struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/piano/api/songs"]["post"]; !ok {
+		t.Errorf("POST /piano/api/songs missing from served document")
+	}
+}
+
+func TestValidatorMatch(t *testing.T) {
+	v := NewValidator(MustLoad())
+
+	tests := []struct {
+		method, path string
+		wantID       string
+		wantParams   map[string]string
+	}{
+		{"POST", "/piano/api/midi/upload", "uploadMIDI", map[string]string{}},
+		{"GET", "/piano/api/midi/42", "getMIDI", map[string]string{"sessionId": "42"}},
+		{"GET", "/math/api/api/users/7/math/stats", "getMathStats", map[string]string{"userId": "7"}},
+		{"GET", "/groups/", "listGroups", map[string]string{}},
+		{"GET", "/groups", "", nil},
+		{"DELETE", "/piano/api/songs/1", "", nil},
+		{"GET", "/piano/api/songs/", "", nil},
+	}
+
+	for _, tt := range tests {
+		op, params := v.match(tt.method, tt.path)
+		if tt.wantID == "" {
+			if op != nil {
+				t.Errorf("%s %s matched %s, want no match", tt.method, tt.path, op.OperationID)
+			}
+			continue
+		}
+		if op == nil || op.OperationID != tt.wantID {
+			t.Errorf("%s %s matched %v, want %s", tt.method, tt.path, op, tt.wantID)
+			continue
+		}
+		if !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("%s %s params = %v, want %v", tt.method, tt.path, params, tt.wantParams)
+		}
+	}
+}
+
+func TestValidatorHandler(t *testing.T) {
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := NewValidator(MustLoad()).Handler(next)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		header      http.Header
+		body        string
+		wantStatus  int
+		wantCode    apierror.Code
+		wantErrors  []apierror.FieldError
+	}{
+		{
+			name:       "valid body reaches handler intact",
+			method:     http.MethodPost,
+			target:     "/math/api/api/users/3/math/process-review",
+			body:       `{"fact":"3+4","mode":"addition","quality":4}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "body constraints",
+			method:     http.MethodPost,
+			target:     "/math/api/api/users/3/math/process-review",
+			body:       `{"mode":"modulo","quality":9}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{
+				{Field: "body.fact", Message: "is required"},
+				{Field: "body.mode", Message: "must be one of [addition subtraction multiplication division mixed]"},
+				{Field: "body.quality", Message: "must be at most 5"},
+			},
+		},
+		{
+			name:       "path and query params",
+			method:     http.MethodGet,
+			target:     "/math/api/api/users/0/math/weak-areas?limit=ten",
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{
+				{Field: "path.userId", Message: "must be at least 1"},
+				{Field: "query.limit", Message: "must be an integer"},
+			},
+		},
+		{
+			name:       "required query param",
+			method:     http.MethodGet,
+			target:     "/math/api/api/math/detect-family",
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{{Field: "query.question", Message: "is required"}},
+		},
+		{
+			name:       "header param",
+			method:     http.MethodPost,
+			target:     "/piano/api/practice",
+			header:     http.Header{"Idempotency-Key": {strings.Repeat("k", 256)}},
+			body:       `{"user_id":1,"song_id":2}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{{Field: "header.Idempotency-Key", Message: "must be at most 255 characters"}},
+		},
+		{
+			name:       "wrong types and nested items",
+			method:     http.MethodPost,
+			target:     "/auth/tokens",
+			body:       `{"name":"ci","scopes":["math:read","root"],"expires_in_days":"7"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{
+				{Field: "body.expires_in_days", Message: "must be an integer"},
+				{Field: "body.scopes[1]", Message: "must be one of [math:read math:write reading:read reading:write typing:read typing:write piano:read piano:write groups:read groups:write gaia:read gaia:write stats:read]"},
+			},
+		},
+		{
+			name:       "exclusive minimum",
+			method:     http.MethodPost,
+			target:     "/typing/api/typing/test",
+			body:       `{"user_id":1,"content":"abc","duration":0}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{{Field: "body.duration", Message: "must be greater than 0"}},
+		},
+		{
+			name:       "missing body",
+			method:     http.MethodPost,
+			target:     "/groups/join",
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{{Field: "body", Message: "is required"}},
+		},
+		{
+			name:       "invalid JSON",
+			method:     http.MethodPost,
+			target:     "/groups/join",
+			body:       `{"join_code":`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []apierror.FieldError{{Field: "body", Message: "must be valid JSON"}},
+		},
+		{
+			name:        "unsupported media type",
+			method:      http.MethodPost,
+			target:      "/groups/join",
+			contentType: "text/plain",
+			body:        "ABC123",
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    apierror.CodeUnsupportedMediaType,
+		},
+		{
+			name:        "multipart is left to the handler",
+			method:      http.MethodPost,
+			target:      "/piano/api/midi/upload",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "--x--",
+			wantStatus:  http.StatusNoContent,
+		},
+		{
+			name:       "undocumented route passes through",
+			method:     http.MethodGet,
+			target:     "/static/app.js",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBody = ""
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusNoContent {
+				if gotBody != tt.body {
+					t.Errorf("handler body = %q, want %q", gotBody, tt.body)
+				}
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var resp struct {
+				Status int                   `json:"status"`
+				Code   apierror.Code         `json:"code"`
+				Errors []apierror.FieldError `json:"errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			wantCode := tt.wantCode
+			if wantCode == "" {
+				wantCode = apierror.CodeValidationFailed
+			}
+			if resp.Status != tt.wantStatus || resp.Code != wantCode || !reflect.DeepEqual(resp.Errors, tt.wantErrors) {
+				t.Errorf("response = %+v, want code %s errors %+v", resp, wantCode, tt.wantErrors)
+			}
+		})
+	}
+}
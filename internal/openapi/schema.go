@@ -0,0 +1,215 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+)
+
+// Schema is the subset of JSON Schema used by the document: types, required
+// properties, enums, numeric bounds, string lengths and patterns, and array
+// items. Keywords outside the subset are ignored.
+type Schema struct {
+	Ref              string             `yaml:"$ref"`
+	Type             string             `yaml:"type"`
+	Format           string             `yaml:"format"`
+	Enum             []interface{}      `yaml:"enum"`
+	Minimum          *float64           `yaml:"minimum"`
+	Maximum          *float64           `yaml:"maximum"`
+	ExclusiveMinimum bool               `yaml:"exclusiveMinimum"`
+	ExclusiveMaximum bool               `yaml:"exclusiveMaximum"`
+	MinLength        *int               `yaml:"minLength"`
+	MaxLength        *int               `yaml:"maxLength"`
+	Pattern          string             `yaml:"pattern"`
+	MinItems         *int               `yaml:"minItems"`
+	MaxItems         *int               `yaml:"maxItems"`
+	Required         []string           `yaml:"required"`
+	Properties       map[string]*Schema `yaml:"properties"`
+	Items            *Schema            `yaml:"items"`
+
+	pattern *regexp.Regexp
+}
+
+// Validate checks a value decoded by encoding/json with UseNumber and
+// returns one problem per violation, with fields named from where.
+func (s *Schema) Validate(where string, value interface{}) []apierror.FieldError {
+	var problems []apierror.FieldError
+	s.validate(where, value, &problems)
+	return problems
+}
+
+func (s *Schema) validate(where string, value interface{}, problems *[]apierror.FieldError) {
+	if s == nil || value == nil {
+		// JSON null decodes to the zero value, as if the field were absent
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, apierror.FieldError{Field: where, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if v, ok := obj[name]; !ok || v == nil {
+				*problems = append(*problems, apierror.FieldError{Field: join(where, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(join(where, name), obj[name], problems)
+			}
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range items {
+			s.Items.validate(fmt.Sprintf("%s[%d]", where, i), item, problems)
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("must match %s", s.Pattern)
+		}
+
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			fail("must be %s", article(s.Type))
+			return
+		}
+		f, err := num.Float64()
+		if err != nil {
+			fail("must be %s", article(s.Type))
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := num.Int64(); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		s.validateBounds(f, fail)
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		fail("must be one of %v", s.Enum)
+	}
+}
+
+func (s *Schema) validateBounds(f float64, fail func(string, ...interface{})) {
+	if lo := s.Minimum; lo != nil {
+		if s.ExclusiveMinimum && f <= *lo {
+			fail("must be greater than %v", *lo)
+		} else if f < *lo {
+			fail("must be at least %v", *lo)
+		}
+	}
+	if hi := s.Maximum; hi != nil {
+		if s.ExclusiveMaximum && f >= *hi {
+			fail("must be less than %v", *hi)
+		} else if f > *hi {
+			fail("must be at most %v", *hi)
+		}
+	}
+}
+
+// inEnum compares value with the enum members by their string form, so a
+// YAML int matches a json.Number
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, member := range s.Enum {
+		if fmt.Sprint(member) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse converts a path or query string to the value Validate expects for
+// the schema type
+func (s *Schema) parse(raw string) (interface{}, bool) {
+	if s == nil {
+		return raw, true
+	}
+	switch s.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(raw), true
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(raw), true
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, false
+		}
+		return b, true
+	default:
+		return raw, true
+	}
+}
+
+func join(where, name string) string {
+	if where == "" {
+		return name
+	}
+	return where + "." + name
+}
+
+// article prefixes a schema type with "a" or "an" for messages
+func article(typ string) string {
+	if typ == "integer" || typ == "object" || typ == "array" {
+		return "an " + typ
+	}
+	return "a " + typ
+}
@@ -0,0 +1,223 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
+)
+
+// logger is the openapi package logger
+var logger = logging.Logger("openapi")
+
+// MaxBodyBytes bounds the JSON bodies the validator buffers. Multipart
+// uploads are not buffered; their limits are enforced by the handlers.
+const MaxBodyBytes = 10 << 20
+
+// Validator rejects requests whose path parameters, query parameters or
+// JSON body do not match the document. Requests for paths or methods the
+// document does not describe pass through, so chi still answers 404 and 405.
+type Validator struct {
+	routes []*route
+}
+
+// route is a compiled path template
+type route struct {
+	segments []string // "{name}" marks a parameter
+	literals int
+	item     *PathItem
+}
+
+// NewValidator compiles the path templates of doc
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{}
+	for template, item := range doc.Paths {
+		rt := &route{
+			segments: strings.Split(strings.TrimPrefix(template, "/"), "/"),
+			item:     item,
+		}
+		for _, seg := range rt.segments {
+			if !isParam(seg) {
+				rt.literals++
+			}
+		}
+		v.routes = append(v.routes, rt)
+	}
+	return v
+}
+
+// Handler validates requests before they reach next. Invalid requests get
+// 400 VALIDATION_FAILED with one entry per problem in "errors".
+func (v *Validator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, params := v.match(r.Method, r.URL.Path)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		problems := validateParams(op, params, r)
+
+		bodyProblems, err := validateBody(op, r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		problems = append(problems, bodyProblems...)
+
+		if len(problems) > 0 {
+			logger.InfoContext(r.Context(), "request failed validation",
+				"operation", op.OperationID, "problems", problems)
+			apierror.Write(w, r, apierror.Validation(summarize(problems), problems...))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// summarize joins problems into one human-readable detail
+func summarize(problems []apierror.FieldError) string {
+	parts := make([]string, len(problems))
+	for i, p := range problems {
+		parts[i] = p.Field + " " + p.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// match finds the operation for a request path. Literal segments win over
+// parameters, so /piano/api/midi/upload is not read as a session ID.
+func (v *Validator) match(method, path string) (*Operation, map[string]string) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	var best *route
+	for _, rt := range v.routes {
+		if rt.item.Operations()[method] == nil || !rt.matches(segments) {
+			continue
+		}
+		if best == nil || rt.literals > best.literals {
+			best = rt
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	for i, seg := range best.segments {
+		if isParam(seg) {
+			params[seg[1:len(seg)-1]] = segments[i]
+		}
+	}
+	return best.item.Operations()[method], params
+}
+
+func (rt *route) matches(segments []string) bool {
+	if len(segments) != len(rt.segments) {
+		return false
+	}
+	for i, seg := range rt.segments {
+		if isParam(seg) {
+			if segments[i] == "" {
+				return false
+			}
+		} else if seg != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// validateParams checks path, query and header parameters in document order
+func validateParams(op *Operation, pathParams map[string]string, r *http.Request) []apierror.FieldError {
+	var problems []apierror.FieldError
+	query := r.URL.Query()
+
+	for _, p := range op.Parameters {
+		var raw string
+		switch p.In {
+		case "path":
+			raw = pathParams[p.Name]
+		case "query":
+			raw = query.Get(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+		}
+		where := p.In + "." + p.Name
+
+		if raw == "" {
+			if p.Required {
+				problems = append(problems, apierror.FieldError{Field: where, Message: "is required"})
+			}
+			continue
+		}
+
+		value, ok := p.Schema.parse(raw)
+		if !ok {
+			problems = append(problems, apierror.FieldError{Field: where, Message: "must be " + article(p.Schema.Type)})
+			continue
+		}
+		problems = append(problems, p.Schema.Validate(where, value)...)
+	}
+	return problems
+}
+
+// validateBody checks a JSON body against the operation's schema and puts
+// the bytes back for the handler. An error means the body could not be
+// validated at all and the request must be rejected with it.
+func validateBody(op *Operation, r *http.Request) ([]apierror.FieldError, *apierror.Error) {
+	body := op.RequestBody
+	if body == nil {
+		return nil, nil
+	}
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return nil, apierror.New(http.StatusUnsupportedMediaType, "invalid Content-Type "+ct)
+		}
+		mediaType = parsed
+	}
+	media, ok := body.Content[mediaType]
+	if !ok {
+		return nil, apierror.New(http.StatusUnsupportedMediaType, "unsupported Content-Type "+mediaType)
+	}
+	if mediaType != "application/json" {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, apierror.New(http.StatusBadRequest, "failed to read request body")
+	}
+	if len(data) > MaxBodyBytes {
+		return nil, apierror.New(http.StatusRequestEntityTooLarge, "request body is too large")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return []apierror.FieldError{{Field: "body", Message: "is required"}}, nil
+		}
+		return nil, nil
+	}
+
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return []apierror.FieldError{{Field: "body", Message: "must be valid JSON"}}, nil
+	}
+	return media.Schema.Validate("body", value), nil
+}
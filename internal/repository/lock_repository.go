@@ -2,20 +2,34 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"unified-go/internal/models"
-	"unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/storage"
 )
 
-// LockRepository defines the interface for lock data access
+// LockRepository defines the interface for lock data access. A lock is a
+// lease: it lasts until it expires unless its holder extends it, and every
+// new lease of a lock gets a higher fencing token than the one before.
 type LockRepository interface {
-	// AcquireLock acquires a distributed lock
+	// AcquireLock takes a lock, or renews it when the holder already has it.
+	// A holder asking with a higher priority than the current one's preempts
+	// it.
 	AcquireLock(ctx context.Context, lockID, holderID string, duration time.Duration, priority int) (*models.Lock, error)
 
-	// ReleaseLock releases a held lock
-	ReleaseLock(ctx context.Context, lockID, holderID string) error
+	// ExtendLock renews the lease identified by its fencing token
+	ExtendLock(ctx context.Context, lockID, holderID string, token int64, duration time.Duration) (*models.Lock, error)
+
+	// ValidateFencingToken checks that a holder's token is the lock's
+	// current lease before a write the lock protects
+	ValidateFencingToken(ctx context.Context, lockID, holderID string, token int64) error
+
+	// ReleaseLock releases the lease identified by its fencing token
+	ReleaseLock(ctx context.Context, lockID, holderID string, token int64) error
 
 	// GetLock retrieves a lock by ID
 	GetLock(ctx context.Context, lockID string) (*models.Lock, error)
@@ -26,8 +40,9 @@ type LockRepository interface {
 	// IsLockAvailable checks if a lock can be acquired
 	IsLockAvailable(ctx context.Context, lockID string) (bool, error)
 
-	// WaitForLock waits for a lock to become available
-	WaitForLock(ctx context.Context, lockID, holderID string, timeout time.Duration) (*models.LockWaitResult, error)
+	// WaitForLock waits up to timeout for a lock, behind the holders that
+	// started waiting for it earlier, and takes it for duration
+	WaitForLock(ctx context.Context, lockID, holderID string, duration, timeout time.Duration, priority int) (*models.LockWaitResult, error)
 
 	// CleanupExpiredLocks removes expired locks
 	CleanupExpiredLocks(ctx context.Context) (int64, error)
@@ -41,78 +56,185 @@ type LockRepository interface {
 
 // lockRepository implements LockRepository
 type lockRepository struct {
-	store *storage.SQLiteStore
+	store storage.Querier
+	*lockQueue
+}
+
+// lockQueue queues the holders waiting for each lock, first come first
+// served. Releases made through the repositories sharing the queue wake the
+// head of it; otherwise it retries when the current lease runs out. The
+// queue lives in this process only: it is lost on restart and not shared with
+// other instances, whose waiters fall back to retrying at lease expiry and
+// may overtake the ones queued here.
+type lockQueue struct {
+	mu      sync.Mutex
+	waiters map[string][]*lockWaiter
 }
 
+// lockWaiter is a holder queued for a lock
+type lockWaiter struct {
+	// wake is signalled when the waiter reaches the head of the queue or
+	// the lock is released
+	wake chan struct{}
+}
+
+// acquireOutcome says how acquire got a lock
+type acquireOutcome int
+
+const (
+	lockGranted acquireOutcome = iota
+	lockRenewed
+	lockPreempted
+)
+
 // NewLockRepository creates a new lock repository
-func NewLockRepository(store *storage.SQLiteStore) LockRepository {
-	return &lockRepository{store: store}
+func NewLockRepository(store storage.Querier) LockRepository {
+	return &lockRepository{store: store, lockQueue: newLockQueue()}
+}
+
+// newLockQueue creates an empty wait queue
+func newLockQueue() *lockQueue {
+	return &lockQueue{waiters: make(map[string][]*lockWaiter)}
 }
 
 func (r *lockRepository) AcquireLock(ctx context.Context, lockID, holderID string, duration time.Duration, priority int) (*models.Lock, error) {
-	expiresAt := time.Now().Add(duration)
-
-	err := r.store.Transaction(ctx, func(tx interface{}) error {
-		// Check if lock exists and is still valid
-		row := r.store.QueryRow(ctx,
-			`SELECT id FROM locks WHERE lock_id = ? AND released_at IS NULL AND expires_at > datetime('now')`,
-			lockID)
-
-		var existingID int64
-		err := row.Scan(&existingID)
-		if err == nil {
-			// Lock is held by someone else
-			return fmt.Errorf("lock already held")
+	outcome, err := r.acquire(ctx, lockID, holderID, duration, priority, r.queued(lockID))
+	switch {
+	case errors.Is(err, ErrLockHeld):
+		if err := r.recordAttempt(ctx, lockID, true, false, 0); err != nil {
+			return nil, err
 		}
+		return nil, ErrLockHeld
+	case err != nil:
+		return nil, err
+	case outcome != lockRenewed:
+		if err := r.recordAttempt(ctx, lockID, outcome == lockPreempted, true, 0); err != nil {
+			return nil, err
+		}
+	}
 
-		// Acquire or reacquire the lock
-		_, err = r.store.Exec(ctx,
-			`INSERT OR REPLACE INTO locks (lock_id, holder_id, expires_at, priority_level, acquired_at)
-			 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
-			lockID, holderID, expiresAt, priority)
+	// Return the acquired lock
+	return r.GetLock(ctx, lockID)
+}
+
+// acquire takes or renews a lock in one transaction. A free lock that
+// holders are queued for is reserved for the head of the queue.
+func (r *lockRepository) acquire(ctx context.Context, lockID, holderID string, duration time.Duration, priority int, reserved bool) (acquireOutcome, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(duration)
+	outcome := lockGranted
+
+	err := r.store.Transaction(ctx, func(tx *sql.Tx) error {
+		// A live lock can only be renewed by its holder, or preempted by a
+		// holder with a higher priority
+		var currentHolder string
+		var currentPriority int
+		err := tx.QueryRowContext(ctx,
+			`SELECT holder_id, priority_level FROM locks WHERE lock_id = ? AND released_at IS NULL AND expires_at > ?`,
+			lockID, now).Scan(&currentHolder, &currentPriority)
+		switch {
+		case err == nil && currentHolder == holderID:
+			// Renewing keeps the lease and its fencing token
+			outcome = lockRenewed
+			_, err = tx.ExecContext(ctx,
+				`UPDATE locks SET expires_at = ?, priority_level = ? WHERE lock_id = ?`,
+				expiresAt, priority, lockID)
+			if err != nil {
+				return fmt.Errorf("failed to renew lock: %w", err)
+			}
+			return nil
+		case err == nil && priority <= currentPriority:
+			return ErrLockHeld
+		case err == nil:
+			outcome = lockPreempted
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("failed to check lock: %w", err)
+		case reserved:
+			return ErrLockHeld
+		}
+
+		// A new lease gets the lock's next fencing token
+		var token int64
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO lock_stats (lock_id, last_fencing_token) VALUES (?, 1)
+			 ON CONFLICT (lock_id) DO UPDATE SET last_fencing_token = last_fencing_token + 1
+			 RETURNING last_fencing_token`,
+			lockID).Scan(&token)
+		if err != nil {
+			return fmt.Errorf("failed to issue fencing token: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO locks (lock_id, holder_id, expires_at, priority_level, acquired_at, fencing_token)
+			 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`,
+			lockID, holderID, expiresAt, priority, token)
 		if err != nil {
 			return fmt.Errorf("failed to acquire lock: %w", err)
 		}
 
 		return nil
 	})
+	return outcome, err
+}
 
+func (r *lockRepository) ExtendLock(ctx context.Context, lockID, holderID string, token int64, duration time.Duration) (*models.Lock, error) {
+	now := time.Now().UTC()
+	result, err := r.store.Exec(ctx,
+		`UPDATE locks SET expires_at = ?
+		 WHERE lock_id = ? AND holder_id = ? AND fencing_token = ? AND released_at IS NULL AND expires_at > ?`,
+		now.Add(duration), lockID, holderID, token, now)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to extend lock: %w", err)
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrStaleFencingToken
+	}
+	return r.GetLock(ctx, lockID)
+}
 
-	// Return the acquired lock
-	row := r.store.QueryRow(ctx,
-		`SELECT id, lock_id, holder_id, expires_at, priority_level, acquired_at, released_at FROM locks WHERE lock_id = ?`,
-		lockID)
-
-	lock := &models.Lock{}
-	err = row.Scan(&lock.ID, &lock.LockID, &lock.HolderID, &lock.ExpiresAt, &lock.PriorityLevel, &lock.AcquiredAt, &lock.ReleasedAt)
+func (r *lockRepository) ValidateFencingToken(ctx context.Context, lockID, holderID string, token int64) error {
+	var count int
+	err := r.store.QueryRow(ctx,
+		`SELECT COUNT(*) FROM locks
+		 WHERE lock_id = ? AND holder_id = ? AND fencing_token = ? AND released_at IS NULL AND expires_at > ?`,
+		lockID, holderID, token, time.Now().UTC()).Scan(&count)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve acquired lock: %w", err)
+		return fmt.Errorf("failed to validate fencing token: %w", err)
 	}
-
-	return lock, nil
+	if count == 0 {
+		return ErrStaleFencingToken
+	}
+	return nil
 }
 
-func (r *lockRepository) ReleaseLock(ctx context.Context, lockID, holderID string) error {
-	_, err := r.store.Exec(ctx,
-		`UPDATE locks SET released_at = CURRENT_TIMESTAMP WHERE lock_id = ? AND holder_id = ?`,
-		lockID, holderID)
+func (r *lockRepository) ReleaseLock(ctx context.Context, lockID, holderID string, token int64) error {
+	result, err := r.store.Exec(ctx,
+		`UPDATE locks SET released_at = CURRENT_TIMESTAMP
+		 WHERE lock_id = ? AND holder_id = ? AND fencing_token = ? AND released_at IS NULL`,
+		lockID, holderID, token)
 	if err != nil {
 		return fmt.Errorf("failed to release lock: %w", err)
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLockNotHeld
+	}
+
+	r.wake(lockID)
 	return nil
 }
 
 func (r *lockRepository) GetLock(ctx context.Context, lockID string) (*models.Lock, error) {
 	row := r.store.QueryRow(ctx,
-		`SELECT id, lock_id, holder_id, expires_at, priority_level, acquired_at, released_at FROM locks WHERE lock_id = ?`,
+		`SELECT id, lock_id, holder_id, expires_at, priority_level, acquired_at, released_at, fencing_token
+		 FROM locks WHERE lock_id = ?`,
 		lockID)
 
 	lock := &models.Lock{}
-	err := row.Scan(&lock.ID, &lock.LockID, &lock.HolderID, &lock.ExpiresAt, &lock.PriorityLevel, &lock.AcquiredAt, &lock.ReleasedAt)
+	err := row.Scan(&lock.ID, &lock.LockID, &lock.HolderID, &lock.ExpiresAt, &lock.PriorityLevel, &lock.AcquiredAt, &lock.ReleasedAt, &lock.FencingToken)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to get lock: %w", err)
 	}
 
@@ -141,6 +263,7 @@ func (r *lockRepository) GetLockStatus(ctx context.Context, lockID string) (*mod
 		AcquiredAt:    lock.AcquiredAt,
 		ReleasedAt:    lock.ReleasedAt,
 		PriorityLevel: lock.PriorityLevel,
+		FencingToken:  lock.FencingToken,
 	}, nil
 }
 
@@ -153,49 +276,195 @@ func (r *lockRepository) IsLockAvailable(ctx context.Context, lockID string) (bo
 	return !status.IsLocked, nil
 }
 
-func (r *lockRepository) WaitForLock(ctx context.Context, lockID, holderID string, timeout time.Duration) (*models.LockWaitResult, error) {
+func (r *lockRepository) WaitForLock(ctx context.Context, lockID, holderID string, duration, timeout time.Duration, priority int) (*models.LockWaitResult, error) {
 	startTime := time.Now()
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
+	waiter := r.enqueue(lockID)
+	defer r.dequeue(lockID, waiter)
 
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	contended := false
 	for {
+		// Only the head of the queue tries to take the lock; the others wait
+		// for their turn
+		var retry *time.Timer
+		if r.isHead(lockID, waiter) {
+			outcome, err := r.acquire(ctx, lockID, holderID, duration, priority, false)
+			if err == nil {
+				waited := time.Since(startTime)
+				if outcome != lockRenewed {
+					if err := r.recordAttempt(ctx, lockID, contended || outcome == lockPreempted, true, waited); err != nil {
+						return nil, err
+					}
+				}
+				lock, err := r.GetLock(ctx, lockID)
+				if err != nil {
+					return nil, err
+				}
+				return &models.LockWaitResult{
+					LockID:       lockID,
+					Acquired:     true,
+					WaitedMS:     waited.Milliseconds(),
+					HolderID:     holderID,
+					ExpiresAt:    lock.ExpiresAt,
+					FencingToken: lock.FencingToken,
+				}, nil
+			}
+			if !errors.Is(err, ErrLockHeld) {
+				return nil, err
+			}
+
+			// Held: try again when the current lease runs out, unless a
+			// release wakes the waiter first
+			untilExpiry, err := r.untilExpiry(ctx, lockID)
+			if err != nil {
+				return nil, err
+			}
+			retry = time.NewTimer(untilExpiry)
+		}
+		contended = true
+
+		var retryC <-chan time.Time
+		if retry != nil {
+			retryC = retry.C
+		}
 		select {
-		case <-ctx.Done():
+		case <-waiter.wake:
+		case <-retryC:
+		case <-deadline.C:
+			// Only acquisitions count towards the average wait
+			if err := r.recordAttempt(ctx, lockID, true, false, 0); err != nil {
+				return nil, err
+			}
 			return &models.LockWaitResult{
 				LockID:   lockID,
 				Acquired: false,
-				WaitedMS: int64(time.Since(startTime).Milliseconds()),
-			}, ctx.Err()
-
-		case <-time.After(timeout):
+				WaitedMS: time.Since(startTime).Milliseconds(),
+			}, ErrLockWaitTimeout
+		case <-ctx.Done():
 			return &models.LockWaitResult{
 				LockID:   lockID,
 				Acquired: false,
-				WaitedMS: int64(timeout.Milliseconds()),
-			}, fmt.Errorf("lock acquisition timeout")
+				WaitedMS: time.Since(startTime).Milliseconds(),
+			}, ctx.Err()
+		}
+		if retry != nil {
+			retry.Stop()
+		}
+	}
+}
 
-		case <-ticker.C:
-			available, err := r.IsLockAvailable(ctx, lockID)
-			if err != nil {
-				return nil, err
-			}
+// untilExpiry returns how long the current lease of a lock has left
+func (r *lockRepository) untilExpiry(ctx context.Context, lockID string) (time.Duration, error) {
+	lock, err := r.GetLock(ctx, lockID)
+	if err != nil {
+		return 0, err
+	}
+	// Wait at least a little so a lease expiring now is not polled
+	remaining := 10 * time.Millisecond
+	if lock != nil && lock.ReleasedAt == nil {
+		if until := time.Until(lock.ExpiresAt); until > remaining {
+			remaining = until
+		}
+	}
+	return remaining, nil
+}
 
-			if available {
-				lock, err := r.AcquireLock(ctx, lockID, holderID, timeout, 5)
-				if err == nil && lock != nil {
-					return &models.LockWaitResult{
-						LockID:    lockID,
-						Acquired:  true,
-						WaitedMS:  int64(time.Since(startTime).Milliseconds()),
-						HolderID:  holderID,
-						ExpiresAt: lock.ExpiresAt,
-					}, nil
-				}
-			}
+// enqueue adds a waiter to the back of a lock's queue
+func (r *lockRepository) enqueue(lockID string) *lockWaiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	waiter := &lockWaiter{wake: make(chan struct{}, 1)}
+	r.waiters[lockID] = append(r.waiters[lockID], waiter)
+	return waiter
+}
+
+// dequeue removes a waiter from a lock's queue, waking the next one when it
+// was at the head
+func (r *lockRepository) dequeue(lockID string, waiter *lockWaiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.waiters[lockID]
+	for i, w := range queue {
+		if w != waiter {
+			continue
+		}
+		queue = append(queue[:i], queue[i+1:]...)
+		if i == 0 && len(queue) > 0 {
+			queue[0].signal()
 		}
+		break
+	}
+	if len(queue) == 0 {
+		delete(r.waiters, lockID)
+		return
+	}
+	r.waiters[lockID] = queue
+}
+
+// isHead reports whether a waiter is next in line for a lock
+func (r *lockRepository) isHead(lockID string, waiter *lockWaiter) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.waiters[lockID]
+	return len(queue) > 0 && queue[0] == waiter
+}
+
+// queued reports whether holders are waiting for a lock
+func (r *lockRepository) queued(lockID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.waiters[lockID]) > 0
+}
+
+// wake tells the head of a lock's queue that the lock was released
+func (r *lockRepository) wake(lockID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if queue := r.waiters[lockID]; len(queue) > 0 {
+		queue[0].signal()
 	}
 }
 
+// signal wakes the waiter unless a wake-up is already pending
+func (w *lockWaiter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// recordAttempt counts an attempt to take a lock for the contention and wait
+// time metrics. Renewals by the current holder are not attempts.
+func (r *lockRepository) recordAttempt(ctx context.Context, lockID string, contended, acquired bool, waited time.Duration) error {
+	contentions, acquisitions := 0, 0
+	if contended {
+		contentions = 1
+	}
+	if acquired {
+		acquisitions = 1
+	}
+	_, err := r.store.Exec(ctx,
+		`INSERT INTO lock_stats (lock_id, attempts, contended, acquisitions, total_wait_ms)
+		 VALUES (?, 1, ?, ?, ?)
+		 ON CONFLICT (lock_id) DO UPDATE SET
+		     attempts = attempts + 1,
+		     contended = contended + excluded.contended,
+		     acquisitions = acquisitions + excluded.acquisitions,
+		     total_wait_ms = total_wait_ms + excluded.total_wait_ms`,
+		lockID, contentions, acquisitions, waited.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to record lock attempt: %w", err)
+	}
+	return nil
+}
+
 func (r *lockRepository) CleanupExpiredLocks(ctx context.Context) (int64, error) {
 	result, err := r.store.Exec(ctx,
 		`DELETE FROM locks WHERE released_at IS NOT NULL AND expires_at < datetime('now')`)
@@ -208,7 +477,7 @@ func (r *lockRepository) CleanupExpiredLocks(ctx context.Context) (int64, error)
 
 func (r *lockRepository) GetHeldLocks(ctx context.Context, holderID string) ([]*models.Lock, error) {
 	rows, err := r.store.Query(ctx,
-		`SELECT id, lock_id, holder_id, expires_at, priority_level, acquired_at, released_at
+		`SELECT id, lock_id, holder_id, expires_at, priority_level, acquired_at, released_at, fencing_token
 		 FROM locks WHERE holder_id = ? AND released_at IS NULL AND expires_at > datetime('now')
 		 ORDER BY acquired_at DESC`,
 		holderID)
@@ -220,7 +489,7 @@ func (r *lockRepository) GetHeldLocks(ctx context.Context, holderID string) ([]*
 	var locks []*models.Lock
 	for rows.Next() {
 		lock := &models.Lock{}
-		err := rows.Scan(&lock.ID, &lock.LockID, &lock.HolderID, &lock.ExpiresAt, &lock.PriorityLevel, &lock.AcquiredAt, &lock.ReleasedAt)
+		err := rows.Scan(&lock.ID, &lock.LockID, &lock.HolderID, &lock.ExpiresAt, &lock.PriorityLevel, &lock.AcquiredAt, &lock.ReleasedAt, &lock.FencingToken)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan lock: %w", err)
 		}
@@ -234,11 +503,10 @@ func (r *lockRepository) GetLockMetrics(ctx context.Context) (*models.LockMetric
 	row := r.store.QueryRow(ctx, `
 		SELECT
 			COUNT(*) as total_locks,
-			SUM(CASE WHEN released_at IS NULL AND expires_at > datetime('now') THEN 1 ELSE 0 END) as active_locks,
-			SUM(CASE WHEN expires_at < datetime('now') THEN 1 ELSE 0 END) as expired_locks,
-			SUM(CASE WHEN released_at IS NOT NULL THEN 1 ELSE 0 END) as released_locks,
-			AVG(CAST((julianday(released_at) - julianday(acquired_at)) * 86400000 AS REAL)) as avg_hold_time_ms,
-			MAX(CAST((julianday('now') - julianday(acquired_at)) * 86400000 AS REAL)) as max_wait_time_ms
+			COALESCE(SUM(CASE WHEN released_at IS NULL AND expires_at > datetime('now') THEN 1 ELSE 0 END), 0) as active_locks,
+			COALESCE(SUM(CASE WHEN expires_at < datetime('now') THEN 1 ELSE 0 END), 0) as expired_locks,
+			COALESCE(SUM(CASE WHEN released_at IS NOT NULL THEN 1 ELSE 0 END), 0) as released_locks,
+			COALESCE(AVG(CAST((julianday(released_at) - julianday(acquired_at)) * 86400000 AS REAL)), 0) as avg_hold_time_ms
 		FROM locks`)
 
 	metrics := &models.LockMetrics{}
@@ -248,15 +516,25 @@ func (r *lockRepository) GetLockMetrics(ctx context.Context) (*models.LockMetric
 		&metrics.ExpiredLocks,
 		&metrics.ReleasedLocks,
 		&metrics.AverageHoldTimeMS,
-		&metrics.AverageWaitTimeMS,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get lock metrics: %w", err)
 	}
 
-	// Calculate contention ratio
-	if metrics.TotalLocks > 0 {
-		metrics.ContentionRatio = float64(metrics.ReleasedLocks) / float64(metrics.TotalLocks)
+	// Wait times and contention come from the recorded acquisition attempts
+	var attempts, contended, acquisitions, totalWaitMS int64
+	err = r.store.QueryRow(ctx,
+		`SELECT COALESCE(SUM(attempts), 0), COALESCE(SUM(contended), 0),
+		        COALESCE(SUM(acquisitions), 0), COALESCE(SUM(total_wait_ms), 0)
+		 FROM lock_stats`).Scan(&attempts, &contended, &acquisitions, &totalWaitMS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock contention: %w", err)
+	}
+	if acquisitions > 0 {
+		metrics.AverageWaitTimeMS = float64(totalWaitMS) / float64(acquisitions)
+	}
+	if attempts > 0 {
+		metrics.ContentionRatio = float64(contended) / float64(attempts)
 	}
 
 	return metrics, nil
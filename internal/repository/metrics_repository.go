@@ -2,14 +2,16 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"sort"
 	"time"
 
-	"unified-go/internal/models"
-	"unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/storage"
 )
 
 // MetricsRepository defines the interface for metrics data access
@@ -40,15 +42,29 @@ type MetricsRepository interface {
 
 	// GetMetricsStats retrieves statistical summary
 	GetMetricsStats(ctx context.Context, period time.Duration) (map[string]interface{}, error)
+
+	// OpenAlert stores a new alert, giving it an ID when it has none
+	OpenAlert(ctx context.Context, alert *models.MetricsAlert) error
+
+	// ResolveAlert marks an open alert resolved at resolvedAt, returning
+	// ErrAlertNotFound when no open alert has the ID
+	ResolveAlert(ctx context.Context, id string, resolvedAt time.Time) error
+
+	// GetAlerts retrieves alerts newest first, only the open ones when
+	// activeOnly is set. A negative limit returns them all.
+	GetAlerts(ctx context.Context, activeOnly bool, limit, offset int) ([]*models.MetricsAlert, error)
+
+	// CountAlerts counts the alerts, only the open ones when activeOnly is set
+	CountAlerts(ctx context.Context, activeOnly bool) (int64, error)
 }
 
 // metricsRepository implements MetricsRepository
 type metricsRepository struct {
-	store *storage.SQLiteStore
+	store storage.Querier
 }
 
 // NewMetricsRepository creates a new metrics repository
-func NewMetricsRepository(store *storage.SQLiteStore) MetricsRepository {
+func NewMetricsRepository(store storage.Querier) MetricsRepository {
 	return &metricsRepository{store: store}
 }
 
@@ -100,7 +116,8 @@ func (r *metricsRepository) GetByTimeRange(ctx context.Context, start, end time.
 	rows, err := r.store.Query(ctx,
 		`SELECT id, timestamp, task_throughput, avg_latency_ms, success_rate, memory_mb, cpu_percent, active_sessions, pending_tasks, metadata
 		 FROM metrics WHERE timestamp BETWEEN ? AND ? ORDER BY timestamp DESC`,
-		start, end)
+		// Timestamps are stored by SQLite's CURRENT_TIMESTAMP, in UTC
+		start.UTC().Format(time.DateTime), end.UTC().Format(time.DateTime))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query metrics: %w", err)
 	}
@@ -210,7 +227,7 @@ func (r *metricsRepository) GetSystemHealth(ctx context.Context) (*models.System
 		score -= 10
 	}
 
-	if health.SuccessRate < 0.9 {
+	if latest.SuccessRate.Valid && health.SuccessRate < 0.9 {
 		score -= 15
 		health.Status = "degraded"
 	}
@@ -326,7 +343,7 @@ func (r *metricsRepository) CleanupOldMetrics(ctx context.Context, retentionDays
 	cutoff := time.Now().AddDate(0, 0, -retentionDays)
 	result, err := r.store.Exec(ctx,
 		`DELETE FROM metrics WHERE timestamp < ?`,
-		cutoff)
+		cutoff.UTC().Format(time.DateTime))
 	if err != nil {
 		return 0, fmt.Errorf("failed to cleanup old metrics: %w", err)
 	}
@@ -368,6 +385,96 @@ func (r *metricsRepository) GetMetricsStats(ctx context.Context, period time.Dur
 
 // Helper functions
 
+func (r *metricsRepository) OpenAlert(ctx context.Context, alert *models.MetricsAlert) error {
+	if alert.ID == "" {
+		id, err := newAlertID()
+		if err != nil {
+			return err
+		}
+		alert.ID = id
+	}
+	if alert.CreatedAt.IsZero() {
+		alert.CreatedAt = time.Now()
+	}
+
+	_, err := r.store.Exec(ctx,
+		`INSERT INTO metrics_alerts (id, metric_name, severity, value, threshold, message, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		alert.ID, alert.MetricName, alert.Severity, alert.Value, alert.Threshold, alert.Message,
+		alert.CreatedAt.UTC().Format(time.DateTime),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open alert: %w", err)
+	}
+	return nil
+}
+
+func (r *metricsRepository) ResolveAlert(ctx context.Context, id string, resolvedAt time.Time) error {
+	result, err := r.store.Exec(ctx,
+		`UPDATE metrics_alerts SET resolved_at = ? WHERE id = ? AND resolved_at IS NULL`,
+		resolvedAt.UTC().Format(time.DateTime), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to resolve alert: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to resolve alert: %w", err)
+	}
+	if n == 0 {
+		return ErrAlertNotFound
+	}
+	return nil
+}
+
+func (r *metricsRepository) GetAlerts(ctx context.Context, activeOnly bool, limit, offset int) ([]*models.MetricsAlert, error) {
+	rows, err := r.store.Query(ctx,
+		`SELECT id, metric_name, severity, value, threshold, message, created_at, resolved_at
+		 FROM metrics_alerts WHERE (? = 0 OR resolved_at IS NULL)
+		 ORDER BY created_at DESC, rowid DESC LIMIT ? OFFSET ?`,
+		activeOnly, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*models.MetricsAlert
+	for rows.Next() {
+		alert := &models.MetricsAlert{}
+		err := rows.Scan(
+			&alert.ID, &alert.MetricName, &alert.Severity, &alert.Value, &alert.Threshold,
+			&alert.Message, &alert.CreatedAt, &alert.ResolvedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+func (r *metricsRepository) CountAlerts(ctx context.Context, activeOnly bool) (int64, error) {
+	var count int64
+	err := r.store.QueryRow(ctx,
+		`SELECT COUNT(*) FROM metrics_alerts WHERE (? = 0 OR resolved_at IS NULL)`,
+		activeOnly,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count alerts: %w", err)
+	}
+	return count, nil
+}
+
+// newAlertID returns a random alert ID
+func newAlertID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate alert ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 func aggregateNullInt64(metrics []*models.Metrics, getter func(*models.Metrics) sql.NullInt64) models.AggregateStats {
 	var values []float64
 	for _, m := range metrics {
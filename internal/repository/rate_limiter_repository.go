@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/storage"
+)
+
+// RateLimiterRepository defines the interface for per-session assignment
+// throttling state
+type RateLimiterRepository interface {
+	// Get retrieves a session's rate limiter state, or nil if it has none
+	Get(ctx context.Context, sessionID string) (*models.RateLimiterState, error)
+
+	// RecordAssignment counts a task assigned to a session at the given time
+	RecordAssignment(ctx context.Context, sessionID string, at time.Time) error
+
+	// RecordRelease counts a task that a session no longer holds, and a
+	// completion when it finished successfully
+	RecordRelease(ctx context.Context, sessionID string, completed bool) error
+
+	// RecordThrottle counts an assignment withheld from a session at the
+	// given time
+	RecordThrottle(ctx context.Context, sessionID string, at time.Time) error
+}
+
+// rateLimiterRepository implements RateLimiterRepository
+type rateLimiterRepository struct {
+	store storage.Querier
+}
+
+// NewRateLimiterRepository creates a new rate limiter repository
+func NewRateLimiterRepository(store storage.Querier) RateLimiterRepository {
+	return &rateLimiterRepository{store: store}
+}
+
+func (r *rateLimiterRepository) Get(ctx context.Context, sessionID string) (*models.RateLimiterState, error) {
+	row := r.store.QueryRow(ctx,
+		`SELECT id, session_id, last_assignment_time, active_task_count, total_assignments,
+		        total_completions, throttled_count, last_throttled
+		 FROM rate_limiter_state WHERE session_id = ?`,
+		sessionID)
+
+	state := &models.RateLimiterState{}
+	err := row.Scan(
+		&state.ID, &state.SessionID, &state.LastAssignmentTime, &state.ActiveTaskCount,
+		&state.TotalAssignments, &state.TotalCompletions, &state.ThrottledCount, &state.LastThrottled,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get rate limiter state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *rateLimiterRepository) RecordAssignment(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.store.Exec(ctx,
+		`INSERT INTO rate_limiter_state (session_id, last_assignment_time, active_task_count, total_assignments)
+		 VALUES (?, ?, 1, 1)
+		 ON CONFLICT (session_id) DO UPDATE SET
+		     last_assignment_time = excluded.last_assignment_time,
+		     active_task_count = active_task_count + 1,
+		     total_assignments = total_assignments + 1`,
+		sessionID, at.UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("failed to record assignment: %w", err)
+	}
+	return nil
+}
+
+func (r *rateLimiterRepository) RecordRelease(ctx context.Context, sessionID string, completed bool) error {
+	completions := 0
+	if completed {
+		completions = 1
+	}
+	_, err := r.store.Exec(ctx,
+		`UPDATE rate_limiter_state
+		 SET active_task_count = MAX(active_task_count - 1, 0), total_completions = total_completions + ?
+		 WHERE session_id = ?`,
+		completions, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to record release: %w", err)
+	}
+	return nil
+}
+
+func (r *rateLimiterRepository) RecordThrottle(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.store.Exec(ctx,
+		`INSERT INTO rate_limiter_state (session_id, throttled_count, last_throttled)
+		 VALUES (?, 1, ?)
+		 ON CONFLICT (session_id) DO UPDATE SET
+		     throttled_count = throttled_count + 1,
+		     last_throttled = excluded.last_throttled`,
+		sessionID, at.UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("failed to record throttle: %w", err)
+	}
+	return nil
+}
@@ -2,7 +2,24 @@
 package repository
 
 import (
-	"unified-go/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jgirmay/unified-go/internal/storage"
+)
+
+// Errors returned by the repositories
+var (
+	ErrTaskNotFound      = errors.New("task not found")
+	ErrTaskNotPending    = errors.New("task is not pending")
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrSessionExists     = errors.New("session already registered")
+	ErrLockHeld          = errors.New("lock already held")
+	ErrLockNotHeld       = errors.New("lock not held by this holder")
+	ErrStaleFencingToken = errors.New("fencing token is not the lock's current lease")
+	ErrLockWaitTimeout   = errors.New("timed out waiting for lock")
+	ErrAlertNotFound     = errors.New("alert not found or already resolved")
 )
 
 // Manager provides access to all repositories
@@ -11,26 +28,52 @@ type Manager interface {
 	Sessions() SessionRepository
 	Locks() LockRepository
 	Metrics() MetricsRepository
+	RateLimits() RateLimiterRepository
+
+	// Transaction runs fn with repositories bound to one transaction, which
+	// commits when fn returns nil and rolls back otherwise. The store is held
+	// for the whole transaction, so fn must only use the repositories it is
+	// given: using this manager's would deadlock, and so would waiting for a
+	// lock.
+	Transaction(ctx context.Context, fn func(tx Manager) error) error
 }
 
 // manager implements Manager
 type manager struct {
-	tasks    TaskRepository
-	sessions SessionRepository
-	locks    LockRepository
-	metrics  MetricsRepository
+	store      storage.Querier
+	queue      *lockQueue
+	tasks      TaskRepository
+	sessions   SessionRepository
+	locks      LockRepository
+	metrics    MetricsRepository
+	rateLimits RateLimiterRepository
 }
 
 // NewManager creates a new repository manager
 func NewManager(store *storage.SQLiteStore) Manager {
+	return newManager(store, newLockQueue())
+}
+
+// newManager creates the repositories over a store, sharing the lock wait
+// queue so releases made within a transaction wake the waiters
+func newManager(store storage.Querier, queue *lockQueue) *manager {
 	return &manager{
-		tasks:    NewTaskRepository(store),
-		sessions: NewSessionRepository(store),
-		locks:    NewLockRepository(store),
-		metrics:  NewMetricsRepository(store),
+		store:      store,
+		queue:      queue,
+		tasks:      NewTaskRepository(store),
+		sessions:   NewSessionRepository(store),
+		locks:      &lockRepository{store: store, lockQueue: queue},
+		metrics:    NewMetricsRepository(store),
+		rateLimits: NewRateLimiterRepository(store),
 	}
 }
 
+func (m *manager) Transaction(ctx context.Context, fn func(tx Manager) error) error {
+	return m.store.Transaction(ctx, func(tx *sql.Tx) error {
+		return fn(newManager(storage.NewTxStore(tx), m.queue))
+	})
+}
+
 func (m *manager) Tasks() TaskRepository {
 	return m.tasks
 }
@@ -47,6 +90,10 @@ func (m *manager) Metrics() MetricsRepository {
 	return m.metrics
 }
 
+func (m *manager) RateLimits() RateLimiterRepository {
+	return m.rateLimits
+}
+
 /*
 Repository Package Overview:
 
@@ -69,7 +116,9 @@ Repositories:
 
 3. LockRepository
    - Interface for distributed locking
-   - Lock acquisition and release
+   - Lease acquisition, renewal and release
+   - Fencing tokens and priority preemption
+   - Fair (FIFO) waiting for a held lock
    - Lock expiration and cleanup
    - Lock contention metrics
 
@@ -78,6 +127,12 @@ Repositories:
    - Time series data collection
    - Aggregated statistics
    - System health calculation
+   - Threshold alerts, open and resolved
+
+5. RateLimiterRepository
+   - Interface for per-session assignment throttling
+   - Active task and assignment counts
+   - Throttle events
 
 Manager:
 The Manager interface provides unified access to all repositories,
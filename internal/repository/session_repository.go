@@ -5,10 +5,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
-	"unified-go/internal/models"
-	"unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/storage"
 )
 
 // SessionRepository defines the interface for session data access
@@ -22,6 +23,9 @@ type SessionRepository interface {
 	// GetBySessionID retrieves a session by session ID string
 	GetBySessionID(ctx context.Context, sessionID string) (*models.Session, error)
 
+	// GetByCurrentTask retrieves the session holding a task, or nil if none does
+	GetByCurrentTask(ctx context.Context, taskID int64) (*models.Session, error)
+
 	// GetByProvider retrieves all sessions for a provider
 	GetByProvider(ctx context.Context, provider string) ([]*models.Session, error)
 
@@ -32,7 +36,7 @@ type SessionRepository interface {
 	GetActive(ctx context.Context) ([]*models.Session, error)
 
 	// UpdateStatus updates session status and reason
-	UpdateStatus(ctx context.Context, sessionID string, status models.SessionState) error
+	UpdateStatus(ctx context.Context, sessionID string, status models.SessionState, reason string) error
 
 	// UpdateHealth updates session health score
 	UpdateHealth(ctx context.Context, sessionID string, healthScore float64) error
@@ -55,8 +59,8 @@ type SessionRepository interface {
 	// GetStateChangeLogs retrieves state change history for a session
 	GetStateChangeLogs(ctx context.Context, sessionID string) ([]*models.SessionStateLog, error)
 
-	// Deactivate marks a session as inactive
-	Deactivate(ctx context.Context, sessionID string) error
+	// Deactivate marks a session as inactive and terminated
+	Deactivate(ctx context.Context, sessionID string, reason string) error
 
 	// Delete removes a session
 	Delete(ctx context.Context, sessionID string) error
@@ -73,11 +77,11 @@ type SessionRepository interface {
 
 // sessionRepository implements SessionRepository
 type sessionRepository struct {
-	store *storage.SQLiteStore
+	store storage.Querier
 }
 
 // NewSessionRepository creates a new session repository
-func NewSessionRepository(store *storage.SQLiteStore) SessionRepository {
+func NewSessionRepository(store storage.Querier) SessionRepository {
 	return &sessionRepository{store: store}
 }
 
@@ -93,6 +97,9 @@ func (r *sessionRepository) Create(ctx context.Context, session *models.SessionC
 		1,     // Active by default
 	)
 	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, ErrSessionExists
+		}
 		return 0, fmt.Errorf("failed to create session: %w", err)
 	}
 	return result.LastInsertId()
@@ -144,6 +151,29 @@ func (r *sessionRepository) GetBySessionID(ctx context.Context, sessionID string
 	return session, nil
 }
 
+func (r *sessionRepository) GetByCurrentTask(ctx context.Context, taskID int64) (*models.Session, error) {
+	row := r.store.QueryRow(ctx,
+		`SELECT id, session_id, session_type, provider, status, current_task_id, last_heartbeat,
+		        health_score, metrics_json, created_at, updated_at, active
+		 FROM sessions WHERE current_task_id = ?`,
+		taskID)
+
+	session := &models.Session{}
+	err := row.Scan(
+		&session.ID, &session.SessionID, &session.SessionType, &session.Provider,
+		&session.Status, &session.CurrentTaskID, &session.LastHeartbeat,
+		&session.HealthScore, &session.MetricsJSON, &session.CreatedAt, &session.UpdatedAt,
+		&session.Active,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session by task: %w", err)
+	}
+	return session, nil
+}
+
 func (r *sessionRepository) GetByProvider(ctx context.Context, provider string) ([]*models.Session, error) {
 	rows, err := r.store.Query(ctx,
 		`SELECT id, session_id, session_type, provider, status, current_task_id, last_heartbeat,
@@ -227,13 +257,13 @@ func (r *sessionRepository) GetActive(ctx context.Context) ([]*models.Session, e
 	return sessions, rows.Err()
 }
 
-func (r *sessionRepository) UpdateStatus(ctx context.Context, sessionID string, status models.SessionState) error {
+func (r *sessionRepository) UpdateStatus(ctx context.Context, sessionID string, status models.SessionState, reason string) error {
 	oldSession, err := r.GetBySessionID(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 	if oldSession == nil {
-		return fmt.Errorf("session not found")
+		return ErrSessionNotFound
 	}
 
 	_, err = r.store.Exec(ctx,
@@ -243,7 +273,7 @@ func (r *sessionRepository) UpdateStatus(ctx context.Context, sessionID string,
 		return fmt.Errorf("failed to update session status: %w", err)
 	}
 
-	return r.LogStateChange(ctx, sessionID, oldSession.Status, status, "Status update")
+	return r.LogStateChange(ctx, sessionID, oldSession.Status, status, reason)
 }
 
 func (r *sessionRepository) UpdateHealth(ctx context.Context, sessionID string, healthScore float64) error {
@@ -279,12 +309,15 @@ func (r *sessionRepository) UpdateMetrics(ctx context.Context, sessionID string,
 }
 
 func (r *sessionRepository) RecordHeartbeat(ctx context.Context, sessionID string) error {
-	_, err := r.store.Exec(ctx,
+	result, err := r.store.Exec(ctx,
 		`UPDATE sessions SET last_heartbeat = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE session_id = ?`,
 		sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to record heartbeat: %w", err)
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
 	return nil
 }
 
@@ -384,14 +417,22 @@ func (r *sessionRepository) GetStateChangeLogs(ctx context.Context, sessionID st
 	return logs, rows.Err()
 }
 
-func (r *sessionRepository) Deactivate(ctx context.Context, sessionID string) error {
-	_, err := r.store.Exec(ctx,
+func (r *sessionRepository) Deactivate(ctx context.Context, sessionID string, reason string) error {
+	oldSession, err := r.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if oldSession == nil {
+		return ErrSessionNotFound
+	}
+
+	_, err = r.store.Exec(ctx,
 		`UPDATE sessions SET active = 0, status = ?, updated_at = CURRENT_TIMESTAMP WHERE session_id = ?`,
 		models.SessionTerminated, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to deactivate session: %w", err)
 	}
-	return r.LogStateChange(ctx, sessionID, models.SessionBusy, models.SessionTerminated, "Session deactivated")
+	return r.LogStateChange(ctx, sessionID, oldSession.Status, models.SessionTerminated, reason)
 }
 
 func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error {
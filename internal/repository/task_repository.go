@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"time"
 
-	"unified-go/internal/models"
-	"unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/storage"
 )
 
 // TaskRepository defines the interface for task data access
@@ -21,10 +21,17 @@ type TaskRepository interface {
 	// GetPending retrieves the next pending task for assignment
 	GetPending(ctx context.Context, limit int) ([]*models.Task, error)
 
+	// GetReady retrieves the pending tasks whose retry backoff has elapsed
+	// at now, highest priority and oldest first
+	GetReady(ctx context.Context, now time.Time, limit int) ([]*models.Task, error)
+
 	// GetByStatus retrieves all tasks with a specific status
 	GetByStatus(ctx context.Context, status models.TaskStatus, limit int, offset int) ([]*models.Task, error)
 
-	// GetBySession retrieves tasks assigned to a session
+	// CountByStatus counts the tasks with a specific status
+	CountByStatus(ctx context.Context, status models.TaskStatus) (int64, error)
+
+	// GetBySession retrieves tasks targeted at a session
 	GetBySession(ctx context.Context, sessionID string, status models.TaskStatus) ([]*models.Task, error)
 
 	// UpdateStatus updates a task's status
@@ -42,11 +49,20 @@ type TaskRepository interface {
 	// IncrementRetry increments the retry count
 	IncrementRetry(ctx context.Context, id int64) error
 
-	// Assign assigns a task to a session
+	// Defer holds a pending task back from GetReady until the given time
+	Defer(ctx context.Context, id int64, until time.Time) error
+
+	// Assign assigns a pending task to a session, returning
+	// ErrTaskNotPending when it is no longer pending
 	Assign(ctx context.Context, id int64, sessionID string) error
 
-	// GetStuckTasks retrieves tasks stuck beyond timeout
-	GetStuckTasks(ctx context.Context, timeoutMinutes int) ([]*models.Task, error)
+	// GetStuckTasks retrieves assigned and in-progress tasks whose timeout
+	// has elapsed at now
+	GetStuckTasks(ctx context.Context, now time.Time) ([]*models.Task, error)
+
+	// GetThroughput counts the tasks completed, and those that failed
+	// without a retry, in [start, end)
+	GetThroughput(ctx context.Context, start, end time.Time) (*models.TaskThroughput, error)
 
 	// GetMetrics retrieves task metrics
 	GetMetrics(ctx context.Context, period time.Duration) (*models.TaskMetrics, error)
@@ -63,11 +79,11 @@ type TaskRepository interface {
 
 // taskRepository implements TaskRepository
 type taskRepository struct {
-	store *storage.SQLiteStore
+	store storage.Querier
 }
 
 // NewTaskRepository creates a new task repository
-func NewTaskRepository(store *storage.SQLiteStore) TaskRepository {
+func NewTaskRepository(store storage.Querier) TaskRepository {
 	return &taskRepository{store: store}
 }
 
@@ -115,7 +131,7 @@ func (r *taskRepository) GetPending(ctx context.Context, limit int) ([]*models.T
 		`SELECT id, content, priority, status, target_session, created_at, updated_at,
 		        completed_at, assigned_at, retry_count, max_retries, timeout_minutes, error_message, metadata
 		 FROM tasks
-		 WHERE status = ? AND retry_count < max_retries
+		 WHERE status = ? AND retry_count <= max_retries
 		 ORDER BY priority DESC, created_at ASC
 		 LIMIT ?`,
 		models.TaskPending, limit,
@@ -141,6 +157,38 @@ func (r *taskRepository) GetPending(ctx context.Context, limit int) ([]*models.T
 	return tasks, rows.Err()
 }
 
+func (r *taskRepository) GetReady(ctx context.Context, now time.Time, limit int) ([]*models.Task, error) {
+	rows, err := r.store.Query(ctx,
+		`SELECT id, content, priority, status, target_session, created_at, updated_at,
+		        completed_at, assigned_at, retry_count, max_retries, timeout_minutes, error_message, metadata
+		 FROM tasks
+		 WHERE status = ? AND retry_count <= max_retries
+		 AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		 ORDER BY priority DESC, created_at ASC
+		 LIMIT ?`,
+		models.TaskPending, now.UTC().Format(time.DateTime), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ready tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		task := &models.Task{}
+		err := rows.Scan(
+			&task.ID, &task.Content, &task.Priority, &task.Status, &task.TargetSession,
+			&task.CreatedAt, &task.UpdatedAt, &task.CompletedAt, &task.AssignedAt,
+			&task.RetryCount, &task.MaxRetries, &task.TimeoutMinutes, &task.ErrorMessage, &task.Metadata,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
 func (r *taskRepository) GetByStatus(ctx context.Context, status models.TaskStatus, limit int, offset int) ([]*models.Task, error) {
 	rows, err := r.store.Query(ctx,
 		`SELECT id, content, priority, status, target_session, created_at, updated_at,
@@ -172,6 +220,15 @@ func (r *taskRepository) GetByStatus(ctx context.Context, status models.TaskStat
 	return tasks, rows.Err()
 }
 
+func (r *taskRepository) CountByStatus(ctx context.Context, status models.TaskStatus) (int64, error) {
+	var count int64
+	err := r.store.QueryRow(ctx, `SELECT COUNT(*) FROM tasks WHERE status = ?`, status).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tasks by status: %w", err)
+	}
+	return count, nil
+}
+
 func (r *taskRepository) GetBySession(ctx context.Context, sessionID string, status models.TaskStatus) ([]*models.Task, error) {
 	rows, err := r.store.Query(ctx,
 		`SELECT id, content, priority, status, target_session, created_at, updated_at,
@@ -209,7 +266,7 @@ func (r *taskRepository) UpdateStatus(ctx context.Context, id int64, status mode
 		return err
 	}
 	if oldTask == nil {
-		return fmt.Errorf("task not found")
+		return ErrTaskNotFound
 	}
 
 	// Update status
@@ -248,12 +305,24 @@ func (r *taskRepository) MarkCompleted(ctx context.Context, id int64) error {
 }
 
 func (r *taskRepository) MarkFailed(ctx context.Context, id int64, errMsg string, shouldRetry bool) error {
+	// Get old status for audit log
+	oldTask, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if oldTask == nil {
+		return ErrTaskNotFound
+	}
+
 	if !shouldRetry {
-		return r.UpdateWithError(ctx, id, models.TaskFailed, errMsg)
+		if err := r.UpdateWithError(ctx, id, models.TaskFailed, errMsg); err != nil {
+			return err
+		}
+		return r.LogStatusChange(ctx, id, oldTask.Status, models.TaskFailed, "Failed: "+errMsg)
 	}
 
 	// If should retry, mark as pending and increment retry count
-	_, err := r.store.Exec(ctx,
+	_, err = r.store.Exec(ctx,
 		`UPDATE tasks SET status = ?, error_message = ?, retry_count = retry_count + 1,
 		 updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
 		models.TaskPending,
@@ -263,7 +332,7 @@ func (r *taskRepository) MarkFailed(ctx context.Context, id int64, errMsg string
 	if err != nil {
 		return fmt.Errorf("failed to mark task failed: %w", err)
 	}
-	return nil
+	return r.LogStatusChange(ctx, id, oldTask.Status, models.TaskPending, "Retrying after failure: "+errMsg)
 }
 
 func (r *taskRepository) IncrementRetry(ctx context.Context, id int64) error {
@@ -277,26 +346,45 @@ func (r *taskRepository) IncrementRetry(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *taskRepository) Assign(ctx context.Context, id int64, sessionID string) error {
+func (r *taskRepository) Defer(ctx context.Context, id int64, until time.Time) error {
 	_, err := r.store.Exec(ctx,
-		`UPDATE tasks SET target_session = ?, status = ?, assigned_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
-		sessionID, models.TaskAssigned, id,
+		`UPDATE tasks SET next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		until.UTC().Format(time.DateTime), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to defer task: %w", err)
+	}
+	return nil
+}
+
+// Assign leaves target_session alone, so a task retried after a failure
+// keeps the affinity it was created with; the session records the task it
+// holds in current_task_id
+func (r *taskRepository) Assign(ctx context.Context, id int64, sessionID string) error {
+	result, err := r.store.Exec(ctx,
+		`UPDATE tasks SET status = ?, assigned_at = CURRENT_TIMESTAMP, next_attempt_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND status = ?`,
+		models.TaskAssigned, id, models.TaskPending,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to assign task: %w", err)
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrTaskNotPending
+	}
 	return r.LogStatusChange(ctx, id, models.TaskPending, models.TaskAssigned, fmt.Sprintf("Assigned to session %s", sessionID))
 }
 
-func (r *taskRepository) GetStuckTasks(ctx context.Context, timeoutMinutes int) ([]*models.Task, error) {
+func (r *taskRepository) GetStuckTasks(ctx context.Context, now time.Time) ([]*models.Task, error) {
+	// assigned_at is stored by CURRENT_TIMESTAMP in UTC
 	rows, err := r.store.Query(ctx,
 		`SELECT id, content, priority, status, target_session, created_at, updated_at,
 		        completed_at, assigned_at, retry_count, max_retries, timeout_minutes, error_message, metadata
 		 FROM tasks
 		 WHERE status IN (?, ?)
-		 AND datetime(assigned_at, '+' || timeout_minutes || ' minutes') < datetime('now')
+		 AND datetime(assigned_at, '+' || timeout_minutes || ' minutes') < ?
 		 ORDER BY assigned_at ASC`,
-		models.TaskAssigned, models.TaskInProgress,
+		models.TaskAssigned, models.TaskInProgress, now.UTC().Format(time.DateTime),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stuck tasks: %w", err)
@@ -319,6 +407,41 @@ func (r *taskRepository) GetStuckTasks(ctx context.Context, timeoutMinutes int)
 	return tasks, rows.Err()
 }
 
+func (r *taskRepository) GetThroughput(ctx context.Context, start, end time.Time) (*models.TaskThroughput, error) {
+	// Timestamps are stored by CURRENT_TIMESTAMP in UTC. A failed task keeps
+	// no completed_at, so it is counted when it was last updated.
+	from, to := start.UTC().Format(time.DateTime), end.UTC().Format(time.DateTime)
+	rows, err := r.store.Query(ctx,
+		`SELECT status, COUNT(*), AVG((julianday(completed_at) - julianday(assigned_at)) * 86400000)
+		 FROM tasks
+		 WHERE (status = ? AND completed_at >= ? AND completed_at < ?)
+		 OR (status = ? AND updated_at >= ? AND updated_at < ?)
+		 GROUP BY status`,
+		models.TaskCompleted, from, to, models.TaskFailed, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task throughput: %w", err)
+	}
+	defer rows.Close()
+
+	throughput := &models.TaskThroughput{}
+	for rows.Next() {
+		var status models.TaskStatus
+		var count int64
+		var latency sql.NullFloat64
+		if err := rows.Scan(&status, &count, &latency); err != nil {
+			return nil, fmt.Errorf("failed to scan task throughput: %w", err)
+		}
+		if status == models.TaskCompleted {
+			throughput.Completed = count
+			throughput.AvgLatencyMS = latency.Float64
+		} else {
+			throughput.Failed = count
+		}
+	}
+	return throughput, rows.Err()
+}
+
 func (r *taskRepository) GetMetrics(ctx context.Context, period time.Duration) (*models.TaskMetrics, error) {
 	// This is a placeholder - actual implementation would aggregate task data
 	// For now, return a zero-value struct
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/health"
+)
+
+// Audio storage thresholds: readiness fails below minAudioFreeBytes and
+// warns below audioFreeWarnPercent of the filesystem
+const (
+	minAudioFreeBytes    = 100 << 20
+	audioFreeWarnPercent = 10
+)
+
+// healthHandler returns server health status
+func healthHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := map[string]interface{}{
+			"status":      "healthy",
+			"go_version":  runtime.Version(),
+			"uptime":      time.Since(serverStartTime).String(),
+			"timestamp":   time.Now().UTC().Format(time.RFC3339),
+			"goroutines":  runtime.NumGoroutine(),
+			"environment": cfg.Environment,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(health)
+	}
+}
+
+// newReadinessChecker registers the dependency probes behind /health/ready
+func newReadinessChecker(cfg *config.Config, db *database.Pool, services *Services) *health.Checker {
+	checker := health.NewChecker()
+
+	checker.Add("database", func(ctx context.Context, report *health.Report) error {
+		return db.HealthCheck()
+	})
+	checker.Add("migrations", migrationsCheck(db))
+	checker.Add("realtime_hub", func(ctx context.Context, report *health.Report) error {
+		if !services.Hub.IsRunning() {
+			return errors.New("hub event loop is not running")
+		}
+		return nil
+	})
+	checker.Add("event_bus", func(ctx context.Context, report *health.Report) error {
+		if !services.Bus.IsRunning() {
+			return errors.New("bus processing loop is not running")
+		}
+		return nil
+	})
+	checker.Add("math_audio_disk", health.DiskSpace(cfg.Apps.Math.AudioDir, minAudioFreeBytes, audioFreeWarnPercent))
+	checker.Add("reading_audio_disk", health.DiskSpace(cfg.Apps.Reading.AudioDir, minAudioFreeBytes, audioFreeWarnPercent))
+
+	return checker
+}
+
+// migrationsCheck fails while any embedded migration is pending, and warns
+// about applied migrations that were edited or are unknown to this build.
+// The embedded migrations are loaded once, and each probe only reads
+// schema_migrations.
+func migrationsCheck(db *database.Pool) health.Check {
+	migrator, loadErr := database.NewServerMigrator(db)
+	return func(ctx context.Context, report *health.Report) error {
+		if loadErr != nil {
+			return loadErr
+		}
+		statuses, err := migrator.Inspect(ctx)
+		if err != nil {
+			return err
+		}
+
+		var pending []string
+		versions := make(map[string]int)
+		for _, s := range statuses {
+			switch s.State {
+			case database.StatePending:
+				pending = append(pending, fmt.Sprintf("%s/%d", s.Target, s.Version))
+			case database.StateModified:
+				report.Warn(fmt.Sprintf("%s/%d has been edited since it was applied", s.Target, s.Version))
+			case database.StateMissing:
+				report.Warn(fmt.Sprintf("%s/%d is applied but unknown to this build", s.Target, s.Version))
+			}
+			if s.State != database.StatePending && s.Version > versions[s.Target] {
+				versions[s.Target] = s.Version
+			}
+		}
+		for target, version := range versions {
+			report.Detail(target+"_version", fmt.Sprint(version))
+		}
+
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/lifecycle"
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// rateLimitCleanupInterval is how often idle buckets are dropped and, with
+// persistence enabled, bucket state is saved
+const rateLimitCleanupInterval = time.Minute
+
+// rateLimits holds the middleware for each configured route group
+type rateLimits struct {
+	auth   func(http.Handler) http.Handler
+	api    func(http.Handler) http.Handler
+	writes func(http.Handler) http.Handler
+}
+
+// newRateLimits builds the route group limiters, or pass-through middleware
+// when rate limiting is disabled. Each limiter's cleanup is registered with
+// the services so its state is saved one last time on shutdown.
+func newRateLimits(cfg *config.Config, db *database.Pool, services *Services) *rateLimits {
+	if !cfg.RateLimit.Enabled {
+		passThrough := func(next http.Handler) http.Handler { return next }
+		return &rateLimits{auth: passThrough, api: passThrough, writes: passThrough}
+	}
+
+	var store *middleware.RateLimitStore
+	if cfg.RateLimit.Persist {
+		store = middleware.NewRateLimitStore(db.DB)
+	}
+
+	limiter := func(name string, rule config.RateLimitRule, writesOnly, perApp bool) func(http.Handler) http.Handler {
+		l := middleware.NewRateLimiter(middleware.RateLimitPolicy{
+			Name:              name,
+			RequestsPerMinute: rule.RequestsPerMinute,
+			Burst:             rule.Burst,
+			WritesOnly:        writesOnly,
+			PerApp:            perApp,
+		})
+		if store != nil {
+			if err := l.SetStore(context.Background(), store); err != nil {
+				logger.Error("failed to load rate limiter state", "limiter", name, "error", err)
+			}
+		}
+		services.register("rate limiter "+name, lifecycle.Hooks{
+			Start: func(ctx context.Context) error {
+				l.StartCleanup(rateLimitCleanupInterval)
+				return nil
+			},
+			Stop: lifecycle.ErrCloser(l.Close),
+		})
+		return l.Handler
+	}
+
+	return &rateLimits{
+		auth:   limiter("auth", cfg.RateLimit.Auth, false, false),
+		api:    limiter("api", cfg.RateLimit.API, false, true),
+		writes: limiter("writes", cfg.RateLimit.Writes, true, true),
+	}
+}
@@ -1,57 +1,269 @@
 package router
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
+	"github.com/jgirmay/unified-go/internal/audit"
 	"github.com/jgirmay/unified-go/internal/config"
 	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/lifecycle"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/metrics"
 	"github.com/jgirmay/unified-go/internal/middleware"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/openapi"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/internal/tenant"
+	"github.com/jgirmay/unified-go/pkg/auth"
 	"github.com/jgirmay/unified-go/pkg/dashboard"
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/gaia"
+	"github.com/jgirmay/unified-go/pkg/groups"
 	"github.com/jgirmay/unified-go/pkg/math"
 	"github.com/jgirmay/unified-go/pkg/piano"
+	"github.com/jgirmay/unified-go/pkg/privacy"
 	"github.com/jgirmay/unified-go/pkg/reading"
+	"github.com/jgirmay/unified-go/pkg/realtime"
 	"github.com/jgirmay/unified-go/pkg/typing"
 )
 
 var serverStartTime = time.Now()
 
+// logger is the router package logger
+var logger = logging.Logger("router")
+
+// sessionCleanupInterval is how often expired session rows are purged
+const sessionCleanupInterval = time.Hour
+
+// idempotencyCleanupInterval is how often expired idempotency keys are purged
+const idempotencyCleanupInterval = time.Hour
+
+// gaiaWatchdogInterval is how often GAIA session health is rescored
+const gaiaWatchdogInterval = 15 * time.Second
+
+// Services are the long-running components started by the server and shared
+// with the routes. Their Run loops must already be running.
+type Services struct {
+	Hub   *realtime.Hub
+	Bus   *events.Bus
+	Audit *audit.Store
+	// Gaia is the GAIA task queue; without it /gaia/api is not mounted
+	Gaia repository.Manager
+	// Lifecycle runs the background work of the routes, such as session
+	// cleanup; without it that work starts immediately and is never stopped
+	Lifecycle *lifecycle.Manager
+}
+
+// register adds a component to the lifecycle manager, or starts it at once
+// when there is none
+func (s *Services) register(name string, hooks lifecycle.Hooks) {
+	if s.Lifecycle != nil {
+		s.Lifecycle.Register(name, hooks)
+		return
+	}
+	if hooks.Start != nil {
+		if err := hooks.Start(context.Background()); err != nil {
+			logger.Error("failed to start component", "component", name, "error", err)
+		}
+	}
+}
+
 // Setup configures and returns the HTTP router
-func Setup(cfg *config.Config, db *database.Pool) *chi.Mux {
+func Setup(cfg *config.Config, db *database.Pool, services *Services) *chi.Mux {
 	r := chi.NewRouter()
 
+	// Validate has already checked the proxies
+	trustedProxies, err := config.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("ignoring invalid trusted proxies", "error", err)
+	}
+
 	// Apply global middleware
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
-	r.Use(middleware.Recovery)
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(middleware.Logging)
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Recovery)
 	r.Use(chimiddleware.Compress(5))
 
 	// CORS middleware
 	corsMiddleware := middleware.NewCORSMiddleware(cfg.CORSOrigins)
 	r.Use(corsMiddleware.Handler)
 
-	// Auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(cfg.SessionSecret, cfg.SessionName)
+	// Auth middleware with server-side sessions
+	sessionStore := middleware.NewSQLiteStore(db.DB, []byte(cfg.SessionSecret))
+	sessionStore.Options.Secure = cfg.IsProduction()
+	services.register("sessions", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			sessionStore.StartCleanup(sessionCleanupInterval)
+			return nil
+		},
+		Stop: lifecycle.Closer(sessionStore.Close),
+	})
+	authMiddleware := middleware.NewAuthMiddlewareWithStore(sessionStore, cfg.SessionName)
 	r.Use(authMiddleware.Handler)
 
-	// Health check endpoint (public)
-	r.Get("/health", healthHandler)
+	// Personal API tokens (Authorization: Bearer) for the Chrome extension and scripts
+	authRouter := auth.NewRouter(db.DB)
+	authRouter.SetSessionStore(sessionStore)
+	bearerAuth := middleware.NewBearerAuth(authRouter.Service())
+	r.Use(bearerAuth.Handler)
+
+	// Each request is scoped to an organization (school) and signed-in users
+	// only reach their own organization
+	tenants := middleware.NewTenants(tenant.NewStore(db.DB), middleware.TenantOptions{
+		Enabled:    cfg.Tenancy.Enabled,
+		Header:     cfg.Tenancy.Header,
+		BaseDomain: cfg.Tenancy.BaseDomain,
+		Default:    cfg.Tenancy.DefaultOrganization,
+	})
+	r.Use(tenants.Handler)
+
+	// Every data-modifying request is written to the audit log
+	r.Use(middleware.Audit(services.Audit))
+
+	// Authorization for per-user app routes; teachers and parents may act
+	// for their students and children
+	groupsRouter := groups.NewRouter(db.DB)
+	authorizer := middleware.NewAuthorizer(groupsRouter.Service())
+	groupsRouter.SetAuthorizer(authorizer)
+
+	// Token-bucket rate limits per route group, keyed by user with IP
+	// fallback, and by app for the api and writes groups
+	limits := newRateLimits(cfg, db, services)
+
+	// Retried app writes, such as results queued by the service workers
+	// while offline, replay the first response to their Idempotency-Key
+	idempotency := middleware.NewIdempotencyStore(db.DB, time.Duration(cfg.Idempotency.TTLHours)*time.Hour)
+	services.register("idempotency keys", lifecycle.Hooks{
+		Start: func(ctx context.Context) error {
+			idempotency.StartCleanup(idempotencyCleanupInterval)
+			return nil
+		},
+		Stop: lifecycle.Closer(idempotency.Close),
+	})
+
+	// Health check endpoints (public): /health/live for liveness and
+	// /health/ready for readiness with dependency probes
+	checker := newReadinessChecker(cfg, db, services)
+	r.Get("/health", healthHandler(cfg))
+	r.Get("/health/live", checker.LiveHandler())
+	r.Get("/health/ready", checker.ReadyHandler())
+
+	// Prometheus metrics endpoint (public)
+	metrics.Default.MustRegister(db, services.Hub, services.Bus)
+	r.Handle("/metrics", metrics.Default.Handler())
+
+	// OpenAPI document and viewer (public); API requests are validated
+	// against the document before they reach the app routers
+	spec := openapi.MustLoad()
+	validator := openapi.NewValidator(spec)
+	r.Get("/openapi.json", spec.Handler())
+	r.Get("/openapi", openapi.ViewerHandler())
 
 	// Global static file serving
 	fileServer := http.FileServer(http.Dir(cfg.StaticDir))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
 
+	// ============================================================
+	// Account Routes
+	// ============================================================
+	r.With(middleware.App("auth"), limits.auth, validator.Handler).Mount("/auth", authRouter.Routes())
+
+	// ============================================================
+	// Classroom and Household Routes
+	// ============================================================
+	r.With(middleware.App("groups"), middleware.RequireAppScope("groups"), limits.api, limits.writes, validator.Handler).Mount("/groups", groupsRouter.Routes())
+
+	// ============================================================
+	// Admin Routes
+	// ============================================================
+	r.With(middleware.App("admin"), middleware.RequireAppScope("admin"), middleware.RequireAdmin(groupsRouter.Service()), limits.api, validator.Handler).
+		Method(http.MethodGet, "/admin/audit", audit.NewHandler(services.Audit))
+
+	// ============================================================
+	// GAIA Task Queue Routes
+	// ============================================================
+	if services.Gaia != nil {
+		// The dispatcher assigns queued tasks to idle sessions in the background
+		dispatcherOptions := gaia.DefaultDispatcherOptions()
+		dispatcherOptions.AssignInterval = time.Duration(cfg.Gaia.AssignIntervalSeconds) * time.Second
+		dispatcherOptions.RetryBackoff = time.Duration(cfg.Gaia.RetryBackoffSeconds) * time.Second
+		dispatcherOptions.HeartbeatTimeout = time.Duration(cfg.Gaia.HeartbeatTimeoutSeconds) * time.Second
+		dispatcher := gaia.NewDispatcher(services.Gaia, dispatcherOptions)
+		services.register("gaia dispatcher", lifecycle.Hooks{
+			Start: func(ctx context.Context) error {
+				dispatcher.Start(time.Duration(cfg.Gaia.DispatchIntervalSeconds) * time.Second)
+				return nil
+			},
+			Stop: lifecycle.Closer(dispatcher.Close),
+		})
+
+		// The watchdog scores session health, taking sessions that stop
+		// sending heartbeats out of rotation and reclaiming their work. The
+		// dashboard shows its events live on the fleet channel.
+		watchdogOptions := gaia.DefaultWatchdogOptions()
+		watchdogOptions.UnhealthyAfter = dispatcherOptions.HeartbeatTimeout
+		watchdogOptions.TerminateAfter = time.Duration(cfg.Gaia.TerminateAfterSeconds) * time.Second
+		watchdog := gaia.NewWatchdog(services.Gaia, dispatcher, services.Bus, watchdogOptions)
+		dashboard.NewFleetHealthRelay(services.Hub).Subscribe(services.Bus)
+		services.register("gaia watchdog", lifecycle.Hooks{
+			Start: func(ctx context.Context) error {
+				watchdog.Start(gaiaWatchdogInterval)
+				return nil
+			},
+			Stop: lifecycle.Closer(watchdog.Close),
+		})
+
+		// The collector samples the server and the queue into the metrics
+		// table and raises alerts, shown live on the system alerts channel
+		collectorOptions := gaia.DefaultCollectorOptions()
+		collectorOptions.Thresholds = alertThresholds(cfg.Gaia.Alerts)
+		collectorOptions.Hysteresis = float64(cfg.Gaia.AlertHysteresisPercent) / 100
+		collectorOptions.RetentionDays = cfg.Gaia.MetricsRetentionDays
+		collector := gaia.NewCollector(services.Gaia, db, services.Bus, collectorOptions)
+		dashboard.NewAlertRelay(services.Hub).Subscribe(services.Bus)
+		services.register("gaia metrics collector", lifecycle.Hooks{
+			Start: func(ctx context.Context) error {
+				collector.Start(time.Duration(cfg.Gaia.MetricsIntervalSeconds) * time.Second)
+				return nil
+			},
+			Stop: lifecycle.Closer(collector.Close),
+		})
+
+		gaiaRouter := gaia.NewRouter(services.Gaia, dispatcher)
+		r.With(middleware.App("gaia"), middleware.RequireAppScope("gaia"), middleware.RequireAdmin(groupsRouter.Service()), limits.api, limits.writes, validator.Handler).Mount("/gaia/api", gaiaRouter.Routes())
+	}
+
+	// ============================================================
+	// Data Export and Account Erasure Routes
+	// ============================================================
+	// No API token scope covers these, so they need a signed-in session
+	privacyRouter := privacy.NewRouter(db.DB)
+	privacyRouter.SetAuthorizer(authorizer)
+	privacyRouter.SetAccountChecker(groupsRouter.Service())
+	privacyRouter.SetPasswordChecker(authRouter.Service())
+	privacyRouter.SetSettings(privacy.Settings{
+		AudioDirs: map[string]string{
+			"math":    cfg.Apps.Math.AudioDir,
+			"reading": cfg.Apps.Reading.AudioDir,
+		},
+	})
+	r.With(middleware.App("privacy"), middleware.RequireAppScope("privacy"), limits.api, limits.writes, validator.Handler).Mount("/privacy", privacyRouter.Routes())
+
 	// ============================================================
 	// Math App Routes
 	// ============================================================
 	r.Route("/math", func(r chi.Router) {
+		r.Use(middleware.App("math"))
+
 		// Math app static files (CSS, JS, service worker, etc.)
 		mathStaticDir := filepath.Join(cfg.StaticDir, "math")
 		mathFileServer := http.FileServer(http.Dir(mathStaticDir))
@@ -71,13 +283,21 @@ func Setup(cfg *config.Config, db *database.Pool) *chi.Mux {
 		})
 
 		// Mount math API routes
-		r.Mount("/api", math.NewRouter(db.DB).Routes())
+		mathRouter := math.NewRouter(db.DB)
+		mathRouter.SetAuthorizer(authorizer)
+		mathRouter.SetSettings(math.Settings{
+			AudioDir:       cfg.Apps.Math.AudioDir,
+			MaxUploadBytes: megabytes(cfg.Apps.Math.MaxUploadMB),
+		})
+		r.With(middleware.RequireAppScope("math"), limits.api, limits.writes, idempotency.Handler, validator.Handler).Mount("/api", mathRouter.Routes())
 	})
 
 	// ============================================================
 	// Reading App Routes
 	// ============================================================
 	r.Route("/reading", func(r chi.Router) {
+		r.Use(middleware.App("reading"))
+
 		// Reading app static files (CSS, JS, service worker, etc.)
 		readingStaticDir := filepath.Join(cfg.StaticDir, "reading")
 		readingFileServer := http.FileServer(http.Dir(readingStaticDir))
@@ -97,21 +317,44 @@ func Setup(cfg *config.Config, db *database.Pool) *chi.Mux {
 		})
 
 		// Mount reading API routes
-		r.Mount("/api", reading.NewRouter(db.DB).Routes())
+		readingRouter := reading.NewRouter(db.DB)
+		readingRouter.SetAuthorizer(authorizer)
+		readingRouter.SetSettings(reading.Settings{
+			AudioDir:        cfg.Apps.Reading.AudioDir,
+			MaxUploadBytes:  megabytes(cfg.Apps.Reading.MaxUploadMB),
+			LeaderboardSize: cfg.Apps.Reading.LeaderboardSize,
+		})
+		r.With(middleware.RequireAppScope("reading"), limits.api, limits.writes, idempotency.Handler, validator.Handler).Mount("/api", readingRouter.Routes())
 	})
 
 	// ============================================================
 	// Piano App Routes
 	// ============================================================
-	r.Mount("/piano", piano.NewRouter(db.DB).Routes())
+	pianoRouter := piano.NewRouter(db.DB)
+	pianoRouter.SetAuthorizer(authorizer)
+	pianoRouter.SetSettings(piano.Settings{
+		MaxUploadBytes:  megabytes(cfg.Apps.Piano.MaxUploadMB),
+		LeaderboardSize: cfg.Apps.Piano.LeaderboardSize,
+	})
+	if err := piano.LoadTemplates(filepath.Join(cfg.TemplateDir, "piano")); err != nil {
+		logger.Warn("piano templates unavailable", "error", err)
+	}
+	r.With(middleware.App("piano"), middleware.RequireAppScope("piano"), limits.api, limits.writes, idempotency.Handler, validator.Handler).Mount("/piano", pianoRouter.Routes())
 
 	// ============================================================
 	// Typing App Routes
 	// ============================================================
-	r.Mount("/typing", typing.NewRouter(db.DB).Routes())
+	typingRouter := typing.NewRouter(db.DB)
+	typingRouter.SetAuthorizer(authorizer)
+	typingRouter.SetSettings(typing.Settings{
+		LeaderboardSize:       cfg.Apps.Typing.LeaderboardSize,
+		RacingLeaderboardSize: cfg.Apps.Typing.RacingLeaderboardSize,
+	})
+	r.With(middleware.App("typing"), middleware.RequireAppScope("typing"), limits.api, limits.writes, idempotency.Handler, validator.Handler).Mount("/typing", typingRouter.Routes())
 
 	// Dashboard routes
 	r.Route("/dashboard", func(r chi.Router) {
+		r.Use(middleware.App("dashboard"))
 		r.Get("/", dashboard.IndexHandler)
 	})
 
@@ -123,20 +366,30 @@ func Setup(cfg *config.Config, db *database.Pool) *chi.Mux {
 	return r
 }
 
-// Note: App routers are now initialized directly in Setup() via NewRouter calls
-// Legacy initializeAppHandlers function removed - all apps use router pattern
-
-// healthHandler returns server health status
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	health := map[string]interface{}{
-		"status":      "healthy",
-		"go_version":  runtime.Version(),
-		"uptime":      time.Since(serverStartTime).String(),
-		"timestamp":   time.Now().UTC().Format(time.RFC3339),
-		"goroutines":  runtime.NumGoroutine(),
-		"environment": "development",
+// alertThresholds lists the configured alerts by metric, leaving out those
+// without bounds
+func alertThresholds(alerts map[string]config.AlertThreshold) []models.AlertThreshold {
+	thresholds := make([]models.AlertThreshold, 0, len(alerts))
+	for metric, a := range alerts {
+		if a.WarningMin == nil && a.WarningMax == nil && a.CriticalMin == nil && a.CriticalMax == nil {
+			continue
+		}
+		thresholds = append(thresholds, models.AlertThreshold{
+			MetricName:  metric,
+			WarningMin:  a.WarningMin,
+			WarningMax:  a.WarningMax,
+			CriticalMin: a.CriticalMin,
+			CriticalMax: a.CriticalMax,
+		})
 	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i].MetricName < thresholds[j].MetricName })
+	return thresholds
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(health)
+// megabytes converts a configured size in MB to bytes
+func megabytes(mb int) int64 {
+	return int64(mb) << 20
 }
+
+// Note: App routers are now initialized directly in Setup() via NewRouter calls
+// Legacy initializeAppHandlers function removed - all apps use router pattern
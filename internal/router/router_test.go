@@ -0,0 +1,173 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/lifecycle"
+	"github.com/jgirmay/unified-go/internal/openapi"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
+)
+
+// setupTestRouter builds the full router over a migrated temporary database
+func setupTestRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+
+	db, err := database.InitPool(filepath.Join(t.TempDir(), "router.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.TemplateDir = filepath.Join("..", "..", "templates")
+	cfg.StaticDir = t.TempDir()
+	cfg.RateLimit.Enabled = false
+
+	gaiaStore, err := storage.NewSQLiteStore(storage.Config{DatabasePath: filepath.Join(t.TempDir(), "gaia.db")})
+	if err != nil {
+		t.Fatalf("failed to open GAIA database: %v", err)
+	}
+	t.Cleanup(func() { gaiaStore.Close() })
+	if err := gaiaStore.Initialize(context.Background()); err != nil {
+		t.Fatalf("failed to migrate GAIA database: %v", err)
+	}
+
+	return Setup(cfg, db, &Services{
+		Hub:   realtime.NewHub(),
+		Bus:   events.NewBus(10),
+		Audit: audit.NewStore(db.DB),
+		Gaia:  repository.NewManager(gaiaStore),
+	})
+}
+
+// undocumented reports routes that are deliberately left out of the OpenAPI
+// document: static file trees, service workers, and the methods other than
+// GET that chi registers for the /metrics handler
+func undocumented(method, route string) bool {
+	switch {
+	case strings.HasSuffix(route, "/static/*"):
+		return true
+	case strings.HasSuffix(route, "/service-worker.js"):
+		return true
+	case route == "/metrics" && method != http.MethodGet:
+		return true
+	}
+	return false
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	r := setupTestRouter(t)
+	spec := openapi.MustLoad()
+
+	routed := make(map[string]bool)
+	err := chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routed[method+" "+route] = true
+		if undocumented(method, route) {
+			return nil
+		}
+		if spec.Operation(method, route) == nil {
+			t.Errorf("%s %s has no entry in internal/openapi/openapi.yaml", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item.Operations() {
+			if !routed[method+" "+path] {
+				t.Errorf("%s %s is documented but not routed", method, path)
+			}
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	r := setupTestRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"missing required query", http.MethodGet, "/math/api/api/math/question?user_id=1&mode=addition", "", http.StatusBadRequest},
+		{"non-integer path param", http.MethodGet, "/typing/api/users/abc/typing/stats", "", http.StatusBadRequest},
+		{"body out of range", http.MethodPost, "/auth/register", `{"username":"ab","password":"x"}`, http.StatusBadRequest},
+		{"unsupported media type", http.MethodPost, "/auth/login", "username=a", http.StatusUnsupportedMediaType},
+		{"valid request reaches handler", http.MethodPost, "/auth/login", `{"username":"nobody","password":"secret123"}`, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.want == http.StatusUnsupportedMediaType {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, apierror.ContentType)
+			}
+		})
+	}
+}
+
+func TestSetupRegistersLifecycle(t *testing.T) {
+	db, err := database.InitPool(filepath.Join(t.TempDir(), "router.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.TemplateDir = filepath.Join("..", "..", "templates")
+	cfg.StaticDir = t.TempDir()
+	cfg.RateLimit.Persist = true
+
+	lc := lifecycle.New()
+	Setup(cfg, db, &Services{Hub: realtime.NewHub(), Bus: events.NewBus(10), Audit: audit.NewStore(db.DB), Lifecycle: lc})
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	report := lc.Stop(context.Background())
+	var stopped []string
+	for _, result := range report {
+		stopped = append(stopped, result.Name)
+	}
+	expected := []string{"idempotency keys", "rate limiter writes", "rate limiter api", "rate limiter auth", "sessions"}
+	if strings.Join(stopped, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v stopped, got %v", expected, stopped)
+	}
+	if err := report.Err(); err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+}
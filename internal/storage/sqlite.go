@@ -8,8 +8,13 @@ import (
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+var logger = logging.Logger("storage")
+
 // Config holds SQLite configuration
 type Config struct {
 	DatabasePath      string
@@ -82,86 +87,18 @@ func NewSQLiteStore(config Config) (*SQLiteStore, error) {
 	}, nil
 }
 
-// Initialize creates tables from migration schema
+// Initialize applies the GAIA schema migrations
 func (s *SQLiteStore) Initialize(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Create tables using schema
-	queries := []string{
-		// Tasks Table
-		`CREATE TABLE IF NOT EXISTS tasks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			content TEXT NOT NULL,
-			priority INTEGER NOT NULL DEFAULT 5,
-			status TEXT NOT NULL DEFAULT 'pending',
-			target_session TEXT,
-			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			completed_at TIMESTAMP,
-			assigned_at TIMESTAMP,
-			retry_count INTEGER DEFAULT 0,
-			max_retries INTEGER DEFAULT 3,
-			timeout_minutes INTEGER DEFAULT 30,
-			error_message TEXT,
-			metadata TEXT
-		)`,
-
-		// Sessions Table
-		`CREATE TABLE IF NOT EXISTS sessions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			session_id TEXT NOT NULL UNIQUE,
-			session_type TEXT NOT NULL,
-			provider TEXT NOT NULL,
-			status TEXT NOT NULL DEFAULT 'idle',
-			current_task_id INTEGER,
-			last_heartbeat TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			health_score REAL DEFAULT 100.0,
-			metrics_json TEXT,
-			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			active INTEGER DEFAULT 1,
-			FOREIGN KEY(current_task_id) REFERENCES tasks(id)
-		)`,
-
-		// Locks Table
-		`CREATE TABLE IF NOT EXISTS locks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			lock_id TEXT NOT NULL UNIQUE,
-			holder_id TEXT NOT NULL,
-			expires_at TIMESTAMP NOT NULL,
-			priority_level INTEGER DEFAULT 5,
-			acquired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			released_at TIMESTAMP
-		)`,
-
-		// Metrics Table
-		`CREATE TABLE IF NOT EXISTS metrics (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			task_throughput INTEGER,
-			avg_latency_ms REAL,
-			success_rate REAL,
-			memory_mb REAL,
-			cpu_percent REAL,
-			active_sessions INTEGER,
-			pending_tasks INTEGER,
-			metadata TEXT
-		)`,
-
-		// Indexes
-		`CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_tasks_target_session ON tasks(target_session)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_provider ON sessions(provider)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_status ON sessions(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_locks_holder_id ON locks(holder_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_metrics_timestamp ON metrics(timestamp)`,
+	migrator, err := database.NewMigrator(s.db, database.MigrationFS(), database.TargetGaia)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	for _, query := range queries {
-		if _, err := s.db.ExecContext(ctx, query); err != nil {
-			return fmt.Errorf("failed to execute schema query: %w", err)
-		}
+	if _, err := migrator.Up(ctx, database.TargetGaia); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
 	return nil
@@ -173,7 +110,7 @@ func (s *SQLiteStore) Query(ctx context.Context, query string, args ...interface
 	defer s.mu.RUnlock()
 
 	if s.config.LogQueries {
-		fmt.Printf("[QUERY] %s (args: %v)\n", query, args)
+		logger.InfoContext(ctx, "query", "query", query, "args", args)
 	}
 
 	return s.db.QueryContext(ctx, query, args...)
@@ -185,7 +122,7 @@ func (s *SQLiteStore) QueryRow(ctx context.Context, query string, args ...interf
 	defer s.mu.RUnlock()
 
 	if s.config.LogQueries {
-		fmt.Printf("[QUERY] %s (args: %v)\n", query, args)
+		logger.InfoContext(ctx, "query", "query", query, "args", args)
 	}
 
 	return s.db.QueryRowContext(ctx, query, args...)
@@ -197,7 +134,7 @@ func (s *SQLiteStore) Exec(ctx context.Context, query string, args ...interface{
 	defer s.mu.RUnlock()
 
 	if s.config.LogQueries {
-		fmt.Printf("[EXEC] %s (args: %v)\n", query, args)
+		logger.InfoContext(ctx, "exec", "query", query, "args", args)
 	}
 
 	return s.db.ExecContext(ctx, query, args...)
@@ -346,8 +283,9 @@ func (s *SQLiteStore) UpdateTaskStatus(ctx context.Context, taskID int64, status
 // GetTask retrieves a task by ID
 func (s *SQLiteStore) GetTask(ctx context.Context, taskID int64) (*TaskRow, error) {
 	row := s.QueryRow(ctx,
-		`SELECT id, content, priority, status, target_session, created_at, updated_at,
-		        completed_at, assigned_at, retry_count, max_retries, timeout_minutes, error_message, metadata
+		`SELECT id, content, priority, status, COALESCE(target_session, ''), created_at, updated_at,
+		        completed_at, assigned_at, retry_count, max_retries, timeout_minutes,
+		        COALESCE(error_message, ''), COALESCE(metadata, '')
 		 FROM tasks WHERE id = ?`,
 		taskID)
 
@@ -431,3 +369,59 @@ func (s *SQLiteStore) InsertMetrics(ctx context.Context, metrics *MetricsRow) (i
 
 	return result.LastInsertId()
 }
+
+// ListTasks returns tasks ordered by priority and age, optionally filtered
+// by status
+func (s *SQLiteStore) ListTasks(ctx context.Context, status string, limit int) ([]*TaskRow, error) {
+	query := `SELECT id, content, priority, status, COALESCE(target_session, ''), created_at, updated_at,
+	        completed_at, assigned_at, retry_count, max_retries, timeout_minutes,
+	        COALESCE(error_message, ''), COALESCE(metadata, '')
+	 FROM tasks`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY priority DESC, created_at, id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*TaskRow
+	for rows.Next() {
+		task := &TaskRow{}
+		if err := rows.Scan(&task.ID, &task.Content, &task.Priority, &task.Status, &task.TargetSession,
+			&task.CreatedAt, &task.UpdatedAt, &task.CompletedAt, &task.AssignedAt,
+			&task.RetryCount, &task.MaxRetries, &task.TimeoutMinutes, &task.ErrorMessage, &task.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// CountTasksByStatus returns the number of tasks in each status
+func (s *SQLiteStore) CountTasksByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := s.Query(ctx, `SELECT status, COUNT(*) FROM tasks GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan task count: %w", err)
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier runs statements against the database, either directly or within an
+// open transaction
+type Querier interface {
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Transaction(ctx context.Context, fn func(*sql.Tx) error) error
+}
+
+// TxStore runs statements within an open transaction
+type TxStore struct {
+	tx *sql.Tx
+}
+
+// NewTxStore creates a store bound to a transaction
+func NewTxStore(tx *sql.Tx) *TxStore {
+	return &TxStore{tx: tx}
+}
+
+// Query executes a SELECT query within the transaction
+func (s *TxStore) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, query, args...)
+}
+
+// QueryRow executes a SELECT query within the transaction and returns a single row
+func (s *TxStore) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.tx.QueryRowContext(ctx, query, args...)
+}
+
+// Exec executes an INSERT/UPDATE/DELETE query within the transaction
+func (s *TxStore) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, query, args...)
+}
+
+// Transaction runs fn within the open transaction, which commits or rolls
+// back as a whole when its owner finishes it
+func (s *TxStore) Transaction(ctx context.Context, fn func(*sql.Tx) error) error {
+	return fn(s.tx)
+}
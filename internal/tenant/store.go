@@ -0,0 +1,109 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// slugPattern matches a DNS label, so a slug can be used as a subdomain
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidSlug reports whether slug can name an organization
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// Store reads and creates organizations
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates an organization store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const organizationColumns = `id, slug, name, shared_content, created_at`
+
+// GetBySlug returns the organization with slug
+func (s *Store) GetBySlug(ctx context.Context, slug string) (*Organization, error) {
+	var org Organization
+	err := s.db.QueryRowContext(ctx, `SELECT `+organizationColumns+` FROM organizations WHERE slug = ?`, slug).
+		Scan(&org.ID, &org.Slug, &org.Name, &org.SharedContent, &org.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org, nil
+}
+
+// List returns every organization, oldest first
+func (s *Store) List(ctx context.Context) ([]Organization, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+organizationColumns+` FROM organizations ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		var org Organization
+		if err := rows.Scan(&org.ID, &org.Slug, &org.Name, &org.SharedContent, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+// Create adds an organization
+func (s *Store) Create(ctx context.Context, slug, name string, sharedContent bool) (*Organization, error) {
+	if !ValidSlug(slug) {
+		return nil, ErrInvalidSlug
+	}
+	if name == "" {
+		name = slug
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO organizations (slug, name, shared_content) VALUES (?, ?, ?)`, slug, name, sharedContent)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, ErrExists
+		}
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return s.GetBySlug(ctx, slug)
+}
+
+// SetSharedContent opts an organization in to or out of shared content
+func (s *Store) SetSharedContent(ctx context.Context, slug string, sharedContent bool) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE organizations SET shared_content = ? WHERE slug = ?`, sharedContent, slug)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemberOf returns the ID of the organization a user belongs to
+func (s *Store) MemberOf(ctx context.Context, userID int) (int64, error) {
+	var orgID int64
+	err := s.db.QueryRowContext(ctx, `SELECT org_id FROM users WHERE id = ?`, userID).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user organization: %w", err)
+	}
+	return orgID, nil
+}
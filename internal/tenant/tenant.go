@@ -0,0 +1,94 @@
+// Package tenant models the organizations, such as schools, that share one
+// deployment. The HTTP middleware puts the request's organization in its
+// context, and repositories restrict their queries to it with Members and
+// Catalog. A context without an organization is deployment-wide: the
+// command-line tools and background jobs use it.
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// The organization created by the migrations. Existing users are moved into
+// it, and every request uses it when tenancy is off.
+const (
+	DefaultID   = 1
+	DefaultSlug = "default"
+)
+
+// Errors returned by the organization store
+var (
+	ErrNotFound    = errors.New("organization not found")
+	ErrExists      = errors.New("organization already exists")
+	ErrInvalidSlug = errors.New("slug must be 1-63 lowercase letters, digits or hyphens")
+)
+
+// Organization is a tenant of the deployment
+type Organization struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	// SharedContent opts the organization in to catalog entries that belong
+	// to no organization
+	SharedContent bool      `json:"shared_content"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type contextKey struct{}
+
+// NewContext returns a context scoped to org
+func NewContext(ctx context.Context, org *Organization) context.Context {
+	return context.WithValue(ctx, contextKey{}, org)
+}
+
+// FromContext returns the organization ctx is scoped to, if any
+func FromContext(ctx context.Context) (*Organization, bool) {
+	org, ok := ctx.Value(contextKey{}).(*Organization)
+	return org, ok && org != nil
+}
+
+// ID returns the organization ID to store on new catalog entries: the
+// context's organization, or NULL for shared content
+func ID(ctx context.Context) sql.NullInt64 {
+	if org, ok := FromContext(ctx); ok {
+		return sql.NullInt64{Int64: org.ID, Valid: true}
+	}
+	return sql.NullInt64{}
+}
+
+// MemberID returns the organization new users join: the context's
+// organization, or the default one
+func MemberID(ctx context.Context) int64 {
+	if org, ok := FromContext(ctx); ok {
+		return org.ID
+	}
+	return DefaultID
+}
+
+// Members returns a condition restricting userColumn to users of the
+// context's organization, and its arguments. Without an organization the
+// condition matches every row.
+func Members(ctx context.Context, userColumn string) (string, []interface{}) {
+	org, ok := FromContext(ctx)
+	if !ok {
+		return "1 = 1", nil
+	}
+	return userColumn + " IN (SELECT id FROM users WHERE org_id = ?)", []interface{}{org.ID}
+}
+
+// Catalog returns a condition restricting a catalog table's orgColumn to the
+// context's organization and, if it opted in, to shared entries. Without an
+// organization the condition matches every row.
+func Catalog(ctx context.Context, orgColumn string) (string, []interface{}) {
+	org, ok := FromContext(ctx)
+	if !ok {
+		return "1 = 1", nil
+	}
+	if org.SharedContent {
+		return "(" + orgColumn + " = ? OR " + orgColumn + " IS NULL)", []interface{}{org.ID}
+	}
+	return orgColumn + " = ?", []interface{}{org.ID}
+}
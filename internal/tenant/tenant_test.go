@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// setupTestStore opens a migrated database
+func setupTestStore(t *testing.T) (*Store, *database.Pool) {
+	t.Helper()
+
+	db, err := database.InitPool(filepath.Join(t.TempDir(), "unified.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return NewStore(db.DB), db
+}
+
+func TestConditions(t *testing.T) {
+	ctx := context.Background()
+
+	if cond, args := Members(ctx, "user_id"); cond != "1 = 1" || args != nil {
+		t.Errorf("Expected no member restriction without an organization, got %q %v", cond, args)
+	}
+	if cond, args := Catalog(ctx, "org_id"); cond != "1 = 1" || args != nil {
+		t.Errorf("Expected no catalog restriction without an organization, got %q %v", cond, args)
+	}
+	if id := ID(ctx); id.Valid {
+		t.Errorf("Expected shared content without an organization, got %v", id)
+	}
+	if id := MemberID(ctx); id != DefaultID {
+		t.Errorf("Expected new users to join the default organization, got %d", id)
+	}
+
+	private := NewContext(ctx, &Organization{ID: 2, Slug: "lincoln"})
+	if cond, args := Members(private, "user_id"); cond != "user_id IN (SELECT id FROM users WHERE org_id = ?)" || len(args) != 1 || args[0] != int64(2) {
+		t.Errorf("Unexpected member restriction %q %v", cond, args)
+	}
+	if cond, args := Catalog(private, "org_id"); cond != "org_id = ?" || len(args) != 1 {
+		t.Errorf("Unexpected catalog restriction %q %v", cond, args)
+	}
+	if id := ID(private); !id.Valid || id.Int64 != 2 {
+		t.Errorf("Expected content to belong to organization 2, got %v", id)
+	}
+	if id := MemberID(private); id != 2 {
+		t.Errorf("Expected new users to join organization 2, got %d", id)
+	}
+
+	shared := NewContext(ctx, &Organization{ID: 2, Slug: "lincoln", SharedContent: true})
+	if cond, _ := Catalog(shared, "org_id"); cond != "(org_id = ? OR org_id IS NULL)" {
+		t.Errorf("Expected shared content in the catalog, got %q", cond)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, db := setupTestStore(t)
+	ctx := context.Background()
+
+	def, err := store.GetBySlug(ctx, DefaultSlug)
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	if def.ID != DefaultID || !def.SharedContent {
+		t.Errorf("Expected the default organization to share content, got %+v", def)
+	}
+
+	org, err := store.Create(ctx, "lincoln", "Lincoln Elementary", false)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if org.Slug != "lincoln" || org.Name != "Lincoln Elementary" || org.SharedContent {
+		t.Errorf("Unexpected organization %+v", org)
+	}
+
+	if _, err := store.Create(ctx, "lincoln", "", false); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	for _, slug := range []string{"", "Lincoln", "-lincoln", "lincoln.edu"} {
+		if _, err := store.Create(ctx, slug, "", false); !errors.Is(err, ErrInvalidSlug) {
+			t.Errorf("Expected ErrInvalidSlug for %q, got %v", slug, err)
+		}
+	}
+	if _, err := store.GetBySlug(ctx, "nowhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.SetSharedContent(ctx, "lincoln", true); err != nil {
+		t.Fatalf("SetSharedContent failed: %v", err)
+	}
+	if org, _ = store.GetBySlug(ctx, "lincoln"); !org.SharedContent {
+		t.Error("Expected lincoln to share content")
+	}
+	if err := store.SetSharedContent(ctx, "nowhere", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	orgs, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(orgs) != 2 || orgs[0].Slug != DefaultSlug || orgs[1].Slug != "lincoln" {
+		t.Errorf("Unexpected organizations %+v", orgs)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (username, password_hash) VALUES ('ada', 'x')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if orgID, err := store.MemberOf(ctx, 1); err != nil || orgID != DefaultID {
+		t.Errorf("Expected existing users in the default organization, got %d, %v", orgID, err)
+	}
+	if _, err := store.MemberOf(ctx, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
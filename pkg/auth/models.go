@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Errors returned by the auth service
+var (
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrUserExists         = errors.New("username is already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrAccountLocked      = errors.New("account is temporarily locked")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidScope       = errors.New("invalid token scope")
+	ErrTokenNotFound      = errors.New("api token not found")
+)
+
+// Account roles
+const (
+	RoleStudent = "student"
+	RoleParent  = "parent"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+// ValidRole reports whether role is a known account role
+func ValidRole(role string) bool {
+	switch role {
+	case RoleStudent, RoleParent, RoleTeacher, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// API token scopes. Each app has a read and a write scope; stats:read grants
+// read access to every app. The gaia scopes only take effect for
+// administrators.
+var ValidScopes = []string{
+	"math:read", "math:write",
+	"reading:read", "reading:write",
+	"typing:read", "typing:write",
+	"piano:read", "piano:write",
+	"groups:read", "groups:write",
+	"gaia:read", "gaia:write",
+	"stats:read",
+}
+
+// ValidScope reports whether scope is a known API token scope
+func ValidScope(scope string) bool {
+	for _, s := range ValidScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// User represents a registered account in the users table
+type User struct {
+	ID                  uint       `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email,omitempty"`
+	Role                string     `json:"role"`
+	OrgID               int64      `json:"org_id"`
+	PasswordHash        string     `json:"-"`
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// IsLocked reports whether the account is locked at the given time
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// RegisterRequest is the payload for POST /auth/register
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+// LoginRequest is the payload for POST /auth/login
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// Validate performs validation on RegisterRequest
+func (r *RegisterRequest) Validate() error {
+	r.Username = strings.TrimSpace(r.Username)
+	r.Email = strings.TrimSpace(r.Email)
+
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		return errors.New("username must be 3-32 characters of letters, digits, '_', '.' or '-'")
+	}
+	if r.Email != "" && !emailPattern.MatchString(r.Email) {
+		return fmt.Errorf("invalid email: %s", r.Email)
+	}
+	// Teacher, parent and admin roles are granted by an administrator
+	if r.Role == "" {
+		r.Role = RoleStudent
+	}
+	if r.Role != RoleStudent {
+		return fmt.Errorf("invalid role: %s: only student accounts can be registered", r.Role)
+	}
+	return ValidatePasswordStrength(r.Password, r.Username)
+}
+
+// Validate performs validation on LoginRequest
+func (r *LoginRequest) Validate() error {
+	r.Username = strings.TrimSpace(r.Username)
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if r.Password == "" {
+		return errors.New("password is required")
+	}
+	return nil
+}
+
+// APIToken is a named personal access token. The secret itself is only
+// returned once, when the token is created.
+type APIToken struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	TokenHash  string     `json:"-"`
+}
+
+// IsExpired reports whether the token has expired at the given time
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// CreateTokenRequest is the payload for POST /auth/tokens
+type CreateTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"`
+}
+
+// Validate performs validation on CreateTokenRequest
+func (r *CreateTokenRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > 64 {
+		return errors.New("name must be at most 64 characters")
+	}
+	if len(r.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range r.Scopes {
+		if !ValidScope(scope) {
+			return fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	if r.ExpiresInDays < 0 || r.ExpiresInDays > 365 {
+		return errors.New("expires_in_days must be between 0 and 365")
+	}
+	return nil
+}
+
+// Password strength rules
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
+// ValidatePasswordStrength checks a password against the strength rules:
+// 8-128 characters, at least one letter and one digit, and not containing
+// the username
+func ValidatePasswordStrength(password, username string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at most %d characters", MaxPasswordLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLetter(c):
+			hasLetter = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain at least one letter and one digit")
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errors.New("password must not contain the username")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import "testing"
+
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "sunshine42", false},
+		{"too short", "abc12", true},
+		{"no digit", "onlyletters", true},
+		{"no letter", "1234567890", true},
+		{"contains username", "Alice2024!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePasswordStrength(tt.password, "alice")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePasswordStrength(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegisterRequest
+		wantErr bool
+	}{
+		{"valid", RegisterRequest{Username: "alice", Password: "sunshine42"}, false},
+		{"missing username", RegisterRequest{Password: "sunshine42"}, true},
+		{"bad username", RegisterRequest{Username: "a b", Password: "sunshine42"}, true},
+		{"bad email", RegisterRequest{Username: "alice", Password: "sunshine42", Email: "nope"}, true},
+		{"weak password", RegisterRequest{Username: "alice", Password: "short"}, true},
+		{"student role", RegisterRequest{Username: "alice", Password: "sunshine42", Role: RoleStudent}, false},
+		{"teacher role not self-assignable", RegisterRequest{Username: "alice", Password: "sunshine42", Role: RoleTeacher}, true},
+		{"parent role not self-assignable", RegisterRequest{Username: "alice", Password: "sunshine42", Role: RoleParent}, true},
+		{"admin role not self-assignable", RegisterRequest{Username: "alice", Password: "sunshine42", Role: RoleAdmin}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
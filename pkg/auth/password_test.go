@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse 1")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, hashAlgorithm+"$") {
+		t.Errorf("Expected hash to carry algorithm prefix, got %s", hash)
+	}
+
+	ok, err := VerifyPassword("correct horse 1", hash)
+	if err != nil || !ok {
+		t.Errorf("Expected password to verify, got %v, %v", ok, err)
+	}
+
+	ok, err = VerifyPassword("wrong horse 1", hash)
+	if err != nil || ok {
+		t.Errorf("Expected wrong password to fail, got %v, %v", ok, err)
+	}
+}
+
+func TestHashPasswordIsSalted(t *testing.T) {
+	a, _ := HashPassword("samepassword1")
+	b, _ := HashPassword("samepassword1")
+	if a == b {
+		t.Error("Expected distinct hashes for the same password")
+	}
+}
+
+func TestVerifyPasswordRejectsMalformedHash(t *testing.T) {
+	for _, encoded := range []string{"", "plaintext", "md5$1$abc$def", "pbkdf2-sha256$x$abc$def"} {
+		if _, err := VerifyPassword("password1", encoded); err == nil {
+			t.Errorf("Expected error for %q", encoded)
+		}
+	}
+}
+
+func TestPBKDF2SHA256Vector(t *testing.T) {
+	// RFC 7914 section 11 test vector
+	key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("pbkdf2SHA256 = %s, want %s", got, want)
+	}
+}
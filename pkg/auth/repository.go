@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// Repository handles database operations for user accounts
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new auth repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const userColumns = `id, username, COALESCE(email, ''), role, org_id, password_hash, failed_login_attempts,
+	locked_until, last_login_at, disabled_at, created_at, updated_at`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var user User
+	var lockedUntil, lastLoginAt, disabledAt sql.NullTime
+
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.OrgID, &user.PasswordHash,
+		&user.FailedLoginAttempts, &lockedUntil, &lastLoginAt, &disabledAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+
+	return &user, nil
+}
+
+// CreateUser inserts a new user in the context's organization and returns its
+// ID. Usernames are unique across every organization.
+func (r *Repository) CreateUser(ctx context.Context, user *User) (uint, error) {
+	if user == nil {
+		return 0, errors.New("user cannot be nil")
+	}
+
+	role := user.Role
+	if role == "" {
+		role = RoleStudent
+	}
+
+	stmt := `INSERT INTO users (username, password_hash, email, role, org_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	result, err := r.db.ExecContext(ctx, stmt, user.Username, user.PasswordHash,
+		sql.NullString{String: user.Email, Valid: user.Email != ""}, role, tenant.MemberID(ctx))
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, ErrUserExists
+		}
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get insert id: %w", err)
+	}
+
+	return uint(id), nil
+}
+
+// GetUserByID retrieves a user by ID, returning nil when no user exists
+func (r *Repository) GetUserByID(ctx context.Context, userID uint) (*User, error) {
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, stmt, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user of the context's organization by
+// username, returning nil when no user exists
+func (r *Repository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	scope, args := tenant.Members(ctx, "id")
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE username = ? COLLATE NOCASE AND ` + scope
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, stmt, append([]interface{}{username}, args...)...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// RecordFailedLogin increments the failed attempt counter and optionally locks the account
+func (r *Repository) RecordFailedLogin(ctx context.Context, userID uint, attempts int, lockedUntil *time.Time) error {
+	var locked sql.NullTime
+	if lockedUntil != nil {
+		locked = sql.NullTime{Time: *lockedUntil, Valid: true}
+	}
+
+	stmt := `UPDATE users SET failed_login_attempts = ?, locked_until = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, stmt, attempts, locked, userID); err != nil {
+		return fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	return nil
+}
+
+// RecordSuccessfulLogin clears the failed attempt counter and stamps the login time
+func (r *Repository) RecordSuccessfulLogin(ctx context.Context, userID uint, at time.Time) error {
+	stmt := `UPDATE users SET failed_login_attempts = 0, locked_until = NULL, last_login_at = ?,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, stmt, at, userID); err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces a user's password hash
+func (r *Repository) UpdatePasswordHash(ctx context.Context, userID uint, hash string) error {
+	stmt := `UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, stmt, hash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetRole changes a user's account role
+func (r *Repository) SetRole(ctx context.Context, userID uint, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	stmt := `UPDATE users SET role = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, stmt, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ListUsers returns the users of the context's organization ordered by ID
+func (r *Repository) ListUsers(ctx context.Context) ([]*User, error) {
+	scope, args := tenant.Members(ctx, "id")
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE ` + scope + ` ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// SetDisabled disables a user's account at the given time, or re-enables it
+// when at is nil
+func (r *Repository) SetDisabled(ctx context.Context, userID uint, at *time.Time) error {
+	var disabledAt sql.NullTime
+	if at != nil {
+		disabledAt = sql.NullTime{Time: *at, Valid: true}
+	}
+
+	stmt := `UPDATE users SET disabled_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, stmt, disabledAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+const tokenColumns = `id, user_id, name, token_prefix, scopes, token_hash, last_used_at, expires_at, created_at`
+
+// scanToken scans a row selected with tokenColumns
+func scanToken(row interface{ Scan(...interface{}) error }) (*APIToken, error) {
+	var token APIToken
+	var scopes string
+	var lastUsedAt, expiresAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes, &token.TokenHash,
+		&lastUsedAt, &expiresAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Split(scopes, " ")
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+
+	return &token, nil
+}
+
+// CreateToken inserts a new API token and returns its ID
+func (r *Repository) CreateToken(ctx context.Context, token *APIToken) (uint, error) {
+	if token == nil {
+		return 0, errors.New("token cannot be nil")
+	}
+
+	var expiresAt sql.NullTime
+	if token.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *token.ExpiresAt, Valid: true}
+	}
+
+	stmt := `INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, stmt, token.UserID, token.Name, token.TokenHash, token.Prefix,
+		strings.Join(token.Scopes, " "), expiresAt, token.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create api token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get insert id: %w", err)
+	}
+
+	return uint(id), nil
+}
+
+// GetTokenByHash retrieves a token by its hash, returning nil when no token exists
+func (r *Repository) GetTokenByHash(ctx context.Context, hash string) (*APIToken, error) {
+	stmt := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE token_hash = ?`
+
+	token, err := scanToken(r.db.QueryRowContext(ctx, stmt, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListTokens returns a user's API tokens, newest first
+func (r *Repository) ListTokens(ctx context.Context, userID uint) ([]*APIToken, error) {
+	stmt := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*APIToken, 0)
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// DeleteToken removes one of a user's API tokens
+func (r *Repository) DeleteToken(ctx context.Context, userID, tokenID uint) error {
+	stmt := `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`
+	result, err := r.db.ExecContext(ctx, stmt, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api token: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserTokens removes all of a user's API tokens and returns how many
+// were deleted
+func (r *Repository) DeleteUserTokens(ctx context.Context, userID uint) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_tokens WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete api tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// TouchToken records that a token was used, writing at most once per interval
+func (r *Repository) TouchToken(ctx context.Context, tokenID uint, at time.Time, interval time.Duration) error {
+	stmt := `UPDATE api_tokens SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at <= ?)`
+	if _, err := r.db.ExecContext(ctx, stmt, at, tokenID, at.Add(-interval)); err != nil {
+		return fmt.Errorf("failed to update api token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupTestDB creates an in-memory SQLite database for testing
+func setupTestDB(t testing.TB) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := `
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		email TEXT,
+		role TEXT NOT NULL DEFAULT 'student',
+		org_id INTEGER NOT NULL DEFAULT 1,
+		failed_login_attempts INTEGER NOT NULL DEFAULT 0,
+		locked_until DATETIME,
+		last_login_at DATETIME,
+		disabled_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER,
+		data TEXT,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at DATETIME,
+		user_agent TEXT,
+		ip_address TEXT
+	);
+
+	CREATE TABLE api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		token_prefix TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		last_used_at DATETIME,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	return db
+}
+
+func TestCreateAndGetUser(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.CreateUser(ctx, &User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	user, err := repo.GetUserByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if user == nil || user.Username != "alice" || user.Email != "alice@example.com" {
+		t.Fatalf("Unexpected user: %+v", user)
+	}
+
+	byName, err := repo.GetUserByUsername(ctx, "ALICE")
+	if err != nil {
+		t.Fatalf("GetUserByUsername failed: %v", err)
+	}
+	if byName == nil || byName.ID != id {
+		t.Errorf("Expected case-insensitive lookup to find user %d, got %+v", id, byName)
+	}
+
+	missing, err := repo.GetUserByUsername(ctx, "nobody")
+	if err != nil || missing != nil {
+		t.Errorf("Expected nil user for unknown username, got %+v, %v", missing, err)
+	}
+}
+
+func TestCreateUserDuplicate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	if _, err := repo.CreateUser(ctx, &User{Username: "bob", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	_, err := repo.CreateUser(ctx, &User{Username: "bob", PasswordHash: "hash"})
+	if !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+}
+
+func TestUsersByOrganization(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	lincoln := tenant.NewContext(context.Background(), &tenant.Organization{ID: 2, Slug: "lincoln"})
+	def := tenant.NewContext(context.Background(), &tenant.Organization{ID: tenant.DefaultID, Slug: tenant.DefaultSlug})
+
+	id, err := repo.CreateUser(lincoln, &User{Username: "carol", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if user, _ := repo.GetUserByID(lincoln, id); user == nil || user.OrgID != 2 {
+		t.Fatalf("Expected carol in organization 2, got %+v", user)
+	}
+
+	if user, err := repo.GetUserByUsername(lincoln, "carol"); err != nil || user == nil {
+		t.Errorf("Expected carol in their organization, got %+v, %v", user, err)
+	}
+	if user, err := repo.GetUserByUsername(def, "carol"); err != nil || user != nil {
+		t.Errorf("Expected no carol in the default organization, got %+v, %v", user, err)
+	}
+	if _, err := repo.CreateUser(def, &User{Username: "carol", PasswordHash: "hash"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected usernames to stay unique across organizations, got %v", err)
+	}
+}
+
+func TestRecordLoginAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.CreateUser(ctx, &User{Username: "carol", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	until := time.Now().Add(time.Hour).UTC()
+	if err := repo.RecordFailedLogin(ctx, id, 3, &until); err != nil {
+		t.Fatalf("RecordFailedLogin failed: %v", err)
+	}
+
+	user, _ := repo.GetUserByID(ctx, id)
+	if user.FailedLoginAttempts != 3 || user.LockedUntil == nil {
+		t.Fatalf("Expected 3 attempts and a lock, got %+v", user)
+	}
+
+	if err := repo.RecordSuccessfulLogin(ctx, id, time.Now().UTC()); err != nil {
+		t.Fatalf("RecordSuccessfulLogin failed: %v", err)
+	}
+
+	user, _ = repo.GetUserByID(ctx, id)
+	if user.FailedLoginAttempts != 0 || user.LockedUntil != nil || user.LastLoginAt == nil {
+		t.Errorf("Expected counters reset and login stamped, got %+v", user)
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.CreateUser(ctx, &User{Username: "dave", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	user, _ := repo.GetUserByID(ctx, id)
+	if user.Role != RoleStudent {
+		t.Errorf("Expected default role %q, got %q", RoleStudent, user.Role)
+	}
+
+	if err := repo.SetRole(ctx, id, RoleTeacher); err != nil {
+		t.Fatalf("SetRole failed: %v", err)
+	}
+	user, _ = repo.GetUserByID(ctx, id)
+	if user.Role != RoleTeacher {
+		t.Errorf("Expected role %q, got %q", RoleTeacher, user.Role)
+	}
+
+	if err := repo.SetRole(ctx, id, "principal"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("Expected ErrInvalidRole, got %v", err)
+	}
+	if err := repo.SetRole(ctx, 999, RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
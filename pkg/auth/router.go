@@ -0,0 +1,449 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/metrics"
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// logger is the auth app logger
+var logger = logging.Logger("auth")
+
+// Router configures account routes
+type Router struct {
+	service  *Service
+	sessions *middleware.SQLiteStore
+}
+
+// NewRouter creates a new auth router
+func NewRouter(db *sql.DB) *Router {
+	repo := NewRepository(db)
+	service := NewService(repo)
+	return &Router{service: service}
+}
+
+// Service returns the underlying auth service
+func (r *Router) Service() *Service {
+	return r.service
+}
+
+// SetSessionStore enables server-side session listing and revocation
+func (r *Router) SetSessionStore(store *middleware.SQLiteStore) {
+	r.sessions = store
+}
+
+// Routes returns the auth router with all configured routes
+func (r *Router) Routes() chi.Router {
+	router := chi.NewRouter()
+
+	router.Get("/login", LoginPageHandler)
+	router.Post("/register", r.Register)
+	router.Post("/login", r.Login)
+	router.Post("/logout", r.Logout)
+	router.Post("/logout-all", r.LogoutAll)
+	router.Get("/me", r.Me)
+	router.Get("/sessions", r.ListSessions)
+	router.Delete("/sessions/{id}", r.RevokeSession)
+	router.Get("/tokens", r.ListTokens)
+	router.Post("/tokens", r.CreateToken)
+	router.Delete("/tokens/{id}", r.RevokeToken)
+
+	return router
+}
+
+// Register creates a new account and signs it in
+func (r *Router) Register(w http.ResponseWriter, req *http.Request) {
+	var body RegisterRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		respondError(w, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := r.service.Register(req.Context(), &body)
+	if err != nil {
+		if errors.Is(err, ErrUserExists) {
+			respondError(w, req, http.StatusConflict, err.Error())
+			return
+		}
+		respondInternalError(w, req, "Failed to register user", err)
+		return
+	}
+
+	if err := r.startSession(w, req, user); err != nil {
+		respondInternalError(w, req, "Failed to start session", err)
+		return
+	}
+
+	audit.Describe(req.Context(), "account.register", "user", user.ID)
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
+
+// Login verifies credentials and marks the session authenticated
+func (r *Router) Login(w http.ResponseWriter, req *http.Request) {
+	var body LoginRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		respondError(w, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := r.service.Authenticate(req.Context(), body.Username, body.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			metrics.Logins.Inc("invalid_credentials")
+			respondError(w, req, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, ErrAccountLocked):
+			metrics.Logins.Inc("locked")
+			respondError(w, req, http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, ErrAccountDisabled):
+			metrics.Logins.Inc("disabled")
+			respondError(w, req, http.StatusForbidden, err.Error())
+		default:
+			metrics.Logins.Inc("error")
+			respondInternalError(w, req, "Failed to log in", err)
+		}
+		return
+	}
+
+	if err := r.startSession(w, req, user); err != nil {
+		metrics.Logins.Inc("error")
+		respondInternalError(w, req, "Failed to start session", err)
+		return
+	}
+	metrics.Logins.Inc("success")
+	audit.Describe(req.Context(), "account.login", "user", user.ID)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
+
+// Logout clears the authenticated session
+func (r *Router) Logout(w http.ResponseWriter, req *http.Request) {
+	if err := endSession(w, req); err != nil {
+		respondInternalError(w, req, "Failed to end session", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// LogoutAll revokes every session of the current user, on all devices
+func (r *Router) LogoutAll(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	if r.sessions == nil {
+		respondError(w, req, http.StatusNotImplemented, "Session management is not enabled")
+		return
+	}
+
+	revoked, err := r.sessions.RevokeUserSessions(req.Context(), userID, "")
+	if err != nil {
+		respondInternalError(w, req, "Failed to revoke sessions", err)
+		return
+	}
+
+	if err := endSession(w, req); err != nil {
+		respondInternalError(w, req, "Failed to end session", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"revoked": revoked,
+	})
+}
+
+// ListSessions returns the current user's active sessions
+func (r *Router) ListSessions(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	if r.sessions == nil {
+		respondError(w, req, http.StatusNotImplemented, "Session management is not enabled")
+		return
+	}
+
+	var currentID string
+	if session := middleware.GetSession(req); session != nil {
+		currentID = session.ID
+	}
+
+	infos, err := r.sessions.ListUserSessions(req.Context(), userID, currentID)
+	if err != nil {
+		respondInternalError(w, req, "Failed to list sessions", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"sessions": infos,
+	})
+}
+
+// RevokeSession signs out one of the current user's sessions
+func (r *Router) RevokeSession(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	if r.sessions == nil {
+		respondError(w, req, http.StatusNotImplemented, "Session management is not enabled")
+		return
+	}
+
+	id := chi.URLParam(req, "id")
+	found, err := r.sessions.RevokeUserSession(req.Context(), userID, id)
+	if err != nil {
+		respondInternalError(w, req, "Failed to revoke session", err)
+		return
+	}
+	if !found {
+		respondError(w, req, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// Me returns the currently signed-in user
+func (r *Router) Me(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	user, err := r.service.GetUser(req.Context(), uint(userID))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+			return
+		}
+		respondInternalError(w, req, "Failed to get user", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
+
+// CreateToken issues a personal API token. Tokens can only be managed from a
+// signed-in browser session, not with another token.
+func (r *Router) CreateToken(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var body CreateTokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		respondError(w, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, raw, err := r.service.CreateToken(req.Context(), uint(userID), &body)
+	if err != nil {
+		respondInternalError(w, req, "Failed to create token", err)
+		return
+	}
+
+	audit.Describe(req.Context(), "token.create", "api_token", token.ID)
+	audit.RecordChange(req.Context(), nil, token)
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"token":   token,
+		"secret":  raw,
+	})
+}
+
+// ListTokens returns the current user's API tokens without their secrets
+func (r *Router) ListTokens(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	tokens, err := r.service.ListTokens(req.Context(), uint(userID))
+	if err != nil {
+		respondInternalError(w, req, "Failed to list tokens", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"tokens":  tokens,
+	})
+}
+
+// RevokeToken deletes one of the current user's API tokens
+func (r *Router) RevokeToken(w http.ResponseWriter, req *http.Request) {
+	userID, ok := middleware.GetUserID(req)
+	if !ok {
+		respondError(w, req, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := r.service.RevokeToken(req.Context(), uint(userID), uint(tokenID)); err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			respondError(w, req, http.StatusNotFound, err.Error())
+			return
+		}
+		respondInternalError(w, req, "Failed to revoke token", err)
+		return
+	}
+
+	audit.Describe(req.Context(), "token.revoke", "api_token", tokenID)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// startSession records the user in the request's session. A server-side
+// session is reissued under a new ID so a pre-login ID cannot be reused.
+func (r *Router) startSession(w http.ResponseWriter, req *http.Request, user *User) error {
+	session := middleware.GetSession(req)
+	if session == nil {
+		return errors.New("session middleware not installed")
+	}
+
+	if r.sessions != nil && session.ID != "" {
+		if err := r.sessions.RevokeSession(req.Context(), session.ID); err != nil {
+			return err
+		}
+		session.ID = ""
+	}
+
+	middleware.SetAuthenticated(session, int(user.ID), user.Username)
+	logging.SetUserID(req.Context(), int(user.ID))
+	audit.SetActor(req.Context(), int(user.ID))
+	return session.Save(req, w)
+}
+
+// endSession clears the request's session and expires its cookie
+func endSession(w http.ResponseWriter, req *http.Request) error {
+	session := middleware.GetSession(req)
+	if session == nil {
+		return nil
+	}
+
+	middleware.ClearSession(session)
+	session.Options.MaxAge = -1
+	return session.Save(req, w)
+}
+
+// Helper function to respond with JSON
+func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// Helper function to respond with a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
+
+// LoginPageHandler serves the sign-in page
+func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Sign In - Unified Educational Platform</title>
+    <style>
+        body { font-family: Arial, sans-serif; max-width: 400px; margin: 50px auto; padding: 20px; }
+        h1 { color: #333; }
+        label { display: block; margin-top: 12px; }
+        input { width: 100%; padding: 8px; box-sizing: border-box; }
+        button { margin-top: 16px; padding: 8px 16px; }
+        .error { color: #c00; margin-top: 12px; }
+    </style>
+</head>
+<body>
+    <h1>Sign In</h1>
+    <form id="login-form">
+        <label>Username <input name="username" autocomplete="username" required></label>
+        <label>Password <input name="password" type="password" autocomplete="current-password" required></label>
+        <button type="submit">Sign In</button>
+        <div class="error" id="error"></div>
+    </form>
+    <script>
+        document.getElementById('login-form').addEventListener('submit', async (e) => {
+            e.preventDefault();
+            const form = new FormData(e.target);
+            const res = await fetch('/auth/login', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ username: form.get('username'), password: form.get('password') })
+            });
+            if (res.ok) {
+                window.location = '/dashboard';
+                return;
+            }
+            const body = await res.json();
+            document.getElementById('error').textContent = body.error || 'Sign in failed';
+        });
+    </script>
+</body>
+</html>
+	`))
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// setupTestServer wraps the auth routes in the session middleware
+func setupTestServer(t *testing.T) http.Handler {
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	am := middleware.NewAuthMiddleware("test-secret", "unified_session")
+	return am.Handler(NewRouter(db).Routes())
+}
+
+// doJSON sends a request with the given cookies and returns the recorder
+func doJSON(h http.Handler, method, path string, body interface{}, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthFlow(t *testing.T) {
+	h := setupTestServer(t)
+
+	w := doJSON(h, "POST", "/register", map[string]string{"username": "frank", "password": "sunshine42"}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 from register, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(h, "GET", "/me", nil, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from /me without a session, got %d", w.Code)
+	}
+
+	w = doJSON(h, "POST", "/login", map[string]string{"username": "frank", "password": "sunshine42"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from login, got %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("Expected session cookie from login")
+	}
+
+	w = doJSON(h, "GET", "/me", nil, cookies)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from /me, got %d: %s", w.Code, w.Body.String())
+	}
+	var me struct {
+		User User `json:"user"`
+	}
+	json.NewDecoder(w.Body).Decode(&me)
+	if me.User.Username != "frank" {
+		t.Errorf("Expected username frank, got %q", me.User.Username)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("pbkdf2")) {
+		t.Error("Password hash leaked in /me response")
+	}
+
+	w = doJSON(h, "POST", "/logout", nil, cookies)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from logout, got %d", w.Code)
+	}
+
+	w = doJSON(h, "GET", "/me", nil, w.Result().Cookies())
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from /me after logout, got %d", w.Code)
+	}
+}
+
+func TestRegisterRejectsWeakPassword(t *testing.T) {
+	h := setupTestServer(t)
+
+	w := doJSON(h, "POST", "/register", map[string]string{"username": "gina", "password": "weak"}, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for weak password, got %d", w.Code)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	h := setupTestServer(t)
+
+	doJSON(h, "POST", "/register", map[string]string{"username": "hank", "password": "sunshine42"}, nil)
+
+	w := doJSON(h, "POST", "/login", map[string]string{"username": "hank", "password": "moonlight42"}, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong password, got %d", w.Code)
+	}
+}
+
+// setupSessionServer wraps the auth routes in the SQLite-backed session middleware
+func setupSessionServer(t *testing.T) http.Handler {
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	store := middleware.NewSQLiteStore(db, []byte("test-secret"))
+	router := NewRouter(db)
+	router.SetSessionStore(store)
+
+	am := middleware.NewAuthMiddlewareWithStore(store, "unified_session")
+	return am.Handler(router.Routes())
+}
+
+func TestSessionManagement(t *testing.T) {
+	h := setupSessionServer(t)
+
+	w := doJSON(h, "POST", "/register", map[string]string{"username": "iris", "password": "sunshine42"}, nil)
+	laptop := w.Result().Cookies()
+
+	login := func() []*http.Cookie {
+		w := doJSON(h, "POST", "/login", map[string]string{"username": "iris", "password": "sunshine42"}, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from login, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Result().Cookies()
+	}
+	phone := login()
+
+	w = doJSON(h, "GET", "/sessions", nil, laptop)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from /sessions, got %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Sessions []middleware.SessionInfo `json:"sessions"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(list.Sessions))
+	}
+	var phoneID string
+	current := 0
+	for _, s := range list.Sessions {
+		if s.Current {
+			current++
+		} else {
+			phoneID = s.ID
+		}
+	}
+	if current != 1 {
+		t.Errorf("Expected exactly one current session, got %d", current)
+	}
+
+	w = doJSON(h, "DELETE", "/sessions/"+phoneID, nil, laptop)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 revoking phone session, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = doJSON(h, "GET", "/me", nil, phone); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from revoked session, got %d", w.Code)
+	}
+
+	tablet := login()
+	w = doJSON(h, "POST", "/logout-all", nil, laptop)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from logout-all, got %d: %s", w.Code, w.Body.String())
+	}
+	for name, cookies := range map[string][]*http.Cookie{"laptop": laptop, "tablet": tablet} {
+		if w = doJSON(h, "GET", "/me", nil, cookies); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 from %s after logout-all, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestSessionManagementRequiresLogin(t *testing.T) {
+	h := setupSessionServer(t)
+
+	if w := doJSON(h, "GET", "/sessions", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from /sessions without a session, got %d", w.Code)
+	}
+	if w := doJSON(h, "POST", "/logout-all", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from /logout-all without a session, got %d", w.Code)
+	}
+}
+
+func TestTokenEndpoints(t *testing.T) {
+	h := setupTestServer(t)
+
+	w := doJSON(h, "POST", "/register", map[string]string{"username": "kira", "password": "sunshine42"}, nil)
+	cookies := w.Result().Cookies()
+
+	w = doJSON(h, "POST", "/tokens", map[string]interface{}{"name": "ext", "scopes": []string{"typing:write"}}, cookies)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 from create token, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token  APIToken `json:"token"`
+		Secret string   `json:"secret"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Secret == "" || created.Token.ID == 0 {
+		t.Fatalf("Expected token and secret, got %+v", created)
+	}
+
+	w = doJSON(h, "GET", "/tokens", nil, cookies)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(created.Secret)) {
+		t.Errorf("Expected token list without secrets, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(h, "POST", "/tokens", map[string]interface{}{"name": "bad", "scopes": []string{"root"}}, cookies)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown scope, got %d", w.Code)
+	}
+
+	if w = doJSON(h, "GET", "/tokens", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 listing tokens without a session, got %d", w.Code)
+	}
+
+	w = doJSON(h, "DELETE", "/tokens/"+strconv.Itoa(int(created.Token.ID)), nil, cookies)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 revoking token, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = doJSON(h, "DELETE", "/tokens/"+strconv.Itoa(int(created.Token.ID)), nil, cookies); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 revoking a missing token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// Lockout policy defaults
+const (
+	DefaultMaxFailedAttempts = 5
+	DefaultLockoutDuration   = 15 * time.Minute
+)
+
+// tokenTouchInterval limits how often a token's last-used time is rewritten
+const tokenTouchInterval = time.Minute
+
+// Service provides account registration and authentication
+type Service struct {
+	repo              *Repository
+	maxFailedAttempts int
+	lockoutDuration   time.Duration
+	now               func() time.Time
+}
+
+// NewService creates a new auth service with the default lockout policy
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo:              repo,
+		maxFailedAttempts: DefaultMaxFailedAttempts,
+		lockoutDuration:   DefaultLockoutDuration,
+		now:               time.Now,
+	}
+}
+
+// SetLockoutPolicy overrides how many failures lock an account and for how long
+func (s *Service) SetLockoutPolicy(maxFailedAttempts int, lockoutDuration time.Duration) {
+	if maxFailedAttempts > 0 {
+		s.maxFailedAttempts = maxFailedAttempts
+	}
+	if lockoutDuration > 0 {
+		s.lockoutDuration = lockoutDuration
+	}
+}
+
+// Register validates the request, hashes the password and creates the user
+func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUserExists
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{
+		Username:     req.Username,
+		Email:        req.Email,
+		Role:         req.Role,
+		PasswordHash: hash,
+	}
+
+	id, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUserByID(ctx, id)
+}
+
+// Authenticate verifies credentials, applying the lockout policy on repeated failures
+func (s *Service) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		// Burn comparable time so unknown usernames are not distinguishable
+		VerifyPassword(password, dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+
+	now := s.now()
+	if user.IsLocked(now) {
+		return nil, ErrAccountLocked
+	}
+
+	ok, err := VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if !ok {
+		attempts := user.FailedLoginAttempts + 1
+		var lockedUntil *time.Time
+		if attempts >= s.maxFailedAttempts {
+			until := now.Add(s.lockoutDuration)
+			lockedUntil = &until
+			attempts = 0
+		}
+		if err := s.repo.RecordFailedLogin(ctx, user.ID, attempts, lockedUntil); err != nil {
+			return nil, err
+		}
+		if lockedUntil != nil {
+			return nil, ErrAccountLocked
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// Only reveal that an account is disabled to someone who knows its password
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	if err := s.repo.RecordSuccessfulLogin(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	user.LastLoginAt = &now
+
+	return user, nil
+}
+
+// ConfirmPassword reports whether password is a user's current password, to
+// re-authenticate them before a destructive action. Wrong passwords count
+// toward the account lockout like failed logins.
+func (s *Service) ConfirmPassword(ctx context.Context, userID uint, password string) (bool, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.Authenticate(ctx, user.Username, password)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrAccountLocked), errors.Is(err, ErrAccountDisabled):
+		return false, nil
+	}
+	return false, err
+}
+
+// GetUser returns the user with the given ID
+func (s *Service) GetUser(ctx context.Context, userID uint) (*User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetUserByUsername returns the user with the given username
+func (s *Service) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ChangePassword validates and stores a new password for a user
+func (s *Service) ChangePassword(ctx context.Context, userID uint, newPassword string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidatePasswordStrength(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePasswordHash(ctx, userID, hash)
+}
+
+// SetRole changes a user's account role
+func (s *Service) SetRole(ctx context.Context, userID uint, role string) error {
+	return s.repo.SetRole(ctx, userID, role)
+}
+
+// ListUsers returns every account
+func (s *Service) ListUsers(ctx context.Context) ([]*User, error) {
+	return s.repo.ListUsers(ctx)
+}
+
+// DisableUser blocks a user from logging in and deletes their API tokens.
+// Sessions live in the session store and are revoked by the caller.
+func (s *Service) DisableUser(ctx context.Context, userID uint) error {
+	now := s.now().UTC()
+	if err := s.repo.SetDisabled(ctx, userID, &now); err != nil {
+		return err
+	}
+	_, err := s.repo.DeleteUserTokens(ctx, userID)
+	return err
+}
+
+// EnableUser lets a disabled user log in again
+func (s *Service) EnableUser(ctx context.Context, userID uint) error {
+	return s.repo.SetDisabled(ctx, userID, nil)
+}
+
+// CreateToken issues a new API token for a user. The returned string is the
+// only copy of the token; only its hash is stored.
+func (s *Service) CreateToken(ctx context.Context, userID uint, req *CreateTokenRequest) (*APIToken, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	raw, prefix, hash, err := generateAPIToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now().UTC()
+	token := &APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		Scopes:    dedupeScopes(req.Scopes),
+		CreatedAt: now,
+		TokenHash: hash,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	id, err := s.repo.CreateToken(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+	token.ID = id
+
+	return token, raw, nil
+}
+
+// ListTokens returns a user's API tokens
+func (s *Service) ListTokens(ctx context.Context, userID uint) ([]*APIToken, error) {
+	return s.repo.ListTokens(ctx, userID)
+}
+
+// RevokeToken deletes one of a user's API tokens
+func (s *Service) RevokeToken(ctx context.Context, userID, tokenID uint) error {
+	return s.repo.DeleteToken(ctx, userID, tokenID)
+}
+
+// ValidateToken resolves a bearer token to its user and scopes, returning nil
+// for unknown or expired tokens. It implements middleware.TokenValidator.
+func (s *Service) ValidateToken(ctx context.Context, raw string) (*middleware.TokenIdentity, error) {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, nil
+	}
+
+	token, err := s.repo.GetTokenByHash(ctx, hashAPIToken(raw))
+	if err != nil || token == nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if token.IsExpired(now) {
+		return nil, nil
+	}
+
+	if err := s.repo.TouchToken(ctx, token.ID, now, tokenTouchInterval); err != nil {
+		// Authentication still succeeds; only the usage timestamp is stale
+		logger.WarnContext(ctx, "failed to record api token use", "token_id", token.ID, "error", err)
+	}
+
+	return &middleware.TokenIdentity{UserID: int(token.UserID), Scopes: token.Scopes}, nil
+}
+
+// dedupeScopes removes repeated scopes, keeping their order
+func dedupeScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+// dummyHash is verified against when a username does not exist
+var dummyHash, _ = HashPassword("unified-go-timing-equalizer-0")
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterAndAuthenticate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &RegisterRequest{Username: "dana", Password: "sunshine42"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if user.PasswordHash == "sunshine42" {
+		t.Fatal("Password stored in plain text")
+	}
+
+	if _, err := service.Register(ctx, &RegisterRequest{Username: "Dana", Password: "sunshine42"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists for duplicate username, got %v", err)
+	}
+
+	authed, err := service.Authenticate(ctx, "dana", "sunshine42")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if authed.ID != user.ID || authed.LastLoginAt == nil {
+		t.Errorf("Unexpected authenticated user: %+v", authed)
+	}
+
+	if _, err := service.Authenticate(ctx, "dana", "wrongpass1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "ghost", "sunshine42"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	service.SetLockoutPolicy(3, 10*time.Minute)
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	if _, err := service.Register(ctx, &RegisterRequest{Username: "erin", Password: "sunshine42"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Authenticate(ctx, "erin", "wrongpass1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Attempt %d: expected ErrInvalidCredentials, got %v", i+1, err)
+		}
+	}
+
+	if _, err := service.Authenticate(ctx, "erin", "wrongpass1"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Expected account to lock on third failure, got %v", err)
+	}
+
+	// Correct password is refused while locked
+	if _, err := service.Authenticate(ctx, "erin", "sunshine42"); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("Expected ErrAccountLocked during lockout, got %v", err)
+	}
+
+	now = now.Add(11 * time.Minute)
+	if _, err := service.Authenticate(ctx, "erin", "sunshine42"); err != nil {
+		t.Errorf("Expected login to succeed after lockout expires, got %v", err)
+	}
+}
+
+func TestConfirmPassword(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	service.SetLockoutPolicy(2, 10*time.Minute)
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &RegisterRequest{Username: "fern", Password: "sunshine42"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if ok, err := service.ConfirmPassword(ctx, user.ID, "sunshine42"); err != nil || !ok {
+		t.Errorf("Expected the current password confirmed, got %v: %v", ok, err)
+	}
+	if ok, err := service.ConfirmPassword(ctx, user.ID, "wrongpass1"); err != nil || ok {
+		t.Errorf("Expected a wrong password refused, got %v: %v", ok, err)
+	}
+
+	// Wrong passwords count toward the lockout
+	service.ConfirmPassword(ctx, user.ID, "wrongpass1")
+	if ok, err := service.ConfirmPassword(ctx, user.ID, "sunshine42"); err != nil || ok {
+		t.Errorf("Expected a locked account refused, got %v: %v", ok, err)
+	}
+
+	if _, err := service.ConfirmPassword(ctx, user.ID+100, "sunshine42"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &RegisterRequest{Username: "jules", Password: "sunshine42"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	token, raw, err := service.CreateToken(ctx, user.ID, &CreateTokenRequest{
+		Name:   "Chrome extension",
+		Scopes: []string{"typing:write", "stats:read", "typing:write"},
+	})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if token.TokenHash == raw || !strings.HasPrefix(raw, token.Prefix) {
+		t.Errorf("Unexpected token hash/prefix: %+v", token)
+	}
+	if len(token.Scopes) != 2 {
+		t.Errorf("Expected duplicate scopes to be dropped, got %v", token.Scopes)
+	}
+
+	identity, err := service.ValidateToken(ctx, raw)
+	if err != nil || identity == nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if identity.UserID != int(user.ID) || len(identity.Scopes) != 2 {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+
+	tokens, err := service.ListTokens(ctx, user.ID)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("Expected 1 token, got %d (%v)", len(tokens), err)
+	}
+	if tokens[0].LastUsedAt == nil {
+		t.Error("Expected last_used_at to be recorded")
+	}
+
+	if identity, _ := service.ValidateToken(ctx, raw+"x"); identity != nil {
+		t.Error("Expected altered token to be rejected")
+	}
+
+	if err := service.RevokeToken(ctx, user.ID+1, token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound revoking another user's token, got %v", err)
+	}
+	if err := service.RevokeToken(ctx, user.ID, token.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if identity, _ := service.ValidateToken(ctx, raw); identity != nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+}
+
+func TestAPITokenExpiry(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	ctx := context.Background()
+
+	_, raw, err := service.CreateToken(ctx, 1, &CreateTokenRequest{Name: "script", Scopes: []string{"math:read"}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	service.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	if identity, _ := service.ValidateToken(ctx, raw); identity != nil {
+		t.Error("Expected expired token to be rejected")
+	}
+
+	if _, _, err := service.CreateToken(ctx, 1, &CreateTokenRequest{Name: "bad", Scopes: []string{"admin"}}); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope, got %v", err)
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(NewRepository(db))
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, &RegisterRequest{Username: "kit", Password: "sunshine42"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	_, raw, err := service.CreateToken(ctx, user.ID, &CreateTokenRequest{Name: "cli", Scopes: []string{"stats:read"}})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	if err := service.DisableUser(ctx, user.ID); err != nil {
+		t.Fatalf("DisableUser failed: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "kit", "sunshine42"); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("Expected ErrAccountDisabled, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "kit", "wrongpass1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials with a wrong password, got %v", err)
+	}
+	if identity, _ := service.ValidateToken(ctx, raw); identity != nil {
+		t.Error("Expected the disabled user's token to be deleted")
+	}
+
+	if err := service.EnableUser(ctx, user.ID); err != nil {
+		t.Fatalf("EnableUser failed: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "kit", "sunshine42"); err != nil {
+		t.Errorf("Authenticate after EnableUser failed: %v", err)
+	}
+
+	if err := service.DisableUser(ctx, user.ID+1); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
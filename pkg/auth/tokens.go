@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// API token format parameters
+const (
+	tokenPrefix       = "ugt_"
+	tokenSecretLength = 32
+	tokenDisplayChars = 8
+)
+
+var tokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateAPIToken returns a new random token, the short prefix shown when
+// listing tokens, and the hash that is stored in place of the token
+func generateAPIToken() (token, prefix, hash string, err error) {
+	secret := make([]byte, tokenSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api token: %w", err)
+	}
+
+	token = tokenPrefix + strings.ToLower(tokenEncoding.EncodeToString(secret))
+	return token, token[:len(tokenPrefix)+tokenDisplayChars], hashAPIToken(token), nil
+}
+
+// hashAPIToken hashes a token for storage and lookup. Tokens carry 256 bits of
+// randomness, so an unsalted SHA-256 is sufficient and allows indexed lookups.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
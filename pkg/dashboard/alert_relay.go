@@ -0,0 +1,57 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
+)
+
+// AlertChannel carries the system alerts
+const AlertChannel = "system:alerts"
+
+// AlertRelay streams the GAIA metrics collector's alerts, as they are raised
+// and resolved, to dashboard clients subscribed to AlertChannel
+type AlertRelay struct {
+	hub *realtime.Hub
+}
+
+// NewAlertRelay creates a new alert relay
+func NewAlertRelay(hub *realtime.Hub) *AlertRelay {
+	return &AlertRelay{hub: hub}
+}
+
+// Subscribe relays the alert events published on the event bus
+func (ar *AlertRelay) Subscribe(bus *events.Bus) {
+	bus.SubscribeMultiple([]events.EventType{events.EventGaiaAlertRaised, events.EventGaiaAlertResolved},
+		func(event *events.Event) error {
+			return ar.HandleAlertEvent(context.Background(), event)
+		})
+}
+
+// HandleAlertEvent broadcasts a GAIA alert event on AlertChannel
+func (ar *AlertRelay) HandleAlertEvent(ctx context.Context, event *events.Event) error {
+	if event == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	switch event.Type {
+	case events.EventGaiaAlertRaised, events.EventGaiaAlertResolved:
+	default:
+		return fmt.Errorf("unknown event type: %s", event.Type)
+	}
+	if _, ok := event.Data["alert_id"].(string); !ok {
+		return fmt.Errorf("alert_id not found in event data")
+	}
+
+	message := map[string]interface{}{
+		"type":      string(event.Type),
+		"timestamp": event.Timestamp,
+	}
+	for key, value := range event.Data {
+		message[key] = value
+	}
+	ar.hub.Broadcast(AlertChannel, message)
+	return nil
+}
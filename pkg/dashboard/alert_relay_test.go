@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
+)
+
+func TestAlertRelay(t *testing.T) {
+	hub := realtime.NewHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	client := realtime.NewClient(hub, nil, 1)
+	hub.Subscribe(client, AlertChannel)
+
+	bus := events.NewBus(10)
+	go bus.Run()
+	NewAlertRelay(hub).Subscribe(bus)
+
+	bus.PublishAsync(events.NewEvent(events.EventGaiaAlertRaised, 0, "gaia", map[string]interface{}{
+		"alert_id":    "a1",
+		"metric_name": "pending_tasks",
+		"severity":    "warning",
+	}))
+	bus.PublishAsync(events.NewEvent(events.EventGaiaAlertResolved, 0, "gaia", map[string]interface{}{
+		"alert_id":    "a1",
+		"metric_name": "pending_tasks",
+		"severity":    "warning",
+	}))
+	bus.Drain(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for hub.GetStats().TotalMessages < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := hub.GetStats().TotalMessages; got != 2 {
+		t.Errorf("expected 2 alert messages delivered, got %d", got)
+	}
+
+	relay := NewAlertRelay(hub)
+	if err := relay.HandleAlertEvent(context.Background(), nil); err == nil {
+		t.Error("expected error for nil event")
+	}
+	if err := relay.HandleAlertEvent(context.Background(), events.NewEvent(events.EventGaiaSessionState, 0, "gaia", map[string]interface{}{"alert_id": "a1"})); err == nil {
+		t.Error("expected error for an event that is not an alert event")
+	}
+	if err := relay.HandleAlertEvent(context.Background(), events.NewEvent(events.EventGaiaAlertRaised, 0, "gaia", map[string]interface{}{})); err == nil {
+		t.Error("expected error for missing alert_id")
+	}
+}
@@ -0,0 +1,57 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
+)
+
+// FleetChannel carries the health of the GAIA worker sessions
+const FleetChannel = "gaia:fleet"
+
+// FleetHealthRelay streams the GAIA watchdog's session health and state
+// changes to dashboard clients subscribed to FleetChannel
+type FleetHealthRelay struct {
+	hub *realtime.Hub
+}
+
+// NewFleetHealthRelay creates a new fleet health relay
+func NewFleetHealthRelay(hub *realtime.Hub) *FleetHealthRelay {
+	return &FleetHealthRelay{hub: hub}
+}
+
+// Subscribe relays the fleet events published on the event bus
+func (fr *FleetHealthRelay) Subscribe(bus *events.Bus) {
+	bus.SubscribeMultiple([]events.EventType{events.EventGaiaSessionHealth, events.EventGaiaSessionState},
+		func(event *events.Event) error {
+			return fr.HandleFleetEvent(context.Background(), event)
+		})
+}
+
+// HandleFleetEvent broadcasts a GAIA session event on FleetChannel
+func (fr *FleetHealthRelay) HandleFleetEvent(ctx context.Context, event *events.Event) error {
+	if event == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	switch event.Type {
+	case events.EventGaiaSessionHealth, events.EventGaiaSessionState:
+	default:
+		return fmt.Errorf("unknown event type: %s", event.Type)
+	}
+	if _, ok := event.Data["session_id"].(string); !ok {
+		return fmt.Errorf("session_id not found in event data")
+	}
+
+	message := map[string]interface{}{
+		"type":      string(event.Type),
+		"timestamp": event.Timestamp,
+	}
+	for key, value := range event.Data {
+		message[key] = value
+	}
+	fr.hub.Broadcast(FleetChannel, message)
+	return nil
+}
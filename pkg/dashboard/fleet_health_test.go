@@ -0,0 +1,56 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/pkg/events"
+	"github.com/jgirmay/unified-go/pkg/realtime"
+)
+
+func TestFleetHealthRelay(t *testing.T) {
+	hub := realtime.NewHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	client := realtime.NewClient(hub, nil, 1)
+	hub.Subscribe(client, FleetChannel)
+
+	bus := events.NewBus(10)
+	go bus.Run()
+	NewFleetHealthRelay(hub).Subscribe(bus)
+
+	bus.PublishAsync(events.NewEvent(events.EventGaiaSessionState, 0, "gaia", map[string]interface{}{
+		"session_id": "worker-1",
+		"status":     "unhealthy",
+	}))
+	bus.PublishAsync(events.NewEvent(events.EventGaiaSessionHealth, 0, "gaia", map[string]interface{}{
+		"session_id":   "worker-1",
+		"health_score": 40.0,
+	}))
+	bus.Drain(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for hub.GetStats().TotalMessages < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := hub.GetStats().TotalMessages; got != 2 {
+		t.Errorf("expected 2 fleet messages delivered, got %d", got)
+	}
+}
+
+func TestHandleFleetEventInvalid(t *testing.T) {
+	relay := NewFleetHealthRelay(realtime.NewHub())
+	ctx := context.Background()
+
+	if err := relay.HandleFleetEvent(ctx, nil); err == nil {
+		t.Error("expected error for nil event")
+	}
+	if err := relay.HandleFleetEvent(ctx, events.NewEvent(events.EventScoreUpdated, 1, "typing", nil)); err == nil {
+		t.Error("expected error for an event that is not a fleet event")
+	}
+	if err := relay.HandleFleetEvent(ctx, events.NewEvent(events.EventGaiaSessionState, 0, "gaia", map[string]interface{}{})); err == nil {
+		t.Error("expected error for missing session_id")
+	}
+}
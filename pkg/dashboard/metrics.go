@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"sort"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// Collect implements metrics.Collector with the WebSocket connection and
+// message counters
+func (h *WebSocketHandler) Collect() []metrics.Family {
+	h.stats.mu.RLock()
+	defer h.stats.mu.RUnlock()
+
+	return []metrics.Family{
+		metrics.Gauge("unified_ws_connections", "Open dashboard WebSocket connections.", float64(h.stats.ActiveConnections)),
+		metrics.Counter("unified_ws_connections_total", "Dashboard WebSocket connections accepted.", float64(h.stats.TotalConnections)),
+		metrics.Counter("unified_ws_connection_errors_total", "Dashboard WebSocket upgrade and connection errors.", float64(h.stats.ConnectionErrors)),
+		metrics.Counter("unified_ws_messages_received_total", "Messages received from dashboard WebSocket clients.", float64(h.stats.TotalMessagesReceived)),
+		metrics.Counter("unified_ws_messages_sent_total", "Messages sent to dashboard WebSocket clients.", float64(h.stats.TotalMessagesSent)),
+	}
+}
+
+// Collect implements metrics.Collector with the notification counts by state
+func (nq *NotificationQueue) Collect() []metrics.Family {
+	stats := nq.GetQueueStats()
+
+	states := make([]string, 0, len(stats))
+	for state := range stats {
+		if state != "total" {
+			states = append(states, state)
+		}
+	}
+	sort.Strings(states)
+
+	family := metrics.Family{
+		Name: "unified_notifications",
+		Help: "Notifications held in the queue, by state.",
+		Type: metrics.TypeGauge,
+	}
+	for _, state := range states {
+		family.Samples = append(family.Samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "state", Value: state}},
+			Value:  float64(stats[state]),
+		})
+	}
+	return []metrics.Family{family}
+}
@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -63,6 +64,8 @@ type NotificationQueue struct {
 	mu               sync.RWMutex
 	processingTicker *time.Ticker
 	stopChan         chan bool
+	stopOnce         sync.Once
+	done             chan struct{}
 }
 
 // DeliveryHandler is a function that delivers a notification
@@ -80,6 +83,7 @@ func NewNotificationQueue() *NotificationQueue {
 		retryBackoff:     5 * time.Second,
 		deliveryHandlers: make(map[NotificationType]DeliveryHandler),
 		stopChan:         make(chan bool),
+		done:             make(chan struct{}),
 	}
 
 	// Start background processor
@@ -133,6 +137,7 @@ func (nq *NotificationQueue) RegisterDeliveryHandler(notificationType Notificati
 
 // processQueue processes pending notifications in background
 func (nq *NotificationQueue) processQueue() {
+	defer close(nq.done)
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
@@ -393,10 +398,54 @@ func (nq *NotificationQueue) PurgeOldNotifications(olderThan time.Duration) int
 
 // Close stops the notification queue processor
 func (nq *NotificationQueue) Close() error {
-	close(nq.stopChan)
+	nq.stopOnce.Do(func() {
+		close(nq.stopChan)
+		<-nq.done
+	})
 	return nil
 }
 
+// Drain stops the processor, then delivers pending notifications, waiting
+// the retry backoff between attempts, until none are left to retry or ctx
+// expires. Notifications still pending at that point are cancelled. It
+// returns the number of notifications dropped: those cancelled and those
+// that failed for good during the drain.
+func (nq *NotificationQueue) Drain(ctx context.Context) int {
+	nq.Close()
+
+	nq.mu.RLock()
+	failedBefore := len(nq.failedQueue)
+	nq.mu.RUnlock()
+
+	for ctx.Err() == nil {
+		nq.processPendingNotifications()
+
+		nq.mu.RLock()
+		pending := len(nq.pendingQueue)
+		nq.mu.RUnlock()
+		if pending == 0 {
+			break
+		}
+
+		timer := time.NewTimer(nq.retryBackoff)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+
+	dropped := len(nq.pendingQueue) + len(nq.failedQueue) - failedBefore
+	for _, notif := range nq.pendingQueue {
+		notif.Status = NotificationStatusCancelled
+	}
+	nq.pendingQueue = nil
+	return dropped
+}
+
 // generateNotificationID generates a unique notification ID
 func (nq *NotificationQueue) generateNotificationID() uint {
 	maxID := uint(0)
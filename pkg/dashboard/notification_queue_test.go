@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -498,3 +499,71 @@ func TestNotificationQueueConcurrency(t *testing.T) {
 		t.Error("no notifications queued")
 	}
 }
+
+func TestNotificationQueueDrain(t *testing.T) {
+	queue := NewNotificationQueue()
+
+	delivered := 0
+	queue.RegisterDeliveryHandler(NotificationTypeInApp, func(n *Notification) error {
+		delivered++
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		queue.QueueNotification(&Notification{UserID: 1, NotificationType: NotificationTypeInApp})
+	}
+
+	if dropped := queue.Drain(context.Background()); dropped != 0 {
+		t.Errorf("expected no dropped notifications, got %d", dropped)
+	}
+	if delivered != 3 {
+		t.Errorf("expected 3 delivered notifications, got %d", delivered)
+	}
+	if err := queue.Close(); err != nil {
+		t.Errorf("expected Close after Drain to succeed, got %v", err)
+	}
+}
+
+func TestNotificationQueueDrainExpired(t *testing.T) {
+	queue := NewNotificationQueue()
+	queue.RegisterDeliveryHandler(NotificationTypeInApp, func(n *Notification) error { return nil })
+	// Stop the processor first so nothing is delivered before Drain
+	queue.Close()
+	for i := 0; i < 2; i++ {
+		queue.QueueNotification(&Notification{UserID: 1, NotificationType: NotificationTypeInApp})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if dropped := queue.Drain(ctx); dropped != 2 {
+		t.Errorf("expected 2 dropped notifications, got %d", dropped)
+	}
+	for _, n := range queue.GetUserNotifications(1, 0) {
+		if n.Status != NotificationStatusCancelled {
+			t.Errorf("expected dropped notifications to be cancelled, got %s", n.Status)
+		}
+	}
+}
+
+func TestNotificationQueueDrainRetries(t *testing.T) {
+	queue := NewNotificationQueue()
+	queue.retryBackoff = 20 * time.Millisecond
+
+	attempts := 0
+	queue.RegisterDeliveryHandler(NotificationTypeInApp, func(n *Notification) error {
+		attempts++
+		return fmt.Errorf("offline")
+	})
+	queue.QueueNotification(&Notification{UserID: 1, NotificationType: NotificationTypeInApp})
+	queue.QueueNotification(&Notification{UserID: 1, NotificationType: NotificationTypeEmail})
+
+	start := time.Now()
+	if dropped := queue.Drain(context.Background()); dropped != 2 {
+		t.Errorf("expected the notifications failing during the drain to be dropped, got %d", dropped)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 delivery attempts, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed < 2*queue.retryBackoff {
+		t.Errorf("expected the drain to wait the retry backoff between attempts, took %v", elapsed)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jgirmay/unified-go/internal/apierror"
 	"github.com/jgirmay/unified-go/pkg/realtime"
 )
 
@@ -349,7 +351,7 @@ func (r *Router) getSystemStats(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	stats, err := r.service.GetSystemStats(ctx)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get system stats", err)
 		return
 	}
 
@@ -360,14 +362,14 @@ func (r *Router) getSystemStats(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getUserProfile(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	ctx := req.Context()
 	profile, err := r.service.GetUserProfile(ctx, uint(userID))
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get user profile", err)
 		return
 	}
 
@@ -378,14 +380,14 @@ func (r *Router) getUserProfile(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getUserAnalytics(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	ctx := req.Context()
 	analytics, err := r.service.GetUserAnalytics(ctx, uint(userID))
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get user analytics", err)
 		return
 	}
 
@@ -396,7 +398,7 @@ func (r *Router) getUserAnalytics(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getUserSessions(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
@@ -412,7 +414,7 @@ func (r *Router) getUserSessions(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	sessions, err := r.service.GetRecentActivity(ctx, uint(userID), limit)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get user sessions", err)
 		return
 	}
 
@@ -424,10 +426,7 @@ func (r *Router) getLeaderboard(w http.ResponseWriter, req *http.Request) {
 	category := chi.URLParam(req, "category")
 
 	if !r.service.ValidateCategory(category) {
-		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error":                   "invalid category",
-			"available_categories": r.service.GetAvailableCategories(),
-		})
+		respondInvalidCategory(w, req, r.service.GetAvailableCategories())
 		return
 	}
 
@@ -443,7 +442,7 @@ func (r *Router) getLeaderboard(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	leaderboard, err := r.leaderboardService.GetLeaderboardByCategory(ctx, category, limit)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get leaderboard", err)
 		return
 	}
 
@@ -463,14 +462,14 @@ func (r *Router) listLeaderboards(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getTrends(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	ctx := req.Context()
 	trends, err := r.service.GetTrends(ctx, uint(userID))
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get trends", err)
 		return
 	}
 
@@ -481,14 +480,14 @@ func (r *Router) getTrends(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getDashboardOverview(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	ctx := req.Context()
 	overview, err := r.service.GetDashboardOverview(ctx, uint(userID))
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get dashboard overview", err)
 		return
 	}
 
@@ -499,14 +498,14 @@ func (r *Router) getDashboardOverview(w http.ResponseWriter, req *http.Request)
 func (r *Router) getRecommendations(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	ctx := req.Context()
 	recommendations, err := r.service.GetRecommendations(ctx, uint(userID))
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get recommendations", err)
 		return
 	}
 
@@ -518,10 +517,7 @@ func (r *Router) getLeaderboardStats(w http.ResponseWriter, req *http.Request) {
 	category := chi.URLParam(req, "category")
 
 	if !r.service.ValidateCategory(category) {
-		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error":                "invalid category",
-			"available_categories": r.service.GetAvailableCategories(),
-		})
+		respondInvalidCategory(w, req, r.service.GetAvailableCategories())
 		return
 	}
 
@@ -537,7 +533,7 @@ func (r *Router) getLeaderboardStats(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	leaderboard, err := r.leaderboardService.GetLeaderboardByCategory(ctx, category, limit)
 	if err != nil {
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		respondInternalError(w, req, "Failed to get leaderboard stats", err)
 		return
 	}
 
@@ -549,27 +545,20 @@ func (r *Router) getLeaderboardStats(w http.ResponseWriter, req *http.Request) {
 func (r *Router) getUserRank(w http.ResponseWriter, req *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(req, "userID"), 10, 32)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
+		respondError(w, req, http.StatusBadRequest, "invalid user ID")
 		return
 	}
 
 	category := chi.URLParam(req, "category")
 	if !r.service.ValidateCategory(category) {
-		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error":                "invalid category",
-			"available_categories": r.service.GetAvailableCategories(),
-		})
+		respondInvalidCategory(w, req, r.service.GetAvailableCategories())
 		return
 	}
 
 	ctx := req.Context()
 	rank, err := r.leaderboardService.GetUserRank(ctx, uint(userID), category)
 	if err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]interface{}{
-			"error":  "user not found in leaderboard",
-			"userID": userID,
-			"category": category,
-		})
+		respondError(w, req, http.StatusNotFound, "user not found in leaderboard")
 		return
 	}
 
@@ -586,3 +575,21 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
 }
+
+// respondError writes a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInvalidCategory rejects an unknown leaderboard category, listing the
+// valid ones
+func respondInvalidCategory(w http.ResponseWriter, r *http.Request, categories []string) {
+	apierror.Write(w, r, apierror.Invalid("category", "must be one of "+strings.Join(categories, ", ")))
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jgirmay/unified-go/internal/apierror"
 	"github.com/jgirmay/unified-go/pkg/unified"
 )
 
@@ -310,10 +311,35 @@ func TestErrorHandling(t *testing.T) {
 			if w.Code != tc.expected {
 				t.Errorf("Expected status %d, got %d", tc.expected, w.Code)
 			}
+			if contentType := w.Header().Get("Content-Type"); contentType != apierror.ContentType {
+				t.Errorf("Expected a problem+json response, got %s", contentType)
+			}
 		})
 	}
 }
 
+// TestInvalidCategoryListsCategories tests that an unknown leaderboard
+// category is reported as a field error naming the valid ones
+func TestInvalidCategoryListsCategories(t *testing.T) {
+	router := NewRouter(nil)
+	req := httptest.NewRequest("GET", "/api/leaderboard/stats/invalid_category", nil)
+	w := httptest.NewRecorder()
+
+	router.router.ServeHTTP(w, req)
+
+	var problem struct {
+		Code   apierror.Code
+		Errors []apierror.FieldError
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if problem.Code != apierror.CodeValidationFailed || len(problem.Errors) != 1 || problem.Errors[0].Field != "category" ||
+		!strings.Contains(problem.Errors[0].Message, "typing_wpm") {
+		t.Errorf("Expected a category field error listing the categories, got %+v", problem)
+	}
+}
+
 // TestContentTypeHeaders tests that responses have correct content type
 func TestContentTypeHeaders(t *testing.T) {
 	router := NewRouter(nil)
@@ -206,6 +206,55 @@ func (s *Service) GetDashboardOverview(ctx context.Context, userID uint) (map[st
 	return overview, nil
 }
 
+// GetGroupRoster returns profiles for a group's members holding the given
+// membership roles, e.g. the students of a classroom
+func (s *Service) GetGroupRoster(ctx context.Context, groupID uint, groupName, kind string, roles ...string) (*unified.GroupRoster, error) {
+	if groupID == 0 {
+		return nil, fmt.Errorf("invalid group ID")
+	}
+	if s.unifiedRepo == nil {
+		return nil, fmt.Errorf("unified repository not configured")
+	}
+
+	memberIDs, err := s.unifiedRepo.GetGroupMemberIDs(ctx, groupID, roles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+
+	profiles, err := s.unifiedRepo.GetUserProfiles(ctx, memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member profiles: %w", err)
+	}
+
+	return s.unifiedSvc.BuildGroupRoster(groupID, groupName, kind, profiles), nil
+}
+
+// GetChildrenProfiles returns profiles for the children linked to a parent
+func (s *Service) GetChildrenProfiles(ctx context.Context, parentID uint) ([]*unified.UnifiedUserProfile, error) {
+	if parentID == 0 {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	if s.unifiedRepo == nil {
+		return nil, fmt.Errorf("unified repository not configured")
+	}
+
+	childIDs, err := s.unifiedRepo.GetChildIDs(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children: %w", err)
+	}
+
+	profiles, err := s.unifiedRepo.GetUserProfiles(ctx, childIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child profiles: %w", err)
+	}
+
+	for _, profile := range profiles {
+		profile.OverallLevel = s.unifiedSvc.CalculateOverallLevel(profile)
+	}
+
+	return profiles, nil
+}
+
 // ValidateCategory checks if a leaderboard category is valid
 func (s *Service) ValidateCategory(category string) bool {
 	validCategories := map[string]bool{
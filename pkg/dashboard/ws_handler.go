@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,9 +9,12 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/jgirmay/unified-go/internal/logging"
 	"github.com/jgirmay/unified-go/pkg/realtime"
 )
 
+var logger = logging.Logger("dashboard")
+
 // WebSocketHandler manages WebSocket connections
 type WebSocketHandler struct {
 	hub            *realtime.Hub
@@ -61,7 +65,7 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 		h.stats.mu.Lock()
 		h.stats.ConnectionErrors++
 		h.stats.mu.Unlock()
-		fmt.Printf("[WebSocket] Upgrade error: %v\n", err)
+		logger.WarnContext(r.Context(), "websocket upgrade failed", "error", err)
 		return
 	}
 
@@ -76,7 +80,7 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 	h.stats.ActiveConnections++
 	h.stats.mu.Unlock()
 
-	fmt.Printf("[WebSocket] User %d connected\n", userID)
+	logger.InfoContext(r.Context(), "websocket connected", "user_id", userID)
 
 	// Set connection limits
 	conn.SetReadLimit(h.maxMessageSize)
@@ -87,11 +91,11 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 	go client.WritePump()
 
 	// Monitor connection
-	go h.monitorConnection(client, userID)
+	go h.monitorConnection(r.Context(), client, userID)
 }
 
 // monitorConnection monitors a client connection
-func (h *WebSocketHandler) monitorConnection(client *realtime.Client, userID uint) {
+func (h *WebSocketHandler) monitorConnection(ctx context.Context, client *realtime.Client, userID uint) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -103,7 +107,7 @@ func (h *WebSocketHandler) monitorConnection(client *realtime.Client, userID uin
 			h.stats.ActiveConnections--
 			h.stats.mu.Unlock()
 
-			fmt.Printf("[WebSocket] User %d disconnected\n", userID)
+			logger.InfoContext(ctx, "websocket disconnected", "user_id", userID)
 			return
 		}
 	}
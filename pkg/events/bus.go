@@ -1,7 +1,9 @@
 package events
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +16,12 @@ type Bus struct {
 	// Event queue for async processing
 	eventQueue chan *Event
 	quit       chan bool
+	quitOnce   sync.Once
+
+	// Drain requests for the Run loop, and whether publishing has closed
+	drain     chan drainRequest
+	closing   bool
+	publishMu sync.RWMutex
 
 	// Event history
 	history     []*Event
@@ -22,6 +30,9 @@ type Bus struct {
 
 	// Bus statistics
 	stats BusStats
+
+	// Whether the Run loop is active, for readiness checks
+	running atomic.Bool
 }
 
 // BusStats contains bus statistics
@@ -33,12 +44,28 @@ type BusStats struct {
 	ActiveSubscribers int64
 }
 
+// DefaultQueueSize is how many unprocessed events NewBus buffers
+const DefaultQueueSize = 1000
+
+// drainRequest asks the Run loop to drain and stop
+type drainRequest struct {
+	ctx     context.Context
+	dropped chan int
+}
+
 // NewBus creates a new event bus
 func NewBus(historySize int) *Bus {
+	return NewBusWithQueue(historySize, DefaultQueueSize)
+}
+
+// NewBusWithQueue creates an event bus that buffers queueSize unprocessed
+// events
+func NewBusWithQueue(historySize, queueSize int) *Bus {
 	return &Bus{
 		subscribers: make(map[EventType][]EventHandler),
-		eventQueue:  make(chan *Event, 1000),
+		eventQueue:  make(chan *Event, queueSize),
 		quit:        make(chan bool),
+		drain:       make(chan drainRequest),
 		history:     make([]*Event, 0, historySize),
 		historySize: historySize,
 	}
@@ -108,6 +135,12 @@ func (b *Bus) Publish(event *Event) error {
 		return nil
 	}
 
+	b.publishMu.RLock()
+	defer b.publishMu.RUnlock()
+	if b.closing {
+		return ErrBusClosed
+	}
+
 	select {
 	case b.eventQueue <- event:
 		b.stats.mu.Lock()
@@ -125,6 +158,15 @@ func (b *Bus) PublishAsync(event *Event) {
 		return
 	}
 
+	b.publishMu.RLock()
+	defer b.publishMu.RUnlock()
+	if b.closing {
+		b.stats.mu.Lock()
+		b.stats.TotalErrors++
+		b.stats.mu.Unlock()
+		return
+	}
+
 	select {
 	case b.eventQueue <- event:
 		b.stats.mu.Lock()
@@ -140,17 +182,40 @@ func (b *Bus) PublishAsync(event *Event) {
 
 // Run starts the event bus processing loop
 func (b *Bus) Run() {
+	b.running.Store(true)
+	defer b.running.Store(false)
+
 	for {
 		select {
 		case event := <-b.eventQueue:
 			b.handleEvent(event)
 
+		case req := <-b.drain:
+			req.dropped <- b.drainQueue(req.ctx)
+			return
+
 		case <-b.quit:
 			return
 		}
 	}
 }
 
+// drainQueue delivers the queued events until the queue is empty or ctx
+// expires, and returns the number left undelivered
+func (b *Bus) drainQueue(ctx context.Context) int {
+	for {
+		if ctx.Err() != nil {
+			return len(b.eventQueue)
+		}
+		select {
+		case event := <-b.eventQueue:
+			b.handleEvent(event)
+		default:
+			return 0
+		}
+	}
+}
+
 // handleEvent delivers an event to all subscribers
 func (b *Bus) handleEvent(event *Event) {
 	if event == nil {
@@ -270,9 +335,39 @@ func (b *Bus) GetStats() BusStats {
 	return b.stats
 }
 
-// Stop stops the event bus
+// IsRunning reports whether the bus processing loop is running
+func (b *Bus) IsRunning() bool {
+	return b.running.Load()
+}
+
+// Stop stops the event bus, dropping any queued events
 func (b *Bus) Stop() {
-	close(b.quit)
+	b.quitOnce.Do(func() { close(b.quit) })
+}
+
+// Drain stops the event bus gracefully: publishing is closed, then the queued
+// events are delivered to their subscribers. Events still queued when ctx
+// expires are dropped, and their number returned.
+func (b *Bus) Drain(ctx context.Context) int {
+	// Wait for in-flight publishers so nothing is queued after the drain
+	b.publishMu.Lock()
+	b.closing = true
+	b.publishMu.Unlock()
+
+	var dropped int
+	req := drainRequest{ctx: ctx, dropped: make(chan int, 1)}
+	select {
+	case b.drain <- req:
+		dropped = <-req.dropped
+	case <-b.quit:
+		dropped = len(b.eventQueue)
+	case <-ctx.Done():
+		// The Run loop is not running, so nothing can deliver the queue
+		dropped = len(b.eventQueue)
+	}
+
+	b.Stop()
+	return dropped
 }
 
 // Clear clears the event history
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -446,3 +447,100 @@ func BenchmarkSubscribe(b *testing.B) {
 		bus.Subscribe(EventSessionStarted, handler)
 	}
 }
+
+// TestCollect tests the bus stats exposed as metrics
+func TestCollect(t *testing.T) {
+	bus := NewBus(100)
+	bus.Subscribe(EventSessionStarted, func(event *Event) error { return nil })
+	bus.Publish(NewSessionStartedEvent(1, "session1", "typing"))
+
+	values := make(map[string]float64)
+	for _, family := range bus.Collect() {
+		values[family.Name] = family.Samples[0].Value
+	}
+
+	if values["unified_bus_published_total"] != 1 {
+		t.Errorf("Expected 1 published event, got %v", values["unified_bus_published_total"])
+	}
+	if values["unified_bus_queue_length"] != 1 {
+		t.Errorf("Expected 1 queued event before Run, got %v", values["unified_bus_queue_length"])
+	}
+	if values["unified_bus_subscribers"] != 1 {
+		t.Errorf("Expected 1 subscriber, got %v", values["unified_bus_subscribers"])
+	}
+}
+
+// TestIsRunning tests that the bus reports its processing loop state
+func TestIsRunning(t *testing.T) {
+	bus := NewBus(100)
+	if bus.IsRunning() {
+		t.Error("Bus should not be running before Run")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		bus.Run()
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if !bus.IsRunning() {
+		t.Error("Bus should be running")
+	}
+
+	bus.Stop()
+	<-done
+	if bus.IsRunning() {
+		t.Error("Bus should not be running after Stop")
+	}
+}
+
+// TestDrain tests that Drain delivers queued events and then closes the bus
+func TestDrain(t *testing.T) {
+	bus := NewBusWithQueue(100, 10)
+
+	var mu sync.Mutex
+	delivered := 0
+	bus.Subscribe(EventSessionStarted, func(event *Event) error {
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+		return nil
+	})
+
+	// Queue events before the loop runs so Drain has work to do
+	for i := 0; i < 5; i++ {
+		bus.PublishAsync(NewSessionStartedEvent(1, "session", "typing"))
+	}
+	go bus.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if dropped := bus.Drain(ctx); dropped != 0 {
+		t.Errorf("Expected no dropped events, got %d", dropped)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 5 {
+		t.Errorf("Expected 5 delivered events, got %d", delivered)
+	}
+	if err := bus.Publish(NewSessionStartedEvent(1, "late", "typing")); err != ErrBusClosed {
+		t.Errorf("Expected ErrBusClosed after Drain, got %v", err)
+	}
+	bus.Stop()
+}
+
+// TestDrainExpired tests that events left when the deadline passes are
+// reported as dropped
+func TestDrainExpired(t *testing.T) {
+	bus := NewBusWithQueue(100, 10)
+	for i := 0; i < 3; i++ {
+		bus.PublishAsync(NewSessionStartedEvent(1, "session", "typing"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if dropped := bus.Drain(ctx); dropped != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", dropped)
+	}
+}
@@ -1,10 +1,15 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+var logger = logging.Logger("events")
+
 // HandlerRegistry manages event handlers with logging and error handling
 type HandlerRegistry struct {
 	bus           *Bus
@@ -38,7 +43,7 @@ func (r *HandlerRegistry) RegisterHandler(eventType EventType, handler EventHand
 	r.bus.Subscribe(eventType, handler)
 
 	if r.loggingEnabled {
-		fmt.Printf("[Events] Registered handler for %s\n", eventType)
+		logger.InfoContext(context.Background(), "registered event handler", "event_type", eventType)
 	}
 
 	return nil
@@ -60,7 +65,7 @@ func (r *HandlerRegistry) RegisterHandlerWithName(eventType EventType, name stri
 	r.bus.Subscribe(eventType, handler)
 
 	if r.loggingEnabled {
-		fmt.Printf("[Events] Registered handler %s for %s\n", name, eventType)
+		logger.InfoContext(context.Background(), "registered event handler", "handler", name, "event_type", eventType)
 	}
 
 	return nil
@@ -221,10 +226,10 @@ func ConditionalChainHandler(conditions []EventFilter, handlers []EventHandler)
 // LoggingHandler wraps a handler to add logging
 func LoggingHandler(name string, handler EventHandler) EventHandler {
 	return func(event *Event) error {
-		fmt.Printf("[%s] Processing event: %s (User: %d, App: %s)\n", name, event.Type, event.UserID, event.App)
+		logger.InfoContext(context.Background(), "processing event", "handler", name, "event_type", event.Type, "user_id", event.UserID, "app", event.App)
 		err := handler(event)
 		if err != nil {
-			fmt.Printf("[%s] Handler error: %v\n", name, err)
+			logger.ErrorContext(context.Background(), "event handler failed", "handler", name, "event_type", event.Type, "error", err)
 		}
 		return err
 	}
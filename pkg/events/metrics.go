@@ -0,0 +1,22 @@
+package events
+
+import (
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// Collect implements metrics.Collector with the bus publish, delivery and
+// error counters
+func (b *Bus) Collect() []metrics.Family {
+	queued := len(b.eventQueue)
+
+	b.stats.mu.RLock()
+	defer b.stats.mu.RUnlock()
+
+	return []metrics.Family{
+		metrics.Counter("unified_bus_published_total", "Events published to the bus.", float64(b.stats.TotalPublished)),
+		metrics.Counter("unified_bus_delivered_total", "Events delivered to subscribers.", float64(b.stats.TotalDelivered)),
+		metrics.Counter("unified_bus_errors_total", "Subscriber errors while handling events.", float64(b.stats.TotalErrors)),
+		metrics.Gauge("unified_bus_subscribers", "Active bus subscribers.", float64(b.stats.ActiveSubscribers)),
+		metrics.Gauge("unified_bus_queue_length", "Events waiting in the async queue.", float64(queued)),
+	}
+}
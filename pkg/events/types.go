@@ -38,6 +38,14 @@ const (
 	EventLeaderboardRefresh EventType = "leaderboard.refresh"
 	EventDailyReportReady   EventType = "daily.report.ready"
 	EventWeeklyReportReady  EventType = "weekly.report.ready"
+
+	// GAIA worker fleet events
+	EventGaiaSessionHealth EventType = "gaia.session.health"
+	EventGaiaSessionState  EventType = "gaia.session.state"
+
+	// GAIA system metric alerts
+	EventGaiaAlertRaised   EventType = "gaia.alert.raised"
+	EventGaiaAlertResolved EventType = "gaia.alert.resolved"
 )
 
 // Event represents a system event
@@ -0,0 +1,428 @@
+package gaia
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/pkg/events"
+)
+
+// alertTransitions counts the alerts opened and resolved by the collector
+var alertTransitions = metrics.NewCounterVec("unified_gaia_alert_transitions_total",
+	"GAIA metric alerts opened and resolved by the collector, by transition: warning, critical or resolved.", "transition")
+
+// firstSampleWindow is how far back the first sample counts finished tasks
+const firstSampleWindow = time.Minute
+
+// severityRank orders the alert severities, no alert being the lowest
+var severityRank = map[string]int{
+	"":                   0,
+	models.AlertWarning:  1,
+	models.AlertCritical: 2,
+}
+
+// StatsSource is a connection pool whose statistics are sampled, such as a
+// *sql.DB
+type StatsSource interface {
+	Stats() sql.DBStats
+}
+
+// CollectorOptions tunes a Collector
+type CollectorOptions struct {
+	// Thresholds open alerts on the sampled metrics, one at a time per
+	// metric
+	Thresholds []models.AlertThreshold
+	// Hysteresis is how far, as a fraction of the threshold, a metric has to
+	// move back past the threshold it crossed before its alert is resolved
+	Hysteresis float64
+	// RetentionDays is how long samples are kept; zero keeps them forever
+	RetentionDays int
+	// CleanupInterval is how often samples older than RetentionDays are
+	// removed
+	CleanupInterval time.Duration
+}
+
+// DefaultCollectorOptions returns the options used when nothing is
+// overridden. They set no thresholds.
+func DefaultCollectorOptions() CollectorOptions {
+	return CollectorOptions{
+		Hysteresis:      0.1,
+		RetentionDays:   7,
+		CleanupInterval: time.Hour,
+	}
+}
+
+// Collector samples the server and the task queue into the metrics table.
+// Each sample holds the heap in use, the goroutines, the database pool's
+// connections and waits, the pending tasks, the active sessions and the
+// throughput, latency and success rate of the tasks finished since the
+// previous sample. A metric that crosses a threshold opens an alert, which
+// is resolved once the metric is back past the threshold by the hysteresis
+// margin; an alert that changes severity is resolved and one is opened at
+// the new severity. Alerts are published on the event bus for the
+// dashboard.
+type Collector struct {
+	repos repository.Manager
+	pool  StatsSource
+	bus   *events.Bus
+	opts  CollectorOptions
+	now   func() time.Time
+
+	mu sync.Mutex
+	// windowEnd is where the previous sample stopped counting finished tasks
+	windowEnd time.Time
+	// poolStats are the pool's statistics at the previous sample
+	poolStats   sql.DBStats
+	lastCleanup time.Time
+	// alerts are the open alerts by metric, loaded by the first pass
+	alerts map[string]*models.MetricsAlert
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewCollector creates a collector that samples pool, which may be nil, and
+// publishes to bus, which may be nil
+func NewCollector(repos repository.Manager, pool StatsSource, bus *events.Bus, opts CollectorOptions) *Collector {
+	return &Collector{repos: repos, pool: pool, bus: bus, opts: opts, now: time.Now}
+}
+
+// Collect takes a sample, records it, opens and resolves alerts on it and
+// removes expired samples when due. It returns the sampled values by metric.
+func (c *Collector) Collect(ctx context.Context) (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	values, err := c.sample(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.record(ctx, values); err != nil {
+		return nil, err
+	}
+	if err := c.evaluate(ctx, now, values); err != nil {
+		return values, err
+	}
+
+	if c.opts.RetentionDays > 0 && now.Sub(c.lastCleanup) >= c.opts.CleanupInterval {
+		n, err := c.repos.Metrics().CleanupOldMetrics(ctx, c.opts.RetentionDays)
+		if err != nil {
+			return values, err
+		}
+		c.lastCleanup = now
+		if n > 0 {
+			logger.InfoContext(ctx, "removed expired metrics samples", "count", n, "retention_days", c.opts.RetentionDays)
+		}
+	}
+	return values, nil
+}
+
+// sample reads the current values. Latency and success rate are left out
+// when no task finished since the previous sample.
+func (c *Collector) sample(ctx context.Context, now time.Time) (map[string]float64, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	values := map[string]float64{
+		"memory_mb":  float64(mem.HeapAlloc) / (1 << 20),
+		"goroutines": float64(runtime.NumGoroutine()),
+	}
+
+	if c.pool != nil {
+		// Waits are counted since the previous sample
+		stats := c.pool.Stats()
+		values["db_open_connections"] = float64(stats.OpenConnections)
+		values["db_in_use"] = float64(stats.InUse)
+		values["db_waits"] = float64(stats.WaitCount - c.poolStats.WaitCount)
+		values["db_wait_ms"] = float64((stats.WaitDuration - c.poolStats.WaitDuration).Milliseconds())
+		c.poolStats = stats
+	}
+
+	pending, err := c.repos.Tasks().CountByStatus(ctx, models.TaskPending)
+	if err != nil {
+		return nil, err
+	}
+	values["pending_tasks"] = float64(pending)
+	sessions, err := c.repos.Sessions().GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values["active_sessions"] = float64(len(sessions))
+
+	// Task times are stored to the second, so the windows are too
+	end := now.Truncate(time.Second)
+	start := c.windowEnd
+	if start.IsZero() {
+		start = end.Add(-firstSampleWindow)
+	}
+	if !end.After(start) {
+		return values, nil
+	}
+	throughput, err := c.repos.Tasks().GetThroughput(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	c.windowEnd = end
+
+	values["tasks_completed"] = float64(throughput.Completed)
+	values["tasks_failed"] = float64(throughput.Failed)
+	values["task_throughput"] = float64(throughput.Completed) / end.Sub(start).Minutes()
+	if throughput.Completed > 0 {
+		values["avg_latency_ms"] = throughput.AvgLatencyMS
+	}
+	if finished := throughput.Completed + throughput.Failed; finished > 0 {
+		values["success_rate"] = float64(throughput.Completed) / float64(finished)
+	}
+	return values, nil
+}
+
+// record stores a sample, the values without a column of their own going in
+// its metadata
+func (c *Collector) record(ctx context.Context, values map[string]float64) error {
+	sample := &models.MetricsCreate{
+		TaskThroughput: countOf(values, "task_throughput"),
+		AvgLatencyMS:   valueOf(values, "avg_latency_ms"),
+		SuccessRate:    valueOf(values, "success_rate"),
+		MemoryMB:       valueOf(values, "memory_mb"),
+		ActiveSessions: countOf(values, "active_sessions"),
+		PendingTasks:   countOf(values, "pending_tasks"),
+	}
+
+	extra := make(map[string]float64, len(values))
+	for name, value := range values {
+		switch name {
+		case "task_throughput", "avg_latency_ms", "success_rate", "memory_mb", "active_sessions", "pending_tasks":
+		default:
+			extra[name] = value
+		}
+	}
+	metadata, err := json.Marshal(extra)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics metadata: %w", err)
+	}
+	sample.Metadata = string(metadata)
+	return c.repos.Metrics().Record(ctx, sample)
+}
+
+// evaluate checks the sample against the thresholds. The first pass picks up
+// the alerts left open by a previous run, resolving those on metrics that no
+// longer have a threshold.
+func (c *Collector) evaluate(ctx context.Context, now time.Time, values map[string]float64) error {
+	if c.alerts == nil {
+		open, err := c.repos.Metrics().GetAlerts(ctx, true, -1, 0)
+		if err != nil {
+			return err
+		}
+		c.alerts = make(map[string]*models.MetricsAlert, len(open))
+		for _, alert := range open {
+			c.alerts[alert.MetricName] = alert
+		}
+
+		configured := make(map[string]bool, len(c.opts.Thresholds))
+		for _, threshold := range c.opts.Thresholds {
+			configured[threshold.MetricName] = true
+		}
+		for _, alert := range open {
+			if !configured[alert.MetricName] {
+				if err := c.resolve(ctx, now, alert, alert.Value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, threshold := range c.opts.Thresholds {
+		// An alert stands while its metric goes unsampled, such as latency
+		// when no task finished
+		value, ok := values[threshold.MetricName]
+		if !ok {
+			continue
+		}
+		if err := c.check(ctx, now, threshold, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check opens, resolves or changes the severity of a metric's alert
+func (c *Collector) check(ctx context.Context, now time.Time, threshold models.AlertThreshold, value float64) error {
+	open := c.alerts[threshold.MetricName]
+	current := ""
+	if open != nil {
+		current = open.Severity
+	}
+
+	severity, bound := breach(threshold, value, 0)
+	if severityRank[severity] <= severityRank[current] {
+		// The open alert stands until the metric leaves the hysteresis band
+		if held, _ := breach(threshold, value, c.opts.Hysteresis); severityRank[held] >= severityRank[current] {
+			return nil
+		}
+	}
+
+	if open != nil {
+		if err := c.resolve(ctx, now, open, value); err != nil {
+			return err
+		}
+	}
+	if severity == "" {
+		return nil
+	}
+
+	alert := &models.MetricsAlert{
+		MetricName: threshold.MetricName,
+		Value:      value,
+		Severity:   severity,
+		Threshold:  bound,
+		CreatedAt:  now,
+		Message:    alertMessage(threshold.MetricName, severity, value, bound),
+	}
+	if err := c.repos.Metrics().OpenAlert(ctx, alert); err != nil {
+		return err
+	}
+	c.alerts[alert.MetricName] = alert
+	alertTransitions.Inc(severity)
+	logger.WarnContext(ctx, "metric alert raised", "alert_id", alert.ID, "metric", alert.MetricName,
+		"severity", severity, "value", value, "threshold", bound)
+	c.publish(events.EventGaiaAlertRaised, alert, value)
+	return nil
+}
+
+// resolve closes an open alert, given the metric's current value
+func (c *Collector) resolve(ctx context.Context, now time.Time, alert *models.MetricsAlert, value float64) error {
+	err := c.repos.Metrics().ResolveAlert(ctx, alert.ID, now)
+	if err != nil && !errors.Is(err, repository.ErrAlertNotFound) {
+		return err
+	}
+	delete(c.alerts, alert.MetricName)
+	if err != nil {
+		// Resolved by someone else since it was read
+		return nil
+	}
+
+	resolvedAt := now
+	alert.ResolvedAt = &resolvedAt
+	alertTransitions.Inc("resolved")
+	logger.InfoContext(ctx, "metric alert resolved", "alert_id", alert.ID, "metric", alert.MetricName,
+		"severity", alert.Severity, "value", value)
+	c.publish(events.EventGaiaAlertResolved, alert, value)
+	return nil
+}
+
+// breach returns the most severe threshold a value is past, and the bound it
+// is past. With a margin, a value within that fraction of a bound is past it.
+func breach(threshold models.AlertThreshold, value, margin float64) (string, float64) {
+	if bound, ok := past(value, threshold.CriticalMin, threshold.CriticalMax, margin); ok {
+		return models.AlertCritical, bound
+	}
+	if bound, ok := past(value, threshold.WarningMin, threshold.WarningMax, margin); ok {
+		return models.AlertWarning, bound
+	}
+	return "", 0
+}
+
+// past reports whether a value is above high or below low, either of which
+// may be nil
+func past(value float64, low, high *float64, margin float64) (float64, bool) {
+	if high != nil && value > *high-margin*math.Abs(*high) {
+		return *high, true
+	}
+	if low != nil && value < *low+margin*math.Abs(*low) {
+		return *low, true
+	}
+	return 0, false
+}
+
+// alertMessage describes the threshold a metric crossed
+func alertMessage(metric, severity string, value, bound float64) string {
+	direction := "above"
+	if value < bound {
+		direction = "below"
+	}
+	return fmt.Sprintf("%s at %g is %s the %s threshold of %g", metric, math.Round(value*100)/100, direction, severity, bound)
+}
+
+// valueOf returns a sampled value, or nil when it was not sampled
+func valueOf(values map[string]float64, name string) *float64 {
+	value, ok := values[name]
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// countOf returns a sampled value rounded to a count, or nil when it was not
+// sampled
+func countOf(values map[string]float64, name string) *int {
+	value, ok := values[name]
+	if !ok {
+		return nil
+	}
+	n := int(math.Round(value))
+	return &n
+}
+
+// publish sends an alert event when the collector has a bus
+func (c *Collector) publish(eventType events.EventType, alert *models.MetricsAlert, value float64) {
+	if c.bus == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"alert_id":    alert.ID,
+		"metric_name": alert.MetricName,
+		"severity":    alert.Severity,
+		"value":       value,
+		"threshold":   alert.Threshold,
+		"message":     alert.Message,
+		"created_at":  alert.CreatedAt,
+	}
+	if alert.ResolvedAt != nil {
+		data["resolved_at"] = *alert.ResolvedAt
+	}
+	c.bus.PublishAsync(events.NewEvent(eventType, 0, "gaia", data))
+}
+
+// Start samples every interval until Close is called
+func (c *Collector) Start(interval time.Duration) {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := c.Collect(context.Background()); err != nil {
+					logger.Error("metrics collection failed", "error", err)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the loop started by Start, waiting for a running pass
+func (c *Collector) Close() {
+	c.stopOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+			<-c.done
+		}
+	})
+}
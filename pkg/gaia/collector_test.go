@@ -0,0 +1,231 @@
+package gaia
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/config"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/internal/storage"
+	"github.com/jgirmay/unified-go/pkg/events"
+)
+
+// setupCollector creates a collector sampling the store's pool, with a fake
+// clock and publishing to a running bus
+func setupCollector(t *testing.T, store *storage.SQLiteStore, opts CollectorOptions) (*Collector, repository.Manager, *events.Bus, *time.Time) {
+	t.Helper()
+
+	repos := repository.NewManager(store)
+	bus := events.NewBus(100)
+	go bus.Run()
+	t.Cleanup(bus.Stop)
+
+	c := NewCollector(repos, store, bus, opts)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	return c, repos, bus, &now
+}
+
+// collect takes a sample
+func collect(t *testing.T, c *Collector) map[string]float64 {
+	t.Helper()
+
+	values, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	return values
+}
+
+// openSeverity returns the severity of the one open alert, or "" when there
+// is none
+func openSeverity(t *testing.T, repos repository.Manager) string {
+	t.Helper()
+
+	alerts, err := repos.Metrics().GetAlerts(context.Background(), true, -1, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts failed: %v", err)
+	}
+	switch len(alerts) {
+	case 0:
+		return ""
+	case 1:
+		return alerts[0].Severity
+	}
+	t.Fatalf("Expected at most one open alert, got %d", len(alerts))
+	return ""
+}
+
+func TestCollectorSamples(t *testing.T) {
+	store := setupStore(t)
+	c, repos, _, now := setupCollector(t, store, DefaultCollectorOptions())
+	ctx := context.Background()
+
+	// A sample past the retention period goes on the first pass
+	old := time.Now().AddDate(0, 0, -30).UTC().Format(time.DateTime)
+	if _, err := store.Exec(ctx, `INSERT INTO metrics (timestamp, pending_tasks) VALUES (?, 9)`, old); err != nil {
+		t.Fatalf("failed to insert old metrics: %v", err)
+	}
+
+	addSession(t, repos, "worker-1")
+	completed := addTask(t, repos, models.TaskCreate{Content: "completed"})
+	failed := addTask(t, repos, models.TaskCreate{Content: "failed"})
+	addTask(t, repos, models.TaskCreate{Content: "pending"})
+	for _, id := range []int64{completed, failed} {
+		if err := repos.Tasks().Assign(ctx, id, "worker-1"); err != nil {
+			t.Fatalf("Assign failed: %v", err)
+		}
+	}
+	if err := repos.Tasks().MarkCompleted(ctx, completed); err != nil {
+		t.Fatalf("MarkCompleted failed: %v", err)
+	}
+	if err := repos.Tasks().MarkFailed(ctx, failed, "model timed out", false); err != nil {
+		t.Fatalf("MarkFailed failed: %v", err)
+	}
+
+	// Task times are stored to the second
+	*now = time.Now().Add(time.Second)
+	values := collect(t, c)
+	for _, metric := range config.AlertMetrics {
+		if _, ok := values[metric]; !ok {
+			t.Errorf("Expected %s sampled, got %v", metric, values)
+		}
+	}
+	if values["pending_tasks"] != 1 || values["active_sessions"] != 1 {
+		t.Errorf("Expected 1 pending task and 1 active session, got %v", values)
+	}
+	if values["tasks_completed"] != 1 || values["tasks_failed"] != 1 || values["success_rate"] != 0.5 {
+		t.Errorf("Expected one task completed and one failed, got %v", values)
+	}
+	if values["memory_mb"] <= 0 || values["goroutines"] <= 0 || values["db_open_connections"] <= 0 {
+		t.Errorf("Expected the runtime and pool sampled, got %v", values)
+	}
+
+	samples, err := repos.Metrics().GetByTimeRange(ctx, time.Now().AddDate(0, 0, -60), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetByTimeRange failed: %v", err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("Expected only the new sample kept, got %d", len(samples))
+	}
+	if s := samples[0]; s.PendingTasks.Int64 != 1 || s.SuccessRate.Float64 != 0.5 || !strings.Contains(s.Metadata.String, `"goroutines"`) {
+		t.Errorf("Unexpected sample recorded: %+v", s)
+	}
+
+	// The finished tasks are not counted again
+	values = collect(t, c)
+	if _, ok := values["tasks_completed"]; ok {
+		t.Errorf("Expected no task window within the same second, got %v", values)
+	}
+	*now = now.Add(time.Minute)
+	values = collect(t, c)
+	if _, ok := values["success_rate"]; ok || values["tasks_completed"] != 0 || values["task_throughput"] != 0 {
+		t.Errorf("Expected no tasks finished in the next window, got %v", values)
+	}
+}
+
+func TestCollectorAlertHysteresis(t *testing.T) {
+	warning, critical := 10.0, 20.0
+	opts := DefaultCollectorOptions()
+	opts.Thresholds = []models.AlertThreshold{{MetricName: "pending_tasks", WarningMax: &warning, CriticalMax: &critical}}
+	c, repos, bus, _ := setupCollector(t, setupStore(t), opts)
+	ctx := context.Background()
+
+	var pending []int64
+	setPending := func(n int) {
+		for len(pending) < n {
+			pending = append(pending, addTask(t, repos, models.TaskCreate{Content: "task"}))
+		}
+		for len(pending) > n {
+			id := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if err := repos.Tasks().UpdateStatus(ctx, id, models.TaskCancelled, "Cancelled"); err != nil {
+				t.Fatalf("UpdateStatus failed: %v", err)
+			}
+		}
+	}
+
+	steps := []struct {
+		pending int
+		want    string
+	}{
+		{11, models.AlertWarning},
+		// Within 10% of the threshold the alert stands
+		{10, models.AlertWarning},
+		{8, ""},
+		{21, models.AlertCritical},
+		{19, models.AlertCritical},
+		// Clear of the critical band, still past the warning threshold
+		{17, models.AlertWarning},
+	}
+	for _, step := range steps {
+		setPending(step.pending)
+		collect(t, c)
+		if got := openSeverity(t, repos); got != step.want {
+			t.Fatalf("With %d pending tasks expected alert %q, got %q", step.pending, step.want, got)
+		}
+	}
+
+	if total, err := repos.Metrics().CountAlerts(ctx, false); err != nil || total != 3 {
+		t.Errorf("Expected 3 alerts opened, got %d: %v", total, err)
+	}
+	alerts, err := repos.Metrics().GetAlerts(ctx, true, 1, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts failed: %v", err)
+	}
+	if alert := alerts[0]; alert.Value != 17 || alert.Threshold != warning || alert.Message != "pending_tasks at 17 is above the warning threshold of 10" {
+		t.Errorf("Unexpected open alert %+v", alert)
+	}
+
+	bus.Drain(ctx)
+	if raised := bus.GetEventsByType(events.EventGaiaAlertRaised, 10); len(raised) != 3 {
+		t.Errorf("Expected 3 raised events, got %d", len(raised))
+	}
+	resolved := bus.GetEventsByType(events.EventGaiaAlertResolved, 10)
+	if len(resolved) != 2 {
+		t.Fatalf("Expected 2 resolved events, got %d", len(resolved))
+	}
+	for _, event := range resolved {
+		if _, ok := event.Data["resolved_at"].(time.Time); !ok || event.Data["alert_id"] == "" {
+			t.Errorf("Unexpected resolved event %v", event.Data)
+		}
+	}
+}
+
+func TestCollectorRestoresOpenAlerts(t *testing.T) {
+	store := setupStore(t)
+	repos := repository.NewManager(store)
+	ctx := context.Background()
+
+	// Left open by a previous run
+	pending := &models.MetricsAlert{MetricName: "pending_tasks", Severity: models.AlertWarning, Value: 11, Threshold: 10, Message: "pending"}
+	goroutines := &models.MetricsAlert{MetricName: "goroutines", Severity: models.AlertWarning, Value: 2000, Threshold: 1000, Message: "goroutines"}
+	for _, alert := range []*models.MetricsAlert{pending, goroutines} {
+		if err := repos.Metrics().OpenAlert(ctx, alert); err != nil {
+			t.Fatalf("OpenAlert failed: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		addTask(t, repos, models.TaskCreate{Content: "task"})
+	}
+
+	warning := 10.0
+	opts := DefaultCollectorOptions()
+	opts.Thresholds = []models.AlertThreshold{{MetricName: "pending_tasks", WarningMax: &warning}}
+	c, _, _, _ := setupCollector(t, store, opts)
+	collect(t, c)
+
+	alerts, err := repos.Metrics().GetAlerts(ctx, true, -1, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != pending.ID {
+		t.Errorf("Expected only the pending_tasks alert kept open, got %+v", alerts)
+	}
+	if err := repos.Metrics().ResolveAlert(ctx, goroutines.ID, time.Now()); err != repository.ErrAlertNotFound {
+		t.Errorf("Expected the alert without a threshold resolved, got %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+)
+
+// ErrTaskNotRunning is returned when a task reported as finished is not
+// assigned to a session
+var ErrTaskNotRunning = errors.New("task is not assigned to a session")
+
+// ErrTaskFinished is returned when a task that already completed, failed or
+// was cancelled is cancelled
+var ErrTaskFinished = errors.New("task has already finished")
+
+// minHealthScore is the lowest health score of a session that gets work,
+// matching the IsHealthy threshold of SessionRepository.GetHealth
+const minHealthScore = 50
+
+// dispatchedTasks counts what the dispatcher did with tasks
+var dispatchedTasks = metrics.NewCounterVec("unified_gaia_tasks_total",
+	"GAIA tasks handled by the dispatcher, by outcome: assigned, completed, retried, failed, timed_out or reclaimed.", "outcome")
+
+// DispatcherOptions tunes a Dispatcher
+type DispatcherOptions struct {
+	// BatchSize is how many ready tasks one pass considers
+	BatchSize int
+	// MaxActiveTasks is how many tasks a session may hold at once
+	MaxActiveTasks int
+	// AssignInterval is the least time between two assignments to the same
+	// session
+	AssignInterval time.Duration
+	// HeartbeatTimeout is how recent a session's last heartbeat must be for
+	// it to get work
+	HeartbeatTimeout time.Duration
+	// RetryBackoff is the delay before a failed task is retried. It doubles
+	// with each retry, up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// DefaultDispatcherOptions returns the options used when nothing is
+// overridden
+func DefaultDispatcherOptions() DispatcherOptions {
+	return DispatcherOptions{
+		BatchSize:        100,
+		MaxActiveTasks:   1,
+		AssignInterval:   5 * time.Second,
+		HeartbeatTimeout: 2 * time.Minute,
+		RetryBackoff:     30 * time.Second,
+		MaxRetryBackoff:  30 * time.Minute,
+	}
+}
+
+// Dispatcher assigns pending tasks to idle sessions. Each pass takes the
+// ready tasks highest priority first and gives each to a healthy idle
+// session, the task's target session when it has one. Sessions are
+// throttled by their rate limiter state. A failed task is retried with
+// exponential backoff up to MaxRetries times after its first run, and a task
+// still running past its timeout is marked stuck and requeued the same way.
+type Dispatcher struct {
+	repos repository.Manager
+	opts  DispatcherOptions
+	now   func() time.Time
+
+	// mu serializes passes and reports so a task is never assigned twice
+	mu       sync.Mutex
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewDispatcher creates a dispatcher over the repositories
+func NewDispatcher(repos repository.Manager, opts DispatcherOptions) *Dispatcher {
+	return &Dispatcher{repos: repos, opts: opts, now: time.Now}
+}
+
+// Tick requeues stuck tasks and then assigns ready ones
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if _, err := d.RequeueStuck(ctx); err != nil {
+		return err
+	}
+	_, err := d.Dispatch(ctx)
+	return err
+}
+
+// Dispatch assigns ready tasks to healthy idle sessions and returns how many
+// were assigned. A task targeted at a session waits until that session is
+// available, and a task that cannot be placed is left for the next pass
+// while the rest of the batch is assigned.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	idle, err := d.repos.Sessions().GetIdleSessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var available []*models.Session
+	for _, session := range idle {
+		if d.healthy(session, now) {
+			available = append(available, session)
+		}
+	}
+	if len(available) == 0 {
+		return 0, nil
+	}
+
+	tasks, err := d.repos.Tasks().GetReady(ctx, now, d.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// throttled caches each session's throttle check for this pass
+	throttled := make(map[string]bool)
+	assigned := 0
+	for _, task := range tasks {
+		if len(available) == 0 {
+			break
+		}
+		i, err := d.pick(ctx, task, available, throttled, now)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to pick a session for task", "task_id", task.ID, "error", err)
+			continue
+		}
+		if i < 0 {
+			continue
+		}
+
+		err = d.assign(ctx, task, available[i], now)
+		if errors.Is(err, repository.ErrTaskNotPending) {
+			// Cancelled since it was read
+			continue
+		}
+		if err != nil {
+			// Rolled back, so the session is still free for the next task
+			logger.ErrorContext(ctx, "failed to assign task", "task_id", task.ID,
+				"session_id", available[i].SessionID, "error", err)
+			continue
+		}
+		available = append(available[:i], available[i+1:]...)
+		assigned++
+	}
+	return assigned, nil
+}
+
+// healthy reports whether an idle session may get work at now
+func (d *Dispatcher) healthy(session *models.Session, now time.Time) bool {
+	return session.Active && session.Status.IsHealthy() && session.HealthScore >= minHealthScore &&
+		now.Sub(session.LastHeartbeat) <= d.opts.HeartbeatTimeout
+}
+
+// pick returns the index in available of the session to run task, or -1
+// when none may take it now
+func (d *Dispatcher) pick(ctx context.Context, task *models.Task, available []*models.Session, throttled map[string]bool, now time.Time) (int, error) {
+	for i, session := range available {
+		if task.TargetSession.Valid && task.TargetSession.String != session.SessionID {
+			continue
+		}
+		limited, ok := throttled[session.SessionID]
+		if !ok {
+			var err error
+			if limited, err = d.throttle(ctx, session.SessionID, now); err != nil {
+				return -1, err
+			}
+			throttled[session.SessionID] = limited
+		}
+		if !limited {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// throttle reports whether a session is over its limits at now, recording
+// the throttle event when it is
+func (d *Dispatcher) throttle(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	state, err := d.repos.RateLimits().Get(ctx, sessionID)
+	if err != nil || state == nil {
+		return false, err
+	}
+	limited := state.ActiveTaskCount >= d.opts.MaxActiveTasks ||
+		(state.LastAssignmentTime != nil && now.Sub(*state.LastAssignmentTime) < d.opts.AssignInterval)
+	if !limited {
+		return false, nil
+	}
+	return true, d.repos.RateLimits().RecordThrottle(ctx, sessionID, now)
+}
+
+// assign gives task to session in one transaction, so a failure leaves
+// neither the task nor the session half assigned
+func (d *Dispatcher) assign(ctx context.Context, task *models.Task, session *models.Session, now time.Time) error {
+	err := d.repos.Transaction(ctx, func(tx repository.Manager) error {
+		if err := tx.Tasks().Assign(ctx, task.ID, session.SessionID); err != nil {
+			return err
+		}
+		if err := tx.Sessions().SetCurrentTask(ctx, session.SessionID, task.ID); err != nil {
+			return err
+		}
+		if err := tx.Sessions().UpdateStatus(ctx, session.SessionID, models.SessionBusy,
+			fmt.Sprintf("Assigned task %d", task.ID)); err != nil {
+			return err
+		}
+		return tx.RateLimits().RecordAssignment(ctx, session.SessionID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	dispatchedTasks.Inc("assigned")
+	logger.InfoContext(ctx, "task assigned", "task_id", task.ID, "session_id", session.SessionID,
+		"priority", int(task.Priority))
+	return nil
+}
+
+// Fence names the lock lease a report is made under. Its fencing token is
+// checked in the same transaction as the report's writes, so a holder whose
+// lease expired or passed to another cannot make them.
+type Fence struct {
+	LockID       string `json:"lock_id"`
+	HolderID     string `json:"holder_id"`
+	FencingToken int64  `json:"fencing_token"`
+}
+
+// check validates the fence within a transaction; a nil fence always passes
+func (f *Fence) check(ctx context.Context, tx repository.Manager) error {
+	if f == nil {
+		return nil
+	}
+	return tx.Locks().ValidateFencingToken(ctx, f.LockID, f.HolderID, f.FencingToken)
+}
+
+// Complete marks a running task completed and frees its session
+func (d *Dispatcher) Complete(ctx context.Context, taskID int64, fence *Fence) (*models.Task, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.repos.Transaction(ctx, func(tx repository.Manager) error {
+		if err := fence.check(ctx, tx); err != nil {
+			return err
+		}
+		task, err := running(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Tasks().MarkCompleted(ctx, task.ID); err != nil {
+			return err
+		}
+		return release(ctx, tx, task.ID, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchedTasks.Inc("completed")
+	return d.repos.Tasks().GetByID(ctx, taskID)
+}
+
+// Fail records a running task's failure and frees its session. The task is
+// requeued after a backoff unless its retries are used up, in which case it
+// is marked failed.
+func (d *Dispatcher) Fail(ctx context.Context, taskID int64, errMsg string, fence *Fence) (*models.Task, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var task *models.Task
+	var delay time.Duration
+	retry, final := 0, false
+	err := d.repos.Transaction(ctx, func(tx repository.Manager) error {
+		if err := fence.check(ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		task, err = running(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := release(ctx, tx, task.ID, false); err != nil {
+			return err
+		}
+
+		retry = task.RetryCount + 1
+		if task.RetryCount >= task.MaxRetries {
+			final = true
+			return tx.Tasks().MarkFailed(ctx, task.ID, errMsg, false)
+		}
+		if err := tx.Tasks().MarkFailed(ctx, task.ID, errMsg, true); err != nil {
+			return err
+		}
+		delay = d.backoff(retry)
+		return tx.Tasks().Defer(ctx, task.ID, d.now().Add(delay))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if final {
+		dispatchedTasks.Inc("failed")
+		logger.WarnContext(ctx, "task failed", "task_id", task.ID, "retries", task.RetryCount, "error", errMsg)
+	} else {
+		dispatchedTasks.Inc("retried")
+		logger.InfoContext(ctx, "task requeued after failure", "task_id", task.ID, "retry", retry,
+			"backoff", delay.String(), "error", errMsg)
+	}
+	return d.repos.Tasks().GetByID(ctx, task.ID)
+}
+
+// Cancel cancels a task that has not finished and frees the session
+// holding it, if any
+func (d *Dispatcher) Cancel(ctx context.Context, taskID int64, reason string, fence *Fence) (*models.Task, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.repos.Transaction(ctx, func(tx repository.Manager) error {
+		if err := fence.check(ctx, tx); err != nil {
+			return err
+		}
+		task, err := tx.Tasks().GetByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return repository.ErrTaskNotFound
+		}
+		if task.Status.IsTerminal() {
+			return fmt.Errorf("%w: %s", ErrTaskFinished, task.Status)
+		}
+		if err := tx.Tasks().UpdateStatus(ctx, taskID, models.TaskCancelled, reason); err != nil {
+			return err
+		}
+		return release(ctx, tx, taskID, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.repos.Tasks().GetByID(ctx, taskID)
+}
+
+// RequeueStuck marks the tasks running past their timeout stuck, frees
+// their sessions and requeues them after a backoff, or fails them when
+// their retries are used up. Each task is handled in one transaction, so a
+// task is never left stuck; one that cannot be handled stays running and is
+// tried again on the next pass. It returns how many tasks timed out.
+func (d *Dispatcher) RequeueStuck(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	tasks, err := d.repos.Tasks().GetStuckTasks(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, task := range tasks {
+		final, err := d.requeueStuck(ctx, task, now)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to requeue stuck task", "task_id", task.ID, "error", err)
+			continue
+		}
+		requeued++
+		dispatchedTasks.Inc("timed_out")
+		if final {
+			logger.WarnContext(ctx, "stuck task failed", "task_id", task.ID, "retries", task.RetryCount)
+		} else {
+			logger.WarnContext(ctx, "stuck task requeued", "task_id", task.ID, "retry", task.RetryCount+1)
+		}
+	}
+	return requeued, nil
+}
+
+// requeueStuck times out one task in a transaction and reports whether its
+// retries were used up
+func (d *Dispatcher) requeueStuck(ctx context.Context, task *models.Task, now time.Time) (bool, error) {
+	reason := fmt.Sprintf("Timed out after %d minutes", task.TimeoutMinutes)
+	final := task.RetryCount >= task.MaxRetries
+	err := d.repos.Transaction(ctx, func(tx repository.Manager) error {
+		if err := tx.Tasks().UpdateStatus(ctx, task.ID, models.TaskStuck, reason); err != nil {
+			return err
+		}
+		if err := release(ctx, tx, task.ID, false); err != nil {
+			return err
+		}
+		if final {
+			return tx.Tasks().MarkFailed(ctx, task.ID, reason, false)
+		}
+		if err := tx.Tasks().IncrementRetry(ctx, task.ID); err != nil {
+			return err
+		}
+		if err := tx.Tasks().UpdateStatus(ctx, task.ID, models.TaskPending, "Requeued after timeout"); err != nil {
+			return err
+		}
+		return tx.Tasks().Defer(ctx, task.ID, now.Add(d.backoff(task.RetryCount+1)))
+	})
+	return final, err
+}
+
+// transaction runs fn in one transaction, serialized with the passes and
+// reports. The dispatcher is locked before the store, as in Dispatch.
+func (d *Dispatcher) transaction(ctx context.Context, fn func(tx repository.Manager) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.repos.Transaction(ctx, fn)
+}
+
+// reclaim returns a task held by a session that died to the queue and
+// reports whether it was still running. The task is ready at once and no
+// retry is counted, since the task did not fail.
+func reclaim(ctx context.Context, repos repository.Manager, taskID int64, reason string) (bool, error) {
+	if err := release(ctx, repos, taskID, false); err != nil {
+		return false, err
+	}
+	task, err := running(ctx, repos, taskID)
+	if errors.Is(err, ErrTaskNotRunning) || errors.Is(err, repository.ErrTaskNotFound) {
+		// Finished or cancelled since the session picked it up
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := repos.Tasks().UpdateStatus(ctx, task.ID, models.TaskPending, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// running gets a task that is assigned to a session
+func running(ctx context.Context, repos repository.Manager, taskID int64) (*models.Task, error) {
+	task, err := repos.Tasks().GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, repository.ErrTaskNotFound
+	}
+	switch task.Status {
+	case models.TaskAssigned, models.TaskInProgress, models.TaskWaitingCompletion:
+		return task, nil
+	default:
+		return nil, ErrTaskNotRunning
+	}
+}
+
+// release clears the task from the session holding it, if any, and returns
+// the session to idle
+func release(ctx context.Context, repos repository.Manager, taskID int64, completed bool) error {
+	session, err := repos.Sessions().GetByCurrentTask(ctx, taskID)
+	if err != nil || session == nil {
+		return err
+	}
+	if err := repos.Sessions().ClearCurrentTask(ctx, session.SessionID); err != nil {
+		return err
+	}
+	if session.Status == models.SessionBusy {
+		if err := repos.Sessions().UpdateStatus(ctx, session.SessionID, models.SessionIdle,
+			fmt.Sprintf("Released task %d", taskID)); err != nil {
+			return err
+		}
+	}
+	return repos.RateLimits().RecordRelease(ctx, session.SessionID, completed)
+}
+
+// backoff returns the delay before the given retry, counting from 1
+func (d *Dispatcher) backoff(retry int) time.Duration {
+	delay := d.opts.RetryBackoff
+	for i := 1; i < retry && delay < d.opts.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxRetryBackoff {
+		delay = d.opts.MaxRetryBackoff
+	}
+	return delay
+}
+
+// Start runs a pass every interval until Close is called
+func (d *Dispatcher) Start(interval time.Duration) {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.Tick(context.Background()); err != nil {
+					logger.Error("task dispatch failed", "error", err)
+				}
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the loop started by Start, waiting for a running pass
+func (d *Dispatcher) Close() {
+	d.stopOnce.Do(func() {
+		if d.stop != nil {
+			close(d.stop)
+			<-d.done
+		}
+	})
+}
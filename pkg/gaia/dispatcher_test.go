@@ -0,0 +1,405 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+)
+
+// setupDispatcher creates a dispatcher over a migrated temporary database
+// whose clock only moves when the test advances it
+func setupDispatcher(t *testing.T, opts DispatcherOptions) (*Dispatcher, repository.Manager, *time.Time) {
+	t.Helper()
+
+	repos := repository.NewManager(setupStore(t))
+	d := NewDispatcher(repos, opts)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	return d, repos, &now
+}
+
+// addSession registers an idle worker session
+func addSession(t *testing.T, repos repository.Manager, sessionID string) {
+	t.Helper()
+
+	_, err := repos.Sessions().Create(context.Background(), &models.SessionCreate{
+		SessionID:   sessionID,
+		SessionType: models.SessionTypeWorker,
+		Provider:    "ollama",
+		Status:      models.SessionIdle,
+	})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+}
+
+// addTask queues a task and returns its ID
+func addTask(t *testing.T, repos repository.Manager, task models.TaskCreate) int64 {
+	t.Helper()
+
+	if task.TimeoutMinutes == 0 {
+		task.TimeoutMinutes = defaultTimeoutMinutes
+	}
+	id, err := repos.Tasks().Create(context.Background(), &task)
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	return id
+}
+
+// dispatch runs a pass and checks how many tasks it assigned
+func dispatch(t *testing.T, d *Dispatcher, want int) {
+	t.Helper()
+
+	n, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if n != want {
+		t.Fatalf("Expected %d tasks assigned, got %d", want, n)
+	}
+}
+
+// getTask returns a task, failing the test when it is missing
+func getTask(t *testing.T, repos repository.Manager, id int64) *models.Task {
+	t.Helper()
+
+	task, err := repos.Tasks().GetByID(context.Background(), id)
+	if err != nil || task == nil {
+		t.Fatalf("failed to get task %d: %v", id, err)
+	}
+	return task
+}
+
+// getSession returns a session, failing the test when it is missing
+func getSession(t *testing.T, repos repository.Manager, sessionID string) *models.Session {
+	t.Helper()
+
+	session, err := repos.Sessions().GetBySessionID(context.Background(), sessionID)
+	if err != nil || session == nil {
+		t.Fatalf("failed to get session %s: %v", sessionID, err)
+	}
+	return session
+}
+
+func TestDispatchPriorityAndAffinity(t *testing.T) {
+	d, repos, _ := setupDispatcher(t, DefaultDispatcherOptions())
+	addSession(t, repos, "worker-1")
+	addSession(t, repos, "worker-2")
+
+	low := addTask(t, repos, models.TaskCreate{Content: "low", Priority: models.PriorityLow})
+	targeted := addTask(t, repos, models.TaskCreate{Content: "targeted", Priority: models.PriorityUrgent, TargetSession: "worker-3"})
+	high := addTask(t, repos, models.TaskCreate{Content: "high", Priority: models.PriorityHigh})
+	lowest := addTask(t, repos, models.TaskCreate{Content: "lowest", Priority: models.PriorityLowest})
+
+	dispatch(t, d, 2)
+	for _, id := range []int64{high, low} {
+		if task := getTask(t, repos, id); task.Status != models.TaskAssigned {
+			t.Errorf("Expected task %q assigned, got %s", task.Content, task.Status)
+		}
+	}
+	for _, id := range []int64{targeted, lowest} {
+		if task := getTask(t, repos, id); task.Status != models.TaskPending {
+			t.Errorf("Expected task %q pending, got %s", task.Content, task.Status)
+		}
+	}
+
+	held := map[int64]bool{}
+	for _, sessionID := range []string{"worker-1", "worker-2"} {
+		session := getSession(t, repos, sessionID)
+		if session.Status != models.SessionBusy || !session.CurrentTaskID.Valid {
+			t.Fatalf("Expected %s busy with a task, got %s", sessionID, session.Status)
+		}
+		held[session.CurrentTaskID.Int64] = true
+	}
+	if !held[high] || !held[low] {
+		t.Errorf("Expected the sessions to hold tasks %d and %d, got %v", high, low, held)
+	}
+
+	// The targeted task waits for its session rather than taking another
+	addSession(t, repos, "worker-3")
+	dispatch(t, d, 1)
+	if session := getSession(t, repos, "worker-3"); session.CurrentTaskID.Int64 != targeted {
+		t.Errorf("Expected worker-3 to hold the targeted task, got %v", session.CurrentTaskID)
+	}
+	if task := getTask(t, repos, targeted); task.TargetSession.String != "worker-3" {
+		t.Errorf("Expected the affinity to be kept, got %v", task.TargetSession)
+	}
+}
+
+func TestDispatchThrottle(t *testing.T) {
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = time.Minute
+	d, repos, now := setupDispatcher(t, opts)
+	addSession(t, repos, "worker-1")
+	first := addTask(t, repos, models.TaskCreate{Content: "first", Priority: models.PriorityHigh})
+	addTask(t, repos, models.TaskCreate{Content: "second", Priority: models.PriorityNormal})
+
+	dispatch(t, d, 1)
+	if _, err := d.Complete(context.Background(), first, nil); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	// Idle again, but assigned to within the interval
+	dispatch(t, d, 0)
+	*now = now.Add(61 * time.Second)
+	dispatch(t, d, 1)
+
+	state, err := repos.RateLimits().Get(context.Background(), "worker-1")
+	if err != nil || state == nil {
+		t.Fatalf("failed to get rate limiter state: %v", err)
+	}
+	if state.TotalAssignments != 2 || state.TotalCompletions != 1 || state.ActiveTaskCount != 1 {
+		t.Errorf("Unexpected rate limiter state %+v", state)
+	}
+	if state.ThrottledCount != 1 || state.LastThrottled == nil {
+		t.Errorf("Expected one throttle event, got %+v", state)
+	}
+}
+
+func TestDispatchSkipsUnhealthySessions(t *testing.T) {
+	d, repos, now := setupDispatcher(t, DefaultDispatcherOptions())
+	addSession(t, repos, "worker-1")
+	addTask(t, repos, models.TaskCreate{Content: "task"})
+
+	if err := repos.Sessions().UpdateHealth(context.Background(), "worker-1", 40); err != nil {
+		t.Fatalf("UpdateHealth failed: %v", err)
+	}
+	dispatch(t, d, 0)
+
+	if err := repos.Sessions().UpdateHealth(context.Background(), "worker-1", 90); err != nil {
+		t.Fatalf("UpdateHealth failed: %v", err)
+	}
+	*now = now.Add(3 * time.Minute)
+	dispatch(t, d, 0)
+
+	*now = now.Add(-3 * time.Minute)
+	dispatch(t, d, 1)
+}
+
+func TestDispatchSkipsTasksItCannotAssign(t *testing.T) {
+	store := setupStore(t)
+	repos := repository.NewManager(store)
+	d := NewDispatcher(repos, DefaultDispatcherOptions())
+	addSession(t, repos, "worker-1")
+	broken := addTask(t, repos, models.TaskCreate{Content: "broken", Priority: models.PriorityUrgent})
+	id := addTask(t, repos, models.TaskCreate{Content: "task"})
+
+	// Assigning the first task fails after the session is already marked busy
+	_, err := store.Exec(context.Background(), fmt.Sprintf(
+		`CREATE TRIGGER fail_assignment BEFORE UPDATE OF current_task_id ON sessions
+		 WHEN NEW.current_task_id = %d BEGIN SELECT RAISE(ABORT, 'assignment failed'); END`, broken))
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	dispatch(t, d, 1)
+
+	if task := getTask(t, repos, broken); task.Status != models.TaskPending {
+		t.Errorf("Expected the failed assignment rolled back, got %s", task.Status)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskAssigned {
+		t.Errorf("Expected the next task assigned, got %s", task.Status)
+	}
+	if session := getSession(t, repos, "worker-1"); session.CurrentTaskID.Int64 != id {
+		t.Errorf("Expected the session to hold task %d, got %v", id, session.CurrentTaskID)
+	}
+	state, err := repos.RateLimits().Get(context.Background(), "worker-1")
+	if err != nil || state == nil || state.TotalAssignments != 1 {
+		t.Errorf("Expected one assignment recorded, got %+v: %v", state, err)
+	}
+}
+
+func TestFailRetriesWithBackoff(t *testing.T) {
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	opts.HeartbeatTimeout = time.Hour
+	d, repos, now := setupDispatcher(t, opts)
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task"})
+	ctx := context.Background()
+
+	// The backoff doubles: 30s before the first retry, 60s before the second
+	// and 2m before the third
+	for retry, backoff := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute} {
+		dispatch(t, d, 1)
+		task, err := d.Fail(ctx, id, "model timed out", nil)
+		if err != nil {
+			t.Fatalf("Fail failed: %v", err)
+		}
+		if task.Status != models.TaskPending || task.RetryCount != retry+1 {
+			t.Fatalf("Expected the task pending with %d retries, got %s with %d", retry+1, task.Status, task.RetryCount)
+		}
+		if session := getSession(t, repos, "worker-1"); session.Status != models.SessionIdle || session.CurrentTaskID.Valid {
+			t.Fatalf("Expected the session freed, got %s holding %v", session.Status, session.CurrentTaskID)
+		}
+
+		*now = now.Add(backoff - time.Second)
+		dispatch(t, d, 0)
+		*now = now.Add(2 * time.Second)
+	}
+
+	dispatch(t, d, 1)
+	task, err := d.Fail(ctx, id, "model timed out", nil)
+	if err != nil {
+		t.Fatalf("Fail failed: %v", err)
+	}
+	if task.Status != models.TaskFailed || task.ErrorMessage.String != "model timed out" {
+		t.Errorf("Expected the task failed once its retries are used up, got %s", task.Status)
+	}
+	dispatch(t, d, 0)
+
+	if _, err := d.Fail(ctx, id, "again", nil); !errors.Is(err, ErrTaskNotRunning) {
+		t.Errorf("Expected ErrTaskNotRunning for a failed task, got %v", err)
+	}
+	if _, err := d.Complete(ctx, 99, nil); !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestRequeueStuck(t *testing.T) {
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	opts.HeartbeatTimeout = time.Hour
+	d, repos, now := setupDispatcher(t, opts)
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task", TimeoutMinutes: 1})
+	ctx := context.Background()
+
+	dispatch(t, d, 1)
+	if n, err := d.RequeueStuck(ctx); err != nil || n != 0 {
+		t.Fatalf("Expected no stuck tasks yet, got %d: %v", n, err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if n, err := d.RequeueStuck(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected 1 stuck task, got %d: %v", n, err)
+	}
+	task := getTask(t, repos, id)
+	if task.Status != models.TaskPending || task.RetryCount != 1 {
+		t.Errorf("Expected the task requeued with 1 retry, got %s with %d", task.Status, task.RetryCount)
+	}
+	if session := getSession(t, repos, "worker-1"); session.Status != models.SessionIdle || session.CurrentTaskID.Valid {
+		t.Errorf("Expected the session freed, got %s holding %v", session.Status, session.CurrentTaskID)
+	}
+
+	logs, err := repos.Tasks().GetStatusChangeLogs(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStatusChangeLogs failed: %v", err)
+	}
+	stuck := false
+	for _, entry := range logs {
+		if entry.NewStatus == string(models.TaskStuck) {
+			stuck = true
+		}
+	}
+	if !stuck {
+		t.Errorf("Expected the task logged as stuck, got %+v", logs)
+	}
+
+	// Requeued after the backoff, then failed once its retries are used up
+	dispatch(t, d, 0)
+	*now = now.Add(31 * time.Second)
+	dispatch(t, d, 1)
+	for timeouts := 2; ; timeouts++ {
+		*now = now.Add(2 * time.Minute)
+		if n, err := d.RequeueStuck(ctx); err != nil || n != 1 {
+			t.Fatalf("Expected 1 stuck task, got %d: %v", n, err)
+		}
+		task := getTask(t, repos, id)
+		if task.Status == models.TaskFailed {
+			if timeouts != task.MaxRetries+1 {
+				t.Errorf("Expected the task failed after %d timeouts, got %d", task.MaxRetries+1, timeouts)
+			}
+			break
+		}
+		if timeouts > task.MaxRetries {
+			t.Fatalf("Expected the task failed after %d timeouts, got %s", task.MaxRetries+1, task.Status)
+		}
+		*now = now.Add(2 * time.Minute)
+		dispatch(t, d, 1)
+	}
+}
+
+func TestRequeueStuckIsAtomic(t *testing.T) {
+	store := setupStore(t)
+	repos := repository.NewManager(store)
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	d := NewDispatcher(repos, opts)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task", TimeoutMinutes: 1})
+	dispatch(t, d, 1)
+
+	// Requeueing the task fails after it was marked stuck and its session
+	// freed, so the whole timeout is rolled back
+	_, err := store.Exec(ctx, `CREATE TRIGGER fail_requeue BEFORE UPDATE OF status ON tasks
+		WHEN NEW.status = 'pending' BEGIN SELECT RAISE(ABORT, 'requeue failed'); END`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if n, err := d.RequeueStuck(ctx); err != nil || n != 0 {
+		t.Fatalf("Expected no task requeued, got %d: %v", n, err)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskAssigned || task.RetryCount != 0 {
+		t.Errorf("Expected the task still assigned without a retry, got %s with %d", task.Status, task.RetryCount)
+	}
+	if session := getSession(t, repos, "worker-1"); session.CurrentTaskID.Int64 != id {
+		t.Errorf("Expected the session still holding the task, got %v", session.CurrentTaskID)
+	}
+
+	// The next pass requeues it
+	if _, err := store.Exec(ctx, `DROP TRIGGER fail_requeue`); err != nil {
+		t.Fatalf("failed to drop trigger: %v", err)
+	}
+	if n, err := d.RequeueStuck(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected 1 stuck task, got %d: %v", n, err)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskPending || task.RetryCount != 1 {
+		t.Errorf("Expected the task requeued with 1 retry, got %s with %d", task.Status, task.RetryCount)
+	}
+}
+
+func TestCancelFinishedTask(t *testing.T) {
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	opts.HeartbeatTimeout = time.Hour
+	d, repos, _ := setupDispatcher(t, opts)
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task"})
+	ctx := context.Background()
+
+	dispatch(t, d, 1)
+	if _, err := d.Complete(ctx, id, nil); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if _, err := d.Cancel(ctx, id, "too late", nil); !errors.Is(err, ErrTaskFinished) {
+		t.Errorf("Expected ErrTaskFinished, got %v", err)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskCompleted {
+		t.Errorf("Expected the task to stay completed, got %s", task.Status)
+	}
+	if _, err := d.Cancel(ctx, 99, "missing", nil); !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, DefaultDispatcherOptions())
+
+	expected := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 7: 30 * time.Minute, 60: 30 * time.Minute}
+	for retry, want := range expected {
+		if got := d.backoff(retry); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", retry, got, want)
+		}
+	}
+}
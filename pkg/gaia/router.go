@@ -0,0 +1,741 @@
+// Package gaia serves the HTTP API of the GAIA task queue: tasks, the
+// sessions that work on them, the locks they coordinate with and the
+// recorded system metrics. Its Dispatcher assigns queued tasks to the
+// sessions and its Collector records the metrics and raises alerts on them.
+// It sits on the repositories in internal/repository over the
+// separate GAIA database.
+package gaia
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+)
+
+// logger is the gaia API logger
+var logger = logging.Logger("gaia")
+
+const (
+	// defaultPageSize and maxPageSize bound task listings
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// defaultTimeoutMinutes is how long an assigned task may run when the
+	// request does not say
+	defaultTimeoutMinutes = 30
+
+	// defaultLockTTL and maxLockTTL bound how long a lock is held before it
+	// expires on its own
+	defaultLockTTL = time.Minute
+	maxLockTTL     = 24 * time.Hour
+
+	// maxLockWait is the longest an acquire request queues for a held lock,
+	// within the server's write timeout
+	maxLockWait = 10 * time.Second
+
+	// defaultMetricsWindow is the period of time series and reports when the
+	// request does not say
+	defaultMetricsWindow = 24 * time.Hour
+)
+
+// timeSeriesMetrics are the metrics columns available as time series
+var timeSeriesMetrics = map[string]bool{
+	"task_throughput": true,
+	"avg_latency_ms":  true,
+	"success_rate":    true,
+	"memory_mb":       true,
+	"cpu_percent":     true,
+	"active_sessions": true,
+	"pending_tasks":   true,
+}
+
+// Router configures the GAIA routes
+type Router struct {
+	repos      repository.Manager
+	dispatcher *Dispatcher
+}
+
+// NewRouter creates a new GAIA router over the repositories. Sessions report
+// finished tasks through the dispatcher.
+func NewRouter(repos repository.Manager, dispatcher *Dispatcher) *Router {
+	return &Router{repos: repos, dispatcher: dispatcher}
+}
+
+// Routes returns the GAIA router with all configured routes. It does not
+// check the caller; mount it behind an admin-only middleware.
+func (r *Router) Routes() chi.Router {
+	router := chi.NewRouter()
+
+	router.Post("/tasks", r.CreateTask)
+	router.Get("/tasks", r.ListTasks)
+	router.Get("/tasks/{id}", r.GetTask)
+	router.Post("/tasks/{id}/cancel", r.CancelTask)
+	router.Post("/tasks/{id}/complete", r.CompleteTask)
+	router.Post("/tasks/{id}/fail", r.FailTask)
+	router.Get("/tasks/{id}/logs", r.GetTaskLogs)
+
+	router.Post("/sessions", r.RegisterSession)
+	router.Post("/sessions/{sessionId}/heartbeat", r.Heartbeat)
+	router.Get("/sessions/{sessionId}/health", r.GetSessionHealth)
+	router.Get("/sessions/{sessionId}/state-logs", r.GetSessionStateLogs)
+
+	router.Post("/locks/{lockId}/acquire", r.AcquireLock)
+	router.Post("/locks/{lockId}/extend", r.ExtendLock)
+	router.Post("/locks/{lockId}/validate", r.ValidateLock)
+	router.Post("/locks/{lockId}/release", r.ReleaseLock)
+	router.Get("/locks/{lockId}", r.GetLockStatus)
+
+	router.Get("/metrics/series", r.GetTimeSeries)
+	router.Get("/metrics/report", r.GetReport)
+	router.Get("/metrics/locks", r.GetLockMetrics)
+	router.Get("/metrics/alerts", r.ListAlerts)
+
+	return router
+}
+
+// CreateTask queues a new task
+func (r *Router) CreateTask(w http.ResponseWriter, req *http.Request) {
+	var input models.TaskCreate
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Content == "" {
+		respondError(w, req, http.StatusBadRequest, "content is required")
+		return
+	}
+	if input.Priority == 0 {
+		input.Priority = models.PriorityNormal
+	}
+	if input.Priority < models.PriorityLowest || input.Priority > models.PriorityUrgent {
+		respondError(w, req, http.StatusBadRequest, "priority must be between 1 and 10")
+		return
+	}
+	if input.TimeoutMinutes == 0 {
+		input.TimeoutMinutes = defaultTimeoutMinutes
+	}
+	if input.TimeoutMinutes < 0 {
+		respondError(w, req, http.StatusBadRequest, "timeout_minutes must not be negative")
+		return
+	}
+
+	id, err := r.repos.Tasks().Create(req.Context(), &input)
+	if err != nil {
+		respondInternalError(w, req, "Failed to create task", err)
+		return
+	}
+	task, err := r.repos.Tasks().GetByID(req.Context(), id)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get task", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.NewResponse(task))
+}
+
+// ListTasks lists the tasks with a status, pending by default, highest
+// priority first
+func (r *Router) ListTasks(w http.ResponseWriter, req *http.Request) {
+	status := models.TaskPending
+	if s := req.URL.Query().Get("status"); s != "" {
+		status = models.TaskStatus(s)
+	}
+	page, pageSize, ok := parsePage(req)
+	if !ok {
+		respondError(w, req, http.StatusBadRequest, "Invalid page or page_size")
+		return
+	}
+
+	tasks, err := r.repos.Tasks().GetByStatus(req.Context(), status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		respondInternalError(w, req, "Failed to list tasks", err)
+		return
+	}
+	total, err := r.repos.Tasks().CountByStatus(req.Context(), status)
+	if err != nil {
+		respondInternalError(w, req, "Failed to count tasks", err)
+		return
+	}
+	if tasks == nil {
+		tasks = []*models.Task{}
+	}
+
+	respondJSON(w, http.StatusOK, models.NewPaginatedResponse(tasks, total, page, pageSize))
+}
+
+// GetTask returns a task
+func (r *Router) GetTask(w http.ResponseWriter, req *http.Request) {
+	task, ok := r.loadTask(w, req)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, models.NewResponse(task))
+}
+
+// reportRequest is the body of the cancel and complete routes. The body is
+// optional; a lock names the lease the report is made under, which must
+// still be current for the report to take effect.
+type reportRequest struct {
+	Lock *Fence `json:"lock"`
+}
+
+// CancelTask cancels a task that has not finished
+func (r *Router) CancelTask(w http.ResponseWriter, req *http.Request) {
+	task, ok := r.loadTask(w, req)
+	if !ok {
+		return
+	}
+	var input reportRequest
+	if !decodeOptionalBody(w, req, &input) {
+		return
+	}
+	task, err := r.dispatcher.Cancel(req.Context(), task.ID, "Cancelled via API", input.Lock)
+	if err != nil {
+		respondTaskReportError(w, req, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(task))
+}
+
+// CompleteTask records that the session running a task finished it
+func (r *Router) CompleteTask(w http.ResponseWriter, req *http.Request) {
+	task, ok := r.loadTask(w, req)
+	if !ok {
+		return
+	}
+	var input reportRequest
+	if !decodeOptionalBody(w, req, &input) {
+		return
+	}
+
+	task, err := r.dispatcher.Complete(req.Context(), task.ID, input.Lock)
+	if err != nil {
+		respondTaskReportError(w, req, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(task))
+}
+
+// failRequest is the body of the fail route
+type failRequest struct {
+	Error string `json:"error"`
+	Lock  *Fence `json:"lock"`
+}
+
+// FailTask records that the session running a task failed it. The task is
+// retried after a backoff until its retries are used up.
+func (r *Router) FailTask(w http.ResponseWriter, req *http.Request) {
+	task, ok := r.loadTask(w, req)
+	if !ok {
+		return
+	}
+	var input failRequest
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Error == "" {
+		respondError(w, req, http.StatusBadRequest, "error is required")
+		return
+	}
+
+	task, err := r.dispatcher.Fail(req.Context(), task.ID, input.Error, input.Lock)
+	if err != nil {
+		respondTaskReportError(w, req, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(task))
+}
+
+// decodeOptionalBody decodes a JSON body that may be left out
+func decodeOptionalBody(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// respondTaskReportError answers a cancel, complete or fail report the
+// dispatcher rejected
+func respondTaskReportError(w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTaskNotFound):
+		respondError(w, req, http.StatusNotFound, "Task not found")
+	case errors.Is(err, ErrTaskNotRunning):
+		respondError(w, req, http.StatusConflict, "Task is not assigned to a session")
+	case errors.Is(err, ErrTaskFinished):
+		respondError(w, req, http.StatusConflict, "Task has already finished")
+	case errors.Is(err, repository.ErrStaleFencingToken):
+		respondError(w, req, http.StatusConflict, "Lease has expired or passed to another holder")
+	default:
+		respondInternalError(w, req, "Failed to update task", err)
+	}
+}
+
+// GetTaskLogs returns a task's status changes, newest first
+func (r *Router) GetTaskLogs(w http.ResponseWriter, req *http.Request) {
+	task, ok := r.loadTask(w, req)
+	if !ok {
+		return
+	}
+
+	logs, err := r.repos.Tasks().GetStatusChangeLogs(req.Context(), task.ID)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get task logs", err)
+		return
+	}
+	if logs == nil {
+		logs = []*models.TaskLog{}
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(logs))
+}
+
+// loadTask gets the task named by the {id} URL parameter, responding with
+// an error when there is none
+func (r *Router) loadTask(w http.ResponseWriter, req *http.Request) (*models.Task, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil || id < 1 {
+		respondError(w, req, http.StatusBadRequest, "Invalid task ID")
+		return nil, false
+	}
+
+	task, err := r.repos.Tasks().GetByID(req.Context(), id)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get task", err)
+		return nil, false
+	}
+	if task == nil {
+		respondError(w, req, http.StatusNotFound, "Task not found")
+		return nil, false
+	}
+	return task, true
+}
+
+// RegisterSession registers a session that will work on tasks
+func (r *Router) RegisterSession(w http.ResponseWriter, req *http.Request) {
+	var input models.SessionCreate
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.SessionID == "" || input.Provider == "" {
+		respondError(w, req, http.StatusBadRequest, "session_id and provider are required")
+		return
+	}
+	if input.SessionType == "" {
+		input.SessionType = models.SessionTypeWorker
+	}
+	switch input.SessionType {
+	case models.SessionTypeInteractive, models.SessionTypeWorker, models.SessionTypeBatch:
+	default:
+		respondError(w, req, http.StatusBadRequest, "session_type must be interactive, worker or batch")
+		return
+	}
+	if input.Status == "" {
+		input.Status = models.SessionIdle
+	}
+	if !input.Status.IsHealthy() {
+		respondError(w, req, http.StatusBadRequest, "status must be idle, busy or waiting_input")
+		return
+	}
+
+	_, err := r.repos.Sessions().Create(req.Context(), &input)
+	if errors.Is(err, repository.ErrSessionExists) {
+		respondError(w, req, http.StatusConflict, "Session is already registered")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to register session", err)
+		return
+	}
+	session, err := r.repos.Sessions().GetBySessionID(req.Context(), input.SessionID)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get session", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.NewResponse(session))
+}
+
+// Heartbeat records that a session is alive and returns its health
+func (r *Router) Heartbeat(w http.ResponseWriter, req *http.Request) {
+	sessionID := chi.URLParam(req, "sessionId")
+
+	err := r.repos.Sessions().RecordHeartbeat(req.Context(), sessionID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		respondError(w, req, http.StatusNotFound, "Session not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to record heartbeat", err)
+		return
+	}
+
+	r.GetSessionHealth(w, req)
+}
+
+// GetSessionHealth returns a session's health score, status and time since
+// its last heartbeat
+func (r *Router) GetSessionHealth(w http.ResponseWriter, req *http.Request) {
+	health, err := r.repos.Sessions().GetHealth(req.Context(), chi.URLParam(req, "sessionId"))
+	if err != nil {
+		respondInternalError(w, req, "Failed to get session health", err)
+		return
+	}
+	if health == nil {
+		respondError(w, req, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(health))
+}
+
+// GetSessionStateLogs returns a session's state changes, newest first
+func (r *Router) GetSessionStateLogs(w http.ResponseWriter, req *http.Request) {
+	sessionID := chi.URLParam(req, "sessionId")
+
+	session, err := r.repos.Sessions().GetBySessionID(req.Context(), sessionID)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get session", err)
+		return
+	}
+	if session == nil {
+		respondError(w, req, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	logs, err := r.repos.Sessions().GetStateChangeLogs(req.Context(), sessionID)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get session state logs", err)
+		return
+	}
+	if logs == nil {
+		logs = []*models.SessionStateLog{}
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(logs))
+}
+
+// lockRequest is the body of the lock routes
+type lockRequest struct {
+	HolderID     string `json:"holder_id"`
+	TTLSeconds   int    `json:"ttl_seconds"`
+	Priority     int    `json:"priority"`
+	WaitSeconds  int    `json:"wait_seconds"`
+	FencingToken int64  `json:"fencing_token"`
+}
+
+// AcquireLock takes a lock for a holder, or renews it when the holder
+// already has it. A higher priority than the holder's preempts it. With
+// wait_seconds the request queues behind earlier waiters until the lock is
+// free.
+func (r *Router) AcquireLock(w http.ResponseWriter, req *http.Request) {
+	input, ok := decodeLockRequest(w, req)
+	if !ok {
+		return
+	}
+	ttl, ok := lockTTL(w, req, input)
+	if !ok {
+		return
+	}
+	if input.Priority == 0 {
+		input.Priority = int(models.PriorityNormal)
+	}
+	wait := time.Duration(input.WaitSeconds) * time.Second
+	if wait < 0 || wait > maxLockWait {
+		respondError(w, req, http.StatusBadRequest, "wait_seconds must be between 0 and 10")
+		return
+	}
+
+	lockID := chi.URLParam(req, "lockId")
+	var err error
+	if wait > 0 {
+		_, err = r.repos.Locks().WaitForLock(req.Context(), lockID, input.HolderID, ttl, wait, input.Priority)
+	} else {
+		_, err = r.repos.Locks().AcquireLock(req.Context(), lockID, input.HolderID, ttl, input.Priority)
+	}
+	if errors.Is(err, repository.ErrLockHeld) {
+		respondError(w, req, http.StatusConflict, "Lock is held by another holder")
+		return
+	}
+	if errors.Is(err, repository.ErrLockWaitTimeout) {
+		respondError(w, req, http.StatusConflict, "Timed out waiting for the lock")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to acquire lock", err)
+		return
+	}
+
+	r.GetLockStatus(w, req)
+}
+
+// ExtendLock renews the holder's lease, identified by its fencing token
+func (r *Router) ExtendLock(w http.ResponseWriter, req *http.Request) {
+	input, ok := decodeLockRequest(w, req)
+	if !ok {
+		return
+	}
+	ttl, ok := lockTTL(w, req, input)
+	if !ok {
+		return
+	}
+
+	_, err := r.repos.Locks().ExtendLock(req.Context(), chi.URLParam(req, "lockId"), input.HolderID, input.FencingToken, ttl)
+	if errors.Is(err, repository.ErrStaleFencingToken) {
+		respondError(w, req, http.StatusConflict, "Lease has expired or passed to another holder")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to extend lock", err)
+		return
+	}
+
+	r.GetLockStatus(w, req)
+}
+
+// ValidateLock checks a holder's fencing token before a write the lock
+// protects, answering 409 when the lease is no longer current
+func (r *Router) ValidateLock(w http.ResponseWriter, req *http.Request) {
+	input, ok := decodeLockRequest(w, req)
+	if !ok {
+		return
+	}
+
+	err := r.repos.Locks().ValidateFencingToken(req.Context(), chi.URLParam(req, "lockId"), input.HolderID, input.FencingToken)
+	if errors.Is(err, repository.ErrStaleFencingToken) {
+		respondError(w, req, http.StatusConflict, "Fencing token is stale")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to validate fencing token", err)
+		return
+	}
+
+	r.GetLockStatus(w, req)
+}
+
+// ReleaseLock releases the holder's lease, identified by its fencing token
+func (r *Router) ReleaseLock(w http.ResponseWriter, req *http.Request) {
+	input, ok := decodeLockRequest(w, req)
+	if !ok {
+		return
+	}
+
+	lockID := chi.URLParam(req, "lockId")
+	err := r.repos.Locks().ReleaseLock(req.Context(), lockID, input.HolderID, input.FencingToken)
+	if errors.Is(err, repository.ErrLockNotHeld) {
+		respondError(w, req, http.StatusConflict, "Lock is not held by this holder")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to release lock", err)
+		return
+	}
+
+	r.GetLockStatus(w, req)
+}
+
+// GetLockStatus reports whether a lock is held, by whom and until when
+func (r *Router) GetLockStatus(w http.ResponseWriter, req *http.Request) {
+	status, err := r.repos.Locks().GetLockStatus(req.Context(), chi.URLParam(req, "lockId"))
+	if err != nil {
+		respondInternalError(w, req, "Failed to get lock status", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(status))
+}
+
+// lockTTL returns the lease duration asked for, by default defaultLockTTL
+func lockTTL(w http.ResponseWriter, req *http.Request, input lockRequest) (time.Duration, bool) {
+	ttl := defaultLockTTL
+	if input.TTLSeconds != 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > maxLockTTL {
+		respondError(w, req, http.StatusBadRequest, "ttl_seconds must be between 1 and 86400")
+		return 0, false
+	}
+	return ttl, true
+}
+
+// decodeLockRequest reads a lock request body, which must name the holder
+func decodeLockRequest(w http.ResponseWriter, req *http.Request) (lockRequest, bool) {
+	var input lockRequest
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return input, false
+	}
+	if input.HolderID == "" {
+		respondError(w, req, http.StatusBadRequest, "holder_id is required")
+		return input, false
+	}
+	return input, true
+}
+
+// GetTimeSeries returns one metric's recorded values between from and to,
+// by default over the last 24 hours
+func (r *Router) GetTimeSeries(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	metric := q.Get("metric")
+	if !timeSeriesMetrics[metric] {
+		respondError(w, req, http.StatusBadRequest, "Unknown metric "+strconv.Quote(metric))
+		return
+	}
+
+	end := time.Now()
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, req, http.StatusBadRequest, "to must be an RFC 3339 time")
+			return
+		}
+		end = t
+	}
+	start := end.Add(-defaultMetricsWindow)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, req, http.StatusBadRequest, "from must be an RFC 3339 time")
+			return
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		respondError(w, req, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	series, err := r.repos.Metrics().GetTimeSeries(req.Context(), metric, start, end)
+	if err != nil {
+		respondInternalError(w, req, "Failed to get time series", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(series))
+}
+
+// GetReport returns the system health, aggregated metrics and
+// recommendations over a period, by default the last 24 hours
+func (r *Router) GetReport(w http.ResponseWriter, req *http.Request) {
+	period := defaultMetricsWindow
+	if v := req.URL.Query().Get("period"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			respondError(w, req, http.StatusBadRequest, "period must be a positive duration such as 24h")
+			return
+		}
+		period = d
+	}
+
+	report, err := r.repos.Metrics().GetPerformanceReport(req.Context(), period)
+	if err != nil {
+		respondInternalError(w, req, "Failed to generate report", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(report))
+}
+
+// GetLockMetrics returns lock counts, hold and wait times and how often
+// holders contend for locks
+func (r *Router) GetLockMetrics(w http.ResponseWriter, req *http.Request) {
+	metrics, err := r.repos.Locks().GetLockMetrics(req.Context())
+	if err != nil {
+		respondInternalError(w, req, "Failed to get lock metrics", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NewResponse(metrics))
+}
+
+// ListAlerts lists the metric alerts newest first, only the open ones
+// with active=true
+func (r *Router) ListAlerts(w http.ResponseWriter, req *http.Request) {
+	activeOnly := false
+	if v := req.URL.Query().Get("active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, req, http.StatusBadRequest, "active must be true or false")
+			return
+		}
+		activeOnly = b
+	}
+	page, pageSize, ok := parsePage(req)
+	if !ok {
+		respondError(w, req, http.StatusBadRequest, "Invalid page or page_size")
+		return
+	}
+
+	alerts, err := r.repos.Metrics().GetAlerts(req.Context(), activeOnly, pageSize, (page-1)*pageSize)
+	if err != nil {
+		respondInternalError(w, req, "Failed to list alerts", err)
+		return
+	}
+	total, err := r.repos.Metrics().CountAlerts(req.Context(), activeOnly)
+	if err != nil {
+		respondInternalError(w, req, "Failed to count alerts", err)
+		return
+	}
+	if alerts == nil {
+		alerts = []*models.MetricsAlert{}
+	}
+
+	respondJSON(w, http.StatusOK, models.NewPaginatedResponse(alerts, total, page, pageSize))
+}
+
+// parsePage reads the page and page_size query parameters
+func parsePage(req *http.Request) (page, pageSize int, ok bool) {
+	page, pageSize = 1, defaultPageSize
+	q := req.URL.Query()
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		pageSize = min(n, maxPageSize)
+	}
+	return page, pageSize, true
+}
+
+// Helper function to respond with JSON
+func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// Helper function to respond with a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
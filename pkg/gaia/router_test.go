@@ -0,0 +1,398 @@
+package gaia
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/internal/storage"
+)
+
+// setupStore opens a migrated temporary GAIA database
+func setupStore(t *testing.T) *storage.SQLiteStore {
+	t.Helper()
+
+	store, err := storage.NewSQLiteStore(storage.Config{DatabasePath: filepath.Join(t.TempDir(), "gaia.db")})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Initialize(context.Background()); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return store
+}
+
+// setupTestRouter serves the GAIA routes over a migrated temporary database
+func setupTestRouter(t *testing.T) (http.Handler, *storage.SQLiteStore) {
+	t.Helper()
+
+	store := setupStore(t)
+	repos := repository.NewManager(store)
+	return NewRouter(repos, NewDispatcher(repos, DefaultDispatcherOptions())).Routes(), store
+}
+
+// call sends a request and decodes the JSON response
+func call(t *testing.T, handler http.Handler, method, target, body string, want int) map[string]interface{} {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != want {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", method, target, want, w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: invalid JSON response: %v", method, target, err)
+	}
+	return resp
+}
+
+// data returns the data object of a models.Response
+func data(resp map[string]interface{}) map[string]interface{} {
+	d, _ := resp["data"].(map[string]interface{})
+	return d
+}
+
+func TestTasks(t *testing.T) {
+	handler, _ := setupTestRouter(t)
+
+	created := data(call(t, handler, http.MethodPost, "/tasks", `{"content":"grade essays","priority":7,"metadata":{"class":"5b"}}`, http.StatusCreated))
+	if created["status"] != "pending" || created["priority"] != float64(7) || created["timeout_minutes"] != float64(30) {
+		t.Errorf("Unexpected task %v", created)
+	}
+	if created["target_session"] != nil {
+		t.Errorf("Expected no target session, got %v", created["target_session"])
+	}
+	if meta, _ := created["metadata"].(map[string]interface{}); meta["class"] != "5b" {
+		t.Errorf("Expected the metadata as JSON, got %v", created["metadata"])
+	}
+	call(t, handler, http.MethodPost, "/tasks", `{"content":"low","priority":1}`, http.StatusCreated)
+	call(t, handler, http.MethodPost, "/tasks", `{"priority":5}`, http.StatusBadRequest)
+	call(t, handler, http.MethodPost, "/tasks", `{"content":"x","priority":11}`, http.StatusBadRequest)
+
+	page := call(t, handler, http.MethodGet, "/tasks?page_size=1", "", http.StatusOK)
+	if page["total"] != float64(2) || page["total_pages"] != float64(2) || page["has_next_page"] != true {
+		t.Errorf("Unexpected page %v", page)
+	}
+	if tasks, _ := page["data"].([]interface{}); len(tasks) != 1 || tasks[0].(map[string]interface{})["content"] != "grade essays" {
+		t.Errorf("Expected the highest priority task first, got %v", page["data"])
+	}
+	call(t, handler, http.MethodGet, "/tasks?page=0", "", http.StatusBadRequest)
+
+	call(t, handler, http.MethodGet, "/tasks/1", "", http.StatusOK)
+	call(t, handler, http.MethodGet, "/tasks/99", "", http.StatusNotFound)
+
+	cancelled := data(call(t, handler, http.MethodPost, "/tasks/1/cancel", "", http.StatusOK))
+	if cancelled["status"] != "cancelled" {
+		t.Errorf("Expected the task to be cancelled, got %v", cancelled["status"])
+	}
+	call(t, handler, http.MethodPost, "/tasks/1/cancel", "", http.StatusConflict)
+
+	logs := call(t, handler, http.MethodGet, "/tasks/1/logs", "", http.StatusOK)
+	if entries, _ := logs["data"].([]interface{}); len(entries) != 1 || entries[0].(map[string]interface{})["new_status"] != "cancelled" {
+		t.Errorf("Expected the cancellation in the task log, got %v", logs["data"])
+	}
+
+	cancelledPage := call(t, handler, http.MethodGet, "/tasks?status=cancelled", "", http.StatusOK)
+	if cancelledPage["total"] != float64(1) {
+		t.Errorf("Expected 1 cancelled task, got %v", cancelledPage["total"])
+	}
+}
+
+func TestTaskReports(t *testing.T) {
+	handler, store := setupTestRouter(t)
+	repos := repository.NewManager(store)
+
+	call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-1","provider":"ollama"}`, http.StatusCreated)
+	call(t, handler, http.MethodPost, "/tasks", `{"content":"grade essays"}`, http.StatusCreated)
+	call(t, handler, http.MethodPost, "/tasks", `{"content":"mark tests"}`, http.StatusCreated)
+
+	call(t, handler, http.MethodPost, "/tasks/1/complete", "", http.StatusConflict)
+	call(t, handler, http.MethodPost, "/tasks/99/complete", "", http.StatusNotFound)
+
+	dispatcher := NewDispatcher(repos, DefaultDispatcherOptions())
+	if n, err := dispatcher.Dispatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Expected 1 task assigned, got %d: %v", n, err)
+	}
+
+	call(t, handler, http.MethodPost, "/tasks/1/fail", `{}`, http.StatusBadRequest)
+	failed := data(call(t, handler, http.MethodPost, "/tasks/1/fail", `{"error":"model timed out"}`, http.StatusOK))
+	if failed["status"] != "pending" || failed["retry_count"] != float64(1) || failed["error_message"] != "model timed out" {
+		t.Errorf("Expected the task requeued for a retry, got %v", failed)
+	}
+
+	dispatcher.now = func() time.Time { return time.Now().Add(10 * time.Second) }
+	if n, err := dispatcher.Dispatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Expected 1 task assigned, got %d: %v", n, err)
+	}
+	completed := data(call(t, handler, http.MethodPost, "/tasks/2/complete", "", http.StatusOK))
+	if completed["status"] != "completed" || completed["completed_at"] == nil {
+		t.Errorf("Expected the task completed, got %v", completed)
+	}
+
+	// A cancelled task frees its session
+	dispatcher.now = func() time.Time { return time.Now().Add(20 * time.Second) }
+	if n, err := dispatcher.Dispatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("Expected the failed task to wait out its backoff, got %d assigned: %v", n, err)
+	}
+	dispatcher.now = func() time.Time { return time.Now().Add(time.Minute) }
+	if n, err := dispatcher.Dispatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Expected the failed task assigned again, got %d: %v", n, err)
+	}
+	call(t, handler, http.MethodPost, "/tasks/1/cancel", "", http.StatusOK)
+	call(t, handler, http.MethodPost, "/tasks/1/cancel", "", http.StatusConflict)
+	call(t, handler, http.MethodPost, "/tasks/2/cancel", "", http.StatusConflict)
+	if status := data(call(t, handler, http.MethodGet, "/tasks/2", "", http.StatusOK))["status"]; status != "completed" {
+		t.Errorf("Expected a completed task to stay completed, got %v", status)
+	}
+	session, err := repos.Sessions().GetBySessionID(context.Background(), "worker-1")
+	if err != nil {
+		t.Fatalf("GetBySessionID failed: %v", err)
+	}
+	if session.Status != models.SessionIdle || session.CurrentTaskID.Valid {
+		t.Errorf("Expected the session idle without a task, got %s holding %v", session.Status, session.CurrentTaskID)
+	}
+}
+
+func TestFencedTaskReports(t *testing.T) {
+	handler, store := setupTestRouter(t)
+	repos := repository.NewManager(store)
+
+	call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-1","provider":"ollama"}`, http.StatusCreated)
+	call(t, handler, http.MethodPost, "/tasks", `{"content":"grade essays"}`, http.StatusCreated)
+	if n, err := NewDispatcher(repos, DefaultDispatcherOptions()).Dispatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Expected 1 task assigned, got %d: %v", n, err)
+	}
+
+	// worker-1's lease passes to worker-2, so its reports are refused
+	call(t, handler, http.MethodPost, "/locks/essays/acquire", `{"holder_id":"worker-1"}`, http.StatusOK)
+	call(t, handler, http.MethodPost, "/locks/essays/acquire", `{"holder_id":"worker-2","priority":9}`, http.StatusOK)
+	stale := `{"lock":{"lock_id":"essays","holder_id":"worker-1","fencing_token":1}}`
+	call(t, handler, http.MethodPost, "/tasks/1/complete", stale, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/tasks/1/fail", `{"error":"late","lock":{"lock_id":"essays","holder_id":"worker-1","fencing_token":1}}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/tasks/1/cancel", stale, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/tasks/1/complete", `{"lock":`, http.StatusBadRequest)
+
+	task, err := repos.Tasks().GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if task.Status != models.TaskAssigned || task.RetryCount != 0 {
+		t.Errorf("Expected the refused reports to change nothing, got %s with %d retries", task.Status, task.RetryCount)
+	}
+
+	completed := data(call(t, handler, http.MethodPost, "/tasks/1/complete", `{"lock":{"lock_id":"essays","holder_id":"worker-2","fencing_token":2}}`, http.StatusOK))
+	if completed["status"] != "completed" {
+		t.Errorf("Expected the current lease holder to complete the task, got %v", completed)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	handler, _ := setupTestRouter(t)
+
+	session := data(call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-1","provider":"ollama"}`, http.StatusCreated))
+	if session["session_type"] != "worker" || session["status"] != "idle" || session["current_task_id"] != nil {
+		t.Errorf("Unexpected session %v", session)
+	}
+	call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-1","provider":"ollama"}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-2"}`, http.StatusBadRequest)
+	call(t, handler, http.MethodPost, "/sessions", `{"session_id":"worker-2","provider":"ollama","session_type":"daemon"}`, http.StatusBadRequest)
+
+	health := data(call(t, handler, http.MethodPost, "/sessions/worker-1/heartbeat", "", http.StatusOK))
+	if health["is_healthy"] != true || health["health_score"] != float64(100) {
+		t.Errorf("Unexpected health %v", health)
+	}
+	call(t, handler, http.MethodPost, "/sessions/nobody/heartbeat", "", http.StatusNotFound)
+
+	call(t, handler, http.MethodGet, "/sessions/worker-1/health", "", http.StatusOK)
+	call(t, handler, http.MethodGet, "/sessions/nobody/health", "", http.StatusNotFound)
+
+	logs := call(t, handler, http.MethodGet, "/sessions/worker-1/state-logs", "", http.StatusOK)
+	if entries, ok := logs["data"].([]interface{}); !ok || len(entries) != 0 {
+		t.Errorf("Expected no state changes, got %v", logs["data"])
+	}
+	call(t, handler, http.MethodGet, "/sessions/nobody/state-logs", "", http.StatusNotFound)
+}
+
+func TestLocks(t *testing.T) {
+	handler, _ := setupTestRouter(t)
+
+	status := data(call(t, handler, http.MethodGet, "/locks/queue", "", http.StatusOK))
+	if status["is_locked"] != false {
+		t.Errorf("Expected an unknown lock to be free, got %v", status)
+	}
+
+	acquired := data(call(t, handler, http.MethodPost, "/locks/queue/acquire", `{"holder_id":"worker-1","ttl_seconds":60}`, http.StatusOK))
+	if acquired["is_locked"] != true || acquired["holder_id"] != "worker-1" {
+		t.Errorf("Unexpected lock %v", acquired)
+	}
+	call(t, handler, http.MethodPost, "/locks/queue/acquire", `{"holder_id":"worker-2"}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/queue/acquire", `{"holder_id":"worker-1"}`, http.StatusOK)
+	call(t, handler, http.MethodPost, "/locks/queue/acquire", `{}`, http.StatusBadRequest)
+
+	call(t, handler, http.MethodPost, "/locks/queue/release", `{"holder_id":"worker-2","fencing_token":1}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/queue/release", `{"holder_id":"worker-1","fencing_token":2}`, http.StatusConflict)
+	released := data(call(t, handler, http.MethodPost, "/locks/queue/release", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusOK))
+	if released["is_locked"] != false {
+		t.Errorf("Expected the lock to be released, got %v", released)
+	}
+	call(t, handler, http.MethodPost, "/locks/queue/release", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusConflict)
+
+	call(t, handler, http.MethodPost, "/locks/queue/acquire", `{"holder_id":"worker-2"}`, http.StatusOK)
+}
+
+func TestLockLeases(t *testing.T) {
+	handler, _ := setupTestRouter(t)
+
+	acquired := data(call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-1","ttl_seconds":60}`, http.StatusOK))
+	if acquired["fencing_token"] != float64(1) {
+		t.Fatalf("Expected the first lease to get token 1, got %v", acquired["fencing_token"])
+	}
+
+	// Renewing keeps the lease; extending needs its token
+	if renewed := data(call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-1"}`, http.StatusOK)); renewed["fencing_token"] != float64(1) {
+		t.Errorf("Expected a renewal to keep token 1, got %v", renewed["fencing_token"])
+	}
+	extended := data(call(t, handler, http.MethodPost, "/locks/repo/extend", `{"holder_id":"worker-1","fencing_token":1,"ttl_seconds":600}`, http.StatusOK))
+	if extended["time_remaining_sec"].(float64) < 590 {
+		t.Errorf("Expected the lease extended to 10 minutes, got %v", extended["time_remaining_sec"])
+	}
+	call(t, handler, http.MethodPost, "/locks/repo/extend", `{"holder_id":"worker-1","fencing_token":2}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/repo/extend", `{"holder_id":"worker-2","fencing_token":1}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/repo/validate", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusOK)
+
+	// A higher priority preempts the holder, whose token goes stale
+	call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-2","priority":5}`, http.StatusConflict)
+	preempted := data(call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-2","priority":8}`, http.StatusOK))
+	if preempted["holder_id"] != "worker-2" || preempted["fencing_token"] != float64(2) {
+		t.Errorf("Expected worker-2 to preempt the lock with token 2, got %v", preempted)
+	}
+	call(t, handler, http.MethodPost, "/locks/repo/validate", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/repo/extend", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusConflict)
+	call(t, handler, http.MethodPost, "/locks/repo/release", `{"holder_id":"worker-1","fencing_token":1}`, http.StatusConflict)
+
+	// Tokens keep increasing after a release
+	call(t, handler, http.MethodPost, "/locks/repo/release", `{"holder_id":"worker-2","fencing_token":2}`, http.StatusOK)
+	call(t, handler, http.MethodPost, "/locks/repo/validate", `{"holder_id":"worker-2","fencing_token":2}`, http.StatusConflict)
+	if again := data(call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-1"}`, http.StatusOK)); again["fencing_token"] != float64(3) {
+		t.Errorf("Expected token 3, got %v", again["fencing_token"])
+	}
+	call(t, handler, http.MethodPost, "/locks/repo/acquire", `{"holder_id":"worker-2","wait_seconds":11}`, http.StatusBadRequest)
+
+	// Three new leases from four attempts, two of them against a holder
+	metrics := data(call(t, handler, http.MethodGet, "/metrics/locks", "", http.StatusOK))
+	if metrics["contention_ratio"] != 0.5 || metrics["active_locks"] != float64(1) {
+		t.Errorf("Unexpected lock metrics %v", metrics)
+	}
+}
+
+func TestWaitForLockIsFair(t *testing.T) {
+	locks := repository.NewManager(setupStore(t)).Locks()
+	ctx := context.Background()
+
+	if _, err := locks.AcquireLock(ctx, "repo", "worker-1", time.Minute, 5); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	// worker-2 queues first, then worker-3
+	results := make(chan *models.LockWaitResult, 2)
+	for _, holder := range []string{"worker-2", "worker-3"} {
+		go func(holder string) {
+			result, err := locks.WaitForLock(ctx, "repo", holder, time.Second, 5*time.Second, 5)
+			if err != nil {
+				t.Errorf("WaitForLock(%s) failed: %v", holder, err)
+			}
+			results <- result
+		}(holder)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// The release wakes the head of the queue
+	if err := locks.ReleaseLock(ctx, "repo", "worker-1", 1); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+	first := <-results
+	if first == nil || first.HolderID != "worker-2" || first.FencingToken != 2 {
+		t.Fatalf("Expected worker-2 to get the lock first, got %+v", first)
+	}
+	if _, err := locks.AcquireLock(ctx, "repo", "worker-4", time.Minute, 5); err != repository.ErrLockHeld {
+		t.Errorf("Expected the lock held by worker-2, got %v", err)
+	}
+
+	// worker-2 never releases; worker-3 gets the lock once the lease expires
+	second := <-results
+	if second == nil || second.HolderID != "worker-3" || second.FencingToken != 3 || second.WaitedMS < 1000 {
+		t.Fatalf("Expected worker-3 to get the lock after worker-2's lease, got %+v", second)
+	}
+
+	result, err := locks.WaitForLock(ctx, "repo", "worker-4", time.Minute, 100*time.Millisecond, 5)
+	if err != repository.ErrLockWaitTimeout || result.Acquired {
+		t.Errorf("Expected the wait to time out, got %+v: %v", result, err)
+	}
+
+	metrics, err := locks.GetLockMetrics(ctx)
+	if err != nil {
+		t.Fatalf("GetLockMetrics failed: %v", err)
+	}
+	if metrics.AverageWaitTimeMS <= 0 || metrics.ContentionRatio <= 0 {
+		t.Errorf("Expected wait time and contention recorded, got %+v", metrics)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	handler, store := setupTestRouter(t)
+
+	cpu, pending := 42.5, 3
+	if _, err := store.InsertMetrics(context.Background(), &storage.MetricsRow{CPUPercent: &cpu, PendingTasks: &pending}); err != nil {
+		t.Fatalf("failed to insert metrics: %v", err)
+	}
+
+	series := data(call(t, handler, http.MethodGet, "/metrics/series?metric=cpu_percent", "", http.StatusOK))
+	points, _ := series["data_points"].([]interface{})
+	if len(points) != 1 || points[0].(map[string]interface{})["value"] != cpu {
+		t.Errorf("Expected one cpu_percent point, got %v", series)
+	}
+	call(t, handler, http.MethodGet, "/metrics/series?metric=disk", "", http.StatusBadRequest)
+	call(t, handler, http.MethodGet, "/metrics/series?metric=cpu_percent&from=yesterday", "", http.StatusBadRequest)
+
+	report := data(call(t, handler, http.MethodGet, "/metrics/report?period=1h", "", http.StatusOK))
+	if report["report_period"] != "1h0m0s" {
+		t.Errorf("Unexpected report period %v", report["report_period"])
+	}
+	if health, _ := report["system_health"].(map[string]interface{}); health["pending_tasks"] != float64(3) {
+		t.Errorf("Expected the latest metrics in the system health, got %v", report["system_health"])
+	}
+	call(t, handler, http.MethodGet, "/metrics/report?period=-1h", "", http.StatusBadRequest)
+
+	repos := repository.NewManager(store)
+	for _, metric := range []string{"memory_mb", "pending_tasks"} {
+		alert := &models.MetricsAlert{MetricName: metric, Severity: models.AlertWarning, Message: metric}
+		if err := repos.Metrics().OpenAlert(context.Background(), alert); err != nil {
+			t.Fatalf("OpenAlert failed: %v", err)
+		}
+		if metric == "memory_mb" {
+			if err := repos.Metrics().ResolveAlert(context.Background(), alert.ID, time.Now()); err != nil {
+				t.Fatalf("ResolveAlert failed: %v", err)
+			}
+		}
+	}
+	if resp := call(t, handler, http.MethodGet, "/metrics/alerts", "", http.StatusOK); resp["total"] != float64(2) {
+		t.Errorf("Expected 2 alerts, got %v", resp["total"])
+	}
+	active := call(t, handler, http.MethodGet, "/metrics/alerts?active=true", "", http.StatusOK)
+	if alerts, _ := active["data"].([]interface{}); len(alerts) != 1 || alerts[0].(map[string]interface{})["metric_name"] != "pending_tasks" {
+		t.Errorf("Expected only the open alert, got %v", active["data"])
+	}
+	call(t, handler, http.MethodGet, "/metrics/alerts?active=maybe", "", http.StatusBadRequest)
+}
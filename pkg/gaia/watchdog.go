@@ -0,0 +1,290 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/pkg/events"
+)
+
+// sessionTransitions counts the session state changes made by the watchdog
+var sessionTransitions = metrics.NewCounterVec("unified_gaia_session_transitions_total",
+	"GAIA session state changes made by the watchdog, by transition: unhealthy, recovered or terminated.", "transition")
+
+// WatchdogOptions tunes a Watchdog
+type WatchdogOptions struct {
+	// HeartbeatInterval is how often sessions are expected to send a
+	// heartbeat. Health starts to decay once a heartbeat is this late.
+	HeartbeatInterval time.Duration
+	// UnhealthyAfter is the heartbeat gap at which the decay alone takes a
+	// session below the health score needed to get work
+	UnhealthyAfter time.Duration
+	// TerminateAfter is the heartbeat gap after which a session is
+	// terminated and its task and locks are reclaimed
+	TerminateAfter time.Duration
+	// MaxFailurePenalty is the health lost by a session that completed none
+	// of the tasks it finished
+	MaxFailurePenalty float64
+}
+
+// DefaultWatchdogOptions returns the options used when nothing is
+// overridden
+func DefaultWatchdogOptions() WatchdogOptions {
+	return WatchdogOptions{
+		HeartbeatInterval: 30 * time.Second,
+		UnhealthyAfter:    2 * time.Minute,
+		TerminateAfter:    10 * time.Minute,
+		MaxFailurePenalty: 40,
+	}
+}
+
+// Watchdog keeps the health of the worker sessions current. Each pass scores
+// every active session from its heartbeat gap and task failure rate, marks
+// sessions below the threshold unhealthy and returns them to work once they
+// recover. A session silent for TerminateAfter is terminated: the locks it
+// holds are released and its task goes back to the queue. Score and state
+// changes are published on the event bus for the dashboard.
+type Watchdog struct {
+	repos      repository.Manager
+	dispatcher *Dispatcher
+	bus        *events.Bus
+	opts       WatchdogOptions
+	now        func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewWatchdog creates a watchdog that reclaims tasks through the dispatcher
+// and publishes to bus, which may be nil
+func NewWatchdog(repos repository.Manager, dispatcher *Dispatcher, bus *events.Bus, opts WatchdogOptions) *Watchdog {
+	return &Watchdog{repos: repos, dispatcher: dispatcher, bus: bus, opts: opts, now: time.Now}
+}
+
+// Check scores every active session and moves it between states, and
+// returns how many sessions were terminated
+func (w *Watchdog) Check(ctx context.Context) (int, error) {
+	now := w.now()
+	sessions, err := w.repos.Sessions().GetActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	terminated := 0
+	for _, session := range sessions {
+		gap := now.Sub(session.LastHeartbeat)
+		if gap < 0 {
+			gap = 0
+		}
+		if gap >= w.opts.TerminateAfter {
+			if err := w.terminate(ctx, session, gap); err != nil {
+				return terminated, err
+			}
+			terminated++
+			continue
+		}
+		if err := w.score(ctx, session, gap); err != nil {
+			return terminated, err
+		}
+	}
+	return terminated, nil
+}
+
+// score updates a live session's health score and marks it unhealthy or
+// recovered when the score crosses the threshold
+func (w *Watchdog) score(ctx context.Context, session *models.Session, gap time.Duration) error {
+	state, err := w.repos.RateLimits().Get(ctx, session.SessionID)
+	if err != nil {
+		return err
+	}
+	score := w.health(gap, state)
+	if score != session.HealthScore {
+		if err := w.repos.Sessions().UpdateHealth(ctx, session.SessionID, score); err != nil {
+			return err
+		}
+		w.publish(events.EventGaiaSessionHealth, session, map[string]interface{}{
+			"status":                  session.Status,
+			"health_score":            score,
+			"previous_health_score":   session.HealthScore,
+			"seconds_since_heartbeat": int64(gap.Seconds()),
+		})
+	}
+
+	var status models.SessionState
+	var reason, transition string
+	switch {
+	case score < minHealthScore && session.Status.IsHealthy():
+		status, transition = models.SessionUnhealthy, "unhealthy"
+		reason = fmt.Sprintf("Health score %.0f below %d, last heartbeat %s ago", score, minHealthScore, gap.Truncate(time.Second))
+	case score >= minHealthScore && session.Status == models.SessionUnhealthy:
+		// Back to work, or to the task it still holds
+		status, transition = models.SessionIdle, "recovered"
+		if session.CurrentTaskID.Valid {
+			status = models.SessionBusy
+		}
+		reason = fmt.Sprintf("Health score recovered to %.0f", score)
+	default:
+		return nil
+	}
+
+	if err := w.repos.Sessions().UpdateStatus(ctx, session.SessionID, status, reason); err != nil {
+		return err
+	}
+	sessionTransitions.Inc(transition)
+	logger.WarnContext(ctx, "session "+transition, "session_id", session.SessionID, "health_score", score,
+		"reason", reason)
+	w.publish(events.EventGaiaSessionState, session, map[string]interface{}{
+		"status":          status,
+		"previous_status": session.Status,
+		"health_score":    score,
+		"reason":          reason,
+	})
+	return nil
+}
+
+// terminate releases the locks of a session that stopped sending
+// heartbeats, returns its task to the queue and then deactivates it, all in
+// one transaction so a failure leaves the session live to be terminated on
+// the next pass
+func (w *Watchdog) terminate(ctx context.Context, session *models.Session, gap time.Duration) error {
+	reason := fmt.Sprintf("No heartbeat for %s", gap.Truncate(time.Second))
+	reclaimReason := fmt.Sprintf("Session %s terminated", session.SessionID)
+	var released []string
+	reclaimed := false
+	err := w.dispatcher.transaction(ctx, func(tx repository.Manager) error {
+		locks, err := tx.Locks().GetHeldLocks(ctx, session.SessionID)
+		if err != nil {
+			return err
+		}
+		released = make([]string, 0, len(locks))
+		for _, lock := range locks {
+			err := tx.Locks().ReleaseLock(ctx, lock.LockID, session.SessionID, lock.FencingToken)
+			if errors.Is(err, repository.ErrLockNotHeld) {
+				// Released or expired since it was read
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			released = append(released, lock.LockID)
+		}
+
+		if session.CurrentTaskID.Valid {
+			reclaimed, err = reclaim(ctx, tx, session.CurrentTaskID.Int64, reclaimReason)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Sessions().UpdateHealth(ctx, session.SessionID, 0); err != nil {
+			return err
+		}
+		return tx.Sessions().Deactivate(ctx, session.SessionID, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"status":          models.SessionTerminated,
+		"previous_status": session.Status,
+		"health_score":    0,
+		"reason":          reason,
+		"released_locks":  released,
+	}
+	if session.CurrentTaskID.Valid {
+		data["reclaimed_task_id"] = session.CurrentTaskID.Int64
+	}
+	if reclaimed {
+		dispatchedTasks.Inc("reclaimed")
+		logger.WarnContext(ctx, "task reclaimed", "task_id", session.CurrentTaskID.Int64, "reason", reclaimReason)
+	}
+
+	sessionTransitions.Inc("terminated")
+	logger.WarnContext(ctx, "session terminated", "session_id", session.SessionID, "reason", reason,
+		"released_locks", len(released))
+	w.publish(events.EventGaiaSessionState, session, data)
+	return nil
+}
+
+// health scores a session from 0 to 100. Once its heartbeat is
+// HeartbeatInterval late it loses 50 points, down to the threshold for
+// work, by the time the heartbeat is UnhealthyAfter late, and keeps losing
+// them at that rate. It also loses up to MaxFailurePenalty in proportion to
+// its failure rate.
+func (w *Watchdog) health(gap time.Duration, state *models.RateLimiterState) float64 {
+	score := 100.0
+	if late := gap - w.opts.HeartbeatInterval; late > 0 {
+		span := w.opts.UnhealthyAfter - w.opts.HeartbeatInterval
+		if span < time.Second {
+			span = time.Second
+		}
+		score -= (100 - minHealthScore) * late.Seconds() / span.Seconds()
+	}
+	score -= w.opts.MaxFailurePenalty * failureRate(state)
+	return math.Max(0, math.Round(score))
+}
+
+// failureRate is the share of the tasks a session finished that it did not
+// complete: failed, timed out, cancelled or reclaimed
+func failureRate(state *models.RateLimiterState) float64 {
+	if state == nil {
+		return 0
+	}
+	finished := state.TotalAssignments - state.ActiveTaskCount
+	if finished <= 0 {
+		return 0
+	}
+	return float64(finished-state.TotalCompletions) / float64(finished)
+}
+
+// publish sends a fleet event about a session when the watchdog has a bus
+func (w *Watchdog) publish(eventType events.EventType, session *models.Session, data map[string]interface{}) {
+	if w.bus == nil {
+		return
+	}
+	data["session_id"] = session.SessionID
+	data["provider"] = session.Provider
+	w.bus.PublishAsync(events.NewEvent(eventType, 0, "gaia", data))
+}
+
+// Start runs a pass every interval until Close is called
+func (w *Watchdog) Start(interval time.Duration) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := w.Check(context.Background()); err != nil {
+					logger.Error("session health check failed", "error", err)
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the loop started by Start, waiting for a running pass
+func (w *Watchdog) Close() {
+	w.stopOnce.Do(func() {
+		if w.stop != nil {
+			close(w.stop)
+			<-w.done
+		}
+	})
+}
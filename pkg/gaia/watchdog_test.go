@@ -0,0 +1,241 @@
+package gaia
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgirmay/unified-go/internal/models"
+	"github.com/jgirmay/unified-go/internal/repository"
+	"github.com/jgirmay/unified-go/pkg/events"
+)
+
+// setupWatchdog creates a watchdog sharing the dispatcher's clock and
+// publishing to a running bus
+func setupWatchdog(t *testing.T) (*Watchdog, *Dispatcher, repository.Manager, *events.Bus, *time.Time) {
+	t.Helper()
+
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	d, repos, now := setupDispatcher(t, opts)
+	bus := events.NewBus(100)
+	go bus.Run()
+	t.Cleanup(bus.Stop)
+
+	w := NewWatchdog(repos, d, bus, DefaultWatchdogOptions())
+	w.now = d.now
+	return w, d, repos, bus, now
+}
+
+// check runs a pass and checks how many sessions it terminated
+func check(t *testing.T, w *Watchdog, want int) {
+	t.Helper()
+
+	n, err := w.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if n != want {
+		t.Fatalf("Expected %d sessions terminated, got %d", want, n)
+	}
+}
+
+// stateReasons returns the reasons a session was moved to a state
+func stateReasons(t *testing.T, repos repository.Manager, sessionID string, state models.SessionState) []string {
+	t.Helper()
+
+	logs, err := repos.Sessions().GetStateChangeLogs(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("GetStateChangeLogs failed: %v", err)
+	}
+	var reasons []string
+	for _, entry := range logs {
+		if entry.NewState == state {
+			reasons = append(reasons, entry.Reason)
+		}
+	}
+	return reasons
+}
+
+func TestWatchdogHealthDecay(t *testing.T) {
+	w, d, repos, _, now := setupWatchdog(t)
+	addSession(t, repos, "worker-1")
+	addTask(t, repos, models.TaskCreate{Content: "task"})
+
+	// Heartbeats are stored to the second
+	*now = getSession(t, repos, "worker-1").LastHeartbeat
+	check(t, w, 0)
+	if session := getSession(t, repos, "worker-1"); session.HealthScore != 100 {
+		t.Errorf("Expected a fresh session at full health, got %v", session.HealthScore)
+	}
+
+	// Half way from the heartbeat interval to the unhealthy threshold
+	*now = now.Add(75 * time.Second)
+	check(t, w, 0)
+	if session := getSession(t, repos, "worker-1"); session.HealthScore != 75 || session.Status != models.SessionIdle {
+		t.Errorf("Expected an idle session at 75, got %s at %v", session.Status, session.HealthScore)
+	}
+
+	*now = now.Add(time.Minute)
+	check(t, w, 0)
+	session := getSession(t, repos, "worker-1")
+	if session.Status != models.SessionUnhealthy || session.HealthScore >= minHealthScore {
+		t.Fatalf("Expected an unhealthy session, got %s at %v", session.Status, session.HealthScore)
+	}
+	if reasons := stateReasons(t, repos, "worker-1", models.SessionUnhealthy); len(reasons) != 1 || !strings.HasPrefix(reasons[0], "Health score") {
+		t.Errorf("Expected the unhealthy transition logged with its reason, got %v", reasons)
+	}
+
+	// A heartbeat brings the session back to work
+	if err := repos.Sessions().RecordHeartbeat(context.Background(), "worker-1"); err != nil {
+		t.Fatalf("RecordHeartbeat failed: %v", err)
+	}
+	*now = time.Now()
+	check(t, w, 0)
+	if session := getSession(t, repos, "worker-1"); session.Status != models.SessionIdle || session.HealthScore != 100 {
+		t.Errorf("Expected the session recovered, got %s at %v", session.Status, session.HealthScore)
+	}
+	dispatch(t, d, 1)
+}
+
+func TestWatchdogFailureRate(t *testing.T) {
+	w, d, repos, _, _ := setupWatchdog(t)
+	addSession(t, repos, "worker-1")
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		id := addTask(t, repos, models.TaskCreate{Content: "task"})
+		dispatch(t, d, 1)
+		var err error
+		if i == 0 {
+			_, err = d.Fail(ctx, id, "model timed out", nil)
+		} else {
+			_, err = d.Complete(ctx, id, nil)
+		}
+		if err != nil {
+			t.Fatalf("failed to finish task: %v", err)
+		}
+	}
+
+	// One of four tasks failed
+	check(t, w, 0)
+	if session := getSession(t, repos, "worker-1"); session.HealthScore != 90 {
+		t.Errorf("Expected a quarter of the failure penalty, got %v", session.HealthScore)
+	}
+
+	expected := []struct {
+		state *models.RateLimiterState
+		want  float64
+	}{
+		{nil, 0},
+		{&models.RateLimiterState{TotalAssignments: 1, ActiveTaskCount: 1}, 0},
+		{&models.RateLimiterState{TotalAssignments: 3, ActiveTaskCount: 1, TotalCompletions: 1}, 0.5},
+		{&models.RateLimiterState{TotalAssignments: 2, TotalCompletions: 0}, 1},
+	}
+	for _, tc := range expected {
+		if got := failureRate(tc.state); got != tc.want {
+			t.Errorf("failureRate(%+v) = %v, want %v", tc.state, got, tc.want)
+		}
+	}
+}
+
+func TestWatchdogTerminateReclaimsWork(t *testing.T) {
+	w, d, repos, bus, now := setupWatchdog(t)
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task"})
+	ctx := context.Background()
+
+	dispatch(t, d, 1)
+	if _, err := repos.Locks().AcquireLock(ctx, "repo:main", "worker-1", time.Hour, 0); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	*now = now.Add(11 * time.Minute)
+	check(t, w, 1)
+
+	session := getSession(t, repos, "worker-1")
+	if session.Active || session.Status != models.SessionTerminated || session.HealthScore != 0 || session.CurrentTaskID.Valid {
+		t.Errorf("Expected the session terminated without a task, got %+v", session)
+	}
+	if reasons := stateReasons(t, repos, "worker-1", models.SessionTerminated); len(reasons) != 1 || !strings.HasPrefix(reasons[0], "No heartbeat for") {
+		t.Errorf("Expected the termination logged with its reason, got %v", reasons)
+	}
+	if locks, err := repos.Locks().GetHeldLocks(ctx, "worker-1"); err != nil || len(locks) != 0 {
+		t.Errorf("Expected the session's locks released, got %d: %v", len(locks), err)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskPending || task.RetryCount != 0 {
+		t.Errorf("Expected the task requeued without a retry, got %s with %d", task.Status, task.RetryCount)
+	}
+
+	// The dead session is not checked again and its task goes to another
+	check(t, w, 0)
+	addSession(t, repos, "worker-2")
+	*now = time.Now()
+	dispatch(t, d, 1)
+	if session := getSession(t, repos, "worker-2"); session.CurrentTaskID.Int64 != id {
+		t.Errorf("Expected worker-2 to pick up the reclaimed task, got %v", session.CurrentTaskID)
+	}
+
+	bus.Drain(ctx)
+	states := bus.GetEventsByType(events.EventGaiaSessionState, 10)
+	if len(states) != 1 {
+		t.Fatalf("Expected 1 state event, got %d", len(states))
+	}
+	data := states[0].Data
+	if data["session_id"] != "worker-1" || data["status"] != models.SessionTerminated || data["reclaimed_task_id"] != id {
+		t.Errorf("Unexpected state event %v", data)
+	}
+	if locks, ok := data["released_locks"].([]string); !ok || len(locks) != 1 || locks[0] != "repo:main" {
+		t.Errorf("Expected the released lock in the event, got %v", data["released_locks"])
+	}
+}
+
+func TestWatchdogTerminateIsAtomic(t *testing.T) {
+	store := setupStore(t)
+	repos := repository.NewManager(store)
+	opts := DefaultDispatcherOptions()
+	opts.AssignInterval = 0
+	d := NewDispatcher(repos, opts)
+	w := NewWatchdog(repos, d, nil, DefaultWatchdogOptions())
+	ctx := context.Background()
+
+	addSession(t, repos, "worker-1")
+	id := addTask(t, repos, models.TaskCreate{Content: "task"})
+	dispatch(t, d, 1)
+	if _, err := repos.Locks().AcquireLock(ctx, "repo:main", "worker-1", time.Hour, 0); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	// Requeueing the task fails, so the whole termination is rolled back
+	_, err := store.Exec(ctx, `CREATE TRIGGER fail_requeue BEFORE UPDATE OF status ON tasks
+		WHEN NEW.status = 'pending' BEGIN SELECT RAISE(ABORT, 'requeue failed'); END`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	later := time.Now().Add(11 * time.Minute)
+	w.now = func() time.Time { return later }
+	if _, err := w.Check(ctx); err == nil {
+		t.Fatal("Expected the termination to fail")
+	}
+
+	session := getSession(t, repos, "worker-1")
+	if !session.Active || session.CurrentTaskID.Int64 != id {
+		t.Errorf("Expected the session left live with its task, got %+v", session)
+	}
+	if locks, err := repos.Locks().GetHeldLocks(ctx, "worker-1"); err != nil || len(locks) != 1 {
+		t.Errorf("Expected the session's lock kept, got %d: %v", len(locks), err)
+	}
+	if task := getTask(t, repos, id); task.Status != models.TaskAssigned {
+		t.Errorf("Expected the task still assigned, got %s", task.Status)
+	}
+
+	// The next pass terminates it
+	if _, err := store.Exec(ctx, `DROP TRIGGER fail_requeue`); err != nil {
+		t.Fatalf("failed to drop trigger: %v", err)
+	}
+	check(t, w, 1)
+	if task := getTask(t, repos, id); task.Status != models.TaskPending {
+		t.Errorf("Expected the task requeued, got %s", task.Status)
+	}
+}
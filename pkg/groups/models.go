@@ -0,0 +1,108 @@
+package groups
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Group kinds
+const (
+	KindClassroom = "classroom"
+	KindHousehold = "household"
+)
+
+// Membership roles within a group
+const (
+	MemberTeacher = "teacher"
+	MemberStudent = "student"
+	MemberParent  = "parent"
+	MemberChild   = "child"
+)
+
+// Errors returned by the groups service
+var (
+	ErrGroupNotFound   = errors.New("group not found")
+	ErrInvalidJoinCode = errors.New("invalid join code")
+	ErrAlreadyMember   = errors.New("already a member of this group")
+	ErrNotPermitted    = errors.New("not permitted")
+)
+
+// Group is a classroom or household that users belong to
+type Group struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	OwnerID   uint      `json:"owner_id"`
+	JoinCode  string    `json:"join_code,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// SupervisorCode lets teachers or parents join as supervisors. Like the
+	// join code, it is only shown to the group's supervisors.
+	SupervisorCode string `json:"supervisor_code,omitempty"`
+
+	// MemberRole is the requesting user's role in the group, when listed for a user
+	MemberRole string `json:"member_role,omitempty"`
+}
+
+// Member is a user's membership in a group
+type Member struct {
+	GroupID  uint      `json:"group_id"`
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// CreateGroupRequest is the payload for POST /groups
+type CreateGroupRequest struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Validate performs validation on CreateGroupRequest
+func (r *CreateGroupRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if r.Kind == "" {
+		r.Kind = KindClassroom
+	}
+	if r.Kind != KindClassroom && r.Kind != KindHousehold {
+		return fmt.Errorf("invalid group kind: %s", r.Kind)
+	}
+	return nil
+}
+
+// JoinGroupRequest is the payload for POST /groups/join. JoinCode may be the
+// group's join code or its supervisor code.
+type JoinGroupRequest struct {
+	JoinCode string `json:"join_code"`
+}
+
+// Validate performs validation on JoinGroupRequest
+func (r *JoinGroupRequest) Validate() error {
+	r.JoinCode = strings.ToUpper(strings.TrimSpace(r.JoinCode))
+	if r.JoinCode == "" {
+		return errors.New("join_code is required")
+	}
+	return nil
+}
+
+// ownerRole returns the membership role the creator of a group receives
+func ownerRole(kind string) string {
+	if kind == KindHousehold {
+		return MemberParent
+	}
+	return MemberTeacher
+}
+
+// supervises reports whether a membership role can see other members' data
+func supervises(role string) bool {
+	return role == MemberTeacher || role == MemberParent
+}
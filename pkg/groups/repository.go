@@ -0,0 +1,245 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// Repository handles database operations for groups and memberships
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new groups repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateGroup inserts a group and adds its owner as the supervising member
+func (r *Repository) CreateGroup(ctx context.Context, group *Group) (uint, error) {
+	if group == nil {
+		return 0, errors.New("group cannot be nil")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO user_groups (name, kind, owner_id, join_code, supervisor_code, created_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		group.Name, group.Kind, group.OwnerID, group.JoinCode, nullCode(group.SupervisorCode))
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, ErrInvalidJoinCode
+		}
+		return 0, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get insert id: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO group_members (group_id, user_id, role, joined_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		id, group.OwnerID, ownerRole(group.Kind)); err != nil {
+		return 0, fmt.Errorf("failed to add group owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit group: %w", err)
+	}
+
+	return uint(id), nil
+}
+
+// GetGroup retrieves a group of the context's organization by ID, returning
+// nil when it does not exist
+func (r *Repository) GetGroup(ctx context.Context, groupID uint) (*Group, error) {
+	scope, args := tenant.Members(ctx, "owner_id")
+	return r.getGroup(ctx, `WHERE id = ? AND `+scope, append([]interface{}{groupID}, args...)...)
+}
+
+// GetGroupByJoinCode retrieves a group of the context's organization by its
+// join code or supervisor code, returning nil when none matches
+func (r *Repository) GetGroupByJoinCode(ctx context.Context, code string) (*Group, error) {
+	scope, args := tenant.Members(ctx, "owner_id")
+	return r.getGroup(ctx, `WHERE (join_code = ? OR supervisor_code = ?) AND `+scope, append([]interface{}{code, code}, args...)...)
+}
+
+func (r *Repository) getGroup(ctx context.Context, where string, args ...interface{}) (*Group, error) {
+	var group Group
+	var supervisorCode sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, kind, owner_id, join_code, supervisor_code, created_at FROM user_groups `+where, args...).
+		Scan(&group.ID, &group.Name, &group.Kind, &group.OwnerID, &group.JoinCode, &supervisorCode, &group.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	group.SupervisorCode = supervisorCode.String
+	return &group, nil
+}
+
+// UpdateJoinCode replaces a group's join code and supervisor code
+func (r *Repository) UpdateJoinCode(ctx context.Context, groupID uint, code, supervisorCode string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_groups SET join_code = ?, supervisor_code = ? WHERE id = ?`, code, nullCode(supervisorCode), groupID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrInvalidJoinCode
+		}
+		return fmt.Errorf("failed to update join code: %w", err)
+	}
+	return nil
+}
+
+// AddMember adds a user to a group with the given membership role
+func (r *Repository) AddMember(ctx context.Context, groupID, userID uint, role string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO group_members (group_id, user_id, role, joined_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		groupID, userID, role)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "PRIMARY KEY") {
+			return ErrAlreadyMember
+		}
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember removes a user from a group
+func (r *Repository) RemoveMember(ctx context.Context, groupID, userID uint) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	return nil
+}
+
+// GetMemberRole returns a user's role in a group, or "" when not a member
+func (r *Repository) GetMemberRole(ctx context.Context, groupID, userID uint) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT role FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get member role: %w", err)
+	}
+	return role, nil
+}
+
+// ListMembers returns all members of a group
+func (r *Repository) ListMembers(ctx context.Context, groupID uint) ([]Member, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT m.group_id, m.user_id, COALESCE(u.username, ''), m.role, m.joined_at
+		FROM group_members m LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = ? ORDER BY m.role, u.username`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]Member, 0)
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.GroupID, &m.UserID, &m.Username, &m.Role, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// ListGroupsForUser returns the groups a user belongs to with their role in each
+func (r *Repository) ListGroupsForUser(ctx context.Context, userID uint) ([]Group, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT g.id, g.name, g.kind, g.owner_id, g.join_code, g.supervisor_code, g.created_at, m.role
+		FROM user_groups g JOIN group_members m ON m.group_id = g.id
+		WHERE m.user_id = ? ORDER BY g.created_at, g.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]Group, 0)
+	for rows.Next() {
+		var g Group
+		var supervisorCode sql.NullString
+		if err := rows.Scan(&g.ID, &g.Name, &g.Kind, &g.OwnerID, &g.JoinCode, &supervisorCode, &g.CreatedAt, &g.MemberRole); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		g.SupervisorCode = supervisorCode.String
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetUserRole returns the account role of a user of the context's
+// organization, or "" when there is no such user
+func (r *Repository) GetUserRole(ctx context.Context, userID uint) (string, error) {
+	scope, args := tenant.Members(ctx, "id")
+	var role string
+	err := r.db.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ? AND `+scope,
+		append([]interface{}{userID}, args...)...).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+// IsGuardian reports whether parentID is a parent in a household where
+// childID is a child
+func (r *Repository) IsGuardian(ctx context.Context, parentID, childID uint) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM group_members p
+			JOIN group_members c ON c.group_id = p.group_id
+			JOIN user_groups g ON g.id = p.group_id
+			WHERE p.user_id = ? AND c.user_id = ? AND g.kind = 'household'
+				AND p.role = 'parent' AND c.role = 'child'
+		)`, parentID, childID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check guardianship: %w", err)
+	}
+	return exists, nil
+}
+
+// Supervises reports whether supervisorID is a teacher of userID in a classroom
+// or a parent of userID in a household
+func (r *Repository) Supervises(ctx context.Context, supervisorID, userID uint) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM group_members s
+			JOIN group_members m ON m.group_id = s.group_id
+			JOIN user_groups g ON g.id = s.group_id
+			WHERE s.user_id = ? AND m.user_id = ? AND (
+				(g.kind = 'classroom' AND s.role = 'teacher' AND m.role = 'student') OR
+				(g.kind = 'household' AND s.role = 'parent' AND m.role = 'child')
+			)
+		)`, supervisorID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check relationship: %w", err)
+	}
+	return exists, nil
+}
+
+// nullCode stores an empty supervisor code as NULL so it stays unique
+func nullCode(code string) sql.NullString {
+	return sql.NullString{String: code, Valid: code != ""}
+}
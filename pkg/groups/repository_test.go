@@ -0,0 +1,148 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupTestDB creates an in-memory SQLite database for testing
+func setupTestDB(t testing.TB) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	// Keep a single connection so every query sees the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	schema := `
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL DEFAULT 'student',
+		org_id INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE user_groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		owner_id INTEGER NOT NULL,
+		join_code TEXT UNIQUE NOT NULL,
+		supervisor_code TEXT UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id)
+	);
+
+	CREATE TABLE typing_tests (id INTEGER PRIMARY KEY, user_id INTEGER, created_at DATETIME);
+	CREATE TABLE math_results (id INTEGER PRIMARY KEY, user_id INTEGER, timestamp DATETIME);
+	CREATE TABLE reading_sessions (id INTEGER PRIMARY KEY, user_id INTEGER, created_at DATETIME);
+	CREATE TABLE piano_lessons (id INTEGER PRIMARY KEY, user_id INTEGER, created_at DATETIME);
+	`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	return db
+}
+
+// createUser inserts a user with the given account role and returns its ID
+func createUser(t testing.TB, db *sql.DB, username, role string) uint {
+	result, err := db.Exec(`INSERT INTO users (username, role) VALUES (?, ?)`, username, role)
+	if err != nil {
+		t.Fatalf("Failed to create user %s: %v", username, err)
+	}
+	id, _ := result.LastInsertId()
+	return uint(id)
+}
+
+func TestCreateGroupAddsOwner(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+	teacherID := createUser(t, db, "ms_frizzle", "teacher")
+
+	groupID, err := repo.CreateGroup(ctx, &Group{Name: "Class 3B", Kind: KindClassroom, OwnerID: teacherID, JoinCode: "ABCD2345"})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	role, err := repo.GetMemberRole(ctx, groupID, teacherID)
+	if err != nil || role != MemberTeacher {
+		t.Errorf("Expected owner to be a teacher member, got %q, %v", role, err)
+	}
+
+	group, err := repo.GetGroupByJoinCode(ctx, "ABCD2345")
+	if err != nil || group == nil || group.ID != groupID {
+		t.Errorf("Expected to find group by join code, got %+v, %v", group, err)
+	}
+
+	_, err = repo.CreateGroup(ctx, &Group{Name: "Dup", Kind: KindClassroom, OwnerID: teacherID, JoinCode: "ABCD2345"})
+	if !errors.Is(err, ErrInvalidJoinCode) {
+		t.Errorf("Expected ErrInvalidJoinCode for duplicate code, got %v", err)
+	}
+}
+
+func TestSupervises(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	teacherID := createUser(t, db, "teacher", "teacher")
+	studentID := createUser(t, db, "student", "student")
+	otherID := createUser(t, db, "other", "student")
+	parentID := createUser(t, db, "parent", "parent")
+
+	classID, _ := repo.CreateGroup(ctx, &Group{Name: "Class", Kind: KindClassroom, OwnerID: teacherID, JoinCode: "CLASS234"})
+	repo.AddMember(ctx, classID, studentID, MemberStudent)
+	repo.AddMember(ctx, classID, otherID, MemberStudent)
+
+	homeID, _ := repo.CreateGroup(ctx, &Group{Name: "Home", Kind: KindHousehold, OwnerID: parentID, JoinCode: "HOME2345"})
+	repo.AddMember(ctx, homeID, studentID, MemberChild)
+
+	tests := []struct {
+		name       string
+		supervisor uint
+		user       uint
+		want       bool
+	}{
+		{"teacher of student", teacherID, studentID, true},
+		{"parent of child", parentID, studentID, true},
+		{"parent of classmate", parentID, otherID, false},
+		{"student of teacher", studentID, teacherID, false},
+		{"classmates", studentID, otherID, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Supervises(ctx, tt.supervisor, tt.user)
+			if err != nil {
+				t.Fatalf("Supervises failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Supervises(%d, %d) = %v, want %v", tt.supervisor, tt.user, got, tt.want)
+			}
+		})
+	}
+
+	if err := repo.AddMember(ctx, classID, studentID, MemberStudent); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("Expected ErrAlreadyMember, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package groups
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/middleware"
+	"github.com/jgirmay/unified-go/pkg/dashboard"
+	"github.com/jgirmay/unified-go/pkg/unified"
+)
+
+// logger is the groups app logger
+var logger = logging.Logger("groups")
+
+// Router configures classroom and household routes
+type Router struct {
+	service *Service
+	authz   *middleware.Authorizer
+}
+
+// NewRouter creates a new groups router
+func NewRouter(db *sql.DB) *Router {
+	repo := NewRepository(db)
+	dashboardSvc := dashboard.NewService(unified.NewRepository(db))
+	service := NewService(repo, dashboardSvc)
+	return &Router{
+		service: service,
+		authz:   middleware.NewAuthorizer(nil),
+	}
+}
+
+// Service returns the underlying groups service
+func (r *Router) Service() *Service {
+	return r.service
+}
+
+// SetAuthorizer sets the authorizer guarding group routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// Routes returns the groups router with all configured routes
+func (r *Router) Routes() chi.Router {
+	router := chi.NewRouter()
+
+	router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUser)
+
+		api.Get("/", r.ListGroups)
+		api.Post("/", r.CreateGroup)
+		api.Post("/join", r.JoinGroup)
+		api.Get("/children", r.GetChildren)
+		api.Get("/{groupId}/roster", r.GetRoster)
+		api.Post("/{groupId}/join-code", r.RegenerateJoinCode)
+	})
+
+	return router
+}
+
+// ListGroups lists the caller's classrooms and households
+func (r *Router) ListGroups(w http.ResponseWriter, req *http.Request) {
+	callerID, _ := middleware.CallerID(req)
+
+	groups, err := r.service.ListGroups(req.Context(), uint(callerID))
+	if err != nil {
+		respondInternalError(w, req, "Failed to list groups", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"groups": groups,
+	})
+}
+
+// CreateGroup creates a classroom or household owned by the caller
+func (r *Router) CreateGroup(w http.ResponseWriter, req *http.Request) {
+	var body CreateGroupRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		respondError(w, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	callerID, _ := middleware.CallerID(req)
+	group, err := r.service.CreateGroup(req.Context(), uint(callerID), &body)
+	if err != nil {
+		respondServiceError(w, req, err, "Failed to create group")
+		return
+	}
+
+	audit.Describe(req.Context(), "group.create", "group", group.ID)
+	respondJSON(w, http.StatusCreated, group)
+}
+
+// JoinGroup adds the caller to a group using its join code
+func (r *Router) JoinGroup(w http.ResponseWriter, req *http.Request) {
+	var body JoinGroupRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		respondError(w, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	callerID, _ := middleware.CallerID(req)
+	group, err := r.service.JoinGroup(req.Context(), uint(callerID), &body)
+	if err != nil {
+		respondServiceError(w, req, err, "Failed to join group")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, group)
+}
+
+// GetChildren returns the caller's linked children with their profiles
+func (r *Router) GetChildren(w http.ResponseWriter, req *http.Request) {
+	callerID, _ := middleware.CallerID(req)
+
+	children, err := r.service.GetChildren(req.Context(), uint(callerID))
+	if err != nil {
+		respondInternalError(w, req, "Failed to get children", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"children": children,
+	})
+}
+
+// GetRoster returns a group's students or children with their profiles
+func (r *Router) GetRoster(w http.ResponseWriter, req *http.Request) {
+	groupID, err := strconv.ParseUint(chi.URLParam(req, "groupId"), 10, 64)
+	if err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	callerID, _ := middleware.CallerID(req)
+	roster, err := r.service.GetRoster(req.Context(), uint(callerID), uint(groupID))
+	if err != nil {
+		respondServiceError(w, req, err, "Failed to get roster")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, roster)
+}
+
+// RegenerateJoinCode issues a new join code for a group
+func (r *Router) RegenerateJoinCode(w http.ResponseWriter, req *http.Request) {
+	groupID, err := strconv.ParseUint(chi.URLParam(req, "groupId"), 10, 64)
+	if err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	callerID, _ := middleware.CallerID(req)
+	group, err := r.service.RegenerateJoinCode(req.Context(), uint(callerID), uint(groupID))
+	if err != nil {
+		respondServiceError(w, req, err, "Failed to regenerate join code")
+		return
+	}
+
+	audit.Describe(req.Context(), "group.regenerate_join_code", "group", groupID)
+	respondJSON(w, http.StatusOK, group)
+}
+
+// respondServiceError maps service errors to HTTP statuses
+func respondServiceError(w http.ResponseWriter, req *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrGroupNotFound):
+		respondError(w, req, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidJoinCode):
+		respondError(w, req, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrAlreadyMember):
+		respondError(w, req, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrNotPermitted):
+		respondError(w, req, http.StatusForbidden, err.Error())
+	default:
+		respondInternalError(w, req, fallback, err)
+	}
+}
+
+// Helper function to respond with JSON
+func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// Helper function to respond with a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
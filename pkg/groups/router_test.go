@@ -0,0 +1,67 @@
+package groups
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// serveAs routes a request as the given caller, or anonymously for 0
+func serveAs(h http.Handler, callerID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if callerID != 0 {
+		req = req.WithContext(middleware.WithCaller(req.Context(), int(callerID)))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestGroupRoutes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	teacherID := createUser(t, db, "teacher", "teacher")
+	studentID := createUser(t, db, "student", "student")
+	h := NewRouter(db).Routes()
+
+	if w := serveAs(h, 0, "GET", "/", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous caller, got %d", w.Code)
+	}
+
+	w := serveAs(h, teacherID, "POST", "/", map[string]string{"name": "Room 12", "kind": "classroom"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 creating class, got %d: %s", w.Code, w.Body.String())
+	}
+	var class Group
+	json.NewDecoder(w.Body).Decode(&class)
+
+	if w := serveAs(h, studentID, "POST", "/", map[string]string{"name": "Mine"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for student creating class, got %d", w.Code)
+	}
+
+	if w := serveAs(h, studentID, "POST", "/join", map[string]string{"join_code": class.JoinCode}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 joining class, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveAs(h, studentID, "POST", "/join", map[string]string{"join_code": class.JoinCode}); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 joining twice, got %d", w.Code)
+	}
+
+	path := "/" + strconv.FormatUint(uint64(class.ID), 10) + "/roster"
+	if w := serveAs(h, teacherID, "GET", path, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for teacher roster, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveAs(h, studentID, "GET", path, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for student roster, got %d", w.Code)
+	}
+}
@@ -0,0 +1,304 @@
+package groups
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/jgirmay/unified-go/pkg/auth"
+	"github.com/jgirmay/unified-go/pkg/dashboard"
+	"github.com/jgirmay/unified-go/pkg/unified"
+)
+
+// joinCodeAlphabet omits characters that are easy to confuse when read aloud
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// joinCodeLength is the number of characters in a join code
+const joinCodeLength = 8
+
+// Service provides classroom and household management
+type Service struct {
+	repo      *Repository
+	dashboard *dashboard.Service
+}
+
+// NewService creates a new groups service
+func NewService(repo *Repository, dashboardSvc *dashboard.Service) *Service {
+	return &Service{
+		repo:      repo,
+		dashboard: dashboardSvc,
+	}
+}
+
+// CreateGroup creates a classroom (teachers) or household (parents) owned by ownerID
+func (s *Service) CreateGroup(ctx context.Context, ownerID uint, req *CreateGroupRequest) (*Group, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	role, err := s.repo.GetUserRole(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case role == auth.RoleAdmin:
+	case req.Kind == KindClassroom && role == auth.RoleTeacher:
+	case req.Kind == KindHousehold && role == auth.RoleParent:
+	default:
+		return nil, fmt.Errorf("%w: a %s cannot create a %s", ErrNotPermitted, role, req.Kind)
+	}
+
+	group := &Group{
+		Name:    req.Name,
+		Kind:    req.Kind,
+		OwnerID: ownerID,
+	}
+
+	// Retry on the rare join code collision
+	for attempt := 0; attempt < 5; attempt++ {
+		if err = generateCodes(group); err != nil {
+			return nil, err
+		}
+
+		group.ID, err = s.repo.CreateGroup(ctx, group)
+		if !errors.Is(err, ErrInvalidJoinCode) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	group.MemberRole = ownerRole(group.Kind)
+	return group, nil
+}
+
+// JoinGroup adds a user to the group identified by a join code. The join code
+// adds students to classrooms and children to households. Teachers and parents
+// join as supervisors with the group's separate supervisor code.
+func (s *Service) JoinGroup(ctx context.Context, userID uint, req *JoinGroupRequest) (*Group, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.GetGroupByJoinCode(ctx, req.JoinCode)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrInvalidJoinCode
+	}
+
+	var memberRole string
+	switch group.Kind {
+	case KindClassroom:
+		memberRole = MemberStudent
+	case KindHousehold:
+		memberRole = MemberChild
+	default:
+		return nil, fmt.Errorf("unknown group kind: %s", group.Kind)
+	}
+
+	if req.JoinCode == group.SupervisorCode {
+		role, err := s.repo.GetUserRole(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Supervising member roles share their names with the account roles
+		memberRole = ownerRole(group.Kind)
+		if role != auth.RoleAdmin && role != memberRole {
+			return nil, fmt.Errorf("%w: a %s cannot join a %s as a %s", ErrNotPermitted, role, group.Kind, memberRole)
+		}
+	}
+
+	if err := s.repo.AddMember(ctx, group.ID, userID, memberRole); err != nil {
+		return nil, err
+	}
+
+	group.MemberRole = memberRole
+	if !supervises(memberRole) {
+		group.JoinCode, group.SupervisorCode = "", ""
+	}
+	return group, nil
+}
+
+// ListGroups returns the groups a user belongs to. Join codes are only shown
+// to the group's teachers and parents.
+func (s *Service) ListGroups(ctx context.Context, userID uint) ([]Group, error) {
+	groups, err := s.repo.ListGroupsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		if !supervises(groups[i].MemberRole) {
+			groups[i].JoinCode, groups[i].SupervisorCode = "", ""
+		}
+	}
+	return groups, nil
+}
+
+// RegenerateJoinCode replaces a group's join code and supervisor code so old
+// invitations stop working
+func (s *Service) RegenerateJoinCode(ctx context.Context, callerID, groupID uint) (*Group, error) {
+	group, err := s.requireSupervisor(ctx, callerID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if err = generateCodes(group); err != nil {
+			return nil, err
+		}
+
+		err = s.repo.UpdateJoinCode(ctx, groupID, group.JoinCode, group.SupervisorCode)
+		if !errors.Is(err, ErrInvalidJoinCode) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// GetRoster returns the supervised members of a group with their unified
+// profiles: the students of a classroom or the children of a household
+func (s *Service) GetRoster(ctx context.Context, callerID, groupID uint) (*unified.GroupRoster, error) {
+	group, err := s.requireSupervisor(ctx, callerID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberRole := MemberStudent
+	if group.Kind == KindHousehold {
+		memberRole = MemberChild
+	}
+
+	return s.dashboard.GetGroupRoster(ctx, group.ID, group.Name, group.Kind, memberRole)
+}
+
+// GetChildren returns the unified profiles of a parent's linked children
+func (s *Service) GetChildren(ctx context.Context, parentID uint) ([]*unified.UnifiedUserProfile, error) {
+	return s.dashboard.GetChildrenProfiles(ctx, parentID)
+}
+
+// CanActFor reports whether callerID may act on userID's data: admins may act
+// for anyone in their organization, teachers for their students and parents
+// for their children. It satisfies middleware.RelationshipChecker.
+func (s *Service) CanActFor(ctx context.Context, callerID, userID int) (bool, error) {
+	if callerID <= 0 || userID <= 0 {
+		return false, nil
+	}
+
+	role, err := s.callerRoleFor(ctx, callerID, userID)
+	if err != nil || role == "" {
+		return false, err
+	}
+	if role == auth.RoleAdmin {
+		return true, nil
+	}
+
+	return s.repo.Supervises(ctx, uint(callerID), uint(userID))
+}
+
+// CanManageAccount reports whether callerID may export or delete userID's
+// account: the user themselves, a parent in their household, or an admin of
+// their organization. Teachers supervise class work but do not manage accounts.
+func (s *Service) CanManageAccount(ctx context.Context, callerID, userID int) (bool, error) {
+	if callerID <= 0 || userID <= 0 {
+		return false, nil
+	}
+	if callerID == userID {
+		return true, nil
+	}
+
+	role, err := s.callerRoleFor(ctx, callerID, userID)
+	if err != nil || role == "" {
+		return false, err
+	}
+	if role == auth.RoleAdmin {
+		return true, nil
+	}
+
+	return s.repo.IsGuardian(ctx, uint(callerID), uint(userID))
+}
+
+// callerRoleFor returns the caller's account role when both the caller and
+// userID belong to the context's organization, and "" otherwise
+func (s *Service) callerRoleFor(ctx context.Context, callerID, userID int) (string, error) {
+	targetRole, err := s.repo.GetUserRole(ctx, uint(userID))
+	if err != nil || targetRole == "" {
+		return "", err
+	}
+	return s.repo.GetUserRole(ctx, uint(callerID))
+}
+
+// IsAdmin reports whether userID has the admin role. It satisfies
+// middleware.AdminChecker.
+func (s *Service) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	if userID <= 0 {
+		return false, nil
+	}
+	role, err := s.repo.GetUserRole(ctx, uint(userID))
+	if err != nil {
+		return false, err
+	}
+	return role == auth.RoleAdmin, nil
+}
+
+// requireSupervisor loads a group and checks that the caller teaches or parents in it
+func (s *Service) requireSupervisor(ctx context.Context, callerID, groupID uint) (*Group, error) {
+	group, err := s.repo.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	memberRole, err := s.repo.GetMemberRole(ctx, groupID, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if supervises(memberRole) {
+		return group, nil
+	}
+
+	role, err := s.repo.GetUserRole(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if role == auth.RoleAdmin {
+		return group, nil
+	}
+
+	return nil, ErrNotPermitted
+}
+
+// generateCodes sets a group's join code and supervisor code
+func generateCodes(group *Group) error {
+	var err error
+	if group.JoinCode, err = generateJoinCode(); err != nil {
+		return err
+	}
+	group.SupervisorCode, err = generateJoinCode()
+	return err
+}
+
+// generateJoinCode returns a random join code
+func generateJoinCode() (string, error) {
+	buf := make([]byte, joinCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate join code: %w", err)
+	}
+
+	for i, b := range buf {
+		buf[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,273 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+	"github.com/jgirmay/unified-go/pkg/dashboard"
+	"github.com/jgirmay/unified-go/pkg/unified"
+)
+
+func setupTestService(t *testing.T) (*Service, func(username, role string) uint) {
+	service, db := setupTestServiceDB(t)
+	return service, func(username, role string) uint { return createUser(t, db, username, role) }
+}
+
+func setupTestServiceDB(t *testing.T) (*Service, *sql.DB) {
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db), dashboard.NewService(unified.NewRepository(db))), db
+}
+
+func TestClassroomInviteAndRoster(t *testing.T) {
+	service, newUser := setupTestService(t)
+	ctx := context.Background()
+
+	teacherID := newUser("teacher", "teacher")
+	aliceID := newUser("alice", "student")
+	bobID := newUser("bob", "student")
+
+	class, err := service.CreateGroup(ctx, teacherID, &CreateGroupRequest{Name: "Period 1", Kind: KindClassroom})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if len(class.JoinCode) != joinCodeLength {
+		t.Fatalf("Expected %d character join code, got %q", joinCodeLength, class.JoinCode)
+	}
+
+	for _, id := range []uint{aliceID, bobID} {
+		joined, err := service.JoinGroup(ctx, id, &JoinGroupRequest{JoinCode: class.JoinCode})
+		if err != nil {
+			t.Fatalf("JoinGroup failed: %v", err)
+		}
+		if joined.MemberRole != MemberStudent || joined.JoinCode != "" {
+			t.Errorf("Expected student membership without join code, got %+v", joined)
+		}
+	}
+
+	roster, err := service.GetRoster(ctx, teacherID, class.ID)
+	if err != nil {
+		t.Fatalf("GetRoster failed: %v", err)
+	}
+	if len(roster.Members) != 2 {
+		t.Fatalf("Expected 2 students on roster, got %d", len(roster.Members))
+	}
+	if roster.Members[0].Username != "alice" || roster.Members[1].Username != "bob" {
+		t.Errorf("Unexpected roster members: %s, %s", roster.Members[0].Username, roster.Members[1].Username)
+	}
+
+	if _, err := service.GetRoster(ctx, aliceID, class.ID); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Expected students to be refused the roster, got %v", err)
+	}
+
+	ok, _ := service.CanActFor(ctx, int(teacherID), int(aliceID))
+	if !ok {
+		t.Error("Expected teacher to act for student")
+	}
+	ok, _ = service.CanActFor(ctx, int(aliceID), int(bobID))
+	if ok {
+		t.Error("Expected classmates not to act for each other")
+	}
+	if ok, _ := service.CanManageAccount(ctx, int(teacherID), int(aliceID)); ok {
+		t.Error("Expected teacher not to manage a student's account")
+	}
+}
+
+func TestHouseholdLinksParentToChildren(t *testing.T) {
+	service, newUser := setupTestService(t)
+	ctx := context.Background()
+
+	parentID := newUser("parent", "parent")
+	childID := newUser("kid", "student")
+	strangerID := newUser("stranger", "student")
+
+	home, err := service.CreateGroup(ctx, parentID, &CreateGroupRequest{Name: "Home", Kind: KindHousehold})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	joined, err := service.JoinGroup(ctx, childID, &JoinGroupRequest{JoinCode: home.JoinCode})
+	if err != nil {
+		t.Fatalf("JoinGroup failed: %v", err)
+	}
+	if joined.MemberRole != MemberChild {
+		t.Errorf("Expected child membership, got %q", joined.MemberRole)
+	}
+
+	children, err := service.GetChildren(ctx, parentID)
+	if err != nil {
+		t.Fatalf("GetChildren failed: %v", err)
+	}
+	if len(children) != 1 || children[0].UserID != childID {
+		t.Fatalf("Expected one linked child, got %+v", children)
+	}
+
+	if ok, _ := service.CanActFor(ctx, int(parentID), int(childID)); !ok {
+		t.Error("Expected parent to act for child")
+	}
+	if ok, _ := service.CanActFor(ctx, int(parentID), int(strangerID)); ok {
+		t.Error("Expected parent not to act for unrelated student")
+	}
+	if ok, _ := service.CanManageAccount(ctx, int(parentID), int(childID)); !ok {
+		t.Error("Expected parent to manage child's account")
+	}
+	if ok, _ := service.CanManageAccount(ctx, int(childID), int(parentID)); ok {
+		t.Error("Expected child not to manage parent's account")
+	}
+}
+
+func TestCreateGroupRequiresRole(t *testing.T) {
+	service, newUser := setupTestService(t)
+	ctx := context.Background()
+
+	studentID := newUser("student", "student")
+	parentID := newUser("parent", "parent")
+	adminID := newUser("admin", "admin")
+
+	if _, err := service.CreateGroup(ctx, studentID, &CreateGroupRequest{Name: "Mine", Kind: KindClassroom}); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Expected students to be refused, got %v", err)
+	}
+	if _, err := service.CreateGroup(ctx, parentID, &CreateGroupRequest{Name: "Class", Kind: KindClassroom}); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Expected parents to be refused classrooms, got %v", err)
+	}
+	if _, err := service.CreateGroup(ctx, adminID, &CreateGroupRequest{Name: "Class", Kind: KindClassroom}); err != nil {
+		t.Errorf("Expected admins to create classrooms, got %v", err)
+	}
+
+	if ok, _ := service.CanActFor(ctx, int(adminID), int(studentID)); !ok {
+		t.Error("Expected admin to act for any user")
+	}
+	if ok, _ := service.CanManageAccount(ctx, int(adminID), int(studentID)); !ok {
+		t.Error("Expected admin to manage any account")
+	}
+	if ok, _ := service.IsAdmin(ctx, int(adminID)); !ok {
+		t.Error("Expected admin to be reported as an admin")
+	}
+	if ok, _ := service.IsAdmin(ctx, int(studentID)); ok {
+		t.Error("Expected student not to be reported as an admin")
+	}
+}
+
+func TestJoinGroupSupervisorCode(t *testing.T) {
+	service, newUser := setupTestService(t)
+	ctx := context.Background()
+
+	ownerID := newUser("owner", "teacher")
+	teacherID := newUser("teacher", "teacher")
+	studentID := newUser("student", "student")
+	aideID := newUser("aide", "teacher")
+
+	class, err := service.CreateGroup(ctx, ownerID, &CreateGroupRequest{Name: "Period 2", Kind: KindClassroom})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if len(class.SupervisorCode) != joinCodeLength || class.SupervisorCode == class.JoinCode {
+		t.Fatalf("Expected a separate supervisor code, got %q", class.SupervisorCode)
+	}
+
+	// The student join code never grants supervision, whatever the account role
+	joined, err := service.JoinGroup(ctx, aideID, &JoinGroupRequest{JoinCode: class.JoinCode})
+	if err != nil {
+		t.Fatalf("JoinGroup failed: %v", err)
+	}
+	if joined.MemberRole != MemberStudent || joined.SupervisorCode != "" {
+		t.Errorf("Expected a teacher using the join code to join as a student, got %+v", joined)
+	}
+
+	if _, err := service.JoinGroup(ctx, studentID, &JoinGroupRequest{JoinCode: class.SupervisorCode}); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Expected students to be refused the supervisor code, got %v", err)
+	}
+
+	joined, err = service.JoinGroup(ctx, teacherID, &JoinGroupRequest{JoinCode: class.SupervisorCode})
+	if err != nil {
+		t.Fatalf("JoinGroup failed: %v", err)
+	}
+	if joined.MemberRole != MemberTeacher || joined.SupervisorCode != class.SupervisorCode {
+		t.Errorf("Expected teacher membership with the codes shown, got %+v", joined)
+	}
+	if ok, _ := service.CanActFor(ctx, int(teacherID), int(aideID)); !ok {
+		t.Error("Expected the co-teacher to act for the class's students")
+	}
+
+	regenerated, err := service.RegenerateJoinCode(ctx, ownerID, class.ID)
+	if err != nil {
+		t.Fatalf("RegenerateJoinCode failed: %v", err)
+	}
+	if regenerated.SupervisorCode == class.SupervisorCode {
+		t.Error("Expected the supervisor code to be replaced")
+	}
+	if _, err := service.JoinGroup(ctx, newUser("late", "teacher"), &JoinGroupRequest{JoinCode: class.SupervisorCode}); !errors.Is(err, ErrInvalidJoinCode) {
+		t.Errorf("Expected the old supervisor code to stop working, got %v", err)
+	}
+}
+
+func TestJoinGroupInvalidCode(t *testing.T) {
+	service, newUser := setupTestService(t)
+	studentID := newUser("student", "student")
+
+	_, err := service.JoinGroup(context.Background(), studentID, &JoinGroupRequest{JoinCode: "NOPE0000"})
+	if !errors.Is(err, ErrInvalidJoinCode) {
+		t.Errorf("Expected ErrInvalidJoinCode, got %v", err)
+	}
+}
+
+func TestRelationshipsStayInOrganization(t *testing.T) {
+	service, db := setupTestServiceDB(t)
+	ctx := context.Background()
+
+	adminID := createUser(t, db, "admin", "admin")
+	teacherID := createUser(t, db, "teacher", "teacher")
+	studentID := createUser(t, db, "student", "student")
+	outsiderID := createUser(t, db, "outsider", "student")
+	if _, err := db.Exec(`UPDATE users SET org_id = 2 WHERE id = ?`, outsiderID); err != nil {
+		t.Fatalf("Failed to move user: %v", err)
+	}
+
+	class, err := service.CreateGroup(ctx, teacherID, &CreateGroupRequest{Name: "Period 3", Kind: KindClassroom})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	for _, id := range []uint{studentID, outsiderID} {
+		if _, err := service.JoinGroup(ctx, id, &JoinGroupRequest{JoinCode: class.JoinCode}); err != nil {
+			t.Fatalf("JoinGroup failed: %v", err)
+		}
+	}
+
+	// Requests are scoped to organization 1
+	orgCtx := tenant.NewContext(ctx, &tenant.Organization{ID: 1, Slug: "default"})
+
+	checks := []struct {
+		name     string
+		check    func(context.Context, int, int) (bool, error)
+		callerID uint
+		userID   uint
+		want     bool
+	}{
+		{"admin acts for member", service.CanActFor, adminID, studentID, true},
+		{"admin acts for outsider", service.CanActFor, adminID, outsiderID, false},
+		{"admin manages outsider", service.CanManageAccount, adminID, outsiderID, false},
+		{"teacher acts for outsider in class", service.CanActFor, teacherID, outsiderID, false},
+	}
+	for _, c := range checks {
+		got, err := c.check(orgCtx, int(c.callerID), int(c.userID))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// Organization 2 cannot see organization 1's groups
+	otherCtx := tenant.NewContext(ctx, &tenant.Organization{ID: 2, Slug: "other"})
+	if _, err := service.GetRoster(otherCtx, outsiderID, class.ID); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected another organization's group to be hidden, got %v", err)
+	}
+	if ok, _ := service.IsAdmin(otherCtx, int(adminID)); ok {
+		t.Error("Expected an admin not to be an admin of another organization")
+	}
+}
@@ -7,8 +7,14 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+// logger is the math app logger
+var logger = logging.Logger("math")
+
 // Handler handles HTTP requests for the math app
 type Handler struct {
 	service             *Service
@@ -16,6 +22,21 @@ type Handler struct {
 	assessmentEngine    *AssessmentEngine
 	analyticsEngine     *AnalyticsEngine
 	phonicsEngine       *PhonicsEngine
+	settings            Settings
+}
+
+// Settings holds the configurable limits of the math app
+type Settings struct {
+	AudioDir       string
+	MaxUploadBytes int64
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		AudioDir:       "data/audio/math",
+		MaxUploadBytes: 10 << 20, // 10MB
+	}
 }
 
 // NewHandler creates a new math handler
@@ -32,24 +53,26 @@ func NewHandler(repo *Repository) *Handler {
 		assessmentEngine: assessmentEngine,
 		analyticsEngine:  analyticsEngine,
 		phonicsEngine:    phonicsEngine,
+		settings:         DefaultSettings(),
 	}
 }
 
-// Response wrapper for API responses
+// Response wrapper for successful API responses; errors are problem+json
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
 }
 
-// Error response helper
-func errorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(APIResponse{
-		Success: false,
-		Error:   message,
-	})
+// Error response helper; errors are sent as problem+json
+func errorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	errorResponse(w, r, http.StatusInternalServerError, message)
 }
 
 // Success response helper
@@ -71,7 +94,7 @@ func (h *Handler) GenerateQuestion(w http.ResponseWriter, r *http.Request) {
 	difficulty := r.URL.Query().Get("difficulty")
 
 	if userID == "" || mode == "" || difficulty == "" {
-		errorResponse(w, http.StatusBadRequest, "Missing required parameters: user_id, mode, difficulty")
+		errorResponse(w, r, http.StatusBadRequest, "Missing required parameters: user_id, mode, difficulty")
 		return
 	}
 
@@ -90,13 +113,13 @@ func (h *Handler) GenerateQuestion(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		errorResponse(w, http.StatusBadRequest, "Missing user_id parameter")
+		errorResponse(w, r, http.StatusBadRequest, "Missing user_id parameter")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -109,7 +132,7 @@ func (h *Handler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -127,7 +150,7 @@ func (h *Handler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
 
 	// Save the response
 	if err := h.service.SaveQuestionResponse(r.Context(), uint(userID), history, 0); err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to save answer")
+		respondInternalError(w, r, "Failed to save answer", err)
 		return
 	}
 
@@ -142,13 +165,13 @@ func (h *Handler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) SaveSession(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		errorResponse(w, http.StatusBadRequest, "Missing user_id parameter")
+		errorResponse(w, r, http.StatusBadRequest, "Missing user_id parameter")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -161,7 +184,7 @@ func (h *Handler) SaveSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -176,7 +199,7 @@ func (h *Handler) SaveSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.ProcessPracticeResult(r.Context(), uint(userID), result); err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to save session")
+		respondInternalError(w, r, "Failed to save session", err)
 		return
 	}
 
@@ -195,13 +218,13 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	stats, err := h.service.repo.GetUserStats(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get stats")
+		respondInternalError(w, r, "Failed to get stats", err)
 		return
 	}
 
@@ -226,13 +249,13 @@ func (h *Handler) GetMastery(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	masteries, err := h.service.repo.GetMasteryByUser(r.Context(), uint(userID), "all")
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get mastery data")
+		respondInternalError(w, r, "Failed to get mastery data", err)
 		return
 	}
 
@@ -249,7 +272,7 @@ func (h *Handler) GetDueForReview(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -263,7 +286,7 @@ func (h *Handler) GetDueForReview(w http.ResponseWriter, r *http.Request) {
 
 	schedules, err := h.service.GetDueForReview(r.Context(), uint(userID), limit)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get due facts")
+		respondInternalError(w, r, "Failed to get due facts", err)
 		return
 	}
 
@@ -278,7 +301,7 @@ func (h *Handler) ProcessReview(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -289,18 +312,18 @@ func (h *Handler) ProcessReview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.Quality < 0 || req.Quality > 5 {
-		errorResponse(w, http.StatusBadRequest, "Quality must be 0-5")
+		errorResponse(w, r, http.StatusBadRequest, "Quality must be 0-5")
 		return
 	}
 
 	schedule, err := h.sm2Engine.ProcessReview(r.Context(), uint(userID), req.Fact, req.Mode, req.Quality)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to process review")
+		respondInternalError(w, r, "Failed to process review", err)
 		return
 	}
 
@@ -317,7 +340,7 @@ func (h *Handler) GetAdaptiveSession(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -331,7 +354,7 @@ func (h *Handler) GetAdaptiveSession(w http.ResponseWriter, r *http.Request) {
 
 	session, err := h.sm2Engine.GenerateAdaptiveSession(r.Context(), uint(userID), size)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to generate session")
+		respondInternalError(w, r, "Failed to generate session", err)
 		return
 	}
 
@@ -345,13 +368,13 @@ func (h *Handler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	analytics, err := h.analyticsEngine.GetUserAnalytics(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get analytics")
+		respondInternalError(w, r, "Failed to get analytics", err)
 		return
 	}
 
@@ -363,7 +386,7 @@ func (h *Handler) GetWeakAreas(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -377,7 +400,7 @@ func (h *Handler) GetWeakAreas(w http.ResponseWriter, r *http.Request) {
 
 	weakAreas, err := h.analyticsEngine.GetWeakAreas(r.Context(), uint(userID), limit)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get weak areas")
+		respondInternalError(w, r, "Failed to get weak areas", err)
 		return
 	}
 
@@ -391,7 +414,7 @@ func (h *Handler) GetPracticePlan(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -402,7 +425,7 @@ func (h *Handler) GetPracticePlan(w http.ResponseWriter, r *http.Request) {
 
 	recommendation, err := h.service.GeneratePracticeRecommendations(r.Context(), uint(userID), mode)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to generate practice plan")
+		respondInternalError(w, r, "Failed to generate practice plan", err)
 		return
 	}
 
@@ -414,13 +437,13 @@ func (h *Handler) GetLearningProfile(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	analysis, err := h.service.AnalyzeUserLearning(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to analyze learning")
+		respondInternalError(w, r, "Failed to analyze learning", err)
 		return
 	}
 
@@ -434,7 +457,7 @@ func (h *Handler) InitializeSR(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -444,13 +467,13 @@ func (h *Handler) InitializeSR(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	schedule, err := h.sm2Engine.InitializeSchedule(r.Context(), uint(userID), req.Fact, req.Mode)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to initialize schedule")
+		respondInternalError(w, r, "Failed to initialize schedule", err)
 		return
 	}
 
@@ -462,13 +485,13 @@ func (h *Handler) GetSM2Progress(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	progress, err := h.sm2Engine.AnalyzeSM2Progress(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get SM-2 progress")
+		respondInternalError(w, r, "Failed to get SM-2 progress", err)
 		return
 	}
 
@@ -482,7 +505,7 @@ func (h *Handler) StartAssessment(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -493,7 +516,7 @@ func (h *Handler) StartAssessment(w http.ResponseWriter, r *http.Request) {
 
 	session, err := h.assessmentEngine.StartAssessment(r.Context(), uint(userID), mode)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to start assessment")
+		respondInternalError(w, r, "Failed to start assessment", err)
 		return
 	}
 
@@ -505,7 +528,7 @@ func (h *Handler) SubmitAssessmentResponse(w http.ResponseWriter, r *http.Reques
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -516,7 +539,7 @@ func (h *Handler) SubmitAssessmentResponse(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -527,7 +550,7 @@ func (h *Handler) SubmitAssessmentResponse(w http.ResponseWriter, r *http.Reques
 
 	result, err := h.assessmentEngine.ProcessResponse(r.Context(), session, req.IsCorrect, req.Mode)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to process response")
+		respondInternalError(w, r, "Failed to process response", err)
 		return
 	}
 
@@ -550,14 +573,14 @@ func (h *Handler) GetAssessmentResults(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	// Get current level estimate
 	level, err := h.assessmentEngine.GetCurrentLevel(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get results")
+		respondInternalError(w, r, "Failed to get results", err)
 		return
 	}
 
@@ -576,7 +599,7 @@ func (h *Handler) GetAssessmentResults(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DetectFactFamily(w http.ResponseWriter, r *http.Request) {
 	question := r.URL.Query().Get("question")
 	if question == "" {
-		errorResponse(w, http.StatusBadRequest, "Missing question parameter")
+		errorResponse(w, r, http.StatusBadRequest, "Missing question parameter")
 		return
 	}
 
@@ -599,13 +622,13 @@ func (h *Handler) GetFactFamilyStats(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	analysis, err := h.phonicsEngine.AnalyzeUserPatternMastery(r.Context(), uint(userID))
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get fact family stats")
+		respondInternalError(w, r, "Failed to get fact family stats", err)
 		return
 	}
 
@@ -617,7 +640,7 @@ func (h *Handler) GetRemediationPlan(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		errorResponse(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
@@ -631,7 +654,7 @@ func (h *Handler) GetRemediationPlan(w http.ResponseWriter, r *http.Request) {
 
 	plan, err := h.phonicsEngine.GetRemediationPlan(r.Context(), uint(userID), limit)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to get remediation plan")
+		respondInternalError(w, r, "Failed to get remediation plan", err)
 		return
 	}
 
@@ -0,0 +1,16 @@
+package math
+
+import (
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// Collect implements metrics.Collector with the sync queue statistics
+func (q *SyncQueue) Collect() []metrics.Family {
+	stats := q.GetStats()
+	return []metrics.Family{
+		metrics.Gauge("unified_math_sync_queue_length", "Sync events waiting in the queue.", float64(stats.Size)),
+		metrics.Gauge("unified_math_sync_queue_capacity", "Maximum sync queue length.", float64(stats.MaxSize)),
+		metrics.Counter("unified_math_sync_processed_total", "Sync events processed.", float64(stats.Processed)),
+		metrics.Counter("unified_math_sync_dropped_total", "Sync events dropped because the queue was full.", float64(stats.Dropped)),
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // Repository handles all database operations for the math app
@@ -838,7 +840,7 @@ type LeaderboardEntry struct {
 	Metric    string
 }
 
-// GetLeaderboard retrieves leaderboard rankings
+// GetLeaderboard retrieves the context organization's leaderboard rankings
 func (r *Repository) GetLeaderboard(ctx context.Context, metric string, limit int) ([]*LeaderboardEntry, error) {
 	var query string
 	var columnName string
@@ -855,16 +857,18 @@ func (r *Repository) GetLeaderboard(ctx context.Context, metric string, limit in
 	}
 
 	// Build query with the appropriate metric
+	scope, args := tenant.Members(ctx, "u.id")
 	query = fmt.Sprintf(`
 		SELECT u.id, u.username, %s as value
 		FROM results r
 		JOIN users u ON r.user_id = u.id
+		WHERE %s
 		GROUP BY r.user_id, u.id, u.username
 		ORDER BY value DESC
 		LIMIT ?
-	`, columnName)
+	`, columnName, scope)
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
 	}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
 // Router handles HTTP routes for math app
@@ -11,6 +13,7 @@ type Router struct {
 	db      *sql.DB
 	handler *Handler
 	router  chi.Router
+	authz   *middleware.Authorizer
 }
 
 // NewRouter creates a new math router
@@ -22,88 +25,106 @@ func NewRouter(db *sql.DB) *Router {
 		db:      db,
 		handler: handler,
 		router:  chi.NewRouter(),
+		authz:   middleware.NewAuthorizer(nil),
 	}
 }
 
+// SetAuthorizer sets the authorizer guarding per-user routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// SetSettings overrides the audio storage and upload limits
+func (r *Router) SetSettings(settings Settings) {
+	r.handler.settings = settings
+}
+
 // Routes configures all math app routes and returns the chi.Router
 func (r *Router) Routes() chi.Router {
-	// ==================== CORE MATH PRACTICE ====================
+	// Public endpoints
 
-	// Generate a new math question
-	r.router.Get("/api/math/question", r.handler.GenerateQuestion)
+	// Detect which fact family a question belongs to
+	r.router.Get("/api/math/detect-family", r.handler.DetectFactFamily)
 
-	// Check answer and get feedback
-	r.router.Post("/api/math/check-answer", r.handler.CheckAnswer)
+	// Per-user endpoints require the caller to be the user or related to them
+	r.router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUserAccess)
 
-	// Save a complete practice session
-	r.router.Post("/api/math/save-session", r.handler.SaveSession)
+		// ==================== CORE MATH PRACTICE ====================
 
-	// Get user statistics
-	r.router.Get("/api/users/{userId}/math/stats", r.handler.GetStats)
+		// Generate a new math question
+		api.Get("/api/math/question", r.handler.GenerateQuestion)
 
-	// Get word/fact mastery information
-	r.router.Get("/api/users/{userId}/math/mastery", r.handler.GetMastery)
+		// Check answer and get feedback
+		api.Post("/api/math/check-answer", r.handler.CheckAnswer)
 
-	// ==================== PRACTICE MANAGEMENT ====================
+		// Save a complete practice session
+		api.Post("/api/math/save-session", r.handler.SaveSession)
 
-	// Get facts due for SM-2 review
-	r.router.Get("/api/users/{userId}/math/due-review", r.handler.GetDueForReview)
+		// Get user statistics
+		api.Get("/api/users/{userId}/math/stats", r.handler.GetStats)
 
-	// Process a review attempt
-	r.router.Post("/api/users/{userId}/math/process-review", r.handler.ProcessReview)
+		// Get word/fact mastery information
+		api.Get("/api/users/{userId}/math/mastery", r.handler.GetMastery)
 
-	// Get an adaptive practice session (40% due, 60% new)
-	r.router.Get("/api/users/{userId}/math/adaptive-session", r.handler.GetAdaptiveSession)
+		// ==================== PRACTICE MANAGEMENT ====================
 
-	// ==================== LEARNING ANALYTICS ====================
+		// Get facts due for SM-2 review
+		api.Get("/api/users/{userId}/math/due-review", r.handler.GetDueForReview)
 
-	// Get comprehensive learning analytics
-	r.router.Get("/api/users/{userId}/math/analytics", r.handler.GetAnalytics)
+		// Process a review attempt
+		api.Post("/api/users/{userId}/math/process-review", r.handler.ProcessReview)
 
-	// Get weak fact families needing practice
-	r.router.Get("/api/users/{userId}/math/weak-areas", r.handler.GetWeakAreas)
+		// Get an adaptive practice session (40% due, 60% new)
+		api.Get("/api/users/{userId}/math/adaptive-session", r.handler.GetAdaptiveSession)
 
-	// Get personalized practice plan
-	r.router.Get("/api/users/{userId}/math/practice-plan", r.handler.GetPracticePlan)
+		// ==================== LEARNING ANALYTICS ====================
 
-	// Get learning profile analysis
-	r.router.Get("/api/users/{userId}/math/learning-profile", r.handler.GetLearningProfile)
+		// Get comprehensive learning analytics
+		api.Get("/api/users/{userId}/math/analytics", r.handler.GetAnalytics)
 
-	// ==================== SPACED REPETITION ====================
+		// Get weak fact families needing practice
+		api.Get("/api/users/{userId}/math/weak-areas", r.handler.GetWeakAreas)
 
-	// Initialize SM-2 schedule for a fact
-	r.router.Post("/api/users/{userId}/math/sr-initialize", r.handler.InitializeSR)
+		// Get personalized practice plan
+		api.Get("/api/users/{userId}/math/practice-plan", r.handler.GetPracticePlan)
 
-	// Get SM-2 progress and statistics
-	r.router.Get("/api/users/{userId}/math/sr-progress", r.handler.GetSM2Progress)
+		// Get learning profile analysis
+		api.Get("/api/users/{userId}/math/learning-profile", r.handler.GetLearningProfile)
 
-	// ==================== ASSESSMENT ====================
+		// ==================== SPACED REPETITION ====================
 
-	// Start a placement assessment (binary search, levels 1-15)
-	r.router.Post("/api/users/{userId}/math/assessment-start", r.handler.StartAssessment)
+		// Initialize SM-2 schedule for a fact
+		api.Post("/api/users/{userId}/math/sr-initialize", r.handler.InitializeSR)
 
-	// Submit an assessment response
-	r.router.Post("/api/users/{userId}/math/assessment-response", r.handler.SubmitAssessmentResponse)
+		// Get SM-2 progress and statistics
+		api.Get("/api/users/{userId}/math/sr-progress", r.handler.GetSM2Progress)
 
-	// Get assessment results and placement
-	r.router.Get("/api/users/{userId}/math/assessment-results", r.handler.GetAssessmentResults)
+		// ==================== ASSESSMENT ====================
 
-	// ==================== FACT FAMILIES ====================
+		// Start a placement assessment (binary search, levels 1-15)
+		api.Post("/api/users/{userId}/math/assessment-start", r.handler.StartAssessment)
 
-	// Detect which fact family a question belongs to
-	r.router.Get("/api/math/detect-family", r.handler.DetectFactFamily)
+		// Submit an assessment response
+		api.Post("/api/users/{userId}/math/assessment-response", r.handler.SubmitAssessmentResponse)
+
+		// Get assessment results and placement
+		api.Get("/api/users/{userId}/math/assessment-results", r.handler.GetAssessmentResults)
+
+		// ==================== FACT FAMILIES ====================
 
-	// Get fact family mastery statistics
-	r.router.Get("/api/users/{userId}/math/family-stats", r.handler.GetFactFamilyStats)
+		// Get fact family mastery statistics
+		api.Get("/api/users/{userId}/math/family-stats", r.handler.GetFactFamilyStats)
 
-	// Get remediation plan for weak areas
-	r.router.Get("/api/users/{userId}/math/remediation-plan", r.handler.GetRemediationPlan)
+		// Get remediation plan for weak areas
+		api.Get("/api/users/{userId}/math/remediation-plan", r.handler.GetRemediationPlan)
 
-	// ==================== AUDIO RECORDING & TRANSCRIPTION ====================
+		// ==================== AUDIO RECORDING & TRANSCRIPTION ====================
 
-	// Audio recording and transcription
-	r.router.Post("/api/audio/record", r.handler.RecordAudio)
-	r.router.Post("/api/audio/transcribe", r.handler.TranscribeAudio)
+		// Audio recording and transcription
+		api.Post("/api/audio/record", r.handler.RecordAudio)
+		api.Post("/api/audio/transcribe", r.handler.TranscribeAudio)
+	})
 
 	return r.router
 }
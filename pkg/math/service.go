@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/metrics"
 )
 
 // Service handles business logic for the math app
@@ -30,6 +32,7 @@ func (s *Service) ProcessPracticeResult(ctx context.Context, userID uint, result
 	if err := s.repo.SaveResult(ctx, result); err != nil {
 		return fmt.Errorf("failed to save result: %w", err)
 	}
+	metrics.SessionsSaved.Inc("math")
 
 	// Update learning profile with practice time
 	profile, _ := s.repo.GetLearningProfile(ctx, userID)
@@ -6,53 +6,9 @@ import (
 	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
-// AuthMiddleware wraps the global auth middleware for piano-specific use
-type PianoAuthMiddleware struct {
-	globalAuth *middleware.AuthMiddleware
-}
-
-// NewPianoAuthMiddleware creates a new piano auth middleware wrapper
-func NewPianoAuthMiddleware(globalAuth *middleware.AuthMiddleware) *PianoAuthMiddleware {
-	return &PianoAuthMiddleware{
-		globalAuth: globalAuth,
-	}
-}
-
-// RequireAuth is a middleware that requires user authentication for protected routes
-func (pam *PianoAuthMiddleware) RequireAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get user ID from session
-		userID, ok := middleware.GetUserID(r)
-		if !ok || userID == 0 {
-			// Redirect unauthenticated users to login
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// RequireAuthJSON is middleware that requires auth and returns JSON error
-func (pam *PianoAuthMiddleware) RequireAuthJSON(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get user ID from session
-		userID, ok := middleware.GetUserID(r)
-		if !ok || userID == 0 {
-			respondJSON(w, http.StatusUnauthorized, map[string]interface{}{
-				"error": "authentication required",
-				"status": 401,
-			})
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// GetUserIDFromRequest extracts user ID from the session
+// GetUserIDFromRequest extracts the authenticated caller's user ID
 func GetUserIDFromRequest(r *http.Request) int {
-	userID, ok := middleware.GetUserID(r)
+	userID, ok := middleware.CallerID(r)
 	if !ok {
 		return 0
 	}
@@ -4,27 +4,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"log"
 	"net/http"
 	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+// logger is the piano app logger
+var logger = logging.Logger("piano")
+
 var (
 	baseTemplate *template.Template
 	songsTemplate *template.Template
 	practiceTemplate *template.Template
 )
 
-// init loads templates at startup
-func init() {
-	var err error
-	templateDir := "pkg/piano/templates"
-
+// LoadTemplates parses the piano page templates from templateDir. Until it
+// succeeds, pages fall back to plain placeholders.
+func LoadTemplates(templateDir string) error {
 	// Load base template with all nested templates
-	baseTemplate, err = template.ParseGlob(filepath.Join(templateDir, "*.html"))
+	tmpl, err := template.ParseGlob(filepath.Join(templateDir, "*.html"))
 	if err != nil {
-		log.Printf("Warning: Could not parse templates from %s: %v", templateDir, err)
+		return fmt.Errorf("failed to parse templates from %s: %w", templateDir, err)
 	}
+	baseTemplate = tmpl
+	return nil
 }
 
 // IndexHandler serves the piano app homepage with song listing
@@ -40,6 +47,7 @@ func (r *Router) SongsHandler(w http.ResponseWriter, req *http.Request) {
 	// Get songs from repository
 	songs, err := r.service.repo.GetSongs(req.Context(), difficulty, 100, 0)
 	if err != nil {
+		logger.ErrorContext(req.Context(), "failed to load songs", "error", err)
 		renderError(w, http.StatusInternalServerError, "Failed to load songs")
 		return
 	}
@@ -53,7 +61,7 @@ func (r *Router) SongsHandler(w http.ResponseWriter, req *http.Request) {
 
 	if baseTemplate != nil {
 		if err := baseTemplate.ExecuteTemplate(w, "base.html", data); err != nil {
-			log.Printf("Error rendering songs template: %v", err)
+			logger.ErrorContext(req.Context(), "failed to render template", "template", "songs", "error", err)
 			renderError(w, http.StatusInternalServerError, "Template rendering error")
 		}
 	} else {
@@ -64,7 +72,7 @@ func (r *Router) SongsHandler(w http.ResponseWriter, req *http.Request) {
 // PracticeHandler displays the practice interface for a specific song
 func (r *Router) PracticeHandler(w http.ResponseWriter, req *http.Request) {
 	// Get song ID from URL
-	songIDStr := req.PathValue("id")
+	songIDStr := chi.URLParam(req, "id")
 
 	var songID int
 	_, err := fmt.Sscanf(songIDStr, "%d", &songID)
@@ -88,7 +96,7 @@ func (r *Router) PracticeHandler(w http.ResponseWriter, req *http.Request) {
 
 	if baseTemplate != nil {
 		if err := baseTemplate.ExecuteTemplate(w, "base.html", data); err != nil {
-			log.Printf("Error rendering practice template: %v", err)
+			logger.ErrorContext(req.Context(), "failed to render template", "template", "practice", "error", err)
 			renderError(w, http.StatusInternalServerError, "Template rendering error")
 		}
 	} else {
@@ -108,6 +116,7 @@ func (r *Router) LeaderboardHandler(w http.ResponseWriter, req *http.Request) {
 	// Get leaderboard data
 	leaderboard, err := r.service.repo.GetLeaderboard(req.Context(), 100)
 	if err != nil {
+		logger.ErrorContext(req.Context(), "failed to load leaderboard", "error", err)
 		renderError(w, http.StatusInternalServerError, "Failed to load leaderboard")
 		return
 	}
@@ -120,7 +129,7 @@ func (r *Router) LeaderboardHandler(w http.ResponseWriter, req *http.Request) {
 
 	if baseTemplate != nil {
 		if err := baseTemplate.ExecuteTemplate(w, "base.html", data); err != nil {
-			log.Printf("Error rendering leaderboard template: %v", err)
+			logger.ErrorContext(req.Context(), "failed to render template", "template", "leaderboard", "error", err)
 			renderError(w, http.StatusInternalServerError, "Template rendering error")
 		}
 	} else {
@@ -189,9 +198,24 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// respondError sends an error JSON response
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]interface{}{
-		"error": message,
-	})
+// respondError sends a problem+json error response
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	apierror.Respond(w, r, status, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
+
+// respondServiceError passes client errors from the service through and
+// reports anything else as an internal error
+func respondServiceError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if apiErr, ok := apierror.As(err); ok {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	respondInternalError(w, r, message, err)
 }
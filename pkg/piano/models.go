@@ -3,9 +3,10 @@ package piano
 import (
 	"encoding/json"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
 )
 
 // Song represents a piano song/piece available for practice
@@ -100,30 +101,30 @@ type MusicQuestion struct {
 // Validate performs validation on Song
 func (s *Song) Validate() error {
 	if s.Title == "" {
-		return errors.New("title is required")
+		return apierror.Invalid("title", "is required")
 	}
 	if s.Composer == "" {
-		return errors.New("composer is required")
+		return apierror.Invalid("composer", "is required")
 	}
 	if len(s.MIDIFile) == 0 {
-		return errors.New("midi_file is required")
+		return apierror.Invalid("midi_file", "is required")
 	}
 	// Validate MIDI header (should start with MThd: 0x4D546864)
 	if len(s.MIDIFile) < 4 || s.MIDIFile[0] != 0x4D || s.MIDIFile[1] != 0x54 {
-		return errors.New("invalid MIDI file format (must start with 'MThd')")
+		return apierror.Invalid("midi_file", "must start with 'MThd'")
 	}
 	if s.Duration <= 0 {
-		return errors.New("duration must be positive")
+		return apierror.Invalid("duration", "must be positive")
 	}
 	if s.BPM < 40 || s.BPM > 300 {
-		return fmt.Errorf("bpm must be between 40 and 300, got %d", s.BPM)
+		return apierror.Invalid("bpm", fmt.Sprintf("must be between 40 and 300, got %d", s.BPM))
 	}
 	if s.Difficulty == "" {
 		s.Difficulty = "intermediate"
 	}
 	validDifficulties := map[string]bool{"beginner": true, "intermediate": true, "advanced": true, "expert": true}
 	if !validDifficulties[s.Difficulty] {
-		return fmt.Errorf("invalid difficulty: %s", s.Difficulty)
+		return apierror.Invalid("difficulty", fmt.Sprintf("is invalid: %s", s.Difficulty))
 	}
 	return nil
 }
@@ -131,28 +132,28 @@ func (s *Song) Validate() error {
 // Validate performs validation on PianoLesson
 func (pl *PianoLesson) Validate() error {
 	if pl.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if pl.SongID == 0 {
-		return errors.New("song_id is required")
+		return apierror.Invalid("song_id", "is required")
 	}
 	if pl.Duration <= 0 {
-		return errors.New("duration must be positive")
+		return apierror.Invalid("duration", "must be positive")
 	}
 	if pl.NotesTotal <= 0 {
-		return errors.New("notes_total must be positive")
+		return apierror.Invalid("notes_total", "must be positive")
 	}
 	if pl.NotesCorrect < 0 || pl.NotesCorrect > pl.NotesTotal {
-		return fmt.Errorf("notes_correct must be between 0 and %d, got %d", pl.NotesTotal, pl.NotesCorrect)
+		return apierror.Invalid("notes_correct", fmt.Sprintf("must be between 0 and %d, got %d", pl.NotesTotal, pl.NotesCorrect))
 	}
 	if pl.Accuracy < 0 || pl.Accuracy > 100 {
-		return fmt.Errorf("accuracy must be between 0 and 100, got %f", pl.Accuracy)
+		return apierror.Invalid("accuracy", fmt.Sprintf("must be between 0 and 100, got %f", pl.Accuracy))
 	}
 	if pl.TempoAccuracy < 0 || pl.TempoAccuracy > 100 {
-		return fmt.Errorf("tempo_accuracy must be between 0 and 100, got %f", pl.TempoAccuracy)
+		return apierror.Invalid("tempo_accuracy", fmt.Sprintf("must be between 0 and 100, got %f", pl.TempoAccuracy))
 	}
 	if pl.Score < 0 || pl.Score > 100 {
-		return fmt.Errorf("score must be between 0 and 100, got %f", pl.Score)
+		return apierror.Invalid("score", fmt.Sprintf("must be between 0 and 100, got %f", pl.Score))
 	}
 	return nil
 }
@@ -160,23 +161,23 @@ func (pl *PianoLesson) Validate() error {
 // Validate performs validation on PracticeSession
 func (ps *PracticeSession) Validate() error {
 	if ps.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if ps.SongID == 0 {
-		return errors.New("song_id is required")
+		return apierror.Invalid("song_id", "is required")
 	}
 	if len(ps.RecordingMIDI) == 0 {
-		return errors.New("recording_midi is required")
+		return apierror.Invalid("recording_midi", "is required")
 	}
 	// Validate MIDI header
 	if len(ps.RecordingMIDI) < 4 || ps.RecordingMIDI[0] != 0x4D || ps.RecordingMIDI[1] != 0x54 {
-		return errors.New("invalid MIDI recording format")
+		return apierror.Invalid("recording_midi", "must start with 'MThd'")
 	}
 	if ps.Duration <= 0 {
-		return errors.New("duration must be positive")
+		return apierror.Invalid("duration", "must be positive")
 	}
 	if ps.NotesTotal <= 0 {
-		return errors.New("notes_total must be positive")
+		return apierror.Invalid("notes_total", "must be positive")
 	}
 	return nil
 }
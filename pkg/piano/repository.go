@@ -6,11 +6,15 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // Repository handles database operations for piano app
 type Repository struct {
-	db *sql.DB
+	db database.Conn
 }
 
 // NewRepository creates a new piano repository
@@ -18,7 +22,13 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// SaveSong saves a piano song to the database with MIDI blob
+// WithTx returns a piano repository whose statements run within tx
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx}
+}
+
+// SaveSong saves a piano song with MIDI blob to the context's organization,
+// or as shared content without one
 func (r *Repository) SaveSong(ctx context.Context, song *Song) (uint, error) {
 	if song == nil {
 		return 0, errors.New("song cannot be nil")
@@ -28,12 +38,12 @@ func (r *Repository) SaveSong(ctx context.Context, song *Song) (uint, error) {
 		return 0, fmt.Errorf("invalid song: %w", err)
 	}
 
-	stmt := `INSERT INTO songs (title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	stmt := `INSERT INTO songs (title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, org_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 
 	result, err := r.db.ExecContext(ctx, stmt,
 		song.Title, song.Composer, song.Description, song.MIDIFile, song.Difficulty,
-		song.Duration, song.BPM, song.TimeSignature, song.KeySignature, song.TotalNotes)
+		song.Duration, song.BPM, song.TimeSignature, song.KeySignature, song.TotalNotes, tenant.ID(ctx))
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to save song: %w", err)
@@ -47,22 +57,24 @@ func (r *Repository) SaveSong(ctx context.Context, song *Song) (uint, error) {
 	return uint(id), nil
 }
 
-// GetSongByID retrieves a song by ID with MIDI data
+// GetSongByID retrieves a song by ID with MIDI data from the context
+// organization's catalog
 func (r *Repository) GetSongByID(ctx context.Context, songID uint) (*Song, error) {
 	if songID == 0 {
 		return nil, errors.New("song_id is required")
 	}
 
 	var song Song
-	stmt := `SELECT id, title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, created_at, updated_at FROM songs WHERE id = ?`
+	scope, args := tenant.Catalog(ctx, "org_id")
+	stmt := `SELECT id, title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, created_at, updated_at FROM songs WHERE id = ? AND ` + scope
 
-	err := r.db.QueryRowContext(ctx, stmt, songID).Scan(
+	err := r.db.QueryRowContext(ctx, stmt, append([]interface{}{songID}, args...)...).Scan(
 		&song.ID, &song.Title, &song.Composer, &song.Description, &song.MIDIFile, &song.Difficulty,
 		&song.Duration, &song.BPM, &song.TimeSignature, &song.KeySignature, &song.TotalNotes, &song.CreatedAt, &song.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("song not found")
+			return nil, apierror.NotFound("Song not found")
 		}
 		return nil, fmt.Errorf("failed to get song: %w", err)
 	}
@@ -70,7 +82,8 @@ func (r *Repository) GetSongByID(ctx context.Context, songID uint) (*Song, error
 	return &song, nil
 }
 
-// GetSongs retrieves songs with optional filtering
+// GetSongs retrieves songs from the context organization's catalog with
+// optional filtering
 func (r *Repository) GetSongs(ctx context.Context, difficulty string, limit, offset int) ([]Song, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 20
@@ -79,22 +92,17 @@ func (r *Repository) GetSongs(ctx context.Context, difficulty string, limit, off
 		offset = 0
 	}
 
-	stmt := `SELECT id, title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, created_at, updated_at FROM songs`
+	scope, args := tenant.Catalog(ctx, "org_id")
+	stmt := `SELECT id, title, composer, description, midi_file, difficulty, duration, bpm, time_signature, key_signature, total_notes, created_at, updated_at FROM songs WHERE ` + scope
 
 	if difficulty != "" {
-		stmt += ` WHERE difficulty = ?`
+		stmt += ` AND difficulty = ?`
+		args = append(args, difficulty)
 	}
 
 	stmt += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
-	var rows *sql.Rows
-	var err error
-
-	if difficulty != "" {
-		rows, err = r.db.QueryContext(ctx, stmt, difficulty, limit, offset)
-	} else {
-		rows, err = r.db.QueryContext(ctx, stmt, limit, offset)
-	}
+	rows, err := r.db.QueryContext(ctx, stmt, append(args, limit, offset)...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get songs: %w", err)
@@ -184,7 +192,7 @@ func (r *Repository) GetMIDIRecording(ctx context.Context, sessionID uint) ([]by
 	err := r.db.QueryRowContext(ctx, stmt, sessionID).Scan(&midiData)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("session not found")
+			return nil, apierror.NotFound("Session not found")
 		}
 		return nil, fmt.Errorf("failed to get MIDI recording: %w", err)
 	}
@@ -192,6 +200,26 @@ func (r *Repository) GetMIDIRecording(ctx context.Context, sessionID uint) ([]by
 	return midiData, nil
 }
 
+// GetPracticeSessionOwner retrieves the user a practice session belongs to
+func (r *Repository) GetPracticeSessionOwner(ctx context.Context, sessionID uint) (uint, error) {
+	if sessionID == 0 {
+		return 0, errors.New("session_id is required")
+	}
+
+	var userID uint
+	stmt := `SELECT user_id FROM practice_sessions WHERE id = ?`
+
+	err := r.db.QueryRowContext(ctx, stmt, sessionID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, apierror.NotFound("Session not found")
+		}
+		return 0, fmt.Errorf("failed to get session owner: %w", err)
+	}
+
+	return userID, nil
+}
+
 // GetUserLessons retrieves all piano lessons for a user
 func (r *Repository) GetUserLessons(ctx context.Context, userID uint, limit, offset int) ([]PianoLesson, error) {
 	if userID == 0 {
@@ -372,7 +400,7 @@ func (r *Repository) GetLessonByID(ctx context.Context, lessonID uint) (*PianoLe
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("lesson not found")
+			return nil, apierror.NotFound("Lesson not found")
 		}
 		return nil, fmt.Errorf("failed to get lesson: %w", err)
 	}
@@ -380,16 +408,17 @@ func (r *Repository) GetLessonByID(ctx context.Context, lessonID uint) (*PianoLe
 	return &lesson, nil
 }
 
-// GetLeaderboard retrieves top pianists by average score
+// GetLeaderboard retrieves the context organization's top pianists by best score
 func (r *Repository) GetLeaderboard(ctx context.Context, limit int) ([]UserProgress, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 10
 	}
 
 	// Get distinct users
-	stmt := `SELECT DISTINCT user_id FROM piano_lessons WHERE completed = 1 ORDER BY user_id`
+	scope, args := tenant.Members(ctx, "user_id")
+	stmt := `SELECT DISTINCT user_id FROM piano_lessons WHERE completed = 1 AND ` + scope + ` ORDER BY user_id`
 
-	rows, err := r.db.QueryContext(ctx, stmt)
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
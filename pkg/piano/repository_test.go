@@ -30,6 +30,7 @@ func setupTestDB(t testing.TB) *sql.DB {
 		time_signature TEXT,
 		key_signature TEXT,
 		total_notes INTEGER,
+		org_id INTEGER,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -7,12 +7,30 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
+// Settings holds the configurable limits of the piano app
+type Settings struct {
+	MaxUploadBytes  int64
+	LeaderboardSize int
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		MaxUploadBytes:  10 << 20, // 10MB
+		LeaderboardSize: 10,
+	}
+}
+
 // Router configures piano app routes
 type Router struct {
-	service *Service
-	auth    *PianoAuthMiddleware
+	service  *Service
+	authz    *middleware.Authorizer
+	settings Settings
 }
 
 // NewRouter creates a new piano router
@@ -20,14 +38,20 @@ func NewRouter(db *sql.DB) *Router {
 	repo := NewRepository(db)
 	service := NewService(repo)
 	return &Router{
-		service: service,
-		auth:    nil, // Auth middleware will be set if available
+		service:  service,
+		authz:    middleware.NewAuthorizer(nil),
+		settings: DefaultSettings(),
 	}
 }
 
-// SetAuthMiddleware sets the auth middleware for this router
-func (r *Router) SetAuthMiddleware(auth *PianoAuthMiddleware) {
-	r.auth = auth
+// SetAuthorizer sets the authorizer guarding per-user routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// SetSettings overrides the upload and leaderboard limits
+func (r *Router) SetSettings(settings Settings) {
+	r.settings = settings
 }
 
 // Routes returns the piano router with all configured routes
@@ -47,35 +71,39 @@ func (r *Router) Routes() chi.Router {
 	router.With(r.requireAuth).Get("/practice/{id}", r.PracticeHandler)
 	router.With(r.requireAuth).Get("/dashboard", r.DashboardHandler)
 
-	// Protected API Routes - require authentication
-	// Song operations (create requires auth)
-	router.With(r.requireAuthJSON).Post("/api/songs", r.CreateSong)
+	// Protected API Routes - the caller must be the user or related to them
+	router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUserAccess)
+
+		// Song operations (create requires auth)
+		api.Post("/api/songs", r.CreateSong)
 
-	// Lesson operations (all require auth)
-	router.With(r.requireAuthJSON).Post("/api/lessons", r.StartLesson)
-	router.With(r.requireAuthJSON).Get("/api/lessons/{id}", r.GetLesson)
-	router.With(r.requireAuthJSON).Get("/api/users/{userId}/lessons", r.GetUserLessons)
+		// Lesson operations (all require auth)
+		api.Post("/api/lessons", r.StartLesson)
+		api.Get("/api/lessons/{id}", r.GetLesson)
+		api.Get("/api/users/{userId}/lessons", r.GetUserLessons)
 
-	// Practice session operations (require auth)
-	router.With(r.requireAuthJSON).Post("/api/practice", r.SavePracticeSession)
-	router.With(r.requireAuthJSON).Get("/api/practice/{id}", r.GetPracticeSession)
+		// Practice session operations (require auth)
+		api.Post("/api/practice", r.SavePracticeSession)
+		api.Get("/api/practice/{id}", r.GetPracticeSession)
 
-	// User progress and metrics (require auth)
-	router.With(r.requireAuthJSON).Get("/api/users/{userId}/progress", r.GetUserProgress)
-	router.With(r.requireAuthJSON).Get("/api/users/{userId}/metrics", r.GetUserMetrics)
-	router.With(r.requireAuthJSON).Get("/api/users/{userId}/evaluation", r.EvaluatePerformance)
+		// User progress and metrics (require auth)
+		api.Get("/api/users/{userId}/progress", r.GetUserProgress)
+		api.Get("/api/users/{userId}/metrics", r.GetUserMetrics)
+		api.Get("/api/users/{userId}/evaluation", r.EvaluatePerformance)
 
-	// Music theory (require auth)
-	router.With(r.requireAuthJSON).Post("/api/theory-quiz", r.GenerateQuiz)
-	router.With(r.requireAuthJSON).Get("/api/sessions/{sessionId}/analysis", r.AnalyzeTheory)
+		// Music theory (require auth)
+		api.Post("/api/theory-quiz", r.GenerateQuiz)
+		api.Get("/api/sessions/{sessionId}/analysis", r.AnalyzeTheory)
 
-	// MIDI operations (require auth)
-	router.With(r.requireAuthJSON).Post("/api/midi/upload", r.UploadMIDI)
-	router.With(r.requireAuthJSON).Get("/api/midi/{sessionId}", r.DownloadMIDI)
+		// MIDI operations (require auth)
+		api.Post("/api/midi/upload", r.UploadMIDI)
+		api.Get("/api/midi/{sessionId}", r.DownloadMIDI)
 
-	// Lesson recommendations (require auth)
-	router.With(r.requireAuthJSON).Get("/api/recommend/{userId}", r.RecommendLesson)
-	router.With(r.requireAuthJSON).Get("/api/progression-path/{userId}", r.GetProgressionPath)
+		// Lesson recommendations (require auth)
+		api.Get("/api/recommend/{userId}", r.RecommendLesson)
+		api.Get("/api/progression-path/{userId}", r.GetProgressionPath)
+	})
 
 	return router
 }
@@ -85,22 +113,7 @@ func (r *Router) requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		userID := GetUserIDFromRequest(req)
 		if userID == 0 {
-			http.Redirect(w, req, "/login", http.StatusSeeOther)
-			return
-		}
-		next.ServeHTTP(w, req)
-	})
-}
-
-// requireAuthJSON is a middleware that requires authentication (returns JSON error)
-func (r *Router) requireAuthJSON(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		userID := GetUserIDFromRequest(req)
-		if userID == 0 {
-			respondJSON(w, http.StatusUnauthorized, map[string]interface{}{
-				"error":  "authentication required",
-				"status": 401,
-			})
+			http.Redirect(w, req, "/auth/login", http.StatusSeeOther)
 			return
 		}
 		next.ServeHTTP(w, req)
@@ -130,7 +143,7 @@ func (r *Router) GetSongs(w http.ResponseWriter, req *http.Request) {
 
 	songs, err := r.service.repo.GetSongs(req.Context(), difficulty, limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get songs: "+err.Error())
+		respondInternalError(w, req, "Failed to get songs", err)
 		return
 	}
 
@@ -145,14 +158,14 @@ func (r *Router) GetSongs(w http.ResponseWriter, req *http.Request) {
 func (r *Router) CreateSong(w http.ResponseWriter, req *http.Request) {
 	var reqData CreateSongRequest
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body - check JSON format")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body - check JSON format")
 		return
 	}
 
 	// Validate input
 	validator := NewValidator()
 	if err := reqData.Validate(validator); err != nil {
-		respondError(w, http.StatusBadRequest, "Validation error: "+err.Error())
+		respondServiceError(w, req, "Invalid request", err)
 		return
 	}
 
@@ -166,23 +179,27 @@ func (r *Router) CreateSong(w http.ResponseWriter, req *http.Request) {
 		TimeSignature: reqData.TimeSignature,
 		KeySignature:  reqData.KeySignature,
 		TotalNotes:    reqData.TotalNotes,
+		Duration:      reqData.Duration,
+		MIDIFile:      reqData.MIDIFile,
 	}
 
 	// Validate MIDI file if provided
 	if len(song.MIDIFile) > 0 {
 		if err := validator.ValidateMIDIFile(song.MIDIFile, 5*1024*1024); err != nil { // 5MB max
-			respondError(w, http.StatusBadRequest, "MIDI validation error: "+err.Error())
+			respondServiceError(w, req, "Invalid MIDI file", err)
 			return
 		}
 	}
 
 	id, err := r.service.repo.SaveSong(req.Context(), song)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save song: "+err.Error())
+		respondServiceError(w, req, "Failed to save song", err)
 		return
 	}
 
 	song.ID = id
+	audit.Describe(req.Context(), "song.create", "song", id)
+	audit.RecordChange(req.Context(), nil, song)
 	respondJSON(w, http.StatusCreated, song)
 }
 
@@ -191,18 +208,18 @@ func (r *Router) GetSong(w http.ResponseWriter, req *http.Request) {
 	idStr := chi.URLParam(req, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid song ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid song ID")
 		return
 	}
 
 	song, err := r.service.repo.GetSongByID(req.Context(), uint(id))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get song")
+		respondServiceError(w, req, "Failed to get song", err)
 		return
 	}
 
 	if song == nil {
-		respondError(w, http.StatusNotFound, "Song not found")
+		respondError(w, req, http.StatusNotFound, "Song not found")
 		return
 	}
 
@@ -213,13 +230,13 @@ func (r *Router) GetSong(w http.ResponseWriter, req *http.Request) {
 func (r *Router) StartLesson(w http.ResponseWriter, req *http.Request) {
 	var lesson PianoLesson
 	if err := json.NewDecoder(req.Body).Decode(&lesson); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	id, err := r.service.repo.SaveLesson(req.Context(), &lesson)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to create lesson: "+err.Error())
+		respondServiceError(w, req, "Failed to create lesson", err)
 		return
 	}
 
@@ -232,18 +249,22 @@ func (r *Router) GetLesson(w http.ResponseWriter, req *http.Request) {
 	idStr := chi.URLParam(req, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid lesson ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid lesson ID")
 		return
 	}
 
 	lesson, err := r.service.repo.GetLessonByID(req.Context(), uint(id))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get lesson")
+		respondServiceError(w, req, "Failed to get lesson", err)
 		return
 	}
 
 	if lesson == nil {
-		respondError(w, http.StatusNotFound, "Lesson not found")
+		respondError(w, req, http.StatusNotFound, "Lesson not found")
+		return
+	}
+
+	if !r.authz.AuthorizeOwner(w, req, int(lesson.UserID)) {
 		return
 	}
 
@@ -255,7 +276,7 @@ func (r *Router) GetUserLessons(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -279,7 +300,7 @@ func (r *Router) GetUserLessons(w http.ResponseWriter, req *http.Request) {
 
 	lessons, err := r.service.repo.GetUserLessons(req.Context(), uint(userID), limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get lessons")
+		respondInternalError(w, req, "Failed to get lessons", err)
 		return
 	}
 
@@ -295,14 +316,14 @@ func (r *Router) SavePracticeSession(w http.ResponseWriter, req *http.Request) {
 	var reqData CreatePracticeRequest
 
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body - check JSON format")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body - check JSON format")
 		return
 	}
 
 	// Get authenticated user ID from session
 	userID := GetUserIDFromRequest(req)
 	if userID == 0 {
-		respondError(w, http.StatusUnauthorized, "User authentication required")
+		respondError(w, req, http.StatusUnauthorized, "User authentication required")
 		return
 	}
 
@@ -312,14 +333,14 @@ func (r *Router) SavePracticeSession(w http.ResponseWriter, req *http.Request) {
 	// Validate input
 	validator := NewValidator()
 	if err := reqData.Validate(validator); err != nil {
-		respondError(w, http.StatusBadRequest, "Validation error: "+err.Error())
+		respondServiceError(w, req, "Invalid request", err)
 		return
 	}
 
 	session, err := r.service.ProcessLesson(req.Context(), uint(userID), reqData.SongID,
 		reqData.RecordedBPM, reqData.Duration, reqData.NotesCorrect, reqData.NotesTotal)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save session: "+err.Error())
+		respondServiceError(w, req, "Failed to save session", err)
 		return
 	}
 
@@ -331,7 +352,11 @@ func (r *Router) GetPracticeSession(w http.ResponseWriter, req *http.Request) {
 	idStr := chi.URLParam(req, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !r.authorizeSession(w, req, uint(id)) {
 		return
 	}
 
@@ -347,13 +372,13 @@ func (r *Router) GetUserProgress(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	progress, err := r.service.repo.GetUserProgress(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get progress")
+		respondInternalError(w, req, "Failed to get progress", err)
 		return
 	}
 
@@ -365,13 +390,13 @@ func (r *Router) GetUserMetrics(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	metrics, err := r.service.GetUserMetrics(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get metrics")
+		respondInternalError(w, req, "Failed to get metrics", err)
 		return
 	}
 
@@ -383,13 +408,13 @@ func (r *Router) EvaluatePerformance(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	evaluation, err := r.service.EvaluatePerformance(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to evaluate performance")
+		respondInternalError(w, req, "Failed to evaluate performance", err)
 		return
 	}
 
@@ -404,7 +429,7 @@ func (r *Router) GenerateQuiz(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -414,7 +439,7 @@ func (r *Router) GenerateQuiz(w http.ResponseWriter, req *http.Request) {
 
 	questions, err := r.service.GenerateMusicTheoryQuiz(req.Context(), reqData.Difficulty, reqData.Count)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to generate quiz: "+err.Error())
+		respondServiceError(w, req, "Failed to generate quiz", err)
 		return
 	}
 
@@ -429,13 +454,17 @@ func (r *Router) AnalyzeTheory(w http.ResponseWriter, req *http.Request) {
 	sessionIDStr := chi.URLParam(req, "sessionId")
 	sessionID, err := strconv.ParseUint(sessionIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !r.authorizeSession(w, req, uint(sessionID)) {
 		return
 	}
 
 	analysis, err := r.service.AnalyzeMusicTheory(req.Context(), uint(sessionID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to analyze theory")
+		respondInternalError(w, req, "Failed to analyze theory", err)
 		return
 	}
 
@@ -444,15 +473,16 @@ func (r *Router) AnalyzeTheory(w http.ResponseWriter, req *http.Request) {
 
 // UploadMIDI handles MIDI file uploads
 func (r *Router) UploadMIDI(w http.ResponseWriter, req *http.Request) {
-	// Parse multipart form
-	if err := req.ParseMultipartForm(10 * 1024 * 1024); err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to parse upload")
+	// Parse multipart form within the configured upload limit
+	req.Body = http.MaxBytesReader(w, req.Body, r.settings.MaxUploadBytes)
+	if err := req.ParseMultipartForm(r.settings.MaxUploadBytes); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Failed to parse upload")
 		return
 	}
 
 	file, _, err := req.FormFile("midi")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "No MIDI file provided")
+		respondError(w, req, http.StatusBadRequest, "No MIDI file provided")
 		return
 	}
 	defer file.Close()
@@ -470,6 +500,8 @@ func (r *Router) UploadMIDI(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	audit.Describe(req.Context(), "midi.upload", "", nil)
+	audit.RecordChange(req.Context(), nil, map[string]int{"size": len(midiData)})
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"uploaded": true,
 		"size":     len(midiData),
@@ -482,13 +514,17 @@ func (r *Router) DownloadMIDI(w http.ResponseWriter, req *http.Request) {
 	sessionIDStr := chi.URLParam(req, "sessionId")
 	sessionID, err := strconv.ParseUint(sessionIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !r.authorizeSession(w, req, uint(sessionID)) {
 		return
 	}
 
 	midiData, err := r.service.repo.GetMIDIRecording(req.Context(), uint(sessionID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get MIDI recording")
+		respondInternalError(w, req, "Failed to get MIDI recording", err)
 		return
 	}
 
@@ -498,19 +534,30 @@ func (r *Router) DownloadMIDI(w http.ResponseWriter, req *http.Request) {
 	w.Write(midiData)
 }
 
+// authorizeSession checks that the caller may act for the user a practice
+// session belongs to, writing the error response when not
+func (r *Router) authorizeSession(w http.ResponseWriter, req *http.Request, sessionID uint) bool {
+	ownerID, err := r.service.repo.GetPracticeSessionOwner(req.Context(), sessionID)
+	if err != nil {
+		respondServiceError(w, req, "Failed to get session", err)
+		return false
+	}
+	return r.authz.AuthorizeOwner(w, req, int(ownerID))
+}
+
 // RecommendLesson recommends a lesson based on user level
 func (r *Router) RecommendLesson(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	// Get user progress to determine difficulty
 	progress, err := r.service.repo.GetUserProgress(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user progress")
+		respondInternalError(w, req, "Failed to get user progress", err)
 		return
 	}
 
@@ -522,7 +569,7 @@ func (r *Router) RecommendLesson(w http.ResponseWriter, req *http.Request) {
 
 	song, err := r.service.GenerateLesson(req.Context(), uint(userID), difficulty)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to recommend lesson: "+err.Error())
+		respondServiceError(w, req, "Failed to recommend lesson", err)
 		return
 	}
 
@@ -537,13 +584,13 @@ func (r *Router) GetProgressionPath(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	path, err := r.service.GetProgressionPath(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get progression path")
+		respondInternalError(w, req, "Failed to get progression path", err)
 		return
 	}
 
@@ -555,7 +602,7 @@ func (r *Router) GetProgressionPath(w http.ResponseWriter, req *http.Request) {
 // GetLeaderboard retrieves the piano leaderboard
 func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
 	limitStr := req.URL.Query().Get("limit")
-	limit := 10
+	limit := r.settings.LeaderboardSize
 
 	if limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
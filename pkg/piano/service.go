@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"math"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
 )
 
 // Service provides business logic for piano operations
@@ -95,19 +98,21 @@ func (s *Service) CalculateCompositeScore(accuracy, tempo, theory float64) float
 // ProcessLesson processes a completed piano lesson
 func (s *Service) ProcessLesson(ctx context.Context, userID uint, songID uint, recordedBPM float64, duration float64, notesCorrect, notesTotal int) (*PracticeSession, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if songID == 0 {
-		return nil, errors.New("song_id is required")
+		return nil, apierror.Invalid("song_id", "is required")
 	}
 
 	if duration <= 0 {
-		return nil, errors.New("duration must be positive")
+		return nil, apierror.Invalid("duration", "must be positive")
 	}
 
 	if notesCorrect < 0 || notesTotal <= 0 {
-		return nil, errors.New("invalid notes: total must be positive, correct must be non-negative")
+		return nil, apierror.Validation("notes_total must be positive and notes_correct non-negative",
+			apierror.FieldError{Field: "notes_total", Message: "must be positive"},
+			apierror.FieldError{Field: "notes_correct", Message: "must be non-negative"})
 	}
 
 	// Retrieve song to get target BPM
@@ -117,7 +122,7 @@ func (s *Service) ProcessLesson(ctx context.Context, userID uint, songID uint, r
 	}
 
 	if song == nil {
-		return nil, errors.New("song not found")
+		return nil, apierror.NotFound("Song not found")
 	}
 
 	session := &PracticeSession{
@@ -140,6 +145,7 @@ func (s *Service) ProcessLesson(ctx context.Context, userID uint, songID uint, r
 	if err != nil {
 		return nil, fmt.Errorf("failed to save practice session: %w", err)
 	}
+	metrics.SessionsSaved.Inc("piano")
 
 	session.ID = id
 	return session, nil
@@ -148,7 +154,7 @@ func (s *Service) ProcessLesson(ctx context.Context, userID uint, songID uint, r
 // GenerateLesson creates a practice lesson recommendation based on user skill level
 func (s *Service) GenerateLesson(ctx context.Context, userID uint, difficulty string) (*Song, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if difficulty == "" {
@@ -158,7 +164,7 @@ func (s *Service) GenerateLesson(ctx context.Context, userID uint, difficulty st
 	// Validate difficulty
 	validDifficulties := map[string]bool{"beginner": true, "intermediate": true, "advanced": true, "expert": true}
 	if !validDifficulties[difficulty] {
-		return nil, errors.New("invalid difficulty level")
+		return nil, apierror.Invalid("difficulty", "is invalid")
 	}
 
 	// Get a random song of the requested difficulty from the repository
@@ -168,7 +174,7 @@ func (s *Service) GenerateLesson(ctx context.Context, userID uint, difficulty st
 	}
 
 	if len(songs) == 0 {
-		return nil, errors.New("no songs available for difficulty level")
+		return nil, apierror.NotFound("No songs available for difficulty level")
 	}
 
 	return &songs[0], nil
@@ -177,7 +183,7 @@ func (s *Service) GenerateLesson(ctx context.Context, userID uint, difficulty st
 // GetProgressionPath determines the optimal learning path for a user
 func (s *Service) GetProgressionPath(ctx context.Context, userID uint) ([]string, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	// Get user progress
@@ -273,7 +279,7 @@ func (s *Service) GenerateMusicTheoryQuiz(ctx context.Context, difficulty string
 	// Validate difficulty
 	validDifficulties := map[string]bool{"beginner": true, "intermediate": true, "advanced": true, "expert": true}
 	if !validDifficulties[difficulty] {
-		return nil, errors.New("invalid difficulty level")
+		return nil, apierror.Invalid("difficulty", "is invalid")
 	}
 
 	// Get theory questions from repository
@@ -283,7 +289,7 @@ func (s *Service) GenerateMusicTheoryQuiz(ctx context.Context, difficulty string
 	}
 
 	if len(questions) == 0 {
-		return nil, errors.New("no theory questions available for difficulty")
+		return nil, apierror.NotFound("No theory questions available for difficulty")
 	}
 
 	// Limit to requested count
@@ -3,6 +3,8 @@ package piano
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
 )
 
 // Validator provides input validation for Piano app operations
@@ -16,27 +18,27 @@ func NewValidator() *Validator {
 // ValidateSongInput validates song creation/update input
 func (v *Validator) ValidateSongInput(title, composer string, bpm int, difficulty string) error {
 	if strings.TrimSpace(title) == "" {
-		return fmt.Errorf("title is required")
+		return apierror.Invalid("title", "is required")
 	}
 
 	if len(title) > 255 {
-		return fmt.Errorf("title must be 255 characters or less")
+		return apierror.Invalid("title", "must be 255 characters or less")
 	}
 
 	if strings.TrimSpace(composer) == "" {
-		return fmt.Errorf("composer is required")
+		return apierror.Invalid("composer", "is required")
 	}
 
 	if len(composer) > 255 {
-		return fmt.Errorf("composer must be 255 characters or less")
+		return apierror.Invalid("composer", "must be 255 characters or less")
 	}
 
 	if bpm < 30 || bpm > 300 {
-		return fmt.Errorf("BPM must be between 30 and 300")
+		return apierror.Invalid("bpm", "must be between 30 and 300")
 	}
 
 	if !v.isValidDifficulty(difficulty) {
-		return fmt.Errorf("difficulty must be one of: beginner, intermediate, advanced, expert")
+		return apierror.Invalid("difficulty", "must be one of: beginner, intermediate, advanced, expert")
 	}
 
 	return nil
@@ -45,27 +47,27 @@ func (v *Validator) ValidateSongInput(title, composer string, bpm int, difficult
 // ValidateLessonInput validates lesson creation input
 func (v *Validator) ValidateLessonInput(userID, songID uint, duration float64, notesCorrect, notesTotal int) error {
 	if userID == 0 {
-		return fmt.Errorf("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 
 	if songID == 0 {
-		return fmt.Errorf("song_id is required")
+		return apierror.Invalid("song_id", "is required")
 	}
 
 	if duration < 0 {
-		return fmt.Errorf("duration must be non-negative")
+		return apierror.Invalid("duration", "must be non-negative")
 	}
 
 	if notesCorrect < 0 {
-		return fmt.Errorf("notes_correct must be non-negative")
+		return apierror.Invalid("notes_correct", "must be non-negative")
 	}
 
 	if notesTotal < 0 {
-		return fmt.Errorf("notes_total must be non-negative")
+		return apierror.Invalid("notes_total", "must be non-negative")
 	}
 
 	if notesCorrect > notesTotal {
-		return fmt.Errorf("notes_correct cannot exceed notes_total")
+		return apierror.Invalid("notes_correct", "cannot exceed notes_total")
 	}
 
 	return nil
@@ -74,31 +76,31 @@ func (v *Validator) ValidateLessonInput(userID, songID uint, duration float64, n
 // ValidatePracticeSessionInput validates practice session input
 func (v *Validator) ValidatePracticeSessionInput(userID, songID, lessonID uint, duration float64, notesHit, notesTotal int, tempoAvg float64) error {
 	if userID == 0 {
-		return fmt.Errorf("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 
 	if songID == 0 {
-		return fmt.Errorf("song_id is required")
+		return apierror.Invalid("song_id", "is required")
 	}
 
 	if duration < 0 {
-		return fmt.Errorf("duration must be non-negative")
+		return apierror.Invalid("duration", "must be non-negative")
 	}
 
 	if notesHit < 0 {
-		return fmt.Errorf("notes_hit must be non-negative")
+		return apierror.Invalid("notes_hit", "must be non-negative")
 	}
 
 	if notesTotal < 0 {
-		return fmt.Errorf("notes_total must be non-negative")
+		return apierror.Invalid("notes_total", "must be non-negative")
 	}
 
 	if notesHit > notesTotal {
-		return fmt.Errorf("notes_hit cannot exceed notes_total")
+		return apierror.Invalid("notes_hit", "cannot exceed notes_total")
 	}
 
 	if tempoAvg < 0 || tempoAvg > 500 {
-		return fmt.Errorf("tempo_average must be between 0 and 500 BPM")
+		return apierror.Invalid("tempo_average", "must be between 0 and 500 BPM")
 	}
 
 	return nil
@@ -107,7 +109,7 @@ func (v *Validator) ValidatePracticeSessionInput(userID, songID, lessonID uint,
 // ValidateScoreInput validates score input (0-100)
 func (v *Validator) ValidateScoreInput(score float64) error {
 	if score < 0 || score > 100 {
-		return fmt.Errorf("score must be between 0 and 100")
+		return apierror.Invalid("score", "must be between 0 and 100")
 	}
 	return nil
 }
@@ -115,15 +117,15 @@ func (v *Validator) ValidateScoreInput(score float64) error {
 // ValidatePagination validates pagination parameters
 func (v *Validator) ValidatePagination(limit, offset int) error {
 	if limit < 1 {
-		return fmt.Errorf("limit must be at least 1")
+		return apierror.Invalid("limit", "must be at least 1")
 	}
 
 	if limit > 1000 {
-		return fmt.Errorf("limit cannot exceed 1000")
+		return apierror.Invalid("limit", "cannot exceed 1000")
 	}
 
 	if offset < 0 {
-		return fmt.Errorf("offset must be non-negative")
+		return apierror.Invalid("offset", "must be non-negative")
 	}
 
 	return nil
@@ -132,16 +134,16 @@ func (v *Validator) ValidatePagination(limit, offset int) error {
 // ValidateMIDIFile validates MIDI file data
 func (v *Validator) ValidateMIDIFile(data []byte, maxSizeBytes int64) error {
 	if len(data) == 0 {
-		return fmt.Errorf("MIDI file is empty")
+		return apierror.Invalid("midi_file", "is empty")
 	}
 
 	if int64(len(data)) > maxSizeBytes {
-		return fmt.Errorf("MIDI file is too large (max %d bytes)", maxSizeBytes)
+		return apierror.Invalid("midi_file", fmt.Sprintf("is too large (max %d bytes)", maxSizeBytes))
 	}
 
 	// Check MIDI header signature
 	if len(data) < 4 || data[0] != 0x4D || data[1] != 0x54 || data[2] != 0x68 || data[3] != 0x64 {
-		return fmt.Errorf("invalid MIDI file format (must start with 'MThd')")
+		return apierror.Invalid("midi_file", "must start with 'MThd'")
 	}
 
 	return nil
@@ -150,7 +152,7 @@ func (v *Validator) ValidateMIDIFile(data []byte, maxSizeBytes int64) error {
 // ValidateUserID validates user ID
 func (v *Validator) ValidateUserID(userID int) error {
 	if userID <= 0 {
-		return fmt.Errorf("user_id must be positive")
+		return apierror.Invalid("user_id", "must be positive")
 	}
 	return nil
 }
@@ -158,7 +160,7 @@ func (v *Validator) ValidateUserID(userID int) error {
 // ValidateSongID validates song ID
 func (v *Validator) ValidateSongID(songID uint) error {
 	if songID == 0 {
-		return fmt.Errorf("song_id is required")
+		return apierror.Invalid("song_id", "is required")
 	}
 	return nil
 }
@@ -184,6 +186,10 @@ type CreateSongRequest struct {
 	TimeSignature string `json:"time_signature"`
 	KeySignature  string `json:"key_signature"`
 	TotalNotes    int    `json:"total_notes"`
+	// Duration is the song's length in seconds
+	Duration float64 `json:"duration"`
+	// MIDIFile is the song's MIDI data, base64 encoded in JSON
+	MIDIFile []byte `json:"midi_file"`
 }
 
 // Validate validates the entire request
@@ -193,27 +199,27 @@ func (csr *CreateSongRequest) Validate(v *Validator) error {
 	}
 
 	if csr.TotalNotes < 1 {
-		return fmt.Errorf("total_notes must be at least 1")
+		return apierror.Invalid("total_notes", "must be at least 1")
 	}
 
 	if csr.TotalNotes > 10000 {
-		return fmt.Errorf("total_notes cannot exceed 10000")
+		return apierror.Invalid("total_notes", "cannot exceed 10000")
 	}
 
 	if strings.TrimSpace(csr.TimeSignature) == "" {
-		return fmt.Errorf("time_signature is required")
+		return apierror.Invalid("time_signature", "is required")
 	}
 
 	if len(csr.TimeSignature) > 10 {
-		return fmt.Errorf("time_signature must be 10 characters or less")
+		return apierror.Invalid("time_signature", "must be 10 characters or less")
 	}
 
 	if strings.TrimSpace(csr.KeySignature) == "" {
-		return fmt.Errorf("key_signature is required")
+		return apierror.Invalid("key_signature", "is required")
 	}
 
 	if len(csr.KeySignature) > 20 {
-		return fmt.Errorf("key_signature must be 20 characters or less")
+		return apierror.Invalid("key_signature", "must be 20 characters or less")
 	}
 
 	return nil
@@ -240,11 +246,11 @@ func (cpr *CreatePracticeRequest) Validate(v *Validator) error {
 	}
 
 	if cpr.Duration < 0 {
-		return fmt.Errorf("duration must be non-negative")
+		return apierror.Invalid("duration", "must be non-negative")
 	}
 
 	if cpr.RecordedBPM < 0 || cpr.RecordedBPM > 500 {
-		return fmt.Errorf("recorded_bpm must be between 0 and 500")
+		return apierror.Invalid("recorded_bpm", "must be between 0 and 500")
 	}
 
 	if err := v.ValidatePracticeSessionInput(uint(cpr.UserID), cpr.SongID, 0, cpr.Duration, cpr.NotesCorrect, cpr.NotesTotal, cpr.RecordedBPM); err != nil {
@@ -0,0 +1,123 @@
+package privacy
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by the privacy service
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrConfirmationMissing = errors.New("confirm_username must match the account's username")
+	ErrPasswordRequired    = errors.New("password must be your current password to erase another account")
+)
+
+// Receipt records an account erasure. It is kept after the account is gone
+// and holds only IDs and counts.
+type Receipt struct {
+	ID          string           `json:"id"`
+	UserID      uint             `json:"user_id"`
+	RequestedBy uint             `json:"requested_by"`
+	RowsDeleted map[string]int64 `json:"rows_deleted"`
+	// RowsAnonymized counts rows kept with the user's ID, IP and recorded
+	// changes cleared
+	RowsAnonymized map[string]int64 `json:"rows_anonymized,omitempty"`
+	FilesDeleted   int              `json:"files_deleted"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// DeleteAccountRequest is the payload for DELETE /privacy/users/{userId}
+type DeleteAccountRequest struct {
+	ConfirmUsername string `json:"confirm_username"`
+	// Password is the caller's own password, required to erase someone else's account
+	Password string `json:"password,omitempty"`
+}
+
+// Manifest is written to manifest.json at the root of an export archive
+type Manifest struct {
+	UserID     uint           `json:"user_id"`
+	Username   string         `json:"username"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Tables     map[string]int `json:"tables"`
+	Files      []string       `json:"files"`
+}
+
+// tableSpec describes where one table keeps a user's rows
+type tableSpec struct {
+	// App is the directory the table is exported under
+	App   string
+	Table string
+	// Where selects the user's rows, with a single ? for the user ID
+	Where string
+	// Omit lists secret columns left out of exports
+	Omit []string
+	// Files maps BLOB columns to the extension they are exported with
+	Files map[string]string
+	// EraseOnly tables are deleted but not exported, because the rows are
+	// bookkeeping or belong to other users
+	EraseOnly bool
+	// Anonymize is the SET clause that strips the user from rows that must
+	// be kept; such rows are updated instead of deleted
+	Anonymize string
+}
+
+const byUserID = "user_id = ?"
+
+// userTables lists every table holding a user's data, children before
+// parents so rows are erased in an order the foreign keys accept. A table
+// added to any migration target with user data must be listed here.
+var userTables = []tableSpec{
+	{App: "reading", Table: "comprehension_tests", Where: "session_id IN (SELECT id FROM reading_sessions WHERE user_id = ?)"},
+	{App: "reading", Table: "reading_sessions", Where: byUserID},
+	{App: "reading", Table: "reading_progress", Where: byUserID},
+
+	{App: "piano", Table: "practice_sessions", Where: byUserID, Files: map[string]string{"recording_midi": ".mid"}},
+	{App: "piano", Table: "piano_lessons", Where: byUserID},
+	{App: "piano", Table: "music_theory_quizzes", Where: byUserID},
+	{App: "piano", Table: "user_music_metrics", Where: byUserID},
+	{App: "piano", Table: "piano_progress", Where: byUserID},
+
+	{App: "math", Table: "results", Where: byUserID},
+	{App: "math", Table: "question_history", Where: byUserID},
+	{App: "math", Table: "mistakes", Where: byUserID},
+	{App: "math", Table: "mastery", Where: byUserID},
+	{App: "math", Table: "learning_profile", Where: byUserID},
+	{App: "math", Table: "performance_patterns", Where: byUserID},
+	{App: "math", Table: "repetition_schedule", Where: byUserID},
+	{App: "math", Table: "math_progress", Where: byUserID},
+
+	{App: "typing", Table: "typing_results", Where: byUserID},
+	{App: "typing", Table: "user_stats", Where: byUserID},
+	{App: "typing", Table: "races", Where: byUserID},
+	{App: "typing", Table: "user_racing_stats", Where: byUserID},
+	{App: "typing", Table: "typing_progress", Where: byUserID},
+
+	{App: "groups", Table: "group_members", Where: byUserID},
+	{App: "groups", Table: "group_members", Where: "group_id IN (SELECT id FROM user_groups WHERE owner_id = ?)", EraseOnly: true},
+	{App: "groups", Table: "user_groups", Where: "owner_id = ?"},
+
+	{App: "account", Table: "sessions", Where: byUserID, Omit: []string{"id", "data"}},
+	{App: "account", Table: "api_tokens", Where: byUserID, Omit: []string{"token_hash"}},
+	{App: "account", Table: "rate_limit_buckets", Where: "bucket_key = 'user:' || ?", EraseOnly: true},
+	{App: "account", Table: "idempotency_keys", Where: "scope = 'user:' || ?", EraseOnly: true},
+	// Entries by or about the user are kept for accountability, without the
+	// recorded changes, which can hold their username or token names
+	{App: "account", Table: "audit_log", Where: "target_type = 'user' AND target_id = CAST(?1 AS TEXT) AND actor_id IS NOT ?1 AND changes IS NOT NULL",
+		EraseOnly: true, Anonymize: "changes = NULL"},
+	{App: "account", Table: "audit_log", Where: "actor_id = ?", Anonymize: "actor_id = NULL, ip = NULL, changes = NULL"},
+	{App: "account", Table: "users", Where: "id = ?", Omit: []string{"password_hash"}},
+}
+
+// omits reports whether column is left out of exports
+func (t tableSpec) omits(column string) bool {
+	for _, c := range t.Omit {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// audioApps are the apps that keep recordings on disk, named
+// <app>_<userID>_<nanos>.<ext> in the app's audio directory
+var audioApps = []string{"math", "reading"}
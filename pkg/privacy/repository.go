@@ -0,0 +1,147 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// Repository reads and erases a user's rows across every app's tables
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new privacy repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// tableData holds the exported columns and rows of one table
+type tableData struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// GetUsername returns the username of an account in the context's
+// organization, so accounts elsewhere are not found
+func (r *Repository) GetUsername(ctx context.Context, userID uint) (string, error) {
+	scope, args := tenant.Members(ctx, "id")
+	var username string
+	err := r.db.QueryRowContext(ctx, `SELECT username FROM users WHERE id = ? AND `+scope,
+		append([]interface{}{userID}, args...)...).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return username, nil
+}
+
+// ExportTable reads a user's rows from a table, without its omitted columns
+func (r *Repository) ExportTable(ctx context.Context, spec tableSpec, userID uint) (*tableData, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM `+spec.Table+` WHERE `+spec.Where, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", spec.Table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &tableData{}
+	var keep []int
+	for i, c := range columns {
+		if !spec.omits(c) {
+			data.Columns = append(data.Columns, c)
+			keep = append(keep, i)
+		}
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", spec.Table, err)
+		}
+
+		row := make([]interface{}, len(keep))
+		for i, idx := range keep {
+			row[i] = values[idx]
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", spec.Table, err)
+	}
+
+	return data, nil
+}
+
+// EraseUser deletes or anonymizes every row of a user in one transaction and
+// records the receipt. beforeCommit runs after the rows are erased and before
+// the commit; if it fails nothing is erased.
+func (r *Repository) EraseUser(ctx context.Context, receipt *Receipt, beforeCommit func() error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt.RowsDeleted = make(map[string]int64)
+	receipt.RowsAnonymized = make(map[string]int64)
+	for _, spec := range userTables {
+		stmt, counts := `DELETE FROM `+spec.Table+` WHERE `+spec.Where, receipt.RowsDeleted
+		if spec.Anonymize != "" {
+			stmt, counts = `UPDATE `+spec.Table+` SET `+spec.Anonymize+` WHERE `+spec.Where, receipt.RowsAnonymized
+		}
+		result, err := tx.ExecContext(ctx, stmt, receipt.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to erase %s: %w", spec.Table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			counts[spec.Table] += n
+		}
+	}
+
+	if beforeCommit != nil {
+		if err := beforeCommit(); err != nil {
+			return err
+		}
+	}
+
+	deletedJSON, err := json.Marshal(receipt.RowsDeleted)
+	if err != nil {
+		return err
+	}
+	anonymizedJSON, err := json.Marshal(receipt.RowsAnonymized)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO deletion_receipts (id, user_id, requested_by, rows_deleted, rows_anonymized, files_deleted, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		receipt.ID, receipt.UserID, receipt.RequestedBy, string(deletedJSON), string(anonymizedJSON),
+		receipt.FilesDeleted, receipt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save deletion receipt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit erasure: %w", err)
+	}
+	return nil
+}
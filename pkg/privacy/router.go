@@ -0,0 +1,248 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/middleware"
+)
+
+// logger is the privacy package logger
+var logger = logging.Logger("privacy")
+
+// AccountChecker reports whether a caller may export or delete another
+// user's account, e.g. a parent for their child or an admin
+type AccountChecker interface {
+	CanManageAccount(ctx context.Context, callerID, userID int) (bool, error)
+}
+
+// PasswordChecker confirms a caller's current password before an account is erased
+type PasswordChecker interface {
+	ConfirmPassword(ctx context.Context, userID uint, password string) (bool, error)
+}
+
+// Settings holds where the apps keep recordings on disk
+type Settings struct {
+	// AudioDirs maps an app with recordings (math, reading) to its audio directory
+	AudioDirs map[string]string
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		AudioDirs: map[string]string{
+			"math":    "data/audio/math",
+			"reading": "data/audio/reading",
+		},
+	}
+}
+
+// Router configures data export and account erasure routes
+type Router struct {
+	service   *Service
+	authz     *middleware.Authorizer
+	accounts  AccountChecker
+	passwords PasswordChecker
+}
+
+// NewRouter creates a new privacy router
+func NewRouter(db *sql.DB) *Router {
+	return &Router{
+		service: NewService(NewRepository(db), DefaultSettings().AudioDirs),
+		authz:   middleware.NewAuthorizer(nil),
+	}
+}
+
+// SetAuthorizer sets the authorizer guarding privacy routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// SetAccountChecker sets who may manage other users' accounts. Without one
+// users may only export or delete their own account.
+func (r *Router) SetAccountChecker(accounts AccountChecker) {
+	r.accounts = accounts
+}
+
+// SetPasswordChecker sets how callers re-authenticate before erasing another
+// user's account. Without one users may only erase their own account.
+func (r *Router) SetPasswordChecker(passwords PasswordChecker) {
+	r.passwords = passwords
+}
+
+// SetSettings overrides the audio directories searched for recordings
+func (r *Router) SetSettings(settings Settings) {
+	r.service.audioDirs = settings.AudioDirs
+}
+
+// Routes returns the privacy router with all configured routes
+func (r *Router) Routes() chi.Router {
+	router := chi.NewRouter()
+
+	router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUser)
+		api.Use(r.requireAccountAccess)
+
+		api.Get("/users/{userId}/export", r.ExportUser)
+		api.Delete("/users/{userId}", r.DeleteUser)
+	})
+
+	return router
+}
+
+// requireAccountAccess rejects callers who may not manage the {userId} account
+func (r *Router) requireAccountAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(req, "userId"))
+		if err != nil || userID <= 0 {
+			respondError(w, req, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+
+		callerID, _ := middleware.CallerID(req)
+		allowed := callerID == userID
+		if !allowed && r.accounts != nil {
+			if allowed, err = r.accounts.CanManageAccount(req.Context(), callerID, userID); err != nil {
+				respondInternalError(w, req, "Failed to check account access", err)
+				return
+			}
+		}
+		if !allowed {
+			respondError(w, req, http.StatusForbidden, "not allowed to manage this account")
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// ExportUser sends a ZIP archive of everything stored about a user. The
+// archive is built in a temporary file so a failure part way through is
+// reported as an error rather than a truncated download.
+func (r *Router) ExportUser(w http.ResponseWriter, req *http.Request) {
+	userID, _ := strconv.ParseUint(chi.URLParam(req, "userId"), 10, 64)
+
+	tmp, err := os.CreateTemp("", "unified-export-*.zip")
+	if err != nil {
+		respondInternalError(w, req, "Failed to export data", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := r.service.Export(req.Context(), uint(userID), tmp); err != nil {
+		respondServiceError(w, req, err, "Failed to export data")
+		return
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		respondInternalError(w, req, "Failed to export data", err)
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.zip", userID, time.Now().UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, tmp)
+}
+
+// DeleteUser erases an account and all of its data and returns the receipt
+func (r *Router) DeleteUser(w http.ResponseWriter, req *http.Request) {
+	userID, _ := strconv.ParseUint(chi.URLParam(req, "userId"), 10, 64)
+
+	var body DeleteAccountRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	callerID, _ := middleware.CallerID(req)
+	if uint64(callerID) != userID {
+		if !r.confirmPassword(w, req, callerID, body.Password) {
+			return
+		}
+	}
+
+	receipt, err := r.service.Erase(req.Context(), uint(userID), uint(callerID), body.ConfirmUsername)
+	if err != nil {
+		respondServiceError(w, req, err, "Failed to delete account")
+		return
+	}
+
+	audit.Describe(req.Context(), "account.erase", "user", userID)
+	audit.RecordChange(req.Context(), nil, map[string]interface{}{
+		"receipt_id":    receipt.ID,
+		"files_deleted": receipt.FilesDeleted,
+	})
+	respondJSON(w, http.StatusOK, receipt)
+}
+
+// confirmPassword checks the caller's password before they erase someone
+// else's account, writing the error response when it does not match
+func (r *Router) confirmPassword(w http.ResponseWriter, req *http.Request, callerID int, password string) bool {
+	if r.passwords == nil || password == "" {
+		respondError(w, req, http.StatusForbidden, ErrPasswordRequired.Error())
+		return false
+	}
+
+	ok, err := r.passwords.ConfirmPassword(req.Context(), uint(callerID), password)
+	if err != nil {
+		respondInternalError(w, req, "Failed to confirm password", err)
+		return false
+	}
+	if !ok {
+		respondError(w, req, http.StatusForbidden, ErrPasswordRequired.Error())
+		return false
+	}
+	return true
+}
+
+// respondServiceError maps service errors to HTTP statuses
+func respondServiceError(w http.ResponseWriter, req *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		respondError(w, req, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConfirmationMissing):
+		respondError(w, req, http.StatusBadRequest, err.Error())
+	default:
+		respondInternalError(w, req, fallback, err)
+	}
+}
+
+// Helper function to respond with JSON
+func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// Helper function to respond with a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
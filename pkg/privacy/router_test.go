@@ -0,0 +1,143 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
+	"github.com/jgirmay/unified-go/internal/tenant"
+)
+
+// guardians lets each parent ID manage the listed child IDs
+type guardians map[int][]int
+
+func (g guardians) CanManageAccount(ctx context.Context, callerID, userID int) (bool, error) {
+	for _, id := range g[callerID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// passwords maps caller IDs to their current password
+type passwords map[uint]string
+
+func (p passwords) ConfirmPassword(ctx context.Context, userID uint, password string) (bool, error) {
+	return p[userID] == password, nil
+}
+
+// serveAs routes a request as the given caller, or anonymously for 0
+func serveAs(h http.Handler, callerID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if callerID != 0 {
+		req = req.WithContext(middleware.WithCaller(req.Context(), int(callerID)))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestPrivacyRoutes(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	childID := seedUser(t, db, audioDirs, "child")
+	parentID := seedUser(t, db, audioDirs, "parent")
+	strangerID := seedUser(t, db, audioDirs, "stranger")
+
+	r := &Router{service: service, authz: middleware.NewAuthorizer(nil)}
+	r.SetAccountChecker(guardians{int(parentID): {int(childID)}})
+	r.SetPasswordChecker(passwords{parentID: "parent-password"})
+	h := r.Routes()
+
+	if w := serveAs(h, 0, "GET", "/users/1/export", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous caller, got %d", w.Code)
+	}
+	if w := serveAs(h, strangerID, "GET", "/users/1/export", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 exporting another user's data, got %d", w.Code)
+	}
+	if w := serveAs(h, childID, "DELETE", "/users/2", map[string]string{"confirm_username": "parent"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for child deleting parent, got %d", w.Code)
+	}
+	if w := serveAs(h, parentID, "GET", "/users/abc/export", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid user ID, got %d", w.Code)
+	}
+
+	w := serveAs(h, parentID, "GET", "/users/1/export", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for parent exporting child's data, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Expected application/zip, got %q", ct)
+	}
+	if files := readZip(t, w.Body.Bytes()); files["manifest.json"] == nil {
+		t.Error("Expected a manifest in the export")
+	}
+
+	if w := serveAs(h, parentID, "DELETE", "/users/1", map[string]string{"confirm_username": "parent", "password": "parent-password"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a mismatched confirmation, got %d", w.Code)
+	}
+
+	// Erasing someone else's account needs the caller's password
+	if w := serveAs(h, parentID, "DELETE", "/users/1", map[string]string{"confirm_username": "child"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the caller's password, got %d", w.Code)
+	}
+	if w := serveAs(h, parentID, "DELETE", "/users/1", map[string]string{"confirm_username": "child", "password": "guess"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 with a wrong password, got %d", w.Code)
+	}
+
+	w = serveAs(h, parentID, "DELETE", "/users/1", map[string]string{"confirm_username": "child", "password": "parent-password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting child's account, got %d: %s", w.Code, w.Body.String())
+	}
+	var receipt Receipt
+	json.NewDecoder(w.Body).Decode(&receipt)
+	if receipt.ID == "" || receipt.UserID != childID || receipt.RequestedBy != parentID {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+
+	if w := serveAs(h, parentID, "GET", "/users/1/export", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 exporting an erased account, got %d", w.Code)
+	}
+}
+
+func TestPrivacyRoutesStayInOrganization(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	aliceID := seedUser(t, db, audioDirs, "alice")
+	if _, err := db.Exec(`INSERT INTO organizations (slug, name) VALUES ('other', 'Other')`); err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+
+	// An admin of the other organization, were the account checker to allow it
+	r := &Router{service: service, authz: middleware.NewAuthorizer(nil)}
+	r.SetAccountChecker(guardians{99: {int(aliceID)}})
+	r.SetPasswordChecker(passwords{99: "admin-password"})
+	h := r.Routes()
+
+	other, err := tenant.NewStore(db).GetBySlug(context.Background(), "other")
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	inOther := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, req.WithContext(tenant.NewContext(req.Context(), other)))
+	})
+
+	body := map[string]string{"confirm_username": "alice", "password": "admin-password"}
+	if w := serveAs(inOther, 99, "DELETE", fmt.Sprintf("/users/%d", aliceID), body); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 erasing an account of another organization, got %d", w.Code)
+	}
+	if w := serveAs(inOther, 99, "GET", fmt.Sprintf("/users/%d/export", aliceID), nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 exporting an account of another organization, got %d", w.Code)
+	}
+}
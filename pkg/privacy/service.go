@@ -0,0 +1,352 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Service builds data exports and erases accounts
+type Service struct {
+	repo      *Repository
+	audioDirs map[string]string
+}
+
+// NewService creates a new privacy service. audioDirs maps an app in
+// audioApps to the directory holding its recordings.
+func NewService(repo *Repository, audioDirs map[string]string) *Service {
+	return &Service{
+		repo:      repo,
+		audioDirs: audioDirs,
+	}
+}
+
+// Export writes a ZIP archive of everything stored about a user to w: each
+// table as JSON and CSV, practice recordings as MIDI files and the user's
+// audio recordings, with a manifest.json listing the contents
+func (s *Service) Export(ctx context.Context, userID uint, w io.Writer) error {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	manifest := &Manifest{
+		UserID:     userID,
+		Username:   username,
+		ExportedAt: time.Now().UTC(),
+		Tables:     make(map[string]int),
+	}
+	zw := &archive{Writer: zip.NewWriter(w), modified: manifest.ExportedAt}
+
+	for _, spec := range userTables {
+		if spec.EraseOnly {
+			continue
+		}
+		data, err := s.repo.ExportTable(ctx, spec, userID)
+		if err != nil {
+			return err
+		}
+		if err := writeTable(zw, spec, data, manifest); err != nil {
+			return err
+		}
+	}
+
+	files, err := s.audioFiles(userID)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := path.Join("audio", f.app, filepath.Base(f.path))
+		if err := zw.copyFile(name, f.path); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	if err := zw.writeJSON("manifest.json", manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Erase deletes every row and recording of a user and returns the receipt.
+// confirmUsername must match the account's username. Recordings are moved
+// aside before the rows are committed and put back if the commit fails, so
+// either everything is erased or nothing is.
+func (s *Service) Erase(ctx context.Context, userID, requestedBy uint, confirmUsername string) (*Receipt, error) {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if confirmUsername != username {
+		return nil, ErrConfirmationMissing
+	}
+
+	id, err := newReceiptID()
+	if err != nil {
+		return nil, err
+	}
+	receipt := &Receipt{
+		ID:          id,
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	files, err := s.audioFiles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var staged []stagedFile
+	err = s.repo.EraseUser(ctx, receipt, func() error {
+		var err error
+		staged, err = stageFiles(receipt.ID, files)
+		receipt.FilesDeleted = len(staged)
+		return err
+	})
+	if err != nil {
+		restoreFiles(staged)
+		return nil, err
+	}
+
+	purgeFiles(staged)
+	logger.InfoContext(ctx, "account erased",
+		"user_id", userID, "requested_by", requestedBy, "receipt_id", receipt.ID,
+		"files_deleted", receipt.FilesDeleted)
+	return receipt, nil
+}
+
+// audioFile is a recording on disk
+type audioFile struct {
+	app  string
+	path string
+}
+
+// audioFiles lists a user's recordings in every app's audio directory
+func (s *Service) audioFiles(userID uint) ([]audioFile, error) {
+	var files []audioFile
+	for _, app := range audioApps {
+		dir := s.audioDirs[app]
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s recordings: %w", app, err)
+		}
+
+		prefix := fmt.Sprintf("%s_%d_", app, userID)
+		for _, e := range entries {
+			if e.Type().IsRegular() && strings.HasPrefix(e.Name(), prefix) {
+				files = append(files, audioFile{app: app, path: filepath.Join(dir, e.Name())})
+			}
+		}
+	}
+	return files, nil
+}
+
+// stagedFile is a recording moved aside while its erasure is pending
+type stagedFile struct {
+	from, to string
+}
+
+// stageFiles moves files into a .erasure-<id> directory next to them and
+// returns the files moved so far
+func stageFiles(id string, files []audioFile) ([]stagedFile, error) {
+	var staged []stagedFile
+	for _, f := range files {
+		trash := filepath.Join(filepath.Dir(f.path), ".erasure-"+id)
+		if err := os.MkdirAll(trash, 0700); err != nil {
+			return staged, fmt.Errorf("failed to stage recordings: %w", err)
+		}
+		to := filepath.Join(trash, filepath.Base(f.path))
+		if err := os.Rename(f.path, to); err != nil {
+			return staged, fmt.Errorf("failed to stage recordings: %w", err)
+		}
+		staged = append(staged, stagedFile{from: f.path, to: to})
+	}
+	return staged, nil
+}
+
+// restoreFiles moves staged files back after a failed erasure
+func restoreFiles(staged []stagedFile) {
+	for _, f := range staged {
+		if err := os.Rename(f.to, f.from); err != nil {
+			logger.Error("failed to restore recording", "path", f.from, "error", err)
+		}
+	}
+	for _, dir := range stagingDirs(staged) {
+		os.Remove(dir)
+	}
+}
+
+// purgeFiles removes the staging directories of committed erasures
+func purgeFiles(staged []stagedFile) {
+	for _, dir := range stagingDirs(staged) {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Error("failed to remove erased recordings", "dir", dir, "error", err)
+		}
+	}
+}
+
+// stagingDirs returns the distinct directories files were staged in
+func stagingDirs(staged []stagedFile) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range staged {
+		dir := filepath.Dir(f.to)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// writeTable adds a table to the archive as <app>/<table>.json and .csv.
+// BLOB values are written as files of their own and referenced by path.
+func writeTable(zw *archive, spec tableSpec, data *tableData, manifest *Manifest) error {
+	base := path.Join(spec.App, spec.Table)
+	manifest.Tables[base] = len(data.Rows)
+
+	records := make([]map[string]interface{}, 0, len(data.Rows))
+	for n, row := range data.Rows {
+		record := make(map[string]interface{}, len(row))
+		for i, value := range row {
+			column := data.Columns[i]
+			if blob, ok := value.([]byte); ok {
+				name := path.Join(base, fmt.Sprintf("%s_%s%s", rowKey(row, data.Columns, n), column, blobExtension(spec, column)))
+				if err := zw.writeFile(name, blob); err != nil {
+					return err
+				}
+				manifest.Files = append(manifest.Files, name)
+				value = name
+				row[i] = name
+			}
+			record[column] = value
+		}
+		records = append(records, record)
+	}
+	if err := zw.writeJSON(base+".json", records); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(data.Columns)
+	for _, row := range data.Rows {
+		fields := make([]string, len(row))
+		for i, value := range row {
+			fields[i] = csvValue(value)
+		}
+		cw.Write(fields)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return zw.writeFile(base+".csv", buf.Bytes())
+}
+
+// rowKey names a row's files by its id column, or by its position
+func rowKey(row []interface{}, columns []string, n int) string {
+	for i, c := range columns {
+		if c == "id" && row[i] != nil {
+			return fmt.Sprint(row[i])
+		}
+	}
+	return fmt.Sprint(n + 1)
+}
+
+// blobExtension returns the file extension for a BLOB column
+func blobExtension(spec tableSpec, column string) string {
+	if ext, ok := spec.Files[column]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+// csvValue formats a column value for CSV
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// archive is an export ZIP whose entries share one modification time
+type archive struct {
+	*zip.Writer
+	modified time.Time
+}
+
+// create starts a new entry in the archive
+func (a *archive) create(name string) (io.Writer, error) {
+	return a.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.modified})
+}
+
+// writeJSON adds an indented JSON document to the archive
+func (a *archive) writeJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return a.writeFile(name, data)
+}
+
+// writeFile adds a file with the given contents to the archive
+func (a *archive) writeFile(name string, data []byte) error {
+	f, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// copyFile adds a file from disk to the archive
+func (a *archive) copyFile(name, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	f, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, in)
+	return err
+}
+
+// newReceiptID returns a random receipt identifier
+func newReceiptID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate receipt id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
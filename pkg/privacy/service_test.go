@@ -0,0 +1,325 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jgirmay/unified-go/internal/database"
+)
+
+// setupTestService opens a migrated database and audio directories with one
+// recording for each of two users
+func setupTestService(t *testing.T) (*Service, *sql.DB, map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+
+	db, err := database.InitPool(filepath.Join(root, "unified.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	audioDirs := map[string]string{
+		"math":    filepath.Join(root, "audio", "math"),
+		"reading": filepath.Join(root, "audio", "reading"),
+	}
+	return NewService(NewRepository(db.DB), audioDirs), db.DB, audioDirs
+}
+
+// seedUser creates a user with rows in several apps and a recording on disk
+func seedUser(t *testing.T, db *sql.DB, audioDirs map[string]string, username string) uint {
+	t.Helper()
+
+	result, err := db.Exec(`INSERT INTO users (username, password_hash) VALUES (?, 'secret-hash')`, username)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	id, _ := result.LastInsertId()
+
+	statements := []string{
+		`INSERT INTO results (user_id, mode, difficulty, total_questions, correct_answers, total_time, average_time, accuracy)
+			VALUES (?, 'addition', 'easy', 10, 9, 30, 3, 90)`,
+		`INSERT INTO reading_sessions (user_id, book_id, wpm) VALUES (?, 1, 120)`,
+		`INSERT INTO comprehension_tests (session_id, question, user_answer)
+			SELECT id, 'Who?', 'Max' FROM reading_sessions WHERE user_id = ?`,
+		`INSERT INTO piano_lessons (user_id, song_id, score) VALUES (?, 1, 88)`,
+		`INSERT INTO practice_sessions (user_id, song_id, lesson_id, recording_midi)
+			SELECT user_id, 1, id, X'4D546864' FROM piano_lessons WHERE user_id = ?`,
+		`INSERT INTO typing_results (user_id, wpm, text_snippet) VALUES (?, 45, 'the quick, brown fox')`,
+		`INSERT INTO sessions (id, user_id, data, user_agent) VALUES ('session-' || ?, ?, 'cookie-data', 'Firefox')`,
+		`INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes) VALUES (?, 'ext', 'token-hash-' || ?, 'ugo_', 'math:read')`,
+		`INSERT INTO rate_limit_buckets (limiter, bucket_key, tokens, updated_at) VALUES ('api', 'user:' || ?, 5, CURRENT_TIMESTAMP)`,
+		`INSERT INTO audit_log (created_at, source, actor_id, action, path, ip) VALUES (CURRENT_TIMESTAMP, 'http', ?, 'result.create', '/math/api/results', '10.0.0.1')`,
+	}
+	for _, stmt := range statements {
+		args := []interface{}{id}
+		if strings.Count(stmt, "?") == 2 {
+			args = append(args, id)
+		}
+		if _, err := db.Exec(stmt, args...); err != nil {
+			t.Fatalf("failed to seed %q: %v", stmt, err)
+		}
+	}
+
+	for app, dir := range audioDirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(dir, fmt.Sprintf("%s_%d_1700000000.webm", app, id))
+		if err := os.WriteFile(name, []byte(app+" recording of "+username), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return uint(id)
+}
+
+func seedCatalog(t *testing.T, db *sql.DB) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO books (title) VALUES ('Max and the Moon');
+		INSERT INTO songs (title, composer) VALUES ('Minuet', 'Bach');`); err != nil {
+		t.Fatalf("failed to seed catalog: %v", err)
+	}
+}
+
+// readZip opens an export archive and returns its files by name
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("export is not a ZIP archive: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestExportContainsOnlyTheUsersData(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	aliceID := seedUser(t, db, audioDirs, "alice")
+	seedUser(t, db, audioDirs, "bob")
+
+	var buf bytes.Buffer
+	if err := service.Export(context.Background(), aliceID, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+
+	var manifest Manifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Username != "alice" || manifest.Tables["math/results"] != 1 || manifest.Tables["reading/comprehension_tests"] != 1 {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(files["math/results.json"], &results); err != nil {
+		t.Fatalf("invalid results JSON: %v", err)
+	}
+	if len(results) != 1 || results[0]["user_id"] != float64(aliceID) {
+		t.Errorf("Expected alice's one math result, got %v", results)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(files["typing/typing_results.csv"])).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid typing CSV: %v", err)
+	}
+	if len(records) != 2 || records[1][len(records[1])-2] != "the quick, brown fox" {
+		t.Errorf("Unexpected typing CSV: %v", records)
+	}
+
+	var practice []map[string]interface{}
+	json.Unmarshal(files["piano/practice_sessions.json"], &practice)
+	if len(practice) != 1 {
+		t.Fatalf("Expected one practice session, got %v", practice)
+	}
+	midiPath, _ := practice[0]["recording_midi"].(string)
+	if !strings.HasSuffix(midiPath, ".mid") || string(files[midiPath]) != "MThd" {
+		t.Errorf("Expected the recording as a MIDI file, got %q", midiPath)
+	}
+
+	if string(files["audio/math/math_1_1700000000.webm"]) != "math recording of alice" {
+		t.Error("Expected alice's math recording in the export")
+	}
+	if _, ok := files["audio/math/math_2_1700000000.webm"]; ok {
+		t.Error("Expected bob's recording to be left out")
+	}
+
+	account := string(files["account/users.json"]) + string(files["account/sessions.json"]) + string(files["account/api_tokens.json"])
+	for _, secret := range []string{"secret-hash", "cookie-data", "session-1", "token-hash-1"} {
+		if strings.Contains(account, secret) {
+			t.Errorf("Expected %q to be left out of the export", secret)
+		}
+	}
+	if _, ok := files["account/rate_limit_buckets.json"]; ok {
+		t.Error("Expected rate limit buckets to be left out of the export")
+	}
+}
+
+func TestExportUnknownUser(t *testing.T) {
+	service, _, _ := setupTestService(t)
+
+	if err := service.Export(context.Background(), 99, io.Discard); err != ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestEraseRemovesEveryRowAndRecording(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	aliceID := seedUser(t, db, audioDirs, "alice")
+	bobID := seedUser(t, db, audioDirs, "bob")
+
+	// Entries about alice record her username and role in their changes
+	for _, stmt := range []string{
+		`INSERT INTO audit_log (created_at, source, actor, action, target_type, target_id, changes)
+			VALUES (CURRENT_TIMESTAMP, 'cli', 'unifiedctl:root', 'user.create', 'user', CAST(?1 AS TEXT), '{"username":{"after":"alice"}}')`,
+		`INSERT INTO audit_log (created_at, source, actor_id, action, target_type, target_id, changes)
+			VALUES (CURRENT_TIMESTAMP, 'http', ?2, 'user.set_role', 'user', CAST(?1 AS TEXT), '{"role":{"after":"teacher"}}')`,
+		`INSERT INTO audit_log (created_at, source, actor_id, action, target_type, target_id, changes, ip)
+			VALUES (CURRENT_TIMESTAMP, 'http', ?1, 'token.create', 'user', CAST(?1 AS TEXT), '{"name":{"after":"alice laptop"}}', '10.0.0.1')`,
+	} {
+		if _, err := db.Exec(stmt, aliceID, bobID); err != nil {
+			t.Fatalf("failed to seed %q: %v", stmt, err)
+		}
+	}
+
+	receipt, err := service.Erase(context.Background(), aliceID, aliceID, "alice")
+	if err != nil {
+		t.Fatalf("Erase failed: %v", err)
+	}
+	if receipt.FilesDeleted != 2 || receipt.RowsDeleted["users"] != 1 || receipt.RowsDeleted["comprehension_tests"] != 1 {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+
+	for _, spec := range userTables {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+spec.Table+` WHERE `+spec.Where, aliceID).Scan(&n); err != nil {
+			t.Fatalf("failed to count %s: %v", spec.Table, err)
+		}
+		if n != 0 {
+			t.Errorf("Expected no rows for alice in %s, found %d", spec.Table, n)
+		}
+	}
+
+	var bobResults int
+	db.QueryRow(`SELECT COUNT(*) FROM results WHERE user_id = ?`, bobID).Scan(&bobResults)
+	if bobResults != 1 {
+		t.Error("Expected bob's data to be kept")
+	}
+
+	for app, dir := range audioDirs {
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), app+"_2_") {
+			t.Errorf("Expected only bob's %s recording to remain, found %v", app, entries)
+		}
+	}
+
+	var stored int
+	db.QueryRow(`SELECT files_deleted FROM deletion_receipts WHERE id = ? AND user_id = ?`, receipt.ID, aliceID).Scan(&stored)
+	if stored != 2 {
+		t.Error("Expected the receipt to be stored")
+	}
+
+	if receipt.RowsAnonymized["audit_log"] != 4 {
+		t.Errorf("Expected alice's audit entries and those about her to be anonymized, got %v", receipt.RowsAnonymized)
+	}
+	var audited, anonymous, redacted, bobActed int
+	db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE actor_id IS NULL AND ip IS NULL),
+		COUNT(*) FILTER (WHERE changes IS NULL), COUNT(*) FILTER (WHERE actor_id = ?) FROM audit_log`, bobID).
+		Scan(&audited, &anonymous, &redacted, &bobActed)
+	if audited != 5 || anonymous != 3 || redacted != 5 || bobActed != 2 {
+		t.Errorf("Expected every audit entry kept, alice's anonymized and none about her with changes, got %d, %d, %d and %d",
+			audited, anonymous, redacted, bobActed)
+	}
+}
+
+func TestEraseRequiresConfirmation(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	aliceID := seedUser(t, db, audioDirs, "alice")
+
+	if _, err := service.Erase(context.Background(), aliceID, aliceID, "alicia"); err != ErrConfirmationMissing {
+		t.Fatalf("Expected ErrConfirmationMissing, got %v", err)
+	}
+
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, aliceID).Scan(&n)
+	if n != 1 {
+		t.Error("Expected the account to be kept")
+	}
+}
+
+func TestEraseRestoresRecordingsWhenTransactionFails(t *testing.T) {
+	service, db, audioDirs := setupTestService(t)
+	seedCatalog(t, db)
+	aliceID := seedUser(t, db, audioDirs, "alice")
+
+	// A trigger makes the receipt insert, the last step before commit, fail
+	if _, err := db.Exec(`CREATE TRIGGER block_receipts BEFORE INSERT ON deletion_receipts
+		BEGIN SELECT RAISE(ABORT, 'receipts are read-only'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Erase(context.Background(), aliceID, aliceID, "alice"); err == nil {
+		t.Fatal("Expected Erase to fail")
+	}
+
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM results WHERE user_id = ?`, aliceID).Scan(&n)
+	if n != 1 {
+		t.Error("Expected the rows to be rolled back")
+	}
+	for _, dir := range audioDirs {
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 || entries[0].IsDir() {
+			t.Errorf("Expected the recording to be restored in %s, found %v", dir, entries)
+		}
+	}
+}
+
+func TestUserTablesCoverEverySchemaTable(t *testing.T) {
+	_, db, _ := setupTestService(t)
+
+	listed := make(map[string]bool)
+	for _, spec := range userTables {
+		listed[spec.Table] = true
+	}
+
+	rows, err := db.Query(`SELECT m.name FROM sqlite_master m, pragma_table_info(m.name) c
+		WHERE m.type = 'table' AND c.name = 'user_id'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		rows.Scan(&table)
+		// Receipts are kept after erasure by design
+		if table != "deletion_receipts" && !listed[table] {
+			t.Errorf("Table %s has a user_id column but is not in userTables", table)
+		}
+	}
+}
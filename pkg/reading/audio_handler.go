@@ -24,7 +24,6 @@ type AudioRecordResponse struct {
 	Success bool   `json:"success"`
 	AudioID string `json:"audio_id,omitempty"`
 	Message string `json:"message,omitempty"`
-	Error   string `json:"error,omitempty"`
 }
 
 // AudioTranscribeRequest represents a transcription request
@@ -39,7 +38,6 @@ type AudioTranscribeResponse struct {
 	AudioID       string `json:"audio_id"`
 	Transcript    string `json:"transcript,omitempty"`
 	Confidence    float64 `json:"confidence,omitempty"`
-	Error         string `json:"error,omitempty"`
 }
 
 // RecordAudio handles POST /api/reading/audio/record
@@ -48,22 +46,14 @@ func (h *Handler) RecordAudio(w http.ResponseWriter, r *http.Request) {
 
 	// Parse multipart form with max 10MB file size
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to parse form: %v", err),
-		})
+		respondError(w, r, http.StatusBadRequest, "Failed to parse form")
 		return
 	}
 
 	// Get audio file from request
 	file, fileHeader, err := r.FormFile("audio")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   "No audio file provided",
-		})
+		respondError(w, r, http.StatusBadRequest, "No audio file provided")
 		return
 	}
 	defer file.Close()
@@ -72,22 +62,14 @@ func (h *Handler) RecordAudio(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.FormValue("user_id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   "Invalid user_id",
-		})
+		respondError(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	// Create audio storage directory if it doesn't exist
 	audioDir := "data/audio/reading"
 	if err := os.MkdirAll(audioDir, 0755); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to create audio directory: %v", err),
-		})
+		respondInternalError(w, r, "Failed to create audio directory", err)
 		return
 	}
 
@@ -98,22 +80,14 @@ func (h *Handler) RecordAudio(w http.ResponseWriter, r *http.Request) {
 	// Save audio file
 	dst, err := os.Create(audioPath)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to save audio file: %v", err),
-		})
+		respondInternalError(w, r, "Failed to save audio file", err)
 		return
 	}
 	defer dst.Close()
 
 	// Copy uploaded file to destination
 	if _, err := io.Copy(dst, file); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to write audio file: %v", err),
-		})
+		respondInternalError(w, r, "Failed to write audio file", err)
 		return
 	}
 
@@ -131,20 +105,12 @@ func (h *Handler) TranscribeAudio(w http.ResponseWriter, r *http.Request) {
 
 	var req AudioTranscribeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioTranscribeResponse{
-			Success: false,
-			Error:   "Invalid request body",
-		})
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.AudioID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioTranscribeResponse{
-			Success: false,
-			Error:   "audio_id is required",
-		})
+		respondError(w, r, http.StatusBadRequest, "audio_id is required")
 		return
 	}
 
@@ -2,12 +2,17 @@ package reading
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
 )
 
+// logger is the reading app logger
+var logger = logging.Logger("reading")
+
 // Handler handles HTTP requests for reading app
 type Handler struct {
 	service *Service
@@ -94,33 +99,45 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// Helper function to respond with error
-func respondError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": false,
-		"error":   message,
-	})
+// Helper function to respond with a problem+json error
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	apierror.Respond(w, r, statusCode, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
+
+// respondServiceError passes client errors from the service through and
+// reports anything else as an internal error
+func respondServiceError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if apiErr, ok := apierror.As(err); ok {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	respondInternalError(w, r, message, err)
 }
 
 // SaveReadingResult saves a reading session result
 // POST /api/save_reading_result
 func (h *Handler) SaveReadingResult(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req SaveReadingResultRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	result, err := h.service.ProcessTestResult(r.Context(), req.UserID, req.BookID, req.Content, req.Duration, req.ErrorCount)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondServiceError(w, r, "Failed to process test result", err)
 		return
 	}
 
@@ -134,25 +151,25 @@ func (h *Handler) SaveReadingResult(w http.ResponseWriter, r *http.Request) {
 // GET /api/get_mastery_stats?user_id=1
 func (h *Handler) GetMasteryStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		respondError(w, http.StatusBadRequest, "user_id parameter required")
+		respondError(w, r, http.StatusBadRequest, "user_id parameter required")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		respondError(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	stats, err := h.service.GetUserStatistics(r.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to get user statistics", err)
 		return
 	}
 
@@ -166,25 +183,25 @@ func (h *Handler) GetMasteryStats(w http.ResponseWriter, r *http.Request) {
 // GET /api/reading_stats?user_id=1
 func (h *Handler) GetReadingStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		respondError(w, http.StatusBadRequest, "user_id parameter required")
+		respondError(w, r, http.StatusBadRequest, "user_id parameter required")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		respondError(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	progress, err := h.service.CalculateUserProgress(r.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to calculate progress", err)
 		return
 	}
 
@@ -198,7 +215,7 @@ func (h *Handler) GetReadingStats(w http.ResponseWriter, r *http.Request) {
 // GET /api/passages?difficulty=intermediate&limit=10&offset=0
 func (h *Handler) GetBooks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -222,7 +239,7 @@ func (h *Handler) GetBooks(w http.ResponseWriter, r *http.Request) {
 
 	books, err := h.service.repo.GetBooks(r.Context(), difficulty, limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to get books", err)
 		return
 	}
 
@@ -236,30 +253,26 @@ func (h *Handler) GetBooks(w http.ResponseWriter, r *http.Request) {
 // GET /api/passages/<id>
 func (h *Handler) GetBook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Extract book ID from path
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reading/api/passages/"), "/")
 	if len(parts) == 0 || parts[0] == "" {
-		respondError(w, http.StatusBadRequest, "Book ID required")
+		respondError(w, r, http.StatusBadRequest, "Book ID required")
 		return
 	}
 
 	bookID, err := strconv.ParseUint(parts[0], 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid book ID")
+		respondError(w, r, http.StatusBadRequest, "Invalid book ID")
 		return
 	}
 
 	book, err := h.service.repo.GetBookByID(r.Context(), uint(bookID))
 	if err != nil {
-		if errors.Is(err, errors.New("book not found")) {
-			respondError(w, http.StatusNotFound, "Book not found")
-		} else {
-			respondError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondServiceError(w, r, "Failed to get book", err)
 		return
 	}
 
@@ -273,13 +286,13 @@ func (h *Handler) GetBook(w http.ResponseWriter, r *http.Request) {
 // POST /api/submit_comprehension
 func (h *Handler) SubmitComprehension(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req SubmitComprehensionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -319,25 +332,25 @@ func (h *Handler) SubmitComprehension(w http.ResponseWriter, r *http.Request) {
 // GET /api/comprehension_stats/<session_id>
 func (h *Handler) GetComprehensionStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reading/api/comprehension_stats/"), "/")
 	if len(parts) == 0 || parts[0] == "" {
-		respondError(w, http.StatusBadRequest, "Session ID required")
+		respondError(w, r, http.StatusBadRequest, "Session ID required")
 		return
 	}
 
 	sessionID, err := strconv.ParseUint(parts[0], 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, r, http.StatusBadRequest, "Invalid session ID")
 		return
 	}
 
 	analysis, err := h.service.GetComprehensionAnalysis(r.Context(), uint(sessionID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to get comprehension analysis", err)
 		return
 	}
 
@@ -351,25 +364,25 @@ func (h *Handler) GetComprehensionStats(w http.ResponseWriter, r *http.Request)
 // GET /api/user_skills/<user_id>
 func (h *Handler) GetUserSkills(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reading/api/user_skills/"), "/")
 	if len(parts) == 0 || parts[0] == "" {
-		respondError(w, http.StatusBadRequest, "User ID required")
+		respondError(w, r, http.StatusBadRequest, "User ID required")
 		return
 	}
 
 	userID, err := strconv.ParseUint(parts[0], 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	analysis, err := h.service.AnalyzeReadingPerformance(r.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to analyze performance", err)
 		return
 	}
 
@@ -383,7 +396,7 @@ func (h *Handler) GetUserSkills(w http.ResponseWriter, r *http.Request) {
 // GET /api/get_leaderboard?limit=10
 func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -397,7 +410,7 @@ func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 
 	leaderboard, err := h.service.GetLeaderboard(r.Context(), limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to get leaderboard", err)
 		return
 	}
 
@@ -411,25 +424,25 @@ func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 // GET /api/recommend_books?user_id=1&limit=5
 func (h *Handler) RecommendBooks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		respondError(w, http.StatusBadRequest, "user_id parameter required")
+		respondError(w, r, http.StatusBadRequest, "user_id parameter required")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user_id")
+		respondError(w, r, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	recommendations, err := h.service.GetBookRecommendations(r.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, r, "Failed to get recommendations", err)
 		return
 	}
 
@@ -5,24 +5,33 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
 // TestReadingIntegration provides integration test setup
 type TestReadingIntegration struct {
 	db     *sql.DB
-	router chi.Router
+	router http.Handler
 	service *Service
 }
 
+// asUser serves requests as the given authenticated caller
+func asUser(userID int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, req.WithContext(middleware.WithCaller(req.Context(), userID)))
+	})
+}
+
 // setupIntegration creates a test database and router
 func setupIntegration(t *testing.T) *TestReadingIntegration {
 	db := setupTestDB(t)
-	router := NewRouter(db).Routes()
+	router := asUser(1, NewRouter(db).Routes())
 	repo := NewRepository(db)
 	service := NewService(repo)
 
@@ -377,7 +386,7 @@ func TestListBooksFiltering(t *testing.T) {
 // setupBenchmark creates a test database and router for benchmarks
 func setupBenchmark(b testing.TB) *TestReadingIntegration {
 	db := setupTestDB(b)
-	router := NewRouter(db).Routes()
+	router := asUser(1, NewRouter(db).Routes())
 	repo := NewRepository(db)
 	service := NewService(repo)
 
@@ -835,6 +844,38 @@ func TestGetLeaderboardHandler(t *testing.T) {
 	}
 }
 
+// TestUserAccessEnforced ensures callers cannot read another user's data
+func TestUserAccessEnforced(t *testing.T) {
+	ti := setupIntegration(t)
+	defer ti.db.Close()
+
+	// Caller 1 asking for user 2's stats is forbidden
+	req := httptest.NewRequest("GET", "/api/users/2/stats", nil)
+	w := httptest.NewRecorder()
+	ti.router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another user's stats, got %d", w.Code)
+	}
+
+	// A user_id in the body is checked too
+	body, _ := json.Marshal(map[string]interface{}{"user_id": 2, "book_id": 1, "content": "x", "time_spent": 1.0})
+	req = httptest.NewRequest("POST", "/api/sessions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	ti.router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for session posted as another user, got %d", w.Code)
+	}
+
+	// Anonymous callers are rejected
+	req = httptest.NewRequest("GET", "/api/users/1/stats", nil)
+	w = httptest.NewRecorder()
+	NewRouter(ti.db).Routes().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous caller, got %d", w.Code)
+	}
+}
+
 // TestUserIsolation ensures users cannot see each other's data
 func TestUserIsolation(t *testing.T) {
 	ti := setupIntegration(t)
@@ -867,7 +908,7 @@ func TestUserIsolation(t *testing.T) {
 	// User 2 should only see their own stats
 	req = httptest.NewRequest("GET", "/api/users/2/stats", nil)
 	w = httptest.NewRecorder()
-	ti.router.ServeHTTP(w, req)
+	asUser(2, NewRouter(ti.db).Routes()).ServeHTTP(w, req)
 
 	var stats2 ReadingStats
 	json.Unmarshal(w.Body.Bytes(), &stats2)
@@ -1109,3 +1150,63 @@ func BenchmarkGetBooks(b *testing.B) {
 		ti.router.ServeHTTP(w, req)
 	}
 }
+
+// TestSessionOwnerChecks tests that sessions fetched by ID are limited to their owner
+func TestSessionOwnerChecks(t *testing.T) {
+	ti := setupIntegration(t)
+	defer ti.db.Close()
+
+	ctx := context.Background()
+
+	book := &Book{
+		Title:        "Private Book",
+		Author:       "Author",
+		Content:      "Content read by another user in a private reading session today",
+		ReadingLevel: "beginner",
+		Language:     "English",
+		WordCount:    11,
+	}
+
+	bookID, err := ti.service.repo.SaveBook(ctx, book)
+	if err != nil {
+		t.Fatalf("Failed to save book: %v", err)
+	}
+	session, err := ti.service.ProcessTestResult(ctx, 2, bookID, book.Content, 60.0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	path := fmt.Sprintf("/api/sessions/%d", session.ID)
+	test := fmt.Sprintf(`{"session_id": %d, "question": "Q", "correct_answer": "A", "score": 50}`, session.ID)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}{
+		{"session", "GET", path, "", http.StatusForbidden},
+		{"comprehension tests", "GET", path + "/comprehension", "", http.StatusForbidden},
+		{"analysis", "GET", path + "/analysis", "", http.StatusForbidden},
+		{"save comprehension test", "POST", "/api/comprehension", test, http.StatusForbidden},
+		{"missing session", "GET", "/api/sessions/999/analysis", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			ti.router.ServeHTTP(w, req)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.statusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	saved, err := ti.service.repo.GetComprehensionTests(ctx, session.ID)
+	if err != nil || len(saved) != 0 {
+		t.Errorf("Expected no test saved for another user's session, got %d: %v", len(saved), err)
+	}
+}
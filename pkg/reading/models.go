@@ -2,9 +2,10 @@ package reading
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
 )
 
 // Book represents a book available for reading practice
@@ -78,22 +79,22 @@ type ReadingTest struct {
 // Validate performs validation on ReadingSession
 func (rs *ReadingSession) Validate() error {
 	if rs.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if rs.BookID == 0 {
-		return errors.New("book_id is required")
+		return apierror.Invalid("book_id", "is required")
 	}
 	if rs.Duration <= 0 {
-		return errors.New("duration must be positive")
+		return apierror.Invalid("duration", "must be positive")
 	}
 	if rs.WPM < 0 || rs.WPM > 500 {
-		return fmt.Errorf("wpm must be between 0 and 500, got %f", rs.WPM)
+		return apierror.Invalid("wpm", fmt.Sprintf("must be between 0 and 500, got %f", rs.WPM))
 	}
 	if rs.Accuracy < 0 || rs.Accuracy > 100 {
-		return fmt.Errorf("accuracy must be between 0 and 100, got %f", rs.Accuracy)
+		return apierror.Invalid("accuracy", fmt.Sprintf("must be between 0 and 100, got %f", rs.Accuracy))
 	}
 	if rs.ComprehensionScore < 0 || rs.ComprehensionScore > 100 {
-		return fmt.Errorf("comprehension score must be between 0 and 100, got %f", rs.ComprehensionScore)
+		return apierror.Invalid("comprehension_score", fmt.Sprintf("must be between 0 and 100, got %f", rs.ComprehensionScore))
 	}
 	return nil
 }
@@ -101,20 +102,20 @@ func (rs *ReadingSession) Validate() error {
 // Validate performs validation on Book
 func (b *Book) Validate() error {
 	if b.Title == "" {
-		return errors.New("title is required")
+		return apierror.Invalid("title", "is required")
 	}
 	if b.Content == "" {
-		return errors.New("content is required")
+		return apierror.Invalid("content", "is required")
 	}
 	if b.ReadingLevel == "" {
 		b.ReadingLevel = "intermediate"
 	}
 	validLevels := map[string]bool{"beginner": true, "intermediate": true, "advanced": true}
 	if !validLevels[b.ReadingLevel] {
-		return fmt.Errorf("invalid reading_level: %s", b.ReadingLevel)
+		return apierror.Invalid("reading_level", fmt.Sprintf("is invalid: %s", b.ReadingLevel))
 	}
 	if len(b.Content) < 50 {
-		return errors.New("content is too short (minimum 50 characters)")
+		return apierror.Invalid("content", "is too short (minimum 50 characters)")
 	}
 	return nil
 }
@@ -122,16 +123,16 @@ func (b *Book) Validate() error {
 // Validate performs validation on ComprehensionTest
 func (ct *ComprehensionTest) Validate() error {
 	if ct.SessionID == 0 {
-		return errors.New("session_id is required")
+		return apierror.Invalid("session_id", "is required")
 	}
 	if ct.Question == "" {
-		return errors.New("question is required")
+		return apierror.Invalid("question", "is required")
 	}
 	if ct.CorrectAnswer == "" {
-		return errors.New("correct_answer is required")
+		return apierror.Invalid("correct_answer", "is required")
 	}
 	if ct.Score < 0 || ct.Score > 100 {
-		return fmt.Errorf("score must be between 0 and 100, got %f", ct.Score)
+		return apierror.Invalid("score", fmt.Sprintf("must be between 0 and 100, got %f", ct.Score))
 	}
 	return nil
 }
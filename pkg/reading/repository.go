@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // Repository handles database operations for reading app
 type Repository struct {
-	db *sql.DB
+	db database.Conn
 }
 
 // NewRepository creates a new reading repository
@@ -17,6 +21,11 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// WithTx returns a reading repository whose statements run within tx
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx}
+}
+
 // SaveLesson saves a reading session to the database
 func (r *Repository) SaveLesson(ctx context.Context, session *ReadingSession) (uint, error) {
 	if session == nil {
@@ -47,7 +56,8 @@ func (r *Repository) SaveLesson(ctx context.Context, session *ReadingSession) (u
 	return uint(id), nil
 }
 
-// SaveBook saves a book to the database
+// SaveBook saves a book to the context's organization, or as shared content
+// without one
 func (r *Repository) SaveBook(ctx context.Context, book *Book) (uint, error) {
 	if book == nil {
 		return 0, errors.New("book cannot be nil")
@@ -61,12 +71,12 @@ func (r *Repository) SaveBook(ctx context.Context, book *Book) (uint, error) {
 		book.WordCount = countWords(book.Content)
 	}
 
-	stmt := `INSERT INTO books (title, author, content, reading_level, language, word_count, estimated_time_minutes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	stmt := `INSERT INTO books (title, author, content, reading_level, language, word_count, estimated_time_minutes, org_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 
 	result, err := r.db.ExecContext(ctx, stmt,
 		book.Title, book.Author, book.Content, book.ReadingLevel, book.Language,
-		book.WordCount, book.EstimatedTime)
+		book.WordCount, book.EstimatedTime, tenant.ID(ctx))
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to save book: %w", err)
@@ -80,22 +90,23 @@ func (r *Repository) SaveBook(ctx context.Context, book *Book) (uint, error) {
 	return uint(id), nil
 }
 
-// GetBookByID retrieves a book by ID
+// GetBookByID retrieves a book by ID from the context organization's catalog
 func (r *Repository) GetBookByID(ctx context.Context, bookID uint) (*Book, error) {
 	if bookID == 0 {
 		return nil, errors.New("book_id is required")
 	}
 
 	var book Book
-	stmt := `SELECT id, title, author, content, reading_level, language, word_count, estimated_time_minutes, created_at, updated_at FROM books WHERE id = ?`
+	scope, args := tenant.Catalog(ctx, "org_id")
+	stmt := `SELECT id, title, author, content, reading_level, language, word_count, estimated_time_minutes, created_at, updated_at FROM books WHERE id = ? AND ` + scope
 
-	err := r.db.QueryRowContext(ctx, stmt, bookID).Scan(
+	err := r.db.QueryRowContext(ctx, stmt, append([]interface{}{bookID}, args...)...).Scan(
 		&book.ID, &book.Title, &book.Author, &book.Content, &book.ReadingLevel,
 		&book.Language, &book.WordCount, &book.EstimatedTime, &book.CreatedAt, &book.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("book not found")
+			return nil, apierror.NotFound("Book not found")
 		}
 		return nil, fmt.Errorf("failed to get book: %w", err)
 	}
@@ -103,7 +114,8 @@ func (r *Repository) GetBookByID(ctx context.Context, bookID uint) (*Book, error
 	return &book, nil
 }
 
-// GetBooks retrieves books with optional filtering
+// GetBooks retrieves books from the context organization's catalog with
+// optional filtering
 func (r *Repository) GetBooks(ctx context.Context, difficulty string, limit, offset int) ([]Book, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 20
@@ -112,22 +124,17 @@ func (r *Repository) GetBooks(ctx context.Context, difficulty string, limit, off
 		offset = 0
 	}
 
-	stmt := `SELECT id, title, author, content, reading_level, language, word_count, estimated_time_minutes, created_at, updated_at FROM books`
+	scope, args := tenant.Catalog(ctx, "org_id")
+	stmt := `SELECT id, title, author, content, reading_level, language, word_count, estimated_time_minutes, created_at, updated_at FROM books WHERE ` + scope
 
 	if difficulty != "" {
-		stmt += ` WHERE reading_level = ?`
+		stmt += ` AND reading_level = ?`
+		args = append(args, difficulty)
 	}
 
 	stmt += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
-	var rows *sql.Rows
-	var err error
-
-	if difficulty != "" {
-		rows, err = r.db.QueryContext(ctx, stmt, difficulty, limit, offset)
-	} else {
-		rows, err = r.db.QueryContext(ctx, stmt, limit, offset)
-	}
+	rows, err := r.db.QueryContext(ctx, stmt, append(args, limit, offset)...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get books: %w", err)
@@ -288,16 +295,17 @@ func (r *Repository) GetUserStats(ctx context.Context, userID uint) (*ReadingSta
 	return stats, nil
 }
 
-// GetLeaderboard retrieves top readers by best WPM
+// GetLeaderboard retrieves the context organization's top readers by best WPM
 func (r *Repository) GetLeaderboard(ctx context.Context, limit int) ([]ReadingStats, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 10
 	}
 
 	// Get all sessions to find unique users
-	stmt := `SELECT id, user_id, book_id, start_time, end_time, wpm, accuracy, comprehension, duration, words_read, error_count, completed, created_at, updated_at FROM reading_sessions ORDER BY wpm DESC`
+	scope, args := tenant.Members(ctx, "user_id")
+	stmt := `SELECT id, user_id, book_id, start_time, end_time, wpm, accuracy, comprehension, duration, words_read, error_count, completed, created_at, updated_at FROM reading_sessions WHERE ` + scope + ` ORDER BY wpm DESC`
 
-	rows, err := r.db.QueryContext(ctx, stmt)
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions for leaderboard: %w", err)
 	}
@@ -405,7 +413,7 @@ func (r *Repository) GetSessionByID(ctx context.Context, sessionID uint) (*Readi
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("session not found")
+			return nil, apierror.NotFound("Session not found")
 		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
@@ -3,9 +3,12 @@ package reading
 import (
 	"context"
 	"database/sql"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/jgirmay/unified-go/internal/tenant"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -27,6 +30,7 @@ func setupTestDB(t testing.TB) *sql.DB {
 		language TEXT DEFAULT 'english',
 		word_count INTEGER,
 		estimated_time_minutes REAL,
+		org_id INTEGER,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -493,6 +497,63 @@ func TestGetBooks(t *testing.T) {
 	}
 }
 
+// TestGetBooksByOrganization tests that catalogs are scoped to the context's
+// organization and include shared books only when it opts in
+func TestGetBooksByOrganization(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+	lincoln := &tenant.Organization{ID: 2, Slug: "lincoln"}
+	roosevelt := &tenant.Organization{ID: 3, Slug: "roosevelt"}
+
+	titles := map[string]context.Context{
+		"Shared":    ctx,
+		"Lincoln":   tenant.NewContext(ctx, lincoln),
+		"Roosevelt": tenant.NewContext(ctx, roosevelt),
+	}
+	ids := map[string]uint{}
+	for title, bookCtx := range titles {
+		id, err := repo.SaveBook(bookCtx, &Book{
+			Title:   title,
+			Content: "This book belongs to exactly one catalog and is long enough to save.",
+		})
+		if err != nil {
+			t.Fatalf("SaveBook() error = %v", err)
+		}
+		ids[title] = id
+	}
+
+	catalog := func(org *tenant.Organization) []string {
+		books, err := repo.GetBooks(tenant.NewContext(ctx, org), "", 10, 0)
+		if err != nil {
+			t.Fatalf("GetBooks() error = %v", err)
+		}
+		var got []string
+		for _, b := range books {
+			got = append(got, b.Title)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	if got := catalog(lincoln); !reflect.DeepEqual(got, []string{"Lincoln"}) {
+		t.Errorf("GetBooks() = %v, want only lincoln's book", got)
+	}
+	lincoln.SharedContent = true
+	if got := catalog(lincoln); !reflect.DeepEqual(got, []string{"Lincoln", "Shared"}) {
+		t.Errorf("GetBooks() = %v, want lincoln's and shared books", got)
+	}
+
+	if _, err := repo.GetBookByID(tenant.NewContext(ctx, lincoln), ids["Roosevelt"]); err == nil {
+		t.Error("GetBookByID() should not return another organization's book")
+	}
+	if books, _ := repo.GetBooks(ctx, "", 10, 0); len(books) != 3 {
+		t.Errorf("GetBooks() without an organization returned %d books, want 3", len(books))
+	}
+}
+
 // TestCountWords tests word counting helper function
 func TestCountWords(t *testing.T) {
 	tests := []struct {
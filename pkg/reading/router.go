@@ -12,18 +12,53 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/audit"
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
+// Settings holds the configurable limits of the reading app
+type Settings struct {
+	AudioDir        string
+	MaxUploadBytes  int64
+	LeaderboardSize int
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		AudioDir:        "data/audio/reading",
+		MaxUploadBytes:  10 << 20, // 10MB
+		LeaderboardSize: 10,
+	}
+}
+
 // Router configures reading app routes
 type Router struct {
-	service *Service
+	service  *Service
+	authz    *middleware.Authorizer
+	settings Settings
 }
 
 // NewRouter creates a new reading router
 func NewRouter(db *sql.DB) *Router {
 	repo := NewRepository(db)
 	service := NewService(repo)
-	return &Router{service: service}
+	return &Router{
+		service:  service,
+		authz:    middleware.NewAuthorizer(nil),
+		settings: DefaultSettings(),
+	}
+}
+
+// SetAuthorizer sets the authorizer guarding per-user routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// SetSettings overrides the audio storage, upload and leaderboard limits
+func (r *Router) SetSettings(settings Settings) {
+	r.settings = settings
 }
 
 // Routes returns the reading router with all configured routes
@@ -33,32 +68,37 @@ func (r *Router) Routes() chi.Router {
 	// Index page
 	router.Get("/", IndexHandler)
 
-	// Book operations
+	// Public book catalog and content checks
 	router.Get("/api/books", r.GetBooks)
-	router.Post("/api/books", r.CreateBook)
 	router.Get("/api/books/{id}", r.GetBook)
+	router.Get("/api/leaderboard", r.GetLeaderboard)
+	router.Post("/api/validate", r.ValidateContent)
 
-	// Reading session operations
-	router.Post("/api/sessions", r.ProcessSession)
-	router.Get("/api/sessions/{id}", r.GetSession)
-	router.Get("/api/users/{userId}/sessions", r.GetUserSessions)
+	// Per-user endpoints require the caller to be the user or related to them
+	router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUserAccess)
 
-	// User statistics
-	router.Get("/api/users/{userId}/stats", r.GetUserStats)
-	router.Get("/api/users/{userId}/progress", r.GetUserProgress)
-	router.Get("/api/leaderboard", r.GetLeaderboard)
+		// Book operations
+		api.Post("/api/books", r.CreateBook)
 
-	// Comprehension tests
-	router.Post("/api/comprehension", r.SaveComprehensionTest)
-	router.Get("/api/sessions/{sessionId}/comprehension", r.GetComprehensionTests)
-	router.Get("/api/sessions/{sessionId}/analysis", r.AnalyzeComprehension)
+		// Reading session operations
+		api.Post("/api/sessions", r.ProcessSession)
+		api.Get("/api/sessions/{id}", r.GetSession)
+		api.Get("/api/users/{userId}/sessions", r.GetUserSessions)
 
-	// Content validation
-	router.Post("/api/validate", r.ValidateContent)
+		// User statistics
+		api.Get("/api/users/{userId}/stats", r.GetUserStats)
+		api.Get("/api/users/{userId}/progress", r.GetUserProgress)
+
+		// Comprehension tests
+		api.Post("/api/comprehension", r.SaveComprehensionTest)
+		api.Get("/api/sessions/{sessionId}/comprehension", r.GetComprehensionTests)
+		api.Get("/api/sessions/{sessionId}/analysis", r.AnalyzeComprehension)
 
-	// Audio recording and transcription
-	router.Post("/api/audio/record", r.RecordAudio)
-	router.Post("/api/audio/transcribe", r.TranscribeAudio)
+		// Audio recording and transcription
+		api.Post("/api/audio/record", r.RecordAudio)
+		api.Post("/api/audio/transcribe", r.TranscribeAudio)
+	})
 
 	return router
 }
@@ -86,7 +126,7 @@ func (r *Router) GetBooks(w http.ResponseWriter, req *http.Request) {
 
 	books, err := r.service.repo.GetBooks(req.Context(), difficulty, limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get books: "+err.Error())
+		respondInternalError(w, req, "Failed to get books", err)
 		return
 	}
 
@@ -101,17 +141,19 @@ func (r *Router) GetBooks(w http.ResponseWriter, req *http.Request) {
 func (r *Router) CreateBook(w http.ResponseWriter, req *http.Request) {
 	var book Book
 	if err := json.NewDecoder(req.Body).Decode(&book); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	id, err := r.service.repo.SaveBook(req.Context(), &book)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to save book: "+err.Error())
+		respondServiceError(w, req, "Failed to save book", err)
 		return
 	}
 
 	book.ID = id
+	audit.Describe(req.Context(), "book.create", "book", id)
+	audit.RecordChange(req.Context(), nil, book)
 	respondJSON(w, http.StatusCreated, book)
 }
 
@@ -120,18 +162,18 @@ func (r *Router) GetBook(w http.ResponseWriter, req *http.Request) {
 	idStr := chi.URLParam(req, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid book ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid book ID")
 		return
 	}
 
 	book, err := r.service.repo.GetBookByID(req.Context(), uint(id))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get book")
+		respondServiceError(w, req, "Failed to get book", err)
 		return
 	}
 
 	if book == nil {
-		respondError(w, http.StatusNotFound, "Book not found")
+		respondError(w, req, http.StatusNotFound, "Book not found")
 		return
 	}
 
@@ -149,14 +191,14 @@ func (r *Router) ProcessSession(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	session, err := r.service.ProcessTestResult(req.Context(), reqData.UserID, reqData.BookID,
 		reqData.Content, reqData.TimeSpent, reqData.Errors)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to process session: "+err.Error())
+		respondServiceError(w, req, "Failed to process session", err)
 		return
 	}
 
@@ -168,18 +210,22 @@ func (r *Router) GetSession(w http.ResponseWriter, req *http.Request) {
 	idStr := chi.URLParam(req, "id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
 		return
 	}
 
 	session, err := r.service.repo.GetSessionByID(req.Context(), uint(id))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get session")
+		respondServiceError(w, req, "Failed to get session", err)
 		return
 	}
 
 	if session == nil {
-		respondError(w, http.StatusNotFound, "Session not found")
+		respondError(w, req, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if !r.authz.AuthorizeOwner(w, req, int(session.UserID)) {
 		return
 	}
 
@@ -191,7 +237,7 @@ func (r *Router) GetUserSessions(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -215,7 +261,7 @@ func (r *Router) GetUserSessions(w http.ResponseWriter, req *http.Request) {
 
 	sessions, err := r.service.GetUserTestHistory(req.Context(), uint(userID), limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get sessions")
+		respondInternalError(w, req, "Failed to get sessions", err)
 		return
 	}
 
@@ -231,13 +277,13 @@ func (r *Router) GetUserStats(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	stats, err := r.service.GetUserStatistics(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get statistics")
+		respondInternalError(w, req, "Failed to get statistics", err)
 		return
 	}
 
@@ -249,13 +295,13 @@ func (r *Router) GetUserProgress(w http.ResponseWriter, req *http.Request) {
 	userIDStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	progress, err := r.service.CalculateUserProgress(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to calculate progress")
+		respondInternalError(w, req, "Failed to calculate progress", err)
 		return
 	}
 
@@ -265,7 +311,7 @@ func (r *Router) GetUserProgress(w http.ResponseWriter, req *http.Request) {
 // GetLeaderboard retrieves the reading leaderboard
 func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
 	limitStr := req.URL.Query().Get("limit")
-	limit := 10
+	limit := r.settings.LeaderboardSize
 
 	if limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
@@ -275,7 +321,7 @@ func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
 
 	stats, err := r.service.GetLeaderboard(req.Context(), limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get leaderboard")
+		respondInternalError(w, req, "Failed to get leaderboard", err)
 		return
 	}
 
@@ -289,13 +335,21 @@ func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
 func (r *Router) SaveComprehensionTest(w http.ResponseWriter, req *http.Request) {
 	var test ComprehensionTest
 	if err := json.NewDecoder(req.Body).Decode(&test); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := test.Validate(); err != nil {
+		respondServiceError(w, req, "Invalid test", err)
+		return
+	}
+	if !r.authorizeSession(w, req, test.SessionID) {
 		return
 	}
 
 	id, err := r.service.repo.SaveComprehensionTest(req.Context(), &test)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to save test: "+err.Error())
+		respondServiceError(w, req, "Failed to save test", err)
 		return
 	}
 
@@ -308,13 +362,17 @@ func (r *Router) GetComprehensionTests(w http.ResponseWriter, req *http.Request)
 	sessionIDStr := chi.URLParam(req, "sessionId")
 	sessionID, err := strconv.ParseUint(sessionIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !r.authorizeSession(w, req, uint(sessionID)) {
 		return
 	}
 
 	tests, err := r.service.repo.GetComprehensionTests(req.Context(), uint(sessionID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get tests")
+		respondInternalError(w, req, "Failed to get tests", err)
 		return
 	}
 
@@ -328,19 +386,34 @@ func (r *Router) AnalyzeComprehension(w http.ResponseWriter, req *http.Request)
 	sessionIDStr := chi.URLParam(req, "sessionId")
 	sessionID, err := strconv.ParseUint(sessionIDStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid session ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !r.authorizeSession(w, req, uint(sessionID)) {
 		return
 	}
 
 	analysis, err := r.service.GetComprehensionAnalysis(req.Context(), uint(sessionID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to analyze comprehension")
+		respondInternalError(w, req, "Failed to analyze comprehension", err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, analysis)
 }
 
+// authorizeSession checks that the caller may act for the user a reading
+// session belongs to, writing the error response when not
+func (r *Router) authorizeSession(w http.ResponseWriter, req *http.Request, sessionID uint) bool {
+	session, err := r.service.repo.GetSessionByID(req.Context(), sessionID)
+	if err != nil {
+		respondServiceError(w, req, "Failed to get session", err)
+		return false
+	}
+	return r.authz.AuthorizeOwner(w, req, int(session.UserID))
+}
+
 // ValidateContent validates reading content
 func (r *Router) ValidateContent(w http.ResponseWriter, req *http.Request) {
 	var reqData struct {
@@ -348,16 +421,12 @@ func (r *Router) ValidateContent(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	err := r.service.ValidateTestContent(reqData.Content)
-	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"valid": false,
-			"error": err.Error(),
-		})
+	if err := r.service.ValidateTestContent(reqData.Content); err != nil {
+		respondServiceError(w, req, "Failed to validate content", err)
 		return
 	}
 
@@ -370,24 +439,17 @@ func (r *Router) ValidateContent(w http.ResponseWriter, req *http.Request) {
 func (r *Router) RecordAudio(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Parse multipart form with max 10MB file size
-	if err := req.ParseMultipartForm(10 << 20); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to parse form: %v", err),
-		})
+	// Parse multipart form within the configured upload limit
+	req.Body = http.MaxBytesReader(w, req.Body, r.settings.MaxUploadBytes)
+	if err := req.ParseMultipartForm(r.settings.MaxUploadBytes); err != nil {
+		respondError(w, req, http.StatusBadRequest, "Failed to parse form")
 		return
 	}
 
 	// Get audio file from request
 	file, fileHeader, err := req.FormFile("audio")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   "No audio file provided",
-		})
+		respondError(w, req, http.StatusBadRequest, "No audio file provided")
 		return
 	}
 	defer file.Close()
@@ -396,22 +458,14 @@ func (r *Router) RecordAudio(w http.ResponseWriter, req *http.Request) {
 	userIDStr := req.FormValue("user_id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   "Invalid user_id",
-		})
+		respondError(w, req, http.StatusBadRequest, "Invalid user_id")
 		return
 	}
 
 	// Create audio storage directory if it doesn't exist
-	audioDir := "data/audio/reading"
+	audioDir := r.settings.AudioDir
 	if err := os.MkdirAll(audioDir, 0755); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to create audio directory: %v", err),
-		})
+		respondInternalError(w, req, "Failed to create audio directory", err)
 		return
 	}
 
@@ -422,22 +476,14 @@ func (r *Router) RecordAudio(w http.ResponseWriter, req *http.Request) {
 	// Save audio file
 	dst, err := os.Create(audioPath)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to save audio file: %v", err),
-		})
+		respondInternalError(w, req, "Failed to save audio file", err)
 		return
 	}
 	defer dst.Close()
 
 	// Copy uploaded file to destination
 	if _, err := io.Copy(dst, file); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(AudioRecordResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to write audio file: %v", err),
-		})
+		respondInternalError(w, req, "Failed to write audio file", err)
 		return
 	}
 
@@ -455,20 +501,12 @@ func (r *Router) TranscribeAudio(w http.ResponseWriter, req *http.Request) {
 
 	var reqData AudioTranscribeRequest
 	if err := json.NewDecoder(req.Body).Decode(&reqData); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioTranscribeResponse{
-			Success: false,
-			Error:   "Invalid request body",
-		})
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if reqData.AudioID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(AudioTranscribeResponse{
-			Success: false,
-			Error:   "audio_id is required",
-		})
+		respondError(w, req, http.StatusBadRequest, "audio_id is required")
 		return
 	}
 
@@ -8,6 +8,9 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
 )
 
 // Service provides business logic for reading operations
@@ -97,23 +100,23 @@ func (s *Service) CalculateAccuracy(typed, expected string) float64 {
 // ProcessTestResult processes a completed reading session
 func (s *Service) ProcessTestResult(ctx context.Context, userID uint, bookID uint, content string, timeSpentSeconds float64, errorCount int) (*ReadingSession, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if bookID == 0 {
-		return nil, errors.New("book_id is required")
+		return nil, apierror.Invalid("book_id", "is required")
 	}
 
 	if len(strings.TrimSpace(content)) == 0 {
-		return nil, errors.New("content is required")
+		return nil, apierror.Invalid("content", "is required")
 	}
 
 	if timeSpentSeconds <= 0 {
-		return nil, errors.New("time_spent must be positive")
+		return nil, apierror.Invalid("time_spent", "must be positive")
 	}
 
 	if errorCount < 0 {
-		return nil, errors.New("errors cannot be negative")
+		return nil, apierror.Invalid("errors", "cannot be negative")
 	}
 
 	// Calculate WPM
@@ -159,6 +162,7 @@ func (s *Service) ProcessTestResult(ctx context.Context, userID uint, bookID uin
 	if err != nil {
 		return nil, fmt.Errorf("failed to save result: %w", err)
 	}
+	metrics.SessionsSaved.Inc("reading")
 
 	session.ID = id
 	return session, nil
@@ -167,7 +171,7 @@ func (s *Service) ProcessTestResult(ctx context.Context, userID uint, bookID uin
 // GetUserStatistics retrieves aggregated statistics for a user
 func (s *Service) GetUserStatistics(ctx context.Context, userID uint) (*ReadingStats, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	stats, err := s.repo.GetUserStats(ctx, userID)
@@ -217,7 +221,7 @@ func (s *Service) GetLeaderboard(ctx context.Context, limit int) ([]ReadingStats
 // GetUserTestHistory returns paginated test history for a user
 func (s *Service) GetUserTestHistory(ctx context.Context, userID uint, limit, offset int) ([]ReadingSession, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if limit <= 0 || limit > 1000 {
@@ -239,15 +243,15 @@ func (s *Service) GetUserTestHistory(ctx context.Context, userID uint, limit, of
 // ValidateTestContent validates reading test content
 func (s *Service) ValidateTestContent(content string) error {
 	if len(strings.TrimSpace(content)) == 0 {
-		return errors.New("content is required")
+		return apierror.Invalid("content", "is required")
 	}
 
 	if len(content) < 50 {
-		return errors.New("content is too short (minimum 50 characters)")
+		return apierror.Invalid("content", "is too short (minimum 50 characters)")
 	}
 
 	if len(content) > 100000 {
-		return errors.New("content is too long (maximum 100000 characters)")
+		return apierror.Invalid("content", "is too long (maximum 100000 characters)")
 	}
 
 	// Check if content has at least some variety
@@ -261,7 +265,7 @@ func (s *Service) ValidateTestContent(content string) error {
 	}
 
 	if !hasLetters {
-		return errors.New("content must contain letters")
+		return apierror.Invalid("content", "must contain letters")
 	}
 
 	return nil
@@ -381,7 +385,7 @@ func (s *Service) GetComprehensionAnalysis(ctx context.Context, sessionID uint)
 // RecommendBooks suggests books based on user's reading level
 func (s *Service) RecommendBooks(ctx context.Context, userID uint, limit int) ([]Book, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if limit <= 0 || limit > 100 {
@@ -472,7 +476,7 @@ func (s *Service) GetBookRecommendations(ctx context.Context, userID uint) (map[
 // AnalyzeReadingPerformance provides detailed performance insights
 func (s *Service) AnalyzeReadingPerformance(ctx context.Context, userID uint) (map[string]interface{}, error) {
 	if userID == 0 {
-		return nil, errors.New("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	stats, err := s.GetUserStatistics(ctx, userID)
@@ -554,7 +558,7 @@ func (s *Service) identifyImprovementAreas(stats *ReadingStats) []string {
 // GetSessionDetails returns comprehensive information about a single reading session
 func (s *Service) GetSessionDetails(ctx context.Context, sessionID uint) (map[string]interface{}, error) {
 	if sessionID == 0 {
-		return nil, errors.New("session_id is required")
+		return nil, apierror.Invalid("session_id", "is required")
 	}
 
 	session, err := s.repo.GetSessionByID(ctx, sessionID)
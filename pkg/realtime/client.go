@@ -1,8 +1,10 @@
 package realtime
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -17,17 +19,29 @@ type Client struct {
 	channels  map[string]bool
 	mu        sync.RWMutex
 	closed    bool
+
+	// Close frame WritePump sends once the buffered messages are written
+	closeFrame []byte
+	// Whether WritePump is running, and closed when it returns
+	pumping  atomic.Bool
+	done     chan struct{}
+	connOnce sync.Once
 }
 
 // NewClient creates a new client
 func NewClient(hub *Hub, conn *websocket.Conn, userID uint) *Client {
+	sendBuffer := DefaultClientSendBuffer
+	if hub != nil && hub.clientSendBuffer > 0 {
+		sendBuffer = hub.clientSendBuffer
+	}
 	return &Client{
 		hub:      hub,
 		conn:     conn,
 		userID:   userID,
-		send:     make(chan interface{}, 256), // Buffered channel
+		send:     make(chan interface{}, sendBuffer), // Buffered channel
 		channels: make(map[string]bool),
 		closed:   false,
+		done:     make(chan struct{}),
 	}
 }
 
@@ -84,20 +98,59 @@ func (c *Client) Send(msg interface{}) {
 	}
 }
 
-// Close closes the client connection
+// Close closes the client connection, discarding buffered messages
 func (c *Client) Close() {
+	c.closeSend(nil)
+	c.closeConn()
+}
+
+// closeGracefully stops accepting messages; WritePump writes the buffered
+// ones, then a going-away close frame, and closes the connection
+func (c *Client) closeGracefully() {
+	c.closeSend(websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+}
+
+// wait waits for WritePump to finish after closeGracefully and returns the
+// number of buffered messages left unsent when ctx expired
+func (c *Client) wait(ctx context.Context) int {
+	if c.done == nil || !c.pumping.Load() {
+		dropped := len(c.send)
+		c.closeConn()
+		return dropped
+	}
+
+	select {
+	case <-c.done:
+		return 0
+	case <-ctx.Done():
+		dropped := len(c.send)
+		c.closeConn()
+		return dropped
+	}
+}
+
+// closeSend closes the send buffer once; WritePump ends with frame, or an
+// empty close frame when nil
+func (c *Client) closeSend(frame []byte) {
 	c.mu.Lock()
 	if c.closed {
 		c.mu.Unlock()
 		return
 	}
 	c.closed = true
+	c.closeFrame = frame
 	c.mu.Unlock()
 
 	close(c.send)
-	if c.conn != nil {
-		c.conn.Close()
-	}
+}
+
+// closeConn closes the underlying connection once
+func (c *Client) closeConn() {
+	c.connOnce.Do(func() {
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
 }
 
 // ReadPump reads messages from the WebSocket connection
@@ -157,10 +210,14 @@ func (c *Client) ReadPump() {
 
 // WritePump writes messages to the WebSocket connection
 func (c *Client) WritePump() {
+	c.pumping.Store(true)
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
 		c.Close()
+		if c.done != nil {
+			close(c.done)
+		}
 	}()
 
 	for {
@@ -170,7 +227,10 @@ func (c *Client) WritePump() {
 
 			if !ok {
 				// Hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.mu.RLock()
+				frame := c.closeFrame
+				c.mu.RUnlock()
+				c.conn.WriteMessage(websocket.CloseMessage, frame)
 				return
 			}
 
@@ -1,7 +1,15 @@
 package realtime
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
+)
+
+// Default buffer sizes used by NewHub
+const (
+	DefaultBroadcastBuffer  = 256
+	DefaultClientSendBuffer = 256
 )
 
 // Hub manages all active WebSocket connections
@@ -30,6 +38,18 @@ type Hub struct {
 	// Stop the hub
 	stop chan bool
 
+	// Stop the hub after draining it
+	drain chan drainRequest
+
+	// Closed when the Run loop exits
+	stopped chan struct{}
+
+	// Capacity of each client's send buffer
+	clientSendBuffer int
+
+	// Whether the Run loop is active, for readiness checks
+	running atomic.Bool
+
 	// Hub statistics
 	stats HubStats
 }
@@ -50,21 +70,40 @@ type HubStats struct {
 	ActiveChannels  int64
 }
 
-// NewHub creates a new Hub instance
+// drainRequest asks the Run loop to drain and stop
+type drainRequest struct {
+	ctx     context.Context
+	dropped chan int
+}
+
+// NewHub creates a new Hub instance with the default buffer sizes
 func NewHub() *Hub {
+	return NewHubWithBuffers(DefaultBroadcastBuffer, DefaultClientSendBuffer)
+}
+
+// NewHubWithBuffers creates a Hub whose broadcast queue holds broadcastBuffer
+// messages and whose clients each buffer clientSendBuffer messages
+func NewHubWithBuffers(broadcastBuffer, clientSendBuffer int) *Hub {
 	return &Hub{
-		clients:       make(map[*Client]bool),
-		subscriptions: make(map[string]map[*Client]bool),
-		userClients:   make(map[uint][]*Client),
-		broadcast:     make(chan BroadcastMessage, 256),
-		register:      make(chan *Client),
-		unregister:    make(chan *Client),
-		stop:          make(chan bool),
+		clients:          make(map[*Client]bool),
+		subscriptions:    make(map[string]map[*Client]bool),
+		userClients:      make(map[uint][]*Client),
+		broadcast:        make(chan BroadcastMessage, broadcastBuffer),
+		register:         make(chan *Client),
+		unregister:       make(chan *Client),
+		stop:             make(chan bool),
+		drain:            make(chan drainRequest),
+		stopped:          make(chan struct{}),
+		clientSendBuffer: clientSendBuffer,
 	}
 }
 
 // Run starts the hub event loop
 func (h *Hub) Run() {
+	h.running.Store(true)
+	defer h.running.Store(false)
+	defer close(h.stopped)
+
 	for {
 		select {
 		case client := <-h.register:
@@ -76,6 +115,10 @@ func (h *Hub) Run() {
 		case msg := <-h.broadcast:
 			h.broadcastMessage(msg)
 
+		case req := <-h.drain:
+			req.dropped <- h.drainAndClose(req.ctx)
+			return
+
 		case <-h.stop:
 			h.shutdown()
 			return
@@ -201,36 +244,78 @@ func (h *Hub) Unsubscribe(client *Client, channel string) {
 	h.stats.mu.Unlock()
 }
 
-// Broadcast sends a message to all clients subscribed to a channel
+// Broadcast sends a message to all clients subscribed to a channel. Once the
+// hub has stopped the message is discarded.
 func (h *Hub) Broadcast(channel string, message interface{}) {
-	h.broadcast <- BroadcastMessage{
+	h.enqueue(BroadcastMessage{
 		Channel: channel,
 		Message: message,
-	}
+	})
 }
 
 // BroadcastToUser sends a message to a specific user on a channel
 func (h *Hub) BroadcastToUser(channel string, userID uint, message interface{}) {
-	h.broadcast <- BroadcastMessage{
+	h.enqueue(BroadcastMessage{
 		Channel: channel,
 		Message: message,
 		UserID:  userID,
+	})
+}
+
+// enqueue queues a broadcast unless the hub has stopped
+func (h *Hub) enqueue(msg BroadcastMessage) {
+	select {
+	case h.broadcast <- msg:
+	case <-h.stopped:
 	}
 }
 
 // Register registers a new client
 func (h *Hub) Register(client *Client) {
-	h.register <- client
+	select {
+	case h.register <- client:
+	case <-h.stopped:
+		client.Close()
+	}
 }
 
 // Unregister unregisters a client
 func (h *Hub) Unregister(client *Client) {
-	h.unregister <- client
+	select {
+	case h.unregister <- client:
+	case <-h.stopped:
+	}
 }
 
-// Stop stops the hub
+// Stop stops the hub, dropping queued broadcasts and closing every client
+// connection immediately
 func (h *Hub) Stop() {
-	h.stop <- true
+	select {
+	case h.stop <- true:
+	case <-h.stopped:
+	}
+}
+
+// Shutdown stops the hub gracefully: queued broadcasts are delivered, then
+// each client's buffered messages are written, followed by a going-away close
+// frame. Whatever is still unsent when ctx expires is dropped, and the number
+// of dropped messages is returned.
+func (h *Hub) Shutdown(ctx context.Context) int {
+	req := drainRequest{ctx: ctx, dropped: make(chan int, 1)}
+	select {
+	case h.drain <- req:
+		return <-req.dropped
+	case <-h.stopped:
+		return 0
+	case <-ctx.Done():
+		// The Run loop is not running, so nothing can deliver the queue
+		return len(h.broadcast)
+	}
+}
+
+// IsRunning reports whether the hub event loop is running
+func (h *Hub) IsRunning() bool {
+	return h.running.Load()
 }
 
 // GetClientCount returns the number of connected clients
@@ -281,7 +366,39 @@ func (h *Hub) GetActiveChannels() []string {
 	return channels
 }
 
-// shutdown gracefully closes the hub
+// drainAndClose delivers the queued broadcasts, then closes every client
+// gracefully, and returns the number of messages dropped
+func (h *Hub) drainAndClose(ctx context.Context) int {
+	dropped := 0
+
+	for queued := true; queued; {
+		select {
+		case msg := <-h.broadcast:
+			if ctx.Err() != nil {
+				dropped++
+				continue
+			}
+			h.broadcastMessage(msg)
+		default:
+			queued = false
+		}
+	}
+
+	h.mu.Lock()
+	clients := h.clients
+	h.clients = make(map[*Client]bool)
+	h.mu.Unlock()
+
+	for client := range clients {
+		client.closeGracefully()
+	}
+	for client := range clients {
+		dropped += client.wait(ctx)
+	}
+	return dropped
+}
+
+// shutdown closes the hub without draining it
 func (h *Hub) shutdown() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -1,8 +1,14 @@
 package realtime
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // TestHubCreation tests hub initialization
@@ -455,6 +461,83 @@ func TestEmptyChannelCleanup(t *testing.T) {
 	}
 }
 
+// TestShutdownClosesClients tests that Shutdown flushes queued messages and
+// sends a going-away close frame
+func TestShutdownClosesClients(t *testing.T) {
+	hub := NewHubWithBuffers(16, 16)
+	go hub.Run()
+
+	connected := make(chan *Client, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		client := NewClient(hub, conn, 1)
+		hub.Register(client)
+		hub.Subscribe(client, "activity:feed")
+		go client.WritePump()
+		connected <- client
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	<-connected
+
+	hub.Broadcast("activity:feed", NewMessage(MessageTypeActivityFeed, "activity:feed", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if dropped := hub.Shutdown(ctx); dropped != 0 {
+		t.Errorf("Expected no dropped messages, got %d", dropped)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected the queued broadcast before the close frame, got %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going-away close frame, got %v", err)
+	}
+
+	// A stopped hub discards broadcasts instead of blocking
+	for i := 0; i < 32; i++ {
+		hub.Broadcast("activity:feed", "late")
+	}
+	hub.Stop()
+}
+
+// TestShutdownReportsDropped tests that messages left in a client's buffer
+// when it cannot be flushed are reported as dropped
+func TestShutdownReportsDropped(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	client := &Client{
+		hub:      hub,
+		userID:   1,
+		channels: make(map[string]bool),
+		send:     make(chan interface{}, 10),
+	}
+	hub.Register(client)
+	client.Send("first")
+	client.Send("second")
+
+	if dropped := hub.Shutdown(context.Background()); dropped != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", dropped)
+	}
+	if !client.IsClosed() {
+		t.Error("Expected the client to be closed")
+	}
+}
+
 // BenchmarkBroadcast benchmarks broadcast performance
 func BenchmarkBroadcast(b *testing.B) {
 	hub := NewHub()
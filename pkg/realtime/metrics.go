@@ -0,0 +1,23 @@
+package realtime
+
+import (
+	"github.com/jgirmay/unified-go/internal/metrics"
+)
+
+// Collect implements metrics.Collector with the hub's connection and
+// message statistics
+func (h *Hub) Collect() []metrics.Family {
+	clients := h.GetClientCount()
+	channels := len(h.GetActiveChannels())
+
+	h.stats.mu.RLock()
+	defer h.stats.mu.RUnlock()
+
+	return []metrics.Family{
+		metrics.Gauge("unified_hub_clients", "WebSocket clients registered with the hub.", float64(clients)),
+		metrics.Gauge("unified_hub_channels", "Channels with at least one subscriber.", float64(channels)),
+		metrics.Counter("unified_hub_clients_registered_total", "WebSocket clients registered since start.", float64(h.stats.TotalClients)),
+		metrics.Counter("unified_hub_broadcasts_total", "Messages broadcast by the hub.", float64(h.stats.TotalBroadcasts)),
+		metrics.Counter("unified_hub_messages_total", "Messages delivered to clients.", float64(h.stats.TotalMessages)),
+	}
+}
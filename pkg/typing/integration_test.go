@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
 // TestTypingIntegration provides integration test setup
@@ -19,6 +21,11 @@ type TestTypingIntegration struct {
 	service *Service
 }
 
+// serve routes a request as authenticated user 1
+func (ti *TestTypingIntegration) serve(w http.ResponseWriter, req *http.Request) {
+	ti.router.Routes().ServeHTTP(w, req.WithContext(middleware.WithCaller(req.Context(), 1)))
+}
+
 // setupIntegration creates a test database and router
 func setupIntegration(t *testing.T) *TestTypingIntegration {
 	db := setupTestDB(t)
@@ -64,7 +71,7 @@ func TestCreateTypingTest(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	ti.router.Routes().ServeHTTP(w, req)
+	ti.serve(w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -107,7 +114,7 @@ func TestGetUserStats(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/users/1/typing/stats", nil)
 	w := httptest.NewRecorder()
 
-	ti.router.Routes().ServeHTTP(w, req)
+	ti.serve(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -152,7 +159,7 @@ func TestGetLeaderboard(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/typing/leaderboard?limit=10", nil)
 	w := httptest.NewRecorder()
 
-	ti.router.Routes().ServeHTTP(w, req)
+	ti.serve(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -189,7 +196,7 @@ func TestGetUserHistory(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/users/1/typing/history?days=30", nil)
 	w := httptest.NewRecorder()
 
-	ti.router.Routes().ServeHTTP(w, req)
+	ti.serve(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -211,7 +218,7 @@ func TestGetLessons(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/typing/lessons", nil)
 	w := httptest.NewRecorder()
 
-	ti.router.Routes().ServeHTTP(w, req)
+	ti.serve(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -269,7 +276,7 @@ func TestErrorHandling(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(tt.method, tt.path, nil)
 			w := httptest.NewRecorder()
-			ti.router.Routes().ServeHTTP(w, req)
+			ti.serve(w, req)
 
 			if w.Code < http.StatusBadRequest {
 				t.Logf("Expected error status for %s, got %d", tt.name, w.Code)
@@ -320,7 +327,7 @@ func BenchmarkTypingTest(b *testing.B) {
 		req := httptest.NewRequest("POST", "/api/typing/test", bytes.NewReader(body))
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
-		ti.router.Routes().ServeHTTP(w, req)
+		ti.serve(w, req)
 	}
 }
 
@@ -350,7 +357,7 @@ func BenchmarkUserStats(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		req := httptest.NewRequest("GET", "/api/users/1/typing/stats", nil)
 		w := httptest.NewRecorder()
-		ti.router.Routes().ServeHTTP(w, req)
+		ti.serve(w, req)
 	}
 }
 
@@ -382,7 +389,7 @@ func BenchmarkLeaderboard(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		req := httptest.NewRequest("GET", "/api/typing/leaderboard?limit=100", nil)
 		w := httptest.NewRecorder()
-		ti.router.Routes().ServeHTTP(w, req)
+		ti.serve(w, req)
 	}
 }
 
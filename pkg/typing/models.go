@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
 )
 
 // TypingTest represents a single typing test result
@@ -50,19 +52,19 @@ type UserStats struct {
 // Validate checks if a TypingTest is valid
 func (t *TypingTest) Validate() error {
 	if t.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if t.WPM < 0 {
-		return errors.New("wpm cannot be negative")
+		return apierror.Invalid("wpm", "cannot be negative")
 	}
 	if t.Accuracy < 0 || t.Accuracy > 100 {
-		return errors.New("accuracy must be between 0 and 100")
+		return apierror.Invalid("accuracy", "must be between 0 and 100")
 	}
 	if t.Duration <= 0 {
-		return errors.New("duration must be positive")
+		return apierror.Invalid("duration", "must be positive")
 	}
 	if t.Errors < 0 {
-		return errors.New("errors cannot be negative")
+		return apierror.Invalid("errors", "cannot be negative")
 	}
 	return nil
 }
@@ -70,22 +72,22 @@ func (t *TypingTest) Validate() error {
 // Validate checks if a TypingResult is valid
 func (r *TypingResult) Validate() error {
 	if r.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if len(r.Content) == 0 {
-		return errors.New("content is required")
+		return apierror.Invalid("content", "is required")
 	}
 	if r.TimeSpent <= 0 {
-		return errors.New("time_spent must be positive")
+		return apierror.Invalid("time_spent", "must be positive")
 	}
 	if r.WPM < 0 {
-		return errors.New("wpm cannot be negative")
+		return apierror.Invalid("wpm", "cannot be negative")
 	}
 	if r.Accuracy < 0 || r.Accuracy > 100 {
-		return errors.New("accuracy must be between 0 and 100")
+		return apierror.Invalid("accuracy", "must be between 0 and 100")
 	}
 	if r.ErrorsCount < 0 {
-		return errors.New("errors_count cannot be negative")
+		return apierror.Invalid("errors_count", "cannot be negative")
 	}
 	return nil
 }
@@ -285,25 +287,25 @@ type Race struct {
 // Validate checks if a Race is valid
 func (r *Race) Validate() error {
 	if r.UserID == 0 {
-		return errors.New("user_id is required")
+		return apierror.Invalid("user_id", "is required")
 	}
 	if r.Mode == "" {
-		return errors.New("mode is required")
+		return apierror.Invalid("mode", "is required")
 	}
 	if r.Placement < 1 || r.Placement > 4 {
-		return errors.New("placement must be between 1 and 4")
+		return apierror.Invalid("placement", "must be between 1 and 4")
 	}
 	if r.WPM < 0 {
-		return errors.New("wpm cannot be negative")
+		return apierror.Invalid("wpm", "cannot be negative")
 	}
 	if r.Accuracy < 0 || r.Accuracy > 100 {
-		return errors.New("accuracy must be between 0 and 100")
+		return apierror.Invalid("accuracy", "must be between 0 and 100")
 	}
 	if r.RaceTime <= 0 {
-		return errors.New("race_time must be positive")
+		return apierror.Invalid("race_time", "must be positive")
 	}
 	if r.XPEarned < 0 {
-		return errors.New("xp_earned cannot be negative")
+		return apierror.Invalid("xp_earned", "cannot be negative")
 	}
 	return nil
 }
@@ -5,11 +5,14 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/database"
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // Repository handles data access for typing operations
 type Repository struct {
-	db *sql.DB
+	db database.Conn
 }
 
 // NewRepository creates a new typing repository
@@ -17,6 +20,11 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// WithTx returns a typing repository whose statements run within tx
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx}
+}
+
 // SaveResult saves a typing test result to the database
 func (r *Repository) SaveResult(ctx context.Context, result *TypingResult) (uint, error) {
 	if err := result.Validate(); err != nil {
@@ -98,12 +106,13 @@ func (r *Repository) GetUserStats(ctx context.Context, userID uint) (*UserStats,
 	return stats, nil
 }
 
-// GetLeaderboard retrieves top users by WPM
+// GetLeaderboard retrieves the context organization's top users by WPM
 func (r *Repository) GetLeaderboard(ctx context.Context, limit int) ([]UserStats, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 10
 	}
 
+	scope, args := tenant.Members(ctx, "user_id")
 	query := `
 		SELECT
 			user_id,
@@ -114,12 +123,12 @@ func (r *Repository) GetLeaderboard(ctx context.Context, limit int) ([]UserStats
 			total_time_typed,
 			last_updated
 		FROM user_stats
-		WHERE total_tests > 0
+		WHERE total_tests > 0 AND ` + scope + `
 		ORDER BY best_wpm DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
 	}
@@ -383,3 +392,66 @@ func (r *Repository) DeleteUserTests(ctx context.Context, userID uint) error {
 
 	return nil
 }
+
+// RecomputeAllStats rebuilds the aggregate stats of every user with typing
+// results and returns how many users were updated
+func (r *Repository) RecomputeAllStats(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT user_id FROM typing_results ORDER BY user_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list typing users: %w", err)
+	}
+	var userIDs []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("typing users query error: %w", err)
+	}
+
+	for i, id := range userIDs {
+		if err := r.updateUserStats(ctx, id); err != nil {
+			return i, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// SaveText stores a typing text in a category of the context's organization,
+// or as shared content without one, reporting whether it was new
+func (r *Repository) SaveText(ctx context.Context, category, content string) (bool, error) {
+	query := "INSERT OR IGNORE INTO typing_texts (org_id, category, content) VALUES (?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, tenant.ID(ctx), category, content)
+	if err != nil {
+		return false, fmt.Errorf("failed to save typing text: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetTexts returns the imported typing texts in a category of the context
+// organization's catalog
+func (r *Repository) GetTexts(ctx context.Context, category string) ([]string, error) {
+	scope, args := tenant.Catalog(ctx, "org_id")
+	query := "SELECT content FROM typing_texts WHERE category = ? AND " + scope + " ORDER BY id"
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{category}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query typing texts: %w", err)
+	}
+	defer rows.Close()
+
+	var texts []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to scan typing text: %w", err)
+		}
+		texts = append(texts, text)
+	}
+	return texts, rows.Err()
+}
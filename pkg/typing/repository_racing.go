@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // SaveRace saves a racing session to the database
@@ -275,7 +277,8 @@ func (r *Repository) updateRacingStats(ctx context.Context, race *Race) error {
 	return nil
 }
 
-// GetRacingLeaderboard retrieves top racers ranked by metric
+// GetRacingLeaderboard retrieves the context organization's top racers ranked
+// by metric
 func (r *Repository) GetRacingLeaderboard(ctx context.Context, metric string, limit int) ([]UserRacingStats, error) {
 	orderBy := "total_xp DESC"
 	switch metric {
@@ -287,15 +290,17 @@ func (r *Repository) GetRacingLeaderboard(ctx context.Context, metric string, li
 		orderBy = "total_races DESC"
 	}
 
+	scope, args := tenant.Members(ctx, "user_id")
 	query := fmt.Sprintf(`
 		SELECT
 			id, user_id, total_races, wins, podiums, total_xp, current_car, last_updated
 		FROM user_racing_stats
+		WHERE %s
 		ORDER BY %s
 		LIMIT ?
-	`, orderBy)
+	`, scope, orderBy)
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
 	}
@@ -7,13 +7,36 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/logging"
+	"github.com/jgirmay/unified-go/internal/middleware"
 )
 
+// logger is the typing app logger
+var logger = logging.Logger("typing")
+
+// Settings holds the configurable limits of the typing app
+type Settings struct {
+	LeaderboardSize       int
+	RacingLeaderboardSize int
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		LeaderboardSize:       100,
+		RacingLeaderboardSize: 10,
+	}
+}
+
 // Router handles HTTP routes for typing app
 type Router struct {
-	db      *sql.DB
-	service *Service
-	router  chi.Router
+	db       *sql.DB
+	service  *Service
+	router   chi.Router
+	authz    *middleware.Authorizer
+	settings Settings
 }
 
 // NewRouter creates a new typing router
@@ -22,41 +45,58 @@ func NewRouter(db *sql.DB) *Router {
 	service := NewService(repo)
 
 	return &Router{
-		db:      db,
-		service: service,
-		router:  chi.NewRouter(),
+		db:       db,
+		service:  service,
+		router:   chi.NewRouter(),
+		authz:    middleware.NewAuthorizer(nil),
+		settings: DefaultSettings(),
 	}
 }
 
+// SetAuthorizer sets the authorizer guarding per-user routes
+func (r *Router) SetAuthorizer(authz *middleware.Authorizer) {
+	r.authz = authz
+}
+
+// SetSettings overrides the leaderboard sizes
+func (r *Router) SetSettings(settings Settings) {
+	r.settings = settings
+}
+
 // Routes configures all typing app routes
 func (r *Router) Routes() chi.Router {
-	// Test endpoints
-	r.router.Post("/api/typing/test", r.CreateTest)
-	r.router.Get("/api/typing/test/{testId}", r.GetTest)
-	r.router.Get("/api/users/{userId}/typing/tests", r.GetUserTests)
-
-	// Statistics endpoints
-	r.router.Get("/api/users/{userId}/typing/stats", r.GetUserStats)
+	// Public endpoints
 	r.router.Get("/api/typing/leaderboard", r.GetLeaderboard)
-	r.router.Get("/api/users/{userId}/typing/history", r.GetHistory)
-
-	// Dashboard
-	r.router.Get("/api/typing/dashboard/{userId}", r.GetDashboard)
-
-	// Lessons endpoints
 	r.router.Get("/api/typing/lessons", r.GetLessons)
 	r.router.Get("/api/typing/lessons/{lessonId}", r.GetLesson)
-
-	// Racing endpoints
-	r.router.Post("/api/racing/start", r.StartRace)
-	r.router.Post("/api/racing/finish", r.FinishRace)
-	r.router.Get("/api/users/{userId}/racing/stats", r.GetRacingStats)
 	r.router.Get("/api/racing/leaderboard", r.GetRacingLeaderboard)
-	r.router.Get("/api/users/{userId}/racing/history", r.GetRaceHistory)
-	r.router.Get("/api/users/{userId}/racing/cars", r.GetUnlockedCars)
-	r.router.Get("/api/users/{userId}/racing/next-car", r.GetNextCarUnlock)
 	r.router.Get("/api/racing/ai-opponent", r.GenerateAIOpponentHandler)
-	r.router.Get("/api/users/{userId}/racing/level", r.GetRaceLevel)
+
+	// Per-user endpoints require the caller to be the user or related to them
+	r.router.Group(func(api chi.Router) {
+		api.Use(r.authz.RequireUserAccess)
+
+		// Test endpoints
+		api.Post("/api/typing/test", r.CreateTest)
+		api.Get("/api/typing/test/{testId}", r.GetTest)
+		api.Get("/api/users/{userId}/typing/tests", r.GetUserTests)
+
+		// Statistics endpoints
+		api.Get("/api/users/{userId}/typing/stats", r.GetUserStats)
+		api.Get("/api/users/{userId}/typing/history", r.GetHistory)
+
+		// Dashboard
+		api.Get("/api/typing/dashboard/{userId}", r.GetDashboard)
+
+		// Racing endpoints
+		api.Post("/api/racing/start", r.StartRace)
+		api.Post("/api/racing/finish", r.FinishRace)
+		api.Get("/api/users/{userId}/racing/stats", r.GetRacingStats)
+		api.Get("/api/users/{userId}/racing/history", r.GetRaceHistory)
+		api.Get("/api/users/{userId}/racing/cars", r.GetUnlockedCars)
+		api.Get("/api/users/{userId}/racing/next-car", r.GetNextCarUnlock)
+		api.Get("/api/users/{userId}/racing/level", r.GetRaceLevel)
+	})
 
 	return r.router
 }
@@ -71,13 +111,13 @@ func (r *Router) CreateTest(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&testData); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	result, err := r.service.ProcessTypingTest(req.Context(), testData.UserID, testData.Content, testData.Duration, testData.Errors)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, req, "Failed to process typing test", err)
 		return
 	}
 
@@ -89,7 +129,7 @@ func (r *Router) GetTest(w http.ResponseWriter, req *http.Request) {
 	testIdStr := chi.URLParam(req, "testId")
 	testID, err := strconv.ParseUint(testIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid test ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid test ID")
 		return
 	}
 
@@ -104,7 +144,7 @@ func (r *Router) GetUserTests(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -125,7 +165,7 @@ func (r *Router) GetUserTests(w http.ResponseWriter, req *http.Request) {
 
 	tests, err := r.service.repo.GetUserTests(req.Context(), uint(userID), limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get tests")
+		respondInternalError(w, req, "Failed to get tests", err)
 		return
 	}
 
@@ -142,13 +182,13 @@ func (r *Router) GetUserStats(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	stats, err := r.service.GetUserProgress(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get stats")
+		respondInternalError(w, req, "Failed to get stats", err)
 		return
 	}
 
@@ -157,7 +197,7 @@ func (r *Router) GetUserStats(w http.ResponseWriter, req *http.Request) {
 
 // GetLeaderboard retrieves typing leaderboard
 func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
-	limit := 100
+	limit := r.settings.LeaderboardSize
 	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
 			limit = l
@@ -166,7 +206,7 @@ func (r *Router) GetLeaderboard(w http.ResponseWriter, req *http.Request) {
 
 	leaderboard, err := r.service.GetLeaderboard(req.Context(), limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get leaderboard")
+		respondInternalError(w, req, "Failed to get leaderboard", err)
 		return
 	}
 
@@ -181,7 +221,7 @@ func (r *Router) GetHistory(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -194,7 +234,7 @@ func (r *Router) GetHistory(w http.ResponseWriter, req *http.Request) {
 
 	history, err := r.service.GetUserHistory(req.Context(), uint(userID), days)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get history")
+		respondInternalError(w, req, "Failed to get history", err)
 		return
 	}
 
@@ -210,13 +250,13 @@ func (r *Router) GetDashboard(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	stats, err := r.service.GetUserProgress(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get dashboard")
+		respondInternalError(w, req, "Failed to get dashboard", err)
 		return
 	}
 
@@ -249,7 +289,7 @@ func (r *Router) GetLesson(w http.ResponseWriter, req *http.Request) {
 	lessonIdStr := chi.URLParam(req, "lessonId")
 	lessonID64, err := strconv.ParseUint(lessonIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid lesson ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid lesson ID")
 		return
 	}
 
@@ -264,7 +304,7 @@ func (r *Router) GetLesson(w http.ResponseWriter, req *http.Request) {
 	if lesson, exists := lessons[lessonID]; exists {
 		respondJSON(w, http.StatusOK, lesson)
 	} else {
-		respondError(w, http.StatusNotFound, "Lesson not found")
+		respondError(w, req, http.StatusNotFound, "Lesson not found")
 	}
 }
 
@@ -276,12 +316,12 @@ func (r *Router) StartRace(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&raceStart); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if raceStart.UserID == 0 {
-		respondError(w, http.StatusBadRequest, "user_id is required")
+		respondError(w, req, http.StatusBadRequest, "user_id is required")
 		return
 	}
 
@@ -305,13 +345,13 @@ func (r *Router) FinishRace(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if err := json.NewDecoder(req.Body).Decode(&raceFinish); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, req, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	race, err := r.service.ProcessRaceResult(req.Context(), raceFinish.UserID, raceFinish.WPM, raceFinish.Accuracy, raceFinish.RaceTime, raceFinish.Placement)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondServiceError(w, req, "Failed to process race result", err)
 		return
 	}
 
@@ -323,13 +363,13 @@ func (r *Router) GetRacingStats(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	stats, err := r.service.GetRacingStats(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get racing stats")
+		respondInternalError(w, req, "Failed to get racing stats", err)
 		return
 	}
 
@@ -343,7 +383,7 @@ func (r *Router) GetRacingLeaderboard(w http.ResponseWriter, req *http.Request)
 		metric = "total_xp"
 	}
 
-	limit := 10
+	limit := r.settings.RacingLeaderboardSize
 	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
 			limit = l
@@ -352,7 +392,7 @@ func (r *Router) GetRacingLeaderboard(w http.ResponseWriter, req *http.Request)
 
 	leaderboard, err := r.service.GetRacingLeaderboard(req.Context(), metric, limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get racing leaderboard")
+		respondInternalError(w, req, "Failed to get racing leaderboard", err)
 		return
 	}
 
@@ -368,7 +408,7 @@ func (r *Router) GetRaceHistory(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -389,7 +429,7 @@ func (r *Router) GetRaceHistory(w http.ResponseWriter, req *http.Request) {
 
 	races, err := r.service.GetRaceHistory(req.Context(), uint(userID), limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get race history")
+		respondInternalError(w, req, "Failed to get race history", err)
 		return
 	}
 
@@ -405,13 +445,13 @@ func (r *Router) GetUnlockedCars(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	cars, err := r.service.GetUnlockedCars(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get cars")
+		respondInternalError(w, req, "Failed to get cars", err)
 		return
 	}
 
@@ -425,13 +465,13 @@ func (r *Router) GetNextCarUnlock(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	nextCar, xpNeeded, err := r.service.GetNextCarUnlock(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get next car")
+		respondInternalError(w, req, "Failed to get next car", err)
 		return
 	}
 
@@ -457,13 +497,13 @@ func (r *Router) GetRaceLevel(w http.ResponseWriter, req *http.Request) {
 	userIdStr := chi.URLParam(req, "userId")
 	userID, err := strconv.ParseUint(userIdStr, 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, req, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	level, err := r.service.CalculateRaceLevel(req.Context(), uint(userID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get race level")
+		respondInternalError(w, req, "Failed to get race level", err)
 		return
 	}
 
@@ -479,11 +519,23 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": message,
-		"status": status,
-	})
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	apierror.Respond(w, r, status, message)
+}
+
+// respondInternalError logs err with the request's context and responds with
+// a generic 500 message
+func respondInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	respondError(w, r, http.StatusInternalServerError, message)
+}
+
+// respondServiceError passes client errors from the service through and
+// reports anything else as an internal error
+func respondServiceError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if apiErr, ok := apierror.As(err); ok {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	respondInternalError(w, r, message, err)
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
 )
 
 // Service provides business logic for typing functionality
@@ -21,15 +24,15 @@ func NewService(repo *Repository) *Service {
 // ProcessTypingTest processes a typing test and calculates metrics
 func (s *Service) ProcessTypingTest(ctx context.Context, userID uint, content string, duration float64, errorCount int) (*TypingResult, error) {
 	if userID == 0 {
-		return nil, fmt.Errorf("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if duration <= 0 {
-		return nil, fmt.Errorf("duration must be positive")
+		return nil, apierror.Invalid("duration", "must be positive")
 	}
 
 	if errorCount < 0 {
-		return nil, fmt.Errorf("errors cannot be negative")
+		return nil, apierror.Invalid("errors", "cannot be negative")
 	}
 
 	// Calculate metrics
@@ -58,6 +61,7 @@ func (s *Service) ProcessTypingTest(ctx context.Context, userID uint, content st
 	if err != nil {
 		return nil, fmt.Errorf("failed to save result: %w", err)
 	}
+	metrics.SessionsSaved.Inc("typing")
 
 	result.ID = id
 	return result, nil
@@ -66,7 +70,7 @@ func (s *Service) ProcessTypingTest(ctx context.Context, userID uint, content st
 // GetUserProgress retrieves user's typing progress and statistics
 func (s *Service) GetUserProgress(ctx context.Context, userID uint) (*UserStats, error) {
 	if userID == 0 {
-		return nil, fmt.Errorf("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	stats, err := s.repo.GetUserStats(ctx, userID)
@@ -94,7 +98,7 @@ func (s *Service) GetLeaderboard(ctx context.Context, limit int) ([]UserStats, e
 // GetUserHistory retrieves user's test history
 func (s *Service) GetUserHistory(ctx context.Context, userID uint, days int) ([]TypingResult, error) {
 	if userID == 0 {
-		return nil, fmt.Errorf("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if days <= 0 {
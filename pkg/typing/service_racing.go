@@ -5,20 +5,23 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+
+	"github.com/jgirmay/unified-go/internal/apierror"
+	"github.com/jgirmay/unified-go/internal/metrics"
 )
 
 // ProcessRaceResult processes a racing session and calculates XP
 func (s *Service) ProcessRaceResult(ctx context.Context, userID uint, wpm, accuracy float64, raceTime float64, placement int) (*Race, error) {
 	if userID == 0 {
-		return nil, fmt.Errorf("user_id is required")
+		return nil, apierror.Invalid("user_id", "is required")
 	}
 
 	if placement < 1 || placement > 4 {
-		return nil, fmt.Errorf("placement must be between 1 and 4")
+		return nil, apierror.Invalid("placement", "must be between 1 and 4")
 	}
 
 	if raceTime <= 0 {
-		return nil, fmt.Errorf("race_time must be positive")
+		return nil, apierror.Invalid("race_time", "must be positive")
 	}
 
 	// Calculate XP
@@ -44,6 +47,7 @@ func (s *Service) ProcessRaceResult(ctx context.Context, userID uint, wpm, accur
 	if err != nil {
 		return nil, fmt.Errorf("failed to save race: %w", err)
 	}
+	metrics.SessionsSaved.Inc("typing")
 
 	race.ID = id
 	return race, nil
@@ -243,10 +247,18 @@ func (s *Service) SimulateAIRace(playerWPM, playerAccuracy float64, aiOpponent *
 	return 2 // AI wins
 }
 
-// GetSelectedText returns a random text sample for the given category
+// GetSelectedText returns a random text sample for the given category,
+// drawn from the built-in samples and any imported texts
 func (s *Service) GetSelectedText(category string) string {
-	samples, exists := TextSamples[category]
-	if !exists || len(samples) == 0 {
+	samples := TextSamples[category]
+	imported, err := s.repo.GetTexts(context.Background(), category)
+	if err != nil {
+		logger.Warn("failed to load imported typing texts", "category", category, "error", err)
+	}
+	if len(imported) > 0 {
+		samples = append(append([]string(nil), samples...), imported...)
+	}
+	if len(samples) == 0 {
 		// Default to common words if category not found
 		samples = TextSamples["common_words"]
 	}
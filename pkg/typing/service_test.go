@@ -9,11 +9,10 @@ import (
 )
 
 // setupServiceTestDB creates a test database with sample data
-func setupServiceTestDB(t *testing.T) (*sql.DB, *Service) {
+func setupServiceTestDB(t testing.TB) (*sql.DB, *Service) {
 	db := setupTestDB(t)
 
-	pool := newPoolFromDB(db)
-	repo := NewRepository(pool)
+	repo := NewRepository(db)
 	service := NewService(repo)
 
 	return db, service
@@ -21,8 +20,6 @@ func setupServiceTestDB(t *testing.T) (*sql.DB, *Service) {
 
 // TestCalculateWPM tests WPM calculation
 func TestCalculateWPM(t *testing.T) {
-	_, service := setupServiceTestDB(t)
-
 	tests := []struct {
 		name           string
 		content        string
@@ -69,7 +66,7 @@ func TestCalculateWPM(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			wpm := service.CalculateWPM(tt.content, tt.timeSpent)
+			wpm := CalculateWPM(len(tt.content), tt.timeSpent)
 			if tt.expectedMinWPM == 0 && tt.expectedMaxWPM == 0 {
 				if wpm != 0 {
 					t.Errorf("CalculateWPM() = %v, want 0", wpm)
@@ -83,84 +80,37 @@ func TestCalculateWPM(t *testing.T) {
 
 // TestCalculateAccuracy tests accuracy calculation
 func TestCalculateAccuracy(t *testing.T) {
-	_, service := setupServiceTestDB(t)
-
 	tests := []struct {
-		name           string
-		typed          string
-		expected       string
-		expectedMinAcc float64
-		expectedMaxAcc float64
+		name      string
+		charCount int
+		errors    int
+		expected  float64
 	}{
-		{
-			name:           "perfect match",
-			typed:          "hello world",
-			expected:       "hello world",
-			expectedMinAcc: 99.9,
-			expectedMaxAcc: 100.0,
-		},
-		{
-			name:           "one character wrong",
-			typed:          "hallo world",
-			expected:       "hello world",
-			expectedMinAcc: 90.0,
-			expectedMaxAcc: 100.0,
-		},
-		{
-			name:           "typed less than expected",
-			typed:          "hello world test",
-			expected:       "hello world",
-			expectedMinAcc: 80.0,
-			expectedMaxAcc: 100.0,
-		},
-		{
-			name:           "typed more than expected",
-			typed:          "hello world extra",
-			expected:       "hello world",
-			expectedMinAcc: 50.0,
-			expectedMaxAcc: 100.0,
-		},
-		{
-			name:           "completely different",
-			typed:          "abcde",
-			expected:       "hello",
-			expectedMinAcc: 0.0,
-			expectedMaxAcc: 20.0,
-		},
-		{
-			name:           "whitespace differences",
-			typed:          "  hello world  ",
-			expected:       "hello world",
-			expectedMinAcc: 99.9,
-			expectedMaxAcc: 100.0,
-		},
-		{
-			name:           "empty typed",
-			typed:          "",
-			expected:       "hello",
-			expectedMinAcc: 0.0,
-			expectedMaxAcc: 20.0,
-		},
+		{"perfect match", 11, 0, 100.0},
+		{"one character wrong", 11, 1, 90.9},
+		{"half wrong", 10, 5, 50.0},
+		{"more errors than characters", 5, 10, 0.0},
+		{"nothing typed", 0, 0, 100.0},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			accuracy := service.CalculateAccuracy(tt.typed, tt.expected)
-			if accuracy < tt.expectedMinAcc || accuracy > tt.expectedMaxAcc {
-				t.Errorf("CalculateAccuracy() = %v, expected between %v and %v", accuracy, tt.expectedMinAcc, tt.expectedMaxAcc)
+			accuracy := CalculateAccuracy(tt.charCount, tt.errors)
+			if accuracy != tt.expected {
+				t.Errorf("CalculateAccuracy(%d, %d) = %v, want %v", tt.charCount, tt.errors, accuracy, tt.expected)
 			}
 		})
 	}
 }
 
-// TestProcessTestResult tests complete test result processing
-func TestProcessTestResult(t *testing.T) {
+// TestProcessTypingTest tests complete test result processing
+func TestProcessTypingTest(t *testing.T) {
 	db, service := setupServiceTestDB(t)
 	defer db.Close()
 
 	ctx := context.Background()
 
-	result, err := service.ProcessTestResult(
+	result, err := service.ProcessTypingTest(
 		ctx,
 		1,
 		"the quick brown fox jumps over the lazy dog",
@@ -169,28 +119,28 @@ func TestProcessTestResult(t *testing.T) {
 	)
 
 	if err != nil {
-		t.Fatalf("ProcessTestResult() error = %v", err)
+		t.Fatalf("ProcessTypingTest() error = %v", err)
 	}
 
 	if result.ID == 0 {
-		t.Error("ProcessTestResult() returned zero ID")
+		t.Error("ProcessTypingTest() returned zero ID")
 	}
 
 	if result.UserID != 1 {
-		t.Errorf("ProcessTestResult() UserID = %v, want 1", result.UserID)
+		t.Errorf("ProcessTypingTest() UserID = %v, want 1", result.UserID)
 	}
 
 	if result.WPM <= 0 {
-		t.Errorf("ProcessTestResult() WPM = %v, want > 0", result.WPM)
+		t.Errorf("ProcessTypingTest() WPM = %v, want > 0", result.WPM)
 	}
 
 	if result.Accuracy <= 0 || result.Accuracy > 100 {
-		t.Errorf("ProcessTestResult() Accuracy = %v, want 0-100", result.Accuracy)
+		t.Errorf("ProcessTypingTest() Accuracy = %v, want 0-100", result.Accuracy)
 	}
 }
 
-// TestProcessTestResultInvalid tests invalid test result processing
-func TestProcessTestResultInvalid(t *testing.T) {
+// TestProcessTypingTestInvalid tests invalid test result processing
+func TestProcessTypingTestInvalid(t *testing.T) {
 	db, service := setupServiceTestDB(t)
 	defer db.Close()
 
@@ -240,38 +190,43 @@ func TestProcessTestResultInvalid(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := service.ProcessTestResult(ctx, tt.userID, tt.content, tt.time, tt.errors)
+			_, err := service.ProcessTypingTest(ctx, tt.userID, tt.content, tt.time, tt.errors)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ProcessTestResult() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ProcessTypingTest() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-// TestGetUserStatistics tests retrieving user statistics
-func TestGetUserStatistics(t *testing.T) {
+// TestGetUserProgress tests retrieving user statistics
+func TestGetUserProgress(t *testing.T) {
 	db, service := setupServiceTestDB(t)
 	defer db.Close()
 
 	ctx := context.Background()
 
-	// Process a test to generate statistics
-	_, err := service.ProcessTestResult(ctx, 1, "test content for statistics", 60, 1)
-	if err != nil {
-		t.Fatalf("ProcessTestResult() error = %v", err)
+	// Process tests to generate statistics
+	for i := 0; i < 5; i++ {
+		if _, err := service.ProcessTypingTest(ctx, 1, "test content for statistics", 60, 1); err != nil {
+			t.Fatalf("ProcessTypingTest() error = %v", err)
+		}
 	}
 
-	stats, err := service.GetUserStatistics(ctx, 1)
+	stats, err := service.GetUserProgress(ctx, 1)
 	if err != nil {
-		t.Fatalf("GetUserStatistics() error = %v", err)
+		t.Fatalf("GetUserProgress() error = %v", err)
 	}
 
 	if stats.UserID != 1 {
-		t.Errorf("GetUserStatistics() UserID = %v, want 1", stats.UserID)
+		t.Errorf("GetUserProgress() UserID = %v, want 1", stats.UserID)
+	}
+
+	if stats.TotalTests != 5 {
+		t.Errorf("GetUserProgress() TotalTests = %d, want 5", stats.TotalTests)
 	}
 
-	if stats.TotalTests == 0 {
-		t.Error("GetUserStatistics() TotalTests = 0, want > 0")
+	if _, err := service.GetUserProgress(ctx, 0); err == nil {
+		t.Error("GetUserProgress() should return error for missing user_id")
 	}
 }
 
@@ -284,7 +239,7 @@ func TestServiceGetLeaderboard(t *testing.T) {
 
 	// Insert test data for multiple users
 	for i := 2; i <= 5; i++ {
-		username := ("user" + string(rune(i)))
+		username := ("user" + string(rune('0'+i)))
 		_, err := db.Exec("INSERT INTO users (id, username) VALUES (?, ?)", i, username)
 		if err != nil {
 			t.Fatalf("failed to insert user: %v", err)
@@ -292,7 +247,7 @@ func TestServiceGetLeaderboard(t *testing.T) {
 
 		// Process tests for each user
 		for j := 0; j < 3; j++ {
-			_, err = service.ProcessTestResult(
+			_, err = service.ProcessTypingTest(
 				ctx,
 				uint(i),
 				"the quick brown fox jumps over the lazy dog",
@@ -300,7 +255,7 @@ func TestServiceGetLeaderboard(t *testing.T) {
 				1,
 			)
 			if err != nil {
-				t.Fatalf("ProcessTestResult() error = %v", err)
+				t.Fatalf("ProcessTypingTest() error = %v", err)
 			}
 		}
 	}
@@ -316,84 +271,8 @@ func TestServiceGetLeaderboard(t *testing.T) {
 	}
 }
 
-// TestValidateTestContent tests content validation
-func TestValidateTestContent(t *testing.T) {
-	_, service := setupServiceTestDB(t)
-
-	tests := []struct {
-		name    string
-		content string
-		wantErr bool
-	}{
-		{
-			name:    "valid content",
-			content: "the quick brown fox jumps over the lazy dog",
-			wantErr: false,
-		},
-		{
-			name:    "too short",
-			content: "hi",
-			wantErr: true,
-		},
-		{
-			name:    "empty content",
-			content: "",
-			wantErr: true,
-		},
-		{
-			name:    "only whitespace",
-			content: "   ",
-			wantErr: true,
-		},
-		{
-			name:    "no letters",
-			content: "123456789012345",
-			wantErr: true,
-		},
-		{
-			name:    "valid with numbers",
-			content: "the quick 123 fox jumps",
-			wantErr: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := service.ValidateTestContent(tt.content)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ValidateTestContent() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
-// TestGetUserTestCount tests getting test count
-func TestGetUserTestCount(t *testing.T) {
-	db, service := setupServiceTestDB(t)
-	defer db.Close()
-
-	ctx := context.Background()
-
-	// Process multiple tests
-	for i := 0; i < 5; i++ {
-		_, err := service.ProcessTestResult(ctx, 1, "test content", 60, 1)
-		if err != nil {
-			t.Fatalf("ProcessTestResult() error = %v", err)
-		}
-	}
-
-	count, err := service.GetUserTestCount(ctx, 1)
-	if err != nil {
-		t.Fatalf("GetUserTestCount() error = %v", err)
-	}
-
-	if count != 5 {
-		t.Errorf("GetUserTestCount() = %d, want 5", count)
-	}
-}
-
-// TestGetUserTestHistory tests retrieving test history
-func TestGetUserTestHistory(t *testing.T) {
+// TestServiceGetUserHistory tests retrieving test history
+func TestServiceGetUserHistory(t *testing.T) {
 	db, service := setupServiceTestDB(t)
 	defer db.Close()
 
@@ -401,25 +280,25 @@ func TestGetUserTestHistory(t *testing.T) {
 
 	// Process multiple tests
 	for i := 0; i < 10; i++ {
-		_, err := service.ProcessTestResult(ctx, 1, "test content", 60, 1)
+		_, err := service.ProcessTypingTest(ctx, 1, "test content", 60, 1)
 		if err != nil {
-			t.Fatalf("ProcessTestResult() error = %v", err)
+			t.Fatalf("ProcessTypingTest() error = %v", err)
 		}
 	}
 
-	// Get history with pagination
-	tests, err := service.GetUserTestHistory(ctx, 1, 5, 0)
+	// A non-positive window falls back to the last 30 days
+	tests, err := service.GetUserHistory(ctx, 1, 0)
 	if err != nil {
-		t.Fatalf("GetUserTestHistory() error = %v", err)
+		t.Fatalf("GetUserHistory() error = %v", err)
 	}
 
-	if len(tests) != 5 {
-		t.Errorf("GetUserTestHistory() returned %d tests, want 5", len(tests))
+	if len(tests) != 10 {
+		t.Errorf("GetUserHistory() returned %d tests, want 10", len(tests))
 	}
 }
 
-// TestEstimateUserLevel tests user level estimation
-func TestEstimateUserLevel(t *testing.T) {
+// TestEstimateTypingLevel tests user level estimation
+func TestEstimateTypingLevel(t *testing.T) {
 	tests := []struct {
 		wpm           float64
 		expectedLevel string
@@ -432,84 +311,63 @@ func TestEstimateUserLevel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.expectedLevel, func(t *testing.T) {
-			level := estimateUserLevel(tt.wpm)
+			level := EstimateTypingLevel(tt.wpm)
 			if level != tt.expectedLevel {
-				t.Errorf("estimateUserLevel(%v) = %s, want %s", tt.wpm, level, tt.expectedLevel)
+				t.Errorf("EstimateTypingLevel(%v) = %s, want %s", tt.wpm, level, tt.expectedLevel)
 			}
 		})
 	}
 }
 
-// TestCalculateUserProgress tests progress calculation
-func TestCalculateUserProgress(t *testing.T) {
-	db, service := setupServiceTestDB(t)
-	defer db.Close()
-
-	ctx := context.Background()
-
-	// Process a test
-	_, err := service.ProcessTestResult(ctx, 1, "test content for progress", 60, 1)
-	if err != nil {
-		t.Fatalf("ProcessTestResult() error = %v", err)
-	}
-
-	progress, err := service.CalculateUserProgress(ctx, 1)
-	if err != nil {
-		t.Fatalf("CalculateUserProgress() error = %v", err)
-	}
-
-	if progress["user_id"] != uint(1) {
-		t.Errorf("CalculateUserProgress() user_id = %v, want 1", progress["user_id"])
-	}
-
-	if progress["total_tests"] == nil {
-		t.Error("CalculateUserProgress() missing total_tests")
+// TestCalculateProgressTrend tests progress calculation
+func TestCalculateProgressTrend(t *testing.T) {
+	tests := []struct {
+		previous, current float64
+		expected          string
+	}{
+		{40, 45, "improving"},
+		{45, 40, "declining"},
+		{40, 40, "stable"},
 	}
 
-	if progress["estimated_level"] == nil {
-		t.Error("CalculateUserProgress() missing estimated_level")
+	for _, tt := range tests {
+		if trend := CalculateProgressTrend(tt.previous, tt.current); trend != tt.expected {
+			t.Errorf("CalculateProgressTrend(%v, %v) = %s, want %s", tt.previous, tt.current, trend, tt.expected)
+		}
 	}
 }
 
-// TestNewServiceWithPool tests creating service with pool
-func TestNewServiceWithPool(t *testing.T) {
-	db := setupTestDB(t)
+// TestNewService tests creating a service
+func TestNewService(t *testing.T) {
+	db, service := setupServiceTestDB(t)
 	defer db.Close()
 
-	pool := newPoolFromDB(db)
-	service := NewServiceWithPool(pool)
-
 	if service == nil {
-		t.Error("NewServiceWithPool() returned nil")
+		t.Fatal("NewService() returned nil")
 	}
 
 	if service.repo == nil {
-		t.Error("NewServiceWithPool() service has nil repo")
+		t.Error("NewService() service has nil repo")
 	}
 }
 
 // BenchmarkCalculateWPM benchmarks WPM calculation
 func BenchmarkCalculateWPM(b *testing.B) {
-	_, service := setupServiceTestDB(&testing.T{})
-
 	content := "the quick brown fox jumps over the lazy dog the quick brown fox jumps over the lazy dog"
 	timeSpent := 120.0
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		service.CalculateWPM(content, timeSpent)
+		CalculateWPM(len(content), timeSpent)
 	}
 }
 
 // BenchmarkCalculateAccuracy benchmarks accuracy calculation
 func BenchmarkCalculateAccuracy(b *testing.B) {
-	_, service := setupServiceTestDB(&testing.T{})
-
-	typed := "the quick brown fox jumps over the lazy dog"
-	expected := "the quick brown fox jumps over the lazy dog"
+	content := "the quick brown fox jumps over the lazy dog"
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		service.CalculateAccuracy(typed, expected)
+		CalculateAccuracy(len(content), 2)
 	}
 }
@@ -33,6 +33,17 @@ type UnifiedUserProfile struct {
 	WeeklyActiveApps    []string
 }
 
+// GroupRoster represents the profiles of a classroom's or household's members
+type GroupRoster struct {
+	GroupID      uint
+	GroupName    string
+	Kind         string // "classroom", "household"
+	Members      []*UnifiedUserProfile
+	AverageLevel float64 // Mean OverallLevel of active members
+	ActiveToday  int
+	GeneratedAt  time.Time
+}
+
 // UnifiedSession represents a normalized session across apps
 type UnifiedSession struct {
 	ID          uint
@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jgirmay/unified-go/internal/tenant"
 )
 
 // Repository handles aggregation of data from all educational app repositories
@@ -96,6 +99,75 @@ func (r *Repository) GetUserProfile(ctx context.Context, userID uint) (*UnifiedU
 	return profile, nil
 }
 
+// GetGroupMemberIDs returns the IDs of a group's members holding one of the
+// given membership roles, or all members when no roles are given
+func (r *Repository) GetGroupMemberIDs(ctx context.Context, groupID uint, roles ...string) ([]uint, error) {
+	if groupID == 0 {
+		return nil, fmt.Errorf("invalid group ID")
+	}
+
+	query := `SELECT user_id FROM group_members WHERE group_id = ?`
+	args := []interface{}{groupID}
+	if len(roles) > 0 {
+		query += ` AND role IN (?` + strings.Repeat(`, ?`, len(roles)-1) + `)`
+		for _, role := range roles {
+			args = append(args, role)
+		}
+	}
+	query += ` ORDER BY user_id`
+
+	return r.queryUserIDs(ctx, query, args...)
+}
+
+// GetChildIDs returns the children linked to a parent through their households
+func (r *Repository) GetChildIDs(ctx context.Context, parentID uint) ([]uint, error) {
+	if parentID == 0 {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	query := `SELECT DISTINCT child.user_id
+		FROM group_members parent
+		JOIN user_groups g ON g.id = parent.group_id AND g.kind = 'household'
+		JOIN group_members child ON child.group_id = parent.group_id AND child.role = 'child'
+		WHERE parent.user_id = ? AND parent.role = 'parent'
+		ORDER BY child.user_id`
+
+	return r.queryUserIDs(ctx, query, parentID)
+}
+
+// GetUserProfiles fetches the profile of each given user
+func (r *Repository) GetUserProfiles(ctx context.Context, userIDs []uint) ([]*UnifiedUserProfile, error) {
+	profiles := make([]*UnifiedUserProfile, 0, len(userIDs))
+	for _, id := range userIDs {
+		profile, err := r.GetUserProfile(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// queryUserIDs runs a query returning a single user ID column
+func (r *Repository) queryUserIDs(ctx context.Context, query string, args ...interface{}) ([]uint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uint, 0)
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // GetCrossAppAnalytics calculates cross-app insights for a user
 func (r *Repository) GetCrossAppAnalytics(ctx context.Context, userID uint) (*CrossAppAnalytics, error) {
 	if userID == 0 {
@@ -243,8 +315,9 @@ func (r *Repository) GetSystemStats(ctx context.Context) (*SystemStats, error) {
 		AppAverageScore:  make(map[string]float64),
 	}
 
-	// Get total user count
-	r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&stats.TotalUsers)
+	// Get total user count of the context's organization
+	scope, args := tenant.Members(ctx, "id")
+	r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE `+scope, args...).Scan(&stats.TotalUsers)
 
 	// Get active users
 	today := time.Now().AddDate(0, 0, -1)
@@ -167,6 +167,38 @@ func (s *Service) CalculateOverallLevel(profile *UnifiedUserProfile) float64 {
 	return totalScore / float64(appCount)
 }
 
+// BuildGroupRoster computes each member's overall level and the group averages
+func (s *Service) BuildGroupRoster(groupID uint, groupName, kind string, members []*UnifiedUserProfile) *GroupRoster {
+	roster := &GroupRoster{
+		GroupID:     groupID,
+		GroupName:   groupName,
+		Kind:        kind,
+		Members:     members,
+		GeneratedAt: time.Now(),
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	levelSum := 0.0
+	levelCount := 0
+
+	for _, member := range members {
+		member.OverallLevel = s.CalculateOverallLevel(member)
+		if member.OverallLevel > 0 {
+			levelSum += member.OverallLevel
+			levelCount++
+		}
+		if !member.LastActivityDate.Before(today) {
+			roster.ActiveToday++
+		}
+	}
+
+	if levelCount > 0 {
+		roster.AverageLevel = levelSum / float64(levelCount)
+	}
+
+	return roster
+}
+
 // MapSkillLevelToString converts normalized score to skill level text
 func (s *Service) MapSkillLevelToString(score float64) string {
 	switch {